@@ -0,0 +1,106 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"memoria/internal/adapter/persistence"
+	"memoria/internal/config"
+	"memoria/migrations"
+)
+
+const usage = `使い方: migrate <command> [options]
+
+コマンド:
+  up                 未適用のマイグレーションをすべて適用する
+  down [-steps N]    直近 N 件（既定 1 件）のマイグレーションを取り消す
+  status             マイグレーションの適用状況を表示する
+  create [-dir DIR] <name>
+                     空の up/down ファイルを作成する（既定 DIR は migrations）
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Print(usage)
+		os.Exit(2)
+	}
+
+	command := os.Args[1]
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	steps := flags.Int("steps", 1, "down で取り消す件数")
+	dir := flags.String("dir", "migrations", "create でファイルを作成するディレクトリ")
+	flags.Parse(os.Args[2:])
+
+	if command == "create" {
+		name := strings.Join(flags.Args(), "_")
+		upPath, downPath, err := persistence.CreateMigrationFiles(*dir, name)
+		if err != nil {
+			log.Fatalf("マイグレーションファイルの作成に失敗しました: %v", err)
+		}
+		fmt.Printf("✓ 作成しました\n  %s\n  %s\n", upPath, downPath)
+		return
+	}
+
+	// 設定の読み込み
+	cfg := config.Load()
+
+	// データベース接続（スキーマには触れない）
+	db, err := persistence.OpenDB(cfg)
+	if err != nil {
+		log.Fatalf("データベース接続に失敗しました: %v", err)
+	}
+
+	migrator, err := persistence.NewMigrator(db, migrations.FS)
+	if err != nil {
+		log.Fatalf("マイグレーションの読み込みに失敗しました: %v", err)
+	}
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up()
+		for _, migration := range applied {
+			fmt.Printf("✓ 適用しました: %06d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			log.Fatalf("マイグレーションに失敗しました: %v", err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("未適用のマイグレーションはありません")
+		}
+	case "down":
+		rolledBack, err := migrator.Down(*steps)
+		for _, migration := range rolledBack {
+			fmt.Printf("✓ 取り消しました: %06d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			log.Fatalf("ロールバックに失敗しました: %v", err)
+		}
+		if len(rolledBack) == 0 {
+			fmt.Println("取り消すマイグレーションはありません")
+		}
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			log.Fatalf("適用状況の取得に失敗しました: %v", err)
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.AppliedAt != nil {
+				state = "applied " + status.AppliedAt.Format("2006-01-02T15:04:05Z07:00")
+			}
+			if status.ChecksumMismatch {
+				state += " (checksum mismatch)"
+			}
+			if status.Missing {
+				state += " (file missing)"
+			}
+			fmt.Printf("%06d_%-40s %s\n", status.Version, status.Name, state)
+		}
+	default:
+		fmt.Print(usage)
+		os.Exit(2)
+	}
+}
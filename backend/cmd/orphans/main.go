@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"memoria/internal/adapter/persistence"
+	"memoria/internal/config"
+)
+
+func main() {
+	// コマンドラインフラグの定義
+	repair := flag.Bool("repair", false, "孤立した行を削除（アルバムのカバーは解除）し、外部キー制約を検証する")
+	flag.Parse()
+
+	// 設定の読み込み
+	cfg := config.Load()
+
+	// データベース接続（スキーマには触れない）
+	db, err := persistence.OpenDB(cfg)
+	if err != nil {
+		log.Fatalf("データベース接続に失敗しました: %v", err)
+	}
+
+	if !*repair {
+		counts, err := persistence.FindOrphans(db)
+		if err != nil {
+			log.Fatalf("孤立した行の検索に失敗しました: %v", err)
+		}
+		if printCounts(counts) == 0 {
+			fmt.Println("孤立した行はありません")
+			return
+		}
+		fmt.Println("\n-repair を付けて実行すると修復します")
+		return
+	}
+
+	counts, err := persistence.RepairOrphans(db)
+	if err != nil {
+		log.Fatalf("修復に失敗しました（変更は取り消されました）: %v", err)
+	}
+	if printCounts(counts) == 0 {
+		fmt.Println("孤立した行はありません")
+	} else {
+		fmt.Println("✓ 修復しました。削除した写真の S3 オブジェクトは object-cleanup ジョブが削除します")
+	}
+
+	if err := persistence.ValidateForeignKeys(db); err != nil {
+		log.Fatalf("外部キー制約の検証に失敗しました: %v", err)
+	}
+	fmt.Println("✓ 外部キー制約を検証しました")
+}
+
+// printCounts shows the foreign keys that have orphans and returns the total.
+func printCounts(counts []persistence.OrphanCount) int64 {
+	var total int64
+	for _, count := range counts {
+		if count.Count == 0 {
+			continue
+		}
+		fmt.Printf("%-45s → %-15s %d 件\n", count.Table+"."+count.Column, count.Parent, count.Count)
+		total += count.Count
+	}
+	return total
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"memoria/internal/adapter/imaging"
+	"memoria/internal/adapter/persistence"
+	"memoria/internal/config"
+	"memoria/internal/di"
+	"memoria/internal/usecase"
+)
+
+func main() {
+	// コマンドラインフラグの定義
+	retryFailed := flag.Bool("failed", false, "失敗した写真も処理し直す")
+	all := flag.Bool("all", false, "処理済みの写真も含め、すべての写真の派生画像を作り直す")
+	flag.Parse()
+
+	// 設定の読み込み
+	cfg := config.Load()
+
+	// データベース接続（スキーマには触れない）
+	db, err := persistence.OpenDB(cfg)
+	if err != nil {
+		log.Fatalf("データベース接続に失敗しました: %v", err)
+	}
+	objectStorage, err := di.BuildObjectStorage(cfg)
+	if err != nil {
+		log.Fatalf("ストレージの初期化に失敗しました: %v", err)
+	}
+	photoVariantUsecase := usecase.NewPhotoVariantUsecase(persistence.NewPhotoRepository(db), objectStorage, imaging.Codec{})
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if *retryFailed || *all {
+		queued, err := photoVariantUsecase.Requeue(ctx, *all)
+		if err != nil {
+			log.Fatalf("写真を処理待ちに戻せませんでした: %v", err)
+		}
+		fmt.Printf("%d 件の写真を処理待ちに戻しました\n", queued)
+	}
+
+	// 処理待ちの写真がなくなるまで処理する（ワーカーと同時に動かしてもよい）
+	total := 0
+	for {
+		handled, err := photoVariantUsecase.ProcessPending(ctx, time.Now())
+		if err != nil {
+			log.Fatalf("処理に失敗しました（%d 件処理済み）: %v", total, err)
+		}
+		if handled == 0 {
+			break
+		}
+		total += handled
+		fmt.Printf("%d 件処理しました\n", total)
+	}
+	fmt.Printf("✓ 完了しました（%d 件）。再試行待ちの写真は photo-variants ジョブが処理します\n", total)
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"memoria/internal/adapter/webpush"
+)
+
+// Web Push 用の VAPID 鍵ペアを生成して .env 形式で出力する
+func main() {
+	publicKey, privateKey, err := webpush.GenerateKeys()
+	if err != nil {
+		log.Fatalf("VAPID 鍵の生成に失敗しました: %v", err)
+	}
+	fmt.Printf("VAPID_PUBLIC_KEY=%s\n", publicKey)
+	fmt.Printf("VAPID_PRIVATE_KEY=%s\n", privateKey)
+}
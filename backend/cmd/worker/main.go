@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os/signal"
+	"syscall"
+
+	"memoria/internal/config"
+	"memoria/internal/di"
+)
+
+func main() {
+	cfg := config.Load()
+
+	runner, err := di.BuildWorker(cfg)
+	if err != nil {
+		log.Fatalf("ワーカーの初期化に失敗しました: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	log.Println("ワーカーを起動しました")
+	<-runner.Start(ctx)
+	log.Println("ワーカーを停止しました")
+}
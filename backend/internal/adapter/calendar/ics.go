@@ -0,0 +1,88 @@
+package calendar
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"memoria/internal/usecase"
+)
+
+// Encoder writes calendars as iCalendar text.
+type Encoder struct{}
+
+// Encode renders the calendar as RFC 5545 text with CRLF line endings and
+// lines folded at 75 octets.
+func (Encoder) Encode(c *usecase.Calendar) []byte {
+	var b strings.Builder
+	writeLine(&b, "BEGIN:VCALENDAR")
+	writeLine(&b, "VERSION:2.0")
+	writeLine(&b, "PRODID:-//memoria//trips//JA")
+	writeLine(&b, "CALSCALE:GREGORIAN")
+	writeLine(&b, "METHOD:PUBLISH")
+	if c.Name != "" {
+		writeLine(&b, "X-WR-CALNAME:"+escapeText(c.Name))
+	}
+	for _, event := range c.Events {
+		writeLine(&b, "BEGIN:VEVENT")
+		writeLine(&b, "UID:"+event.UID)
+		writeLine(&b, "DTSTAMP:"+event.Stamp.UTC().Format("20060102T150405Z"))
+		writeLine(&b, "DTSTART"+formatTime(event.Kind, event.Start))
+		if !event.End.IsZero() {
+			writeLine(&b, "DTEND"+formatTime(event.Kind, event.End))
+		}
+		writeLine(&b, "SUMMARY:"+escapeText(event.Summary))
+		if event.Description != "" {
+			writeLine(&b, "DESCRIPTION:"+escapeText(event.Description))
+		}
+		if event.Location != "" {
+			writeLine(&b, "LOCATION:"+escapeText(event.Location))
+		}
+		writeLine(&b, "END:VEVENT")
+	}
+	writeLine(&b, "END:VCALENDAR")
+	return []byte(b.String())
+}
+
+// formatTime writes UTC times such as 20240801T010000Z, floating times
+// without the Z and dates as VALUE=DATE.
+func formatTime(kind usecase.CalendarTimeKind, t time.Time) string {
+	switch kind {
+	case usecase.CalendarTimeDate:
+		return ";VALUE=DATE:" + t.Format("20060102")
+	case usecase.CalendarTimeFloating:
+		return ":" + t.Format("20060102T150405")
+	default:
+		return ":" + t.UTC().Format("20060102T150405Z")
+	}
+}
+
+func escapeText(value string) string {
+	value = strings.ReplaceAll(value, "\r\n", "\n")
+	value = strings.ReplaceAll(value, "\r", "\n")
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\n", `\n`,
+	)
+	return replacer.Replace(value)
+}
+
+// writeLine folds content lines longer than 75 octets without splitting a
+// UTF-8 sequence; continuation lines start with a single space.
+func writeLine(b *strings.Builder, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = 74 // the leading space counts toward the limit
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
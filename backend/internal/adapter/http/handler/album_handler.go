@@ -12,11 +12,13 @@ import (
 
 type AlbumHandler struct {
 	albumUsecase *usecase.AlbumUsecase
+	photoUsecase *usecase.PhotoUsecase
 }
 
-func NewAlbumHandler(albumUsecase *usecase.AlbumUsecase) *AlbumHandler {
+func NewAlbumHandler(albumUsecase *usecase.AlbumUsecase, photoUsecase *usecase.PhotoUsecase) *AlbumHandler {
 	return &AlbumHandler{
 		albumUsecase: albumUsecase,
+		photoUsecase: photoUsecase,
 	}
 }
 
@@ -34,6 +36,11 @@ type AlbumResponse struct {
 	CreatedAt    string `json:"created_at"`
 }
 
+type AlbumListResponse struct {
+	Albums     []AlbumResponse `json:"albums"`
+	NextCursor *string         `json:"next_cursor"`
+}
+
 func (h *AlbumHandler) CreateAlbum(c echo.Context) error {
 	userVal := c.Get("user")
 	user, ok := userVal.(*model.User)
@@ -51,7 +58,7 @@ func (h *AlbumHandler) CreateAlbum(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	album, err := h.albumUsecase.CreateAlbum(req.Title, req.Description, user.ID, groupID)
+	album, err := h.albumUsecase.CreateAlbum(c.Request().Context(), req.Title, req.Description, user.ID, groupID)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
@@ -76,7 +83,7 @@ func (h *AlbumHandler) GetAlbum(c echo.Context) error {
 		return err
 	}
 
-	album, err := h.albumUsecase.GetAlbum(uint(id), groupID)
+	album, err := h.albumUsecase.GetAlbum(c.Request().Context(), uint(id), groupID)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusNotFound, "album not found")
 	}
@@ -97,14 +104,22 @@ func (h *AlbumHandler) GetAllAlbums(c echo.Context) error {
 		return err
 	}
 
-	albums, err := h.albumUsecase.GetAllAlbums(groupID)
+	opts, err := parseListOptions(c)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
-	response := make([]AlbumResponse, len(albums))
-	for i, album := range albums {
-		response[i] = AlbumResponse{
+	page, err := h.albumUsecase.GetAllAlbums(c.Request().Context(), groupID, opts)
+	if err != nil {
+		return listErrorOr(err, echo.NewHTTPError(http.StatusInternalServerError, err.Error()))
+	}
+
+	response := AlbumListResponse{
+		Albums:     make([]AlbumResponse, len(page.Items)),
+		NextCursor: nextCursor(page.NextCursor),
+	}
+	for i, album := range page.Items {
+		response.Albums[i] = AlbumResponse{
 			ID:           album.ID,
 			Title:        album.Title,
 			Description:  album.Description,
@@ -129,7 +144,7 @@ func (h *AlbumHandler) UpdateAlbum(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid album ID")
 	}
 
-	groupID, err := getGroupIDFromContext(c)
+	member, err := getGroupMemberFromContext(c)
 	if err != nil {
 		return err
 	}
@@ -139,9 +154,9 @@ func (h *AlbumHandler) UpdateAlbum(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	album, err := h.albumUsecase.UpdateAlbum(uint(id), req.Title, req.Description, req.CoverPhotoID, groupID)
+	album, err := h.albumUsecase.UpdateAlbum(c.Request().Context(), uint(id), req.Title, req.Description, req.CoverPhotoID, member)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return forbiddenOr(err, echo.NewHTTPError(http.StatusInternalServerError, err.Error()))
 	}
 
 	return c.JSON(http.StatusOK, AlbumResponse{
@@ -160,17 +175,13 @@ func (h *AlbumHandler) DeleteAlbum(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid album ID")
 	}
 
-	groupID, err := getGroupIDFromContext(c)
+	member, err := getGroupMemberFromContext(c)
 	if err != nil {
 		return err
 	}
 
-	if _, err := h.albumUsecase.GetAlbum(uint(id), groupID); err != nil {
-		return echo.NewHTTPError(http.StatusNotFound, "album not found")
-	}
-
-	if err := h.albumUsecase.DeleteAlbum(uint(id)); err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	if err := h.albumUsecase.DeleteAlbum(c.Request().Context(), uint(id), member); err != nil {
+		return forbiddenOr(err, echo.NewHTTPError(http.StatusNotFound, "album not found"))
 	}
 
 	return c.NoContent(http.StatusNoContent)
@@ -186,6 +197,27 @@ type PhotoResponse struct {
 	Height      int    `json:"height"`
 	UploadedBy  uint   `json:"uploaded_by"`
 	CreatedAt   string `json:"created_at"`
+	// Status is pending until the variants are made, or failed if the
+	// image could not be read.
+	Status   string                 `json:"status"`
+	Variants []PhotoVariantResponse `json:"variants"`
+	// URL and the variants' URLs are presigned GETs that work until
+	// URLExpiresAt.
+	URL          string `json:"url"`
+	URLExpiresAt string `json:"url_expires_at"`
+}
+
+type PhotoVariantResponse struct {
+	Name   string `json:"name"`
+	S3Key  string `json:"s3_key"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	URL    string `json:"url"`
+}
+
+type PhotoListResponse struct {
+	Photos     []PhotoResponse `json:"photos"`
+	NextCursor *string         `json:"next_cursor"`
 }
 
 func (h *AlbumHandler) GetAlbumPhotos(c echo.Context) error {
@@ -199,25 +231,60 @@ func (h *AlbumHandler) GetAlbumPhotos(c echo.Context) error {
 		return err
 	}
 
-	photos, err := h.albumUsecase.GetAlbumPhotos(uint(id), groupID)
+	opts, err := parseListOptions(c)
+	if err != nil {
+		return err
+	}
+
+	page, err := h.albumUsecase.GetAlbumPhotos(c.Request().Context(), uint(id), groupID, opts)
+	if err != nil {
+		return listErrorOr(err, echo.NewHTTPError(http.StatusInternalServerError, err.Error()))
+	}
+
+	variants, err := h.albumUsecase.GetPhotoVariants(c.Request().Context(), page.Items)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
-	response := make([]PhotoResponse, len(photos))
-	for i, photo := range photos {
-		response[i] = PhotoResponse{
-			ID:          photo.ID,
-			AlbumID:     photo.AlbumID,
-			S3Key:       photo.S3Key,
-			ContentType: photo.ContentType,
-			SizeBytes:   photo.SizeBytes,
-			Width:       photo.Width,
-			Height:      photo.Height,
-			UploadedBy:  photo.UploadedBy,
-			CreatedAt:   photo.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	response := PhotoListResponse{
+		Photos:     make([]PhotoResponse, len(page.Items)),
+		NextCursor: nextCursor(page.NextCursor),
+	}
+	for i, photo := range page.Items {
+		urls, err := h.photoUsecase.SignURLs(photo, variants[photo.ID])
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 		}
+		response.Photos[i] = buildPhotoResponse(photo, variants[photo.ID], urls)
 	}
 
 	return c.JSON(http.StatusOK, response)
 }
+
+func buildPhotoResponse(photo *model.Photo, variants []*model.PhotoVariant, urls *usecase.PhotoURLs) PhotoResponse {
+	response := PhotoResponse{
+		ID:           photo.ID,
+		AlbumID:      photo.AlbumID,
+		S3Key:        photo.S3Key,
+		ContentType:  photo.ContentType,
+		SizeBytes:    photo.SizeBytes,
+		Width:        photo.Width,
+		Height:       photo.Height,
+		UploadedBy:   photo.UploadedBy,
+		CreatedAt:    photo.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		Status:       photo.Status,
+		Variants:     make([]PhotoVariantResponse, len(variants)),
+		URL:          urls.Original,
+		URLExpiresAt: urls.ExpiresAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	for i, variant := range variants {
+		response.Variants[i] = PhotoVariantResponse{
+			Name:   variant.Name,
+			S3Key:  variant.S3Key,
+			Width:  variant.Width,
+			Height: variant.Height,
+			URL:    urls.Variants[variant.Name],
+		}
+	}
+	return response
+}
@@ -51,7 +51,7 @@ func (h *AuthHandler) Login(c echo.Context) error {
 	}
 
 	backPath := sanitizeBackPath(req.BackPath)
-	user, sessionCookie, refreshToken, idToken, err := h.authUsecase.Login(req.Email, req.Password, backPath)
+	user, sessionCookie, refreshToken, idToken, err := h.authUsecase.Login(c.Request().Context(), req.Email, req.Password, backPath)
 	if err != nil {
 		if authErr, ok := err.(*usecase.AuthError); ok {
 			return c.JSON(http.StatusUnauthorized, map[string]string{
@@ -93,7 +93,7 @@ func (h *AuthHandler) Refresh(c echo.Context) error {
 	if err := c.Bind(&req); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
-	sessionCookie, newRefresh, idToken, err := h.authUsecase.RefreshSession(req.RefreshToken)
+	sessionCookie, newRefresh, idToken, err := h.authUsecase.RefreshSession(c.Request().Context(), req.RefreshToken)
 	if err != nil {
 		if authErr, ok := err.(*usecase.AuthError); ok {
 			return c.JSON(http.StatusUnauthorized, map[string]string{
@@ -129,7 +129,7 @@ func (h *AuthHandler) Signup(c echo.Context) error {
 	}
 
 	backPath := sanitizeBackPath(req.BackPath)
-	user, sessionCookie, err := h.authUsecase.Signup(req.Email, req.Password, req.DisplayName, backPath)
+	user, sessionCookie, err := h.authUsecase.Signup(c.Request().Context(), req.Email, req.Password, req.DisplayName, backPath)
 	if err != nil {
 		if authErr, ok := err.(*usecase.AuthError); ok {
 			return c.JSON(http.StatusBadRequest, map[string]string{
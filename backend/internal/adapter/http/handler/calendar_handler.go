@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"memoria/internal/domain/model"
+	"memoria/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+const calendarContentType = "text/calendar; charset=utf-8"
+
+type CalendarHandler struct {
+	calendarUsecase *usecase.CalendarUsecase
+}
+
+func NewCalendarHandler(calendarUsecase *usecase.CalendarUsecase) *CalendarHandler {
+	return &CalendarHandler{
+		calendarUsecase: calendarUsecase,
+	}
+}
+
+type CalendarFeedResponse struct {
+	Active     bool    `json:"active"`
+	URL        string  `json:"url,omitempty"`
+	WebcalURL  string  `json:"webcal_url,omitempty"`
+	CreatedAt  *string `json:"created_at,omitempty"`
+	LastUsedAt *string `json:"last_used_at,omitempty"`
+}
+
+func (h *CalendarHandler) ExportTrip(c echo.Context) error {
+	id, err := parseTripID(c)
+	if err != nil {
+		return err
+	}
+
+	groupID, err := getGroupIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	body, err := h.calendarUsecase.ExportTrip(c.Request().Context(), id, groupID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "trip not found")
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="trip-%d.ics"`, id))
+	return c.Blob(http.StatusOK, calendarContentType, body)
+}
+
+// GetFeed serves the subscription feed. The token in the path is the only
+// credential, so calendar apps can poll it without a session.
+func (h *CalendarHandler) GetFeed(c echo.Context) error {
+	body, err := h.calendarUsecase.RenderFeed(c.Request().Context(), c.Param("token"))
+	if err != nil {
+		if errors.Is(err, usecase.ErrCalendarFeedNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "calendar feed not found")
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	c.Response().Header().Set("Cache-Control", "private, max-age=900")
+	return c.Blob(http.StatusOK, calendarContentType, body)
+}
+
+func (h *CalendarHandler) GetFeedSettings(c echo.Context) error {
+	user, ok := c.Get("user").(*model.User)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid user")
+	}
+
+	feedToken, err := h.calendarUsecase.GetFeedToken(c.Request().Context(), user.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if feedToken == nil {
+		return c.JSON(http.StatusOK, CalendarFeedResponse{Active: false})
+	}
+
+	return c.JSON(http.StatusOK, buildCalendarFeedResponse(feedToken, ""))
+}
+
+// CreateFeed issues a new subscription URL and revokes the previous one.
+func (h *CalendarHandler) CreateFeed(c echo.Context) error {
+	user, ok := c.Get("user").(*model.User)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid user")
+	}
+
+	token, feedToken, err := h.calendarUsecase.IssueFeedToken(c.Request().Context(), user.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	feedURL := fmt.Sprintf("%s://%s/api/calendar/%s/trips.ics", c.Scheme(), c.Request().Host, token)
+	return c.JSON(http.StatusCreated, buildCalendarFeedResponse(feedToken, feedURL))
+}
+
+func (h *CalendarHandler) DeleteFeed(c echo.Context) error {
+	user, ok := c.Get("user").(*model.User)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid user")
+	}
+
+	if err := h.calendarUsecase.RevokeFeedToken(c.Request().Context(), user.ID); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func buildCalendarFeedResponse(feedToken *model.CalendarFeedToken, feedURL string) CalendarFeedResponse {
+	createdAt := feedToken.CreatedAt.Format("2006-01-02T15:04:05Z07:00")
+	response := CalendarFeedResponse{
+		Active:    true,
+		CreatedAt: &createdAt,
+	}
+	if feedURL != "" {
+		response.URL = feedURL
+		response.WebcalURL = "webcal://" + strings.SplitN(feedURL, "://", 2)[1]
+	}
+	if feedToken.LastUsedAt != nil {
+		lastUsedAt := feedToken.LastUsedAt.Format("2006-01-02T15:04:05Z07:00")
+		response.LastUsedAt = &lastUsedAt
+	}
+	return response
+}
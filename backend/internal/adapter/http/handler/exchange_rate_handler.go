@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"memoria/internal/domain/model"
+	"memoria/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+// maxExchangeRateCSVSize caps CSV imports; a year of daily rates for a
+// dozen pairs is well under this.
+const maxExchangeRateCSVSize = 1 << 20
+
+type ExchangeRateHandler struct {
+	exchangeRateUsecase *usecase.ExchangeRateUsecase
+}
+
+func NewExchangeRateHandler(exchangeRateUsecase *usecase.ExchangeRateUsecase) *ExchangeRateHandler {
+	return &ExchangeRateHandler{
+		exchangeRateUsecase: exchangeRateUsecase,
+	}
+}
+
+type ExchangeRateRequest struct {
+	FromCurrency  string  `json:"from_currency" validate:"required"`
+	ToCurrency    string  `json:"to_currency" validate:"required"`
+	EffectiveDate string  `json:"effective_date" validate:"required"`
+	Rate          float64 `json:"rate" validate:"required"`
+}
+
+type ExchangeRateResponse struct {
+	ID            uint    `json:"id"`
+	FromCurrency  string  `json:"from_currency"`
+	ToCurrency    string  `json:"to_currency"`
+	EffectiveDate string  `json:"effective_date"`
+	Rate          float64 `json:"rate"`
+	CreatedBy     uint    `json:"created_by"`
+	UpdatedAt     string  `json:"updated_at"`
+}
+
+func (h *ExchangeRateHandler) GetExchangeRates(c echo.Context) error {
+	groupID, err := getGroupIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	rates, err := h.exchangeRateUsecase.GetRates(c.Request().Context(), groupID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	response := make([]ExchangeRateResponse, len(rates))
+	for i, rate := range rates {
+		response[i] = buildExchangeRateResponse(rate)
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *ExchangeRateHandler) SetExchangeRate(c echo.Context) error {
+	member, err := getGroupMemberFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var req ExchangeRateRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	rate, err := h.exchangeRateUsecase.SetRate(c.Request().Context(), req.FromCurrency, req.ToCurrency, req.EffectiveDate, req.Rate, member)
+	if err != nil {
+		return forbiddenOr(err, echo.NewHTTPError(http.StatusBadRequest, err.Error()))
+	}
+
+	return c.JSON(http.StatusOK, buildExchangeRateResponse(rate))
+}
+
+// ImportExchangeRates accepts a CSV of "date,from,to,rate" rows either as a
+// multipart "file" field or as a text/csv request body.
+func (h *ExchangeRateHandler) ImportExchangeRates(c echo.Context) error {
+	member, err := getGroupMemberFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var body io.Reader
+	if strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "file is required")
+		}
+		if fileHeader.Size > maxExchangeRateCSVSize {
+			return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "csv is too large")
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		defer file.Close()
+		body = file
+	} else {
+		body = http.MaxBytesReader(c.Response(), c.Request().Body, maxExchangeRateCSVSize)
+	}
+
+	rates, err := h.exchangeRateUsecase.ImportCSV(c.Request().Context(), body, member)
+	if err != nil {
+		return forbiddenOr(err, echo.NewHTTPError(http.StatusBadRequest, err.Error()))
+	}
+
+	response := make([]ExchangeRateResponse, len(rates))
+	for i, rate := range rates {
+		response[i] = buildExchangeRateResponse(rate)
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *ExchangeRateHandler) DeleteExchangeRate(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid exchange rate ID")
+	}
+
+	member, err := getGroupMemberFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.exchangeRateUsecase.DeleteRate(c.Request().Context(), uint(id), member); err != nil {
+		return forbiddenOr(err, echo.NewHTTPError(http.StatusNotFound, "exchange rate not found"))
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func buildExchangeRateResponse(rate *model.ExchangeRate) ExchangeRateResponse {
+	return ExchangeRateResponse{
+		ID:            rate.ID,
+		FromCurrency:  rate.FromCurrency,
+		ToCurrency:    rate.ToCurrency,
+		EffectiveDate: rate.EffectiveDate,
+		Rate:          rate.Rate,
+		CreatedBy:     rate.CreatedBy,
+		UpdatedAt:     rate.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
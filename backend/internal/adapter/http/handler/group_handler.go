@@ -1,7 +1,9 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"memoria/internal/domain/model"
 	"memoria/internal/usecase"
@@ -25,11 +27,28 @@ type CreateGroupRequest struct {
 	Name string `json:"name" validate:"required"`
 }
 
+type UpdateGroupRequest struct {
+	Name            string `json:"name" validate:"required"`
+	Description     string `json:"description"`
+	DefaultCurrency string `json:"default_currency"`
+	// UploadMaxBytes lowers the server's upload limit; null removes it.
+	UploadMaxBytes *int64 `json:"upload_max_bytes"`
+}
+
+type TransferGroupRequest struct {
+	UserID uint `json:"user_id" validate:"required"`
+}
+
 type GroupResponse struct {
-	ID        uint   `json:"id"`
-	Name      string `json:"name"`
-	CreatedBy uint   `json:"created_by"`
-	CreatedAt string `json:"created_at"`
+	ID              uint    `json:"id"`
+	Name            string  `json:"name"`
+	Description     string  `json:"description"`
+	DefaultCurrency string  `json:"default_currency"`
+	CreatedBy       uint    `json:"created_by"`
+	CreatedAt       string  `json:"created_at"`
+	DeletedAt       *string `json:"deleted_at,omitempty"`
+	PurgeAfter      *string `json:"purge_after,omitempty"`
+	UploadMaxBytes  *int64  `json:"upload_max_bytes"`
 }
 
 func (h *GroupHandler) CreateGroup(c echo.Context) error {
@@ -44,17 +63,12 @@ func (h *GroupHandler) CreateGroup(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	group, err := h.groupUsecase.CreateGroup(req.Name, user.ID)
+	group, err := h.groupUsecase.CreateGroup(c.Request().Context(), req.Name, user.ID)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	return c.JSON(http.StatusCreated, GroupResponse{
-		ID:        group.ID,
-		Name:      group.Name,
-		CreatedBy: group.CreatedBy,
-		CreatedAt: group.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	})
+	return c.JSON(http.StatusCreated, buildGroupResponse(group))
 }
 
 func (h *GroupHandler) GetMyGroups(c echo.Context) error {
@@ -64,22 +78,151 @@ func (h *GroupHandler) GetMyGroups(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusUnauthorized, "invalid user")
 	}
 
-	groups, err := h.groupUsecase.GetGroupsForUser(user.ID)
+	groups, err := h.groupUsecase.GetGroupsForUser(c.Request().Context(), user.ID)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
-	response := make([]GroupResponse, len(groups))
-	for i, group := range groups {
-		response[i] = GroupResponse{
-			ID:        group.ID,
-			Name:      group.Name,
-			CreatedBy: group.CreatedBy,
-			CreatedAt: group.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	return c.JSON(http.StatusOK, buildGroupResponses(groups))
+}
+
+// GetDeletedGroups lists the groups the user deleted that can still be
+// restored.
+func (h *GroupHandler) GetDeletedGroups(c echo.Context) error {
+	user, ok := c.Get("user").(*model.User)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid user")
+	}
+
+	groups, err := h.groupUsecase.GetDeletedGroups(c.Request().Context(), user.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, buildGroupResponses(groups))
+}
+
+// UpdateGroup changes the group's name and settings. Managers only.
+func (h *GroupHandler) UpdateGroup(c echo.Context) error {
+	actor, err := h.getActor(c)
+	if err != nil {
+		return err
+	}
+
+	var req UpdateGroupRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	group, err := h.groupUsecase.UpdateGroup(c.Request().Context(), req.Name, req.Description, req.DefaultCurrency, req.UploadMaxBytes, actor)
+	if err != nil {
+		return forbiddenOr(err, echo.NewHTTPError(http.StatusBadRequest, err.Error()))
+	}
+
+	return c.JSON(http.StatusOK, buildGroupResponse(group))
+}
+
+// DeleteGroup schedules the group for deletion. Creator only.
+func (h *GroupHandler) DeleteGroup(c echo.Context) error {
+	actor, err := h.getActor(c)
+	if err != nil {
+		return err
+	}
+
+	group, err := h.groupUsecase.DeleteGroup(c.Request().Context(), actor)
+	if err != nil {
+		return forbiddenOr(err, echo.NewHTTPError(http.StatusInternalServerError, err.Error()))
+	}
+
+	return c.JSON(http.StatusAccepted, buildGroupResponse(group))
+}
+
+// RestoreGroup cancels a scheduled deletion. Creator only.
+func (h *GroupHandler) RestoreGroup(c echo.Context) error {
+	groupID, err := parseGroupID(c)
+	if err != nil {
+		return err
+	}
+
+	user, ok := c.Get("user").(*model.User)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid user")
+	}
+
+	group, err := h.groupUsecase.RestoreGroup(c.Request().Context(), groupID, user.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrGroupNotDeleted), errors.Is(err, usecase.ErrGroupRestoreExpired):
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
 		}
+		return forbiddenOr(err, echo.NewHTTPError(http.StatusNotFound, "group not found"))
 	}
 
-	return c.JSON(http.StatusOK, response)
+	return c.JSON(http.StatusOK, buildGroupResponse(group))
+}
+
+// TransferGroup hands the creator role to another member. Creator only.
+func (h *GroupHandler) TransferGroup(c echo.Context) error {
+	actor, err := h.getActor(c)
+	if err != nil {
+		return err
+	}
+
+	var req TransferGroupRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if req.UserID == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "user_id is required")
+	}
+
+	group, err := h.groupUsecase.TransferGroup(c.Request().Context(), req.UserID, actor)
+	if err != nil {
+		return forbiddenOr(err, echo.NewHTTPError(http.StatusNotFound, "member not found"))
+	}
+
+	return c.JSON(http.StatusOK, buildGroupResponse(group))
+}
+
+// LeaveGroup removes the current user from the group.
+func (h *GroupHandler) LeaveGroup(c echo.Context) error {
+	actor, err := h.getActor(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.groupUsecase.LeaveGroup(c.Request().Context(), actor); err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrCreatorCannotLeave), errors.Is(err, usecase.ErrLastManager):
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RemoveMember takes a member out of the group. Managers only.
+func (h *GroupHandler) RemoveMember(c echo.Context) error {
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid user ID")
+	}
+
+	actor, err := h.getActor(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.groupUsecase.RemoveMember(c.Request().Context(), uint(userID), actor); err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrCannotRemoveCreator), errors.Is(err, usecase.ErrLastManager):
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return forbiddenOr(err, echo.NewHTTPError(http.StatusNotFound, "member not found"))
+	}
+
+	return c.NoContent(http.StatusNoContent)
 }
 
 type GroupMemberResponse struct {
@@ -102,18 +245,18 @@ func (h *GroupHandler) GetGroupMembers(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusUnauthorized, "invalid user")
 	}
 
-	if _, err := h.groupUsecase.GetMembership(groupID, user.ID); err != nil {
+	if _, err := h.groupUsecase.GetMembership(c.Request().Context(), groupID, user.ID); err != nil {
 		return echo.NewHTTPError(http.StatusForbidden, "group access required")
 	}
 
-	members, err := h.groupUsecase.GetGroupMembers(groupID)
+	members, err := h.groupUsecase.GetGroupMembers(c.Request().Context(), groupID)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
 	response := make([]GroupMemberResponse, 0, len(members))
 	for _, member := range members {
-		userInfo, err := h.userUsecase.GetUserByID(member.UserID)
+		userInfo, err := h.userUsecase.GetUserByID(c.Request().Context(), member.UserID)
 		if err != nil {
 			c.Logger().Errorf("Failed to get user info for user ID %d: %v", member.UserID, err)
 			continue
@@ -129,3 +272,98 @@ func (h *GroupHandler) GetGroupMembers(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, response)
 }
+
+type UpdateMemberRoleRequest struct {
+	Role string `json:"role" validate:"required"`
+}
+
+// UpdateMemberRole promotes or demotes a member. Managers only.
+func (h *GroupHandler) UpdateMemberRole(c echo.Context) error {
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid user ID")
+	}
+
+	actor, err := h.getActor(c)
+	if err != nil {
+		return err
+	}
+
+	var req UpdateMemberRoleRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	member, err := h.groupUsecase.ChangeMemberRole(c.Request().Context(), uint(userID), req.Role, actor)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrInvalidRole):
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		case errors.Is(err, usecase.ErrLastManager):
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return forbiddenOr(err, echo.NewHTTPError(http.StatusNotFound, "member not found"))
+	}
+
+	userInfo, err := h.userUsecase.GetUserByID(c.Request().Context(), member.UserID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, GroupMemberResponse{
+		UserID:      member.UserID,
+		Email:       userInfo.Email,
+		DisplayName: userInfo.DisplayName,
+		Role:        member.Role,
+		JoinedAt:    member.JoinedAt.Format("2006-01-02T15:04:05Z07:00"),
+	})
+}
+
+// getActor returns the current user's membership in the :id group, which
+// must not be scheduled for deletion.
+func (h *GroupHandler) getActor(c echo.Context) (*model.GroupMember, error) {
+	groupID, err := parseGroupID(c)
+	if err != nil {
+		return nil, err
+	}
+
+	user, ok := c.Get("user").(*model.User)
+	if !ok {
+		return nil, echo.NewHTTPError(http.StatusUnauthorized, "invalid user")
+	}
+
+	actor, err := h.groupUsecase.GetMembership(c.Request().Context(), groupID, user.ID)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusForbidden, "group access required")
+	}
+	return actor, nil
+}
+
+func buildGroupResponse(group *model.Group) GroupResponse {
+	response := GroupResponse{
+		ID:              group.ID,
+		Name:            group.Name,
+		Description:     group.Description,
+		DefaultCurrency: group.DefaultCurrency,
+		CreatedBy:       group.CreatedBy,
+		CreatedAt:       group.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UploadMaxBytes:  group.UploadMaxBytes,
+	}
+	if group.DeletedAt != nil {
+		deletedAt := group.DeletedAt.Format("2006-01-02T15:04:05Z07:00")
+		response.DeletedAt = &deletedAt
+	}
+	if group.PurgeAfter != nil {
+		purgeAfter := group.PurgeAfter.Format("2006-01-02T15:04:05Z07:00")
+		response.PurgeAfter = &purgeAfter
+	}
+	return response
+}
+
+func buildGroupResponses(groups []*model.Group) []GroupResponse {
+	response := make([]GroupResponse, len(groups))
+	for i, group := range groups {
+		response[i] = buildGroupResponse(group)
+	}
+	return response
+}
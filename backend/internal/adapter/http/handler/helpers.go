@@ -1,8 +1,14 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
+
+	"memoria/internal/domain/model"
+	"memoria/internal/domain/repository"
+	"memoria/internal/usecase"
 
 	"github.com/labstack/echo/v4"
 )
@@ -25,6 +31,100 @@ func getGroupIDFromContext(c echo.Context) (uint, error) {
 	return groupID, nil
 }
 
+// getGroupMemberFromContext returns the caller's membership set by
+// RequireGroup. Usecases check its role against the permission policy.
+func getGroupMemberFromContext(c echo.Context) (*model.GroupMember, error) {
+	member, ok := c.Get("group_member").(*model.GroupMember)
+	if !ok {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "invalid group")
+	}
+	return member, nil
+}
+
+// forbiddenOr maps a policy denial to 403 and any other error to fallback.
+func forbiddenOr(err error, fallback *echo.HTTPError) error {
+	if errors.Is(err, usecase.ErrForbidden) {
+		return echo.NewHTTPError(http.StatusForbidden, "permission denied")
+	}
+	return fallback
+}
+
+// parseListOptions reads the paging and filter query parameters shared by
+// every list endpoint. Dates are RFC 3339 or YYYY-MM-DD in UTC; a bare "to"
+// date includes that whole day.
+func parseListOptions(c echo.Context) (repository.ListOptions, error) {
+	opts := repository.ListOptions{
+		Cursor: c.QueryParam("cursor"),
+		Type:   c.QueryParam("type"),
+	}
+	if raw := c.QueryParam("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			return opts, echo.NewHTTPError(http.StatusBadRequest, "invalid limit")
+		}
+		opts.Limit = parsed
+	}
+	switch sort := repository.SortOrder(c.QueryParam("sort")); sort {
+	case repository.SortDefault, repository.SortNewest, repository.SortOldest:
+		opts.Sort = sort
+	default:
+		return opts, echo.NewHTTPError(http.StatusBadRequest, "invalid sort")
+	}
+	switch timing := repository.TripTiming(c.QueryParam("timing")); timing {
+	case "", repository.TripUpcoming, repository.TripPast:
+		opts.Timing = timing
+	default:
+		return opts, echo.NewHTTPError(http.StatusBadRequest, "invalid timing")
+	}
+	for param, id := range map[string]*uint{"author_id": &opts.AuthorID, "tag_id": &opts.TagID} {
+		if raw := c.QueryParam(param); raw != "" {
+			parsed, err := strconv.ParseUint(raw, 10, 32)
+			if err != nil {
+				return opts, echo.NewHTTPError(http.StatusBadRequest, "invalid "+param)
+			}
+			*id = uint(parsed)
+		}
+	}
+	for param, bound := range map[string]**time.Time{"from": &opts.From, "to": &opts.To} {
+		if raw := c.QueryParam(param); raw != "" {
+			parsed, err := parseListDate(raw, param == "to")
+			if err != nil {
+				return opts, echo.NewHTTPError(http.StatusBadRequest, "invalid "+param)
+			}
+			*bound = &parsed
+		}
+	}
+	return opts, nil
+}
+
+func parseListDate(raw string, endOfDay bool) (time.Time, error) {
+	if date, err := time.Parse("2006-01-02", raw); err == nil {
+		if endOfDay {
+			return date.AddDate(0, 0, 1), nil
+		}
+		return date, nil
+	}
+	return time.Parse(time.RFC3339, raw)
+}
+
+// listErrorOr maps a stale or forged cursor to 400 and any other error to
+// fallback.
+func listErrorOr(err error, fallback *echo.HTTPError) error {
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid cursor")
+	}
+	return fallback
+}
+
+// nextCursor is the JSON value of a page's next_cursor, null on the last
+// page.
+func nextCursor(cursor string) *string {
+	if cursor == "" {
+		return nil
+	}
+	return &cursor
+}
+
 func setSessionCookie(c echo.Context, value string, secure bool, maxAge int, domain string) {
 	cookie := &http.Cookie{
 		Name:     "memoria_session",
@@ -56,3 +156,39 @@ func clearSessionCookie(c echo.Context, secure bool, domain string) {
 	}
 	c.SetCookie(cookie)
 }
+
+// objectErrorOr answers a failed storage.OpenObject with the matching
+// status, and any other error with 500.
+func objectErrorOr(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, usecase.ErrNotModified):
+		return c.NoContent(http.StatusNotModified)
+	case errors.Is(err, usecase.ErrRangeNotSatisfiable):
+		return echo.NewHTTPError(http.StatusRequestedRangeNotSatisfiable, err.Error())
+	case errors.Is(err, usecase.ErrObjectNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+}
+
+// streamObject writes an opened object, or the range of it, with its
+// headers and closes it.
+func streamObject(c echo.Context, object *usecase.Object) error {
+	defer object.Body.Close()
+
+	header := c.Response().Header()
+	header.Set("Accept-Ranges", "bytes")
+	header.Set("Content-Length", strconv.FormatInt(object.ContentLength, 10))
+	if object.ETag != "" {
+		header.Set("ETag", object.ETag)
+	}
+	if !object.LastModified.IsZero() {
+		header.Set("Last-Modified", object.LastModified.UTC().Format(http.TimeFormat))
+	}
+	status := http.StatusOK
+	if object.ContentRange != "" {
+		header.Set("Content-Range", object.ContentRange)
+		status = http.StatusPartialContent
+	}
+	return c.Stream(status, object.ContentType, object.Body)
+}
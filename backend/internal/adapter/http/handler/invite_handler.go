@@ -46,22 +46,9 @@ type CreateInviteResponse struct {
 }
 
 func (h *InviteHandler) CreateInvite(c echo.Context) error {
-	userVal := c.Get("user")
-	user, ok := userVal.(*model.User)
-	if !ok {
-		return echo.NewHTTPError(http.StatusUnauthorized, "invalid user")
-	}
-
-	groupMemberVal := c.Get("group_member")
-	member, ok := groupMemberVal.(*model.GroupMember)
-	if !ok || member.Role != "manager" {
-		return echo.NewHTTPError(http.StatusForbidden, "group manager required")
-	}
-
-	groupIDVal := c.Get("group_id")
-	groupID, ok := groupIDVal.(uint)
-	if !ok {
-		return echo.NewHTTPError(http.StatusBadRequest, "invalid group")
+	member, err := getGroupMemberFromContext(c)
+	if err != nil {
+		return err
 	}
 
 	var req CreateInviteRequest
@@ -69,9 +56,9 @@ func (h *InviteHandler) CreateInvite(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	invite, err := h.inviteUsecase.CreateInvite(req.Email, req.Role, user.ID, groupID)
+	invite, err := h.inviteUsecase.CreateInvite(c.Request().Context(), req.Email, req.Role, member)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return forbiddenOr(err, echo.NewHTTPError(http.StatusInternalServerError, err.Error()))
 	}
 
 	return c.JSON(http.StatusCreated, CreateInviteResponse{
@@ -95,18 +82,18 @@ type VerifyInviteResponse struct {
 func (h *InviteHandler) VerifyInvite(c echo.Context) error {
 	token := c.Param("token")
 
-	invite, err := h.inviteUsecase.VerifyInvite(token)
+	invite, err := h.inviteUsecase.VerifyInvite(c.Request().Context(), token)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	group, err := h.groupUsecase.GetGroup(invite.GroupID)
+	group, err := h.groupUsecase.GetGroup(c.Request().Context(), invite.GroupID)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusNotFound, "group not found")
 	}
 
 	userExists := false
-	if _, err := h.userUsecase.GetUserByEmail(invite.Email); err == nil {
+	if _, err := h.userUsecase.GetUserByEmail(c.Request().Context(), invite.Email); err == nil {
 		userExists = true
 	}
 
@@ -144,7 +131,7 @@ func (h *InviteHandler) AcceptInvite(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusUnauthorized, "invalid user")
 	}
 
-	if err := h.inviteUsecase.AcceptInviteForUser(token, user); err != nil {
+	if err := h.inviteUsecase.AcceptInviteForUser(c.Request().Context(), token, user); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
@@ -164,7 +151,7 @@ func (h *InviteHandler) SignupInvite(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	invite, err := h.inviteUsecase.VerifyInvite(token)
+	invite, err := h.inviteUsecase.VerifyInvite(c.Request().Context(), token)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
@@ -174,7 +161,7 @@ func (h *InviteHandler) SignupInvite(c echo.Context) error {
 	}
 
 	backPath := sanitizeBackPath(req.BackPath)
-	user, sessionCookie, err := h.authUsecase.Signup(req.Email, req.Password, req.DisplayName, backPath)
+	user, sessionCookie, err := h.authUsecase.Signup(c.Request().Context(), req.Email, req.Password, req.DisplayName, backPath)
 	if err != nil {
 		if authErr, ok := err.(*usecase.AuthError); ok {
 			return c.JSON(http.StatusBadRequest, map[string]string{
@@ -185,7 +172,7 @@ func (h *InviteHandler) SignupInvite(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	if err := h.inviteUsecase.AcceptInviteForUser(token, user); err != nil {
+	if err := h.inviteUsecase.AcceptInviteForUser(c.Request().Context(), token, user); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
@@ -208,7 +195,7 @@ func (h *InviteHandler) DeclineInvite(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusUnauthorized, "invalid user")
 	}
 
-	if err := h.inviteUsecase.DeclineInviteForUser(token, user); err != nil {
+	if err := h.inviteUsecase.DeclineInviteForUser(c.Request().Context(), token, user); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
@@ -228,21 +215,14 @@ type InviteListResponse struct {
 }
 
 func (h *InviteHandler) GetGroupInvites(c echo.Context) error {
-	groupMemberVal := c.Get("group_member")
-	member, ok := groupMemberVal.(*model.GroupMember)
-	if !ok || member.Role != "manager" {
-		return echo.NewHTTPError(http.StatusForbidden, "group manager required")
-	}
-
-	groupIDVal := c.Get("group_id")
-	groupID, ok := groupIDVal.(uint)
-	if !ok {
-		return echo.NewHTTPError(http.StatusBadRequest, "invalid group")
+	member, err := getGroupMemberFromContext(c)
+	if err != nil {
+		return err
 	}
 
-	invites, err := h.inviteUsecase.GetGroupInvites(groupID)
+	invites, err := h.inviteUsecase.GetGroupInvites(c.Request().Context(), member)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return forbiddenOr(err, echo.NewHTTPError(http.StatusInternalServerError, err.Error()))
 	}
 
 	response := make([]InviteListResponse, len(invites))
@@ -264,16 +244,9 @@ func (h *InviteHandler) GetGroupInvites(c echo.Context) error {
 
 // グループ用: 招待削除
 func (h *InviteHandler) DeleteInvite(c echo.Context) error {
-	groupMemberVal := c.Get("group_member")
-	member, ok := groupMemberVal.(*model.GroupMember)
-	if !ok || member.Role != "manager" {
-		return echo.NewHTTPError(http.StatusForbidden, "group manager required")
-	}
-
-	groupIDVal := c.Get("group_id")
-	groupID, ok := groupIDVal.(uint)
-	if !ok {
-		return echo.NewHTTPError(http.StatusBadRequest, "invalid group")
+	member, err := getGroupMemberFromContext(c)
+	if err != nil {
+		return err
 	}
 
 	inviteID := c.Param("id")
@@ -282,8 +255,8 @@ func (h *InviteHandler) DeleteInvite(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid invite ID")
 	}
 
-	if err := h.inviteUsecase.DeleteInvite(id, groupID); err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	if err := h.inviteUsecase.DeleteInvite(c.Request().Context(), id, member); err != nil {
+		return forbiddenOr(err, echo.NewHTTPError(http.StatusInternalServerError, err.Error()))
 	}
 
 	return c.NoContent(http.StatusNoContent)
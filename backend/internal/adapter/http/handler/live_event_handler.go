@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"memoria/internal/domain/model"
+	"memoria/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+// liveEventHeartbeat keeps proxies from closing idle streams; membership is
+// re-checked on the same tick.
+const liveEventHeartbeat = 25 * time.Second
+
+type LiveEventHandler struct {
+	liveEventUsecase *usecase.LiveEventUsecase
+}
+
+func NewLiveEventHandler(liveEventUsecase *usecase.LiveEventUsecase) *LiveEventHandler {
+	return &LiveEventHandler{
+		liveEventUsecase: liveEventUsecase,
+	}
+}
+
+// Stream serves the group's live events as Server-Sent Events. Browsers
+// resend the last received id in Last-Event-ID when they reconnect; when it
+// is no longer buffered a "resync" event tells the client to reload.
+func (h *LiveEventHandler) Stream(c echo.Context) error {
+	user, ok := c.Get("user").(*model.User)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid user")
+	}
+
+	groupID, err := getGroupIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	lastEventID := c.Request().Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.QueryParam("last_event_id")
+	}
+
+	sub := h.liveEventUsecase.Subscribe(c.Request().Context(), groupID, lastEventID)
+	defer sub.Close()
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+
+	fmt.Fprint(res, "retry: 3000\n\n")
+	if sub.Resync {
+		writeLiveEvent(res, sub.LatestID, "resync", []byte("{}"))
+	}
+	for _, e := range sub.Replay {
+		writeLiveEvent(res, e.ID, e.Type, e.Data)
+	}
+	res.Flush()
+
+	ticker := time.NewTicker(liveEventHeartbeat)
+	defer ticker.Stop()
+	ctx := c.Request().Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e, ok := <-sub.Events:
+			if !ok {
+				// Dropped by the broker; the client reconnects and resumes.
+				return nil
+			}
+			writeLiveEvent(res, e.ID, e.Type, e.Data)
+			res.Flush()
+		case <-ticker.C:
+			if !h.liveEventUsecase.IsMember(c.Request().Context(), groupID, user.ID) {
+				writeLiveEvent(res, "", "revoked", []byte("{}"))
+				res.Flush()
+				return nil
+			}
+			fmt.Fprint(res, ": ping\n\n")
+			res.Flush()
+		}
+	}
+}
+
+func writeLiveEvent(res *echo.Response, id, eventType string, data []byte) {
+	if id != "" {
+		fmt.Fprintf(res, "id: %s\n", id)
+	}
+	fmt.Fprintf(res, "event: %s\ndata: %s\n\n", eventType, data)
+}
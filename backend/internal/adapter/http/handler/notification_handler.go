@@ -0,0 +1,310 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"memoria/internal/domain/model"
+	"memoria/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+type NotificationHandler struct {
+	notificationUsecase *usecase.NotificationUsecase
+	vapidPublicKey      string
+}
+
+func NewNotificationHandler(notificationUsecase *usecase.NotificationUsecase, vapidPublicKey string) *NotificationHandler {
+	return &NotificationHandler{
+		notificationUsecase: notificationUsecase,
+		vapidPublicKey:      vapidPublicKey,
+	}
+}
+
+type NotificationResponse struct {
+	ID        uint    `json:"id"`
+	Category  string  `json:"category"`
+	Title     string  `json:"title"`
+	Body      string  `json:"body"`
+	Count     int     `json:"count"`
+	ReadAt    *string `json:"read_at,omitempty"`
+	CreatedAt string  `json:"created_at"`
+}
+
+type NotificationListResponse struct {
+	Notifications []NotificationResponse `json:"notifications"`
+	UnreadCount   int64                  `json:"unread_count"`
+	NextCursor    *string                `json:"next_cursor"`
+}
+
+type NotificationSettingPayload struct {
+	Category string `json:"category"`
+	Enabled  bool   `json:"enabled"`
+}
+
+// PushSubscriptionRequest takes the keys either flat or nested under "keys"
+// as in the browser's PushSubscription.toJSON().
+type PushSubscriptionRequest struct {
+	Endpoint string `json:"endpoint" validate:"required"`
+	Auth     string `json:"auth"`
+	P256dh   string `json:"p256dh"`
+	Keys     struct {
+		Auth   string `json:"auth"`
+		P256dh string `json:"p256dh"`
+	} `json:"keys"`
+}
+
+type PushSubscriptionResponse struct {
+	ID        uint   `json:"id"`
+	Endpoint  string `json:"endpoint"`
+	CreatedAt string `json:"created_at"`
+}
+
+func (h *NotificationHandler) GetNotifications(c echo.Context) error {
+	user, ok := c.Get("user").(*model.User)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid user")
+	}
+
+	var cursor uint64
+	if raw := c.QueryParam("cursor"); raw != "" {
+		parsed, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid cursor")
+		}
+		cursor = parsed
+	}
+	limit := 0
+	if raw := c.QueryParam("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid limit")
+		}
+		limit = parsed
+	}
+	unreadOnly := c.QueryParam("unread") == "true"
+
+	page, err := h.notificationUsecase.GetNotifications(c.Request().Context(), user.ID, uint(cursor), limit, unreadOnly)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	response := NotificationListResponse{
+		Notifications: make([]NotificationResponse, len(page.Notifications)),
+		UnreadCount:   page.UnreadCount,
+	}
+	for i, notification := range page.Notifications {
+		response.Notifications[i] = buildNotificationResponse(notification)
+	}
+	if page.NextCursor != 0 {
+		nextCursor := strconv.FormatUint(uint64(page.NextCursor), 10)
+		response.NextCursor = &nextCursor
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *NotificationHandler) MarkAsRead(c echo.Context) error {
+	user, ok := c.Get("user").(*model.User)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid user")
+	}
+
+	id, err := parseNotificationID(c)
+	if err != nil {
+		return err
+	}
+
+	notification, err := h.notificationUsecase.MarkAsRead(c.Request().Context(), id, user.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "notification not found")
+	}
+
+	return c.JSON(http.StatusOK, buildNotificationResponse(notification))
+}
+
+func (h *NotificationHandler) MarkAllAsRead(c echo.Context) error {
+	user, ok := c.Get("user").(*model.User)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid user")
+	}
+
+	updated, err := h.notificationUsecase.MarkAllAsRead(c.Request().Context(), user.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, map[string]int64{"updated": updated})
+}
+
+func (h *NotificationHandler) DeleteNotification(c echo.Context) error {
+	user, ok := c.Get("user").(*model.User)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid user")
+	}
+
+	id, err := parseNotificationID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.notificationUsecase.DeleteNotification(c.Request().Context(), id, user.ID); err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "notification not found")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *NotificationHandler) GetSettings(c echo.Context) error {
+	user, ok := c.Get("user").(*model.User)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid user")
+	}
+
+	preferences, err := h.notificationUsecase.GetSettings(c.Request().Context(), user.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, buildNotificationSettingsResponse(preferences))
+}
+
+func (h *NotificationHandler) UpdateSettings(c echo.Context) error {
+	user, ok := c.Get("user").(*model.User)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid user")
+	}
+
+	var req []NotificationSettingPayload
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	preferences := make([]usecase.NotificationPreference, len(req))
+	for i, setting := range req {
+		preferences[i] = usecase.NotificationPreference{Category: setting.Category, Enabled: setting.Enabled}
+	}
+
+	updated, err := h.notificationUsecase.UpdateSettings(c.Request().Context(), user.ID, preferences)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidNotificationCategory) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, buildNotificationSettingsResponse(updated))
+}
+
+// GetVAPIDPublicKey returns the applicationServerKey for
+// PushManager.subscribe(). 404 means web push is not configured.
+func (h *NotificationHandler) GetVAPIDPublicKey(c echo.Context) error {
+	if h.vapidPublicKey == "" {
+		return echo.NewHTTPError(http.StatusNotFound, "web push is not configured")
+	}
+	return c.JSON(http.StatusOK, map[string]string{"public_key": h.vapidPublicKey})
+}
+
+func (h *NotificationHandler) GetPushSubscriptions(c echo.Context) error {
+	user, ok := c.Get("user").(*model.User)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid user")
+	}
+
+	subscriptions, err := h.notificationUsecase.GetPushSubscriptions(c.Request().Context(), user.ID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	response := make([]PushSubscriptionResponse, len(subscriptions))
+	for i, subscription := range subscriptions {
+		response[i] = buildPushSubscriptionResponse(subscription)
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *NotificationHandler) CreatePushSubscription(c echo.Context) error {
+	user, ok := c.Get("user").(*model.User)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid user")
+	}
+
+	var req PushSubscriptionRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if req.Auth == "" && req.P256dh == "" {
+		req.Auth = req.Keys.Auth
+		req.P256dh = req.Keys.P256dh
+	}
+
+	subscription, err := h.notificationUsecase.RegisterPushSubscription(c.Request().Context(), user.ID, req.Endpoint, req.Auth, req.P256dh)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidPushSubscription) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusCreated, buildPushSubscriptionResponse(subscription))
+}
+
+func (h *NotificationHandler) DeletePushSubscription(c echo.Context) error {
+	user, ok := c.Get("user").(*model.User)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid user")
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid subscription ID")
+	}
+
+	if err := h.notificationUsecase.DeletePushSubscription(c.Request().Context(), uint(id), user.ID); err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "push subscription not found")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func parseNotificationID(c echo.Context) (uint, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "invalid notification ID")
+	}
+	return uint(id), nil
+}
+
+func buildNotificationResponse(notification *model.Notification) NotificationResponse {
+	response := NotificationResponse{
+		ID:        notification.ID,
+		Category:  notification.Category,
+		Title:     notification.Title,
+		Body:      notification.Body,
+		Count:     notification.Count,
+		CreatedAt: notification.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if notification.ReadAt != nil {
+		readAt := notification.ReadAt.Format("2006-01-02T15:04:05Z07:00")
+		response.ReadAt = &readAt
+	}
+	return response
+}
+
+func buildNotificationSettingsResponse(preferences []usecase.NotificationPreference) []NotificationSettingPayload {
+	response := make([]NotificationSettingPayload, len(preferences))
+	for i, preference := range preferences {
+		response[i] = NotificationSettingPayload{Category: preference.Category, Enabled: preference.Enabled}
+	}
+	return response
+}
+
+func buildPushSubscriptionResponse(subscription *model.WebPushSubscription) PushSubscriptionResponse {
+	return PushSubscriptionResponse{
+		ID:        subscription.ID,
+		Endpoint:  subscription.Endpoint,
+		CreatedAt: subscription.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -23,6 +24,7 @@ func NewPhotoHandler(photoUsecase *usecase.PhotoUsecase) *PhotoHandler {
 type PresignRequest struct {
 	Filename    string `json:"filename" validate:"required"`
 	ContentType string `json:"content_type" validate:"required"`
+	SizeBytes   int64  `json:"size_bytes" validate:"required"`
 }
 
 type PresignResponse struct {
@@ -52,9 +54,9 @@ func (h *PhotoHandler) GeneratePresignedURL(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	url, key, err := h.photoUsecase.GenerateUploadURL(uint(albumID), req.Filename, req.ContentType, user.ID, groupID)
+	url, key, err := h.photoUsecase.GenerateUploadURL(c.Request().Context(), uint(albumID), req.Filename, req.ContentType, req.SizeBytes, user.ID, groupID)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return uploadErrorOr(c, err)
 	}
 
 	return c.JSON(http.StatusOK, PresignResponse{
@@ -63,12 +65,10 @@ func (h *PhotoHandler) GeneratePresignedURL(c echo.Context) error {
 	})
 }
 
+// CreatePhotoRequest names the uploaded key. The content type, size and
+// dimensions are read from the uploaded file.
 type CreatePhotoRequest struct {
-	S3Key       string `json:"s3_key" validate:"required"`
-	ContentType string `json:"content_type" validate:"required"`
-	SizeBytes   int64  `json:"size_bytes" validate:"required"`
-	Width       int    `json:"width"`
-	Height      int    `json:"height"`
+	S3Key string `json:"s3_key" validate:"required"`
 }
 
 func (h *PhotoHandler) CreatePhoto(c echo.Context) error {
@@ -93,34 +93,20 @@ func (h *PhotoHandler) CreatePhoto(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	photo, err := h.photoUsecase.CreatePhoto(
-		uint(albumID),
-		req.S3Key,
-		req.ContentType,
-		req.SizeBytes,
-		req.Width,
-		req.Height,
-		user.ID,
-		groupID,
-	)
+	photo, err := h.photoUsecase.CreatePhoto(c.Request().Context(), uint(albumID), req.S3Key, user.ID, groupID)
+	if err != nil {
+		return uploadErrorOr(c, err)
+	}
+
+	urls, err := h.photoUsecase.SignURLs(photo, nil)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
-	return c.JSON(http.StatusCreated, PhotoResponse{
-		ID:          photo.ID,
-		AlbumID:     photo.AlbumID,
-		S3Key:       photo.S3Key,
-		ContentType: photo.ContentType,
-		SizeBytes:   photo.SizeBytes,
-		Width:       photo.Width,
-		Height:      photo.Height,
-		UploadedBy:  photo.UploadedBy,
-		CreatedAt:   photo.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	})
+	return c.JSON(http.StatusCreated, buildPhotoResponse(photo, nil, urls))
 }
 
-func (h *PhotoHandler) DeletePhoto(c echo.Context) error {
+func (h *PhotoHandler) GetPhoto(c echo.Context) error {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid photo ID")
@@ -131,9 +117,88 @@ func (h *PhotoHandler) DeletePhoto(c echo.Context) error {
 		return err
 	}
 
-	if err := h.photoUsecase.DeletePhoto(uint(id), groupID); err != nil {
+	photo, err := h.photoUsecase.GetPhoto(c.Request().Context(), uint(id), groupID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "photo not found")
+	}
+	variants, err := h.photoUsecase.GetVariants(c.Request().Context(), photo)
+	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
+	urls, err := h.photoUsecase.SignURLs(photo, variants)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, buildPhotoResponse(photo, variants, urls))
+}
+
+// GetPhotoContent streams the photo, or ?variant=, through the server for
+// clients that cannot load the presigned URLs. Range and If-None-Match are
+// honored.
+func (h *PhotoHandler) GetPhotoContent(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid photo ID")
+	}
+
+	groupID, err := getGroupIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	req := c.Request()
+	photo, err := h.photoUsecase.GetPhoto(req.Context(), uint(id), groupID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "photo not found")
+	}
+	object, err := h.photoUsecase.OpenContent(req.Context(), photo, c.QueryParam("variant"), req.Header.Get("Range"), req.Header.Get("If-None-Match"))
+	if errors.Is(err, usecase.ErrPhotoVariantNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return objectErrorOr(c, err)
+	}
+	c.Response().Header().Set("Cache-Control", "private, no-cache")
+	return streamObject(c, object)
+}
+
+type UploadErrorResponse struct {
+	Code         string   `json:"code"`
+	Message      string   `json:"message"`
+	MaxBytes     int64    `json:"max_bytes,omitempty"`
+	AllowedTypes []string `json:"allowed_types,omitempty"`
+}
+
+// uploadErrorOr answers a rejected upload with 400 and its code, and any
+// other error with 500.
+func uploadErrorOr(c echo.Context, err error) error {
+	var uploadErr *usecase.UploadError
+	if !errors.As(err, &uploadErr) {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusBadRequest, UploadErrorResponse{
+		Code:         uploadErr.Code,
+		Message:      uploadErr.Message,
+		MaxBytes:     uploadErr.MaxBytes,
+		AllowedTypes: uploadErr.AllowedTypes,
+	})
+}
+
+func (h *PhotoHandler) DeletePhoto(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid photo ID")
+	}
+
+	member, err := getGroupMemberFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.photoUsecase.DeletePhoto(c.Request().Context(), uint(id), member); err != nil {
+		return forbiddenOr(err, echo.NewHTTPError(http.StatusInternalServerError, err.Error()))
+	}
 
 	return c.NoContent(http.StatusNoContent)
 }
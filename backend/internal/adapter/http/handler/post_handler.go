@@ -37,6 +37,11 @@ type PostResponse struct {
 	CreatedAt   string `json:"created_at"`
 }
 
+type PostListResponse struct {
+	Posts      []PostResponse `json:"posts"`
+	NextCursor *string        `json:"next_cursor"`
+}
+
 func (h *PostHandler) CreatePost(c echo.Context) error {
 	userVal := c.Get("user")
 	user, ok := userVal.(*model.User)
@@ -54,7 +59,7 @@ func (h *PostHandler) CreatePost(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	post, err := h.postUsecase.CreatePost(req.Type, req.Title, req.Body, user.ID, req.Tags, groupID)
+	post, err := h.postUsecase.CreatePost(c.Request().Context(), req.Type, req.Title, req.Body, user.ID, req.Tags, groupID)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
@@ -81,7 +86,7 @@ func (h *PostHandler) GetPost(c echo.Context) error {
 		return err
 	}
 
-	post, err := h.postUsecase.GetPost(uint(id), groupID)
+	post, err := h.postUsecase.GetPost(c.Request().Context(), uint(id), groupID)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusNotFound, "post not found")
 	}
@@ -103,14 +108,22 @@ func (h *PostHandler) GetAllPosts(c echo.Context) error {
 		return err
 	}
 
-	posts, err := h.postUsecase.GetAllPosts(groupID)
+	opts, err := parseListOptions(c)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
-	response := make([]PostResponse, len(posts))
-	for i, post := range posts {
-		response[i] = PostResponse{
+	page, err := h.postUsecase.GetAllPosts(c.Request().Context(), groupID, opts)
+	if err != nil {
+		return listErrorOr(err, echo.NewHTTPError(http.StatusInternalServerError, err.Error()))
+	}
+
+	response := PostListResponse{
+		Posts:      make([]PostResponse, len(page.Items)),
+		NextCursor: nextCursor(page.NextCursor),
+	}
+	for i, post := range page.Items {
+		response.Posts[i] = PostResponse{
 			ID:          post.ID,
 			Type:        post.Type,
 			Title:       post.Title,
@@ -137,7 +150,7 @@ func (h *PostHandler) UpdatePost(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid post ID")
 	}
 
-	groupID, err := getGroupIDFromContext(c)
+	member, err := getGroupMemberFromContext(c)
 	if err != nil {
 		return err
 	}
@@ -147,9 +160,9 @@ func (h *PostHandler) UpdatePost(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	post, err := h.postUsecase.UpdatePost(uint(id), req.Type, req.Title, req.Body, req.Tags, groupID)
+	post, err := h.postUsecase.UpdatePost(c.Request().Context(), uint(id), req.Type, req.Title, req.Body, req.Tags, member)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return forbiddenOr(err, echo.NewHTTPError(http.StatusInternalServerError, err.Error()))
 	}
 
 	return c.JSON(http.StatusOK, PostResponse{
@@ -169,13 +182,13 @@ func (h *PostHandler) DeletePost(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid post ID")
 	}
 
-	groupID, err := getGroupIDFromContext(c)
+	member, err := getGroupMemberFromContext(c)
 	if err != nil {
 		return err
 	}
 
-	if err := h.postUsecase.DeletePost(uint(id), groupID); err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	if err := h.postUsecase.DeletePost(c.Request().Context(), uint(id), member); err != nil {
+		return forbiddenOr(err, echo.NewHTTPError(http.StatusInternalServerError, err.Error()))
 	}
 
 	return c.NoContent(http.StatusNoContent)
@@ -198,7 +211,7 @@ func (h *PostHandler) AddLike(c echo.Context) error {
 		return err
 	}
 
-	if err := h.postUsecase.AddLike(uint(postID), user.ID, groupID); err != nil {
+	if err := h.postUsecase.AddLike(c.Request().Context(), uint(postID), user.ID, groupID); err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
@@ -222,7 +235,7 @@ func (h *PostHandler) RemoveLike(c echo.Context) error {
 		return err
 	}
 
-	if err := h.postUsecase.RemoveLike(uint(postID), user.ID, groupID); err != nil {
+	if err := h.postUsecase.RemoveLike(c.Request().Context(), uint(postID), user.ID, groupID); err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
@@ -241,6 +254,11 @@ type CommentResponse struct {
 	CreatedAt string `json:"created_at"`
 }
 
+type CommentListResponse struct {
+	Comments   []CommentResponse `json:"comments"`
+	NextCursor *string           `json:"next_cursor"`
+}
+
 func (h *PostHandler) CreateComment(c echo.Context) error {
 	userVal := c.Get("user")
 	user, ok := userVal.(*model.User)
@@ -263,7 +281,7 @@ func (h *PostHandler) CreateComment(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	comment, err := h.postUsecase.CreateComment(uint(postID), user.ID, req.Body, groupID)
+	comment, err := h.postUsecase.CreateComment(c.Request().Context(), uint(postID), user.ID, req.Body, groupID)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
@@ -283,8 +301,13 @@ func (h *PostHandler) DeleteComment(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid comment ID")
 	}
 
-	if err := h.postUsecase.DeleteComment(uint(id)); err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	member, err := getGroupMemberFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.postUsecase.DeleteComment(c.Request().Context(), uint(id), member); err != nil {
+		return forbiddenOr(err, echo.NewHTTPError(http.StatusNotFound, "comment not found"))
 	}
 
 	return c.NoContent(http.StatusNoContent)
@@ -301,14 +324,22 @@ func (h *PostHandler) GetComments(c echo.Context) error {
 		return err
 	}
 
-	comments, err := h.postUsecase.GetComments(uint(postID), groupID)
+	opts, err := parseListOptions(c)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
-	response := make([]CommentResponse, len(comments))
-	for i, comment := range comments {
-		response[i] = CommentResponse{
+	page, err := h.postUsecase.GetComments(c.Request().Context(), uint(postID), groupID, opts)
+	if err != nil {
+		return listErrorOr(err, echo.NewHTTPError(http.StatusInternalServerError, err.Error()))
+	}
+
+	response := CommentListResponse{
+		Comments:   make([]CommentResponse, len(page.Items)),
+		NextCursor: nextCursor(page.NextCursor),
+	}
+	for i, comment := range page.Items {
+		response.Comments[i] = CommentResponse{
 			ID:        comment.ID,
 			PostID:    comment.PostID,
 			UserID:    comment.UserID,
@@ -330,7 +361,7 @@ func (h *PostHandler) AddAlbum(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid post ID")
 	}
 
-	groupID, err := getGroupIDFromContext(c)
+	member, err := getGroupMemberFromContext(c)
 	if err != nil {
 		return err
 	}
@@ -340,8 +371,8 @@ func (h *PostHandler) AddAlbum(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	if err := h.postUsecase.AddAlbum(uint(postID), req.AlbumID, groupID); err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	if err := h.postUsecase.AddAlbum(c.Request().Context(), uint(postID), req.AlbumID, member); err != nil {
+		return forbiddenOr(err, echo.NewHTTPError(http.StatusInternalServerError, err.Error()))
 	}
 
 	return c.NoContent(http.StatusCreated)
@@ -358,13 +389,13 @@ func (h *PostHandler) RemoveAlbum(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid album ID")
 	}
 
-	groupID, err := getGroupIDFromContext(c)
+	member, err := getGroupMemberFromContext(c)
 	if err != nil {
 		return err
 	}
 
-	if err := h.postUsecase.RemoveAlbum(uint(postID), uint(albumID), groupID); err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	if err := h.postUsecase.RemoveAlbum(c.Request().Context(), uint(postID), uint(albumID), member); err != nil {
+		return forbiddenOr(err, echo.NewHTTPError(http.StatusInternalServerError, err.Error()))
 	}
 
 	return c.NoContent(http.StatusNoContent)
@@ -380,7 +411,7 @@ func (h *PostHandler) AddPhoto(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid post ID")
 	}
 
-	groupID, err := getGroupIDFromContext(c)
+	member, err := getGroupMemberFromContext(c)
 	if err != nil {
 		return err
 	}
@@ -390,8 +421,8 @@ func (h *PostHandler) AddPhoto(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	if err := h.postUsecase.AddPhoto(uint(postID), req.PhotoID, groupID); err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	if err := h.postUsecase.AddPhoto(c.Request().Context(), uint(postID), req.PhotoID, member); err != nil {
+		return forbiddenOr(err, echo.NewHTTPError(http.StatusInternalServerError, err.Error()))
 	}
 
 	return c.NoContent(http.StatusCreated)
@@ -408,13 +439,13 @@ func (h *PostHandler) RemovePhoto(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid photo ID")
 	}
 
-	groupID, err := getGroupIDFromContext(c)
+	member, err := getGroupMemberFromContext(c)
 	if err != nil {
 		return err
 	}
 
-	if err := h.postUsecase.RemovePhoto(uint(postID), uint(photoID), groupID); err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	if err := h.postUsecase.RemovePhoto(c.Request().Context(), uint(postID), uint(photoID), member); err != nil {
+		return forbiddenOr(err, echo.NewHTTPError(http.StatusInternalServerError, err.Error()))
 	}
 
 	return c.NoContent(http.StatusNoContent)
@@ -426,7 +457,7 @@ type TagResponse struct {
 }
 
 func (h *PostHandler) GetAllTags(c echo.Context) error {
-	tags, err := h.postUsecase.GetAllTags()
+	tags, err := h.postUsecase.GetAllTags(c.Request().Context())
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"memoria/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+type SearchHandler struct {
+	searchUsecase *usecase.SearchUsecase
+}
+
+func NewSearchHandler(searchUsecase *usecase.SearchUsecase) *SearchHandler {
+	return &SearchHandler{
+		searchUsecase: searchUsecase,
+	}
+}
+
+type SearchResultResponse struct {
+	Type     string  `json:"type"`
+	ID       uint    `json:"id"`
+	ParentID uint    `json:"parent_id,omitempty"`
+	Title    string  `json:"title"`
+	Snippet  string  `json:"snippet"`
+	Score    float64 `json:"score"`
+	Date     string  `json:"date"`
+}
+
+type SearchResponse struct {
+	Results []SearchResultResponse `json:"results"`
+}
+
+// Search finds posts, comments, albums, trips and schedule items in the
+// group, best match first.
+func (h *SearchHandler) Search(c echo.Context) error {
+	groupID, err := getGroupIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	limit := 0
+	if raw := c.QueryParam("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid limit")
+		}
+		limit = parsed
+	}
+
+	hits, err := h.searchUsecase.Search(c.Request().Context(), groupID, c.QueryParam("q"), limit)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidSearchQuery) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	response := SearchResponse{Results: make([]SearchResultResponse, len(hits))}
+	for i, hit := range hits {
+		response.Results[i] = SearchResultResponse{
+			Type:     hit.Type,
+			ID:       hit.ID,
+			ParentID: hit.ParentID,
+			Title:    hit.Title,
+			Snippet:  hit.Snippet,
+			Score:    hit.Rank,
+			Date:     hit.Date.Format("2006-01-02T15:04:05Z07:00"),
+		}
+	}
+	return c.JSON(http.StatusOK, response)
+}
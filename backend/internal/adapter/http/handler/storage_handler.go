@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"memoria/internal/adapter/storage"
+	"memoria/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+// StorageHandler serves the signed URLs of storage.LocalStorage, standing
+// in for S3 when STORAGE_DRIVER=local. The signature is the only
+// authorization, as with S3's presigned URLs.
+type StorageHandler struct {
+	localStorage *storage.LocalStorage
+}
+
+func NewStorageHandler(localStorage *storage.LocalStorage) *StorageHandler {
+	return &StorageHandler{
+		localStorage: localStorage,
+	}
+}
+
+// GetObject downloads an object. Range and If-None-Match are honored.
+func (h *StorageHandler) GetObject(c echo.Context) error {
+	req := c.Request()
+	key := objectKey(c)
+	if err := h.localStorage.VerifyGet(key, c.QueryParam("expires"), c.QueryParam("signature")); err != nil {
+		return echo.NewHTTPError(http.StatusForbidden, err.Error())
+	}
+
+	object, err := h.localStorage.OpenObject(req.Context(), key, req.Header.Get("Range"), req.Header.Get("If-None-Match"))
+	if err != nil {
+		return objectErrorOr(c, err)
+	}
+	c.Response().Header().Set("Cache-Control", "private, max-age=900")
+	return streamObject(c, object)
+}
+
+// PutObject uploads an object, or with ?uploadId= a part of a multipart
+// upload. Like S3, the URL only accepts the content type and Content-Length
+// it was signed for.
+func (h *StorageHandler) PutObject(c echo.Context) error {
+	req := c.Request()
+	key := objectKey(c)
+	if uploadID := c.QueryParam("uploadId"); uploadID != "" {
+		return h.putPart(c, key, uploadID)
+	}
+	contentType := req.Header.Get(echo.HeaderContentType)
+	if err := h.localStorage.VerifyPut(key, contentType, req.ContentLength, c.QueryParam("expires"), c.QueryParam("signature")); err != nil {
+		return echo.NewHTTPError(http.StatusForbidden, err.Error())
+	}
+
+	err := h.localStorage.WriteObject(req.Context(), key, contentType, req.ContentLength, req.Body)
+	if errors.Is(err, storage.ErrSizeMismatch) {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+// putPart uploads a part and returns its ETag in the ETag header, as S3
+// does.
+func (h *StorageHandler) putPart(c echo.Context, key, uploadID string) error {
+	req := c.Request()
+	partNumber, err := strconv.Atoi(c.QueryParam("partNumber"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid part number")
+	}
+	if err := h.localStorage.VerifyPart(key, uploadID, partNumber, req.ContentLength, c.QueryParam("expires"), c.QueryParam("signature")); err != nil {
+		return echo.NewHTTPError(http.StatusForbidden, err.Error())
+	}
+
+	etag, err := h.localStorage.WritePart(req.Context(), key, uploadID, partNumber, req.ContentLength, req.Body)
+	switch {
+	case errors.Is(err, usecase.ErrUploadNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, storage.ErrSizeMismatch), errors.Is(err, usecase.ErrInvalidPart):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case err != nil:
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	c.Response().Header().Set("ETag", etag)
+	return c.NoContent(http.StatusOK)
+}
+
+// objectKey reads the key from the decoded path; echo's wildcard param
+// would still be escaped for keys with special characters.
+func objectKey(c echo.Context) string {
+	return strings.TrimPrefix(c.Request().URL.Path, storage.LocalPathPrefix)
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"memoria/internal/domain/model"
+	"memoria/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+type TrashHandler struct {
+	trashUsecase *usecase.TrashUsecase
+}
+
+func NewTrashHandler(trashUsecase *usecase.TrashUsecase) *TrashHandler {
+	return &TrashHandler{
+		trashUsecase: trashUsecase,
+	}
+}
+
+type TrashItemResponse struct {
+	Type          string `json:"type"`
+	ID            uint   `json:"id"`
+	ParentID      uint   `json:"parent_id,omitempty"`
+	ParentDeleted bool   `json:"parent_deleted"`
+	Title         string `json:"title"`
+	AuthorID      uint   `json:"author_id"`
+	DeletedAt     string `json:"deleted_at"`
+	PurgeAt       string `json:"purge_at"`
+}
+
+// GetTrash lists the group's deleted content, newest first.
+func (h *TrashHandler) GetTrash(c echo.Context) error {
+	groupID, err := getGroupIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	items, err := h.trashUsecase.GetTrash(c.Request().Context(), groupID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	response := make([]TrashItemResponse, len(items))
+	for i, item := range items {
+		response[i] = h.buildTrashItemResponse(item)
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// Restore takes an item out of the trash. Allowed to whoever may delete it.
+func (h *TrashHandler) Restore(c echo.Context) error {
+	member, err := getGroupMemberFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid ID")
+	}
+
+	if err := h.trashUsecase.Restore(c.Request().Context(), c.Param("type"), uint(id), member); err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrInvalidTrashType):
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		case errors.Is(err, usecase.ErrTrashParentDeleted):
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return forbiddenOr(err, echo.NewHTTPError(http.StatusNotFound, "item not found in trash"))
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *TrashHandler) buildTrashItemResponse(item *model.TrashItem) TrashItemResponse {
+	return TrashItemResponse{
+		Type:          item.Type,
+		ID:            item.ID,
+		ParentID:      item.ParentID,
+		ParentDeleted: item.ParentDeleted,
+		Title:         item.Title,
+		AuthorID:      item.AuthorID,
+		DeletedAt:     item.DeletedAt.Format("2006-01-02T15:04:05Z07:00"),
+		PurgeAt:       h.trashUsecase.PurgeAt(item).Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
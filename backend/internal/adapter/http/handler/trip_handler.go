@@ -1,14 +1,17 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"memoria/internal/domain/model"
 	"memoria/internal/usecase"
 
 	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
 )
 
 type TripHandler struct {
@@ -22,21 +25,23 @@ func NewTripHandler(tripUsecase *usecase.TripUsecase) *TripHandler {
 }
 
 type CreateTripRequest struct {
-	Title    string  `json:"title" validate:"required"`
-	StartAt  string  `json:"start_at" validate:"required"`
-	EndAt    string  `json:"end_at" validate:"required"`
-	Note     string  `json:"note"`
-	NotifyAt *string `json:"notify_at"`
-	AlbumIDs []uint  `json:"album_ids"`
-	PostIDs  []uint  `json:"post_ids"`
+	Title        string  `json:"title" validate:"required"`
+	StartAt      string  `json:"start_at" validate:"required"`
+	EndAt        string  `json:"end_at" validate:"required"`
+	Note         string  `json:"note"`
+	NotifyAt     *string `json:"notify_at"`
+	BaseCurrency string  `json:"base_currency"`
+	AlbumIDs     []uint  `json:"album_ids"`
+	PostIDs      []uint  `json:"post_ids"`
 }
 
 type UpdateTripRequest struct {
-	Title    string  `json:"title" validate:"required"`
-	StartAt  string  `json:"start_at" validate:"required"`
-	EndAt    string  `json:"end_at" validate:"required"`
-	Note     string  `json:"note"`
-	NotifyAt *string `json:"notify_at"`
+	Title        string  `json:"title" validate:"required"`
+	StartAt      string  `json:"start_at" validate:"required"`
+	EndAt        string  `json:"end_at" validate:"required"`
+	Note         string  `json:"note"`
+	NotifyAt     *string `json:"notify_at"`
+	BaseCurrency string  `json:"base_currency"`
 }
 
 type TripAlbumResponse struct {
@@ -54,16 +59,22 @@ type TripPostResponse struct {
 }
 
 type TripResponse struct {
-	ID        uint    `json:"id"`
-	Title     string  `json:"title"`
-	StartAt   string  `json:"start_at"`
-	EndAt     string  `json:"end_at"`
-	Note      string  `json:"note"`
-	CreatedBy uint    `json:"created_by"`
-	NotifyAt  *string `json:"notify_at,omitempty"`
-	CreatedAt string  `json:"created_at"`
-	Albums    []TripAlbumResponse `json:"albums,omitempty"`
-	Posts     []TripPostResponse  `json:"posts,omitempty"`
+	ID           uint                `json:"id"`
+	Title        string              `json:"title"`
+	StartAt      string              `json:"start_at"`
+	EndAt        string              `json:"end_at"`
+	Note         string              `json:"note"`
+	CreatedBy    uint                `json:"created_by"`
+	NotifyAt     *string             `json:"notify_at,omitempty"`
+	BaseCurrency string              `json:"base_currency"`
+	CreatedAt    string              `json:"created_at"`
+	Albums       []TripAlbumResponse `json:"albums,omitempty"`
+	Posts        []TripPostResponse  `json:"posts,omitempty"`
+}
+
+type TripListResponse struct {
+	Trips      []TripResponse `json:"trips"`
+	NextCursor *string        `json:"next_cursor"`
 }
 
 type TripScheduleItemPayload struct {
@@ -106,6 +117,7 @@ type TripTransportPayload struct {
 	HighwayCostYen       int64   `json:"highway_cost_yen"`
 	RentalFeeYen         int64   `json:"rental_fee_yen"`
 	FareYen              int64   `json:"fare_yen"`
+	Currency             string  `json:"currency"`
 }
 
 type TripTransportRequest struct {
@@ -126,6 +138,7 @@ type TripLodgingPayload struct {
 	CheckOut          string `json:"check_out"`
 	ReservationNumber string `json:"reservation_number"`
 	CostYen           int64  `json:"cost_yen"`
+	Currency          string `json:"currency"`
 }
 
 type TripLodgingRequest struct {
@@ -138,8 +151,9 @@ type TripLodgingResponse struct {
 }
 
 type TripBudgetItemPayload struct {
-	Name    string `json:"name"`
-	CostYen int64  `json:"cost_yen"`
+	Name     string `json:"name"`
+	CostYen  int64  `json:"cost_yen"`
+	Currency string `json:"currency"`
 }
 
 type TripBudgetItemRequest struct {
@@ -149,13 +163,24 @@ type TripBudgetItemRequest struct {
 type TripBudgetItemResponse struct {
 	ID      uint   `json:"id"`
 	TripBudgetItemPayload
+	ConvertedCost *int64 `json:"converted_cost"`
+}
+
+type CurrencyAmountResponse struct {
+	Currency string `json:"currency"`
+	Amount   int64  `json:"amount"`
 }
 
 type TripBudgetResponse struct {
-	TransportTotal int64                   `json:"transport_total"`
-	LodgingTotal   int64                   `json:"lodging_total"`
-	Total          int64                   `json:"total"`
-	Items          []TripBudgetItemResponse `json:"items"`
+	BaseCurrency       string                   `json:"base_currency"`
+	TransportTotal     int64                    `json:"transport_total"`
+	TransportOriginals []CurrencyAmountResponse `json:"transport_originals"`
+	LodgingTotal       int64                    `json:"lodging_total"`
+	LodgingOriginals   []CurrencyAmountResponse `json:"lodging_originals"`
+	Total              int64                    `json:"total"`
+	Items              []TripBudgetItemResponse `json:"items"`
+	Actual             TripActualResponse       `json:"actual"`
+	MissingRates       []string                 `json:"missing_rates"`
 }
 
 func (h *TripHandler) CreateTrip(c echo.Context) error {
@@ -194,7 +219,12 @@ func (h *TripHandler) CreateTrip(c echo.Context) error {
 		notifyAt = &parsed
 	}
 
-	trip, err := h.tripUsecase.CreateTrip(req.Title, startAt, endAt, req.Note, user.ID, notifyAt, req.AlbumIDs, req.PostIDs, groupID)
+	baseCurrency, err := parseCurrencyParam(req.BaseCurrency, "base_currency")
+	if err != nil {
+		return err
+	}
+
+	trip, err := h.tripUsecase.CreateTrip(c.Request().Context(), req.Title, startAt, endAt, req.Note, user.ID, notifyAt, baseCurrency, req.AlbumIDs, req.PostIDs, groupID)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
@@ -206,14 +236,15 @@ func (h *TripHandler) CreateTrip(c echo.Context) error {
 	}
 
 	return c.JSON(http.StatusCreated, TripResponse{
-		ID:        trip.ID,
-		Title:     trip.Title,
-		StartAt:   trip.StartAt.Format("2006-01-02T15:04:05Z07:00"),
-		EndAt:     trip.EndAt.Format("2006-01-02T15:04:05Z07:00"),
-		Note:      trip.Note,
-		CreatedBy: trip.CreatedBy,
-		NotifyAt:  notifyAtStr,
-		CreatedAt: trip.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		ID:           trip.ID,
+		Title:        trip.Title,
+		StartAt:      trip.StartAt.Format("2006-01-02T15:04:05Z07:00"),
+		EndAt:        trip.EndAt.Format("2006-01-02T15:04:05Z07:00"),
+		Note:         trip.Note,
+		CreatedBy:    trip.CreatedBy,
+		NotifyAt:     notifyAtStr,
+		BaseCurrency: trip.BaseCurrency,
+		CreatedAt:    trip.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 	})
 }
 
@@ -223,28 +254,37 @@ func (h *TripHandler) GetAllTrips(c echo.Context) error {
 		return err
 	}
 
-	trips, err := h.tripUsecase.GetAllTrips(groupID)
+	opts, err := parseListOptions(c)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
-	response := make([]TripResponse, len(trips))
-	for i, trip := range trips {
+	page, err := h.tripUsecase.GetAllTrips(c.Request().Context(), groupID, opts)
+	if err != nil {
+		return listErrorOr(err, echo.NewHTTPError(http.StatusInternalServerError, err.Error()))
+	}
+
+	response := TripListResponse{
+		Trips:      make([]TripResponse, len(page.Items)),
+		NextCursor: nextCursor(page.NextCursor),
+	}
+	for i, trip := range page.Items {
 		var notifyAtStr *string
 		if trip.NotifyAt != nil {
 			str := trip.NotifyAt.Format("2006-01-02T15:04:05Z07:00")
 			notifyAtStr = &str
 		}
 
-		response[i] = TripResponse{
-			ID:        trip.ID,
-			Title:     trip.Title,
-			StartAt:   trip.StartAt.Format("2006-01-02T15:04:05Z07:00"),
-			EndAt:     trip.EndAt.Format("2006-01-02T15:04:05Z07:00"),
-			Note:      trip.Note,
-			CreatedBy: trip.CreatedBy,
-			NotifyAt:  notifyAtStr,
-			CreatedAt: trip.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		response.Trips[i] = TripResponse{
+			ID:           trip.ID,
+			Title:        trip.Title,
+			StartAt:      trip.StartAt.Format("2006-01-02T15:04:05Z07:00"),
+			EndAt:        trip.EndAt.Format("2006-01-02T15:04:05Z07:00"),
+			Note:         trip.Note,
+			CreatedBy:    trip.CreatedBy,
+			NotifyAt:     notifyAtStr,
+			BaseCurrency: trip.BaseCurrency,
+			CreatedAt:    trip.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		}
 	}
 
@@ -262,12 +302,12 @@ func (h *TripHandler) GetTrip(c echo.Context) error {
 		return err
 	}
 
-	trip, err := h.tripUsecase.GetTrip(uint(id), groupID)
+	trip, err := h.tripUsecase.GetTrip(c.Request().Context(), uint(id), groupID)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusNotFound, "trip not found")
 	}
 
-	albums, posts, err := h.tripUsecase.GetTripRelations(trip.ID, groupID)
+	albums, posts, err := h.tripUsecase.GetTripRelations(c.Request().Context(), trip.ID, groupID)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
@@ -286,7 +326,7 @@ func (h *TripHandler) GetSchedule(c echo.Context) error {
 		return err
 	}
 
-	items, err := h.tripUsecase.GetSchedule(uint(id), groupID)
+	items, err := h.tripUsecase.GetSchedule(c.Request().Context(), uint(id), groupID)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
@@ -331,7 +371,7 @@ func (h *TripHandler) UpdateSchedule(c echo.Context) error {
 		}
 	}
 
-	if err := h.tripUsecase.UpdateSchedule(uint(id), items, groupID); err != nil {
+	if err := h.tripUsecase.UpdateSchedule(c.Request().Context(), uint(id), items, groupID); err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 	return c.NoContent(http.StatusNoContent)
@@ -348,7 +388,7 @@ func (h *TripHandler) GetTransports(c echo.Context) error {
 		return err
 	}
 
-	transports, err := h.tripUsecase.GetTransports(uint(id), groupID)
+	transports, err := h.tripUsecase.GetTransports(c.Request().Context(), uint(id), groupID)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
@@ -382,6 +422,7 @@ func (h *TripHandler) GetTransports(c echo.Context) error {
 				HighwayCostYen:       transport.HighwayCostYen,
 				RentalFeeYen:         transport.RentalFeeYen,
 				FareYen:              transport.FareYen,
+				Currency:             transport.Currency,
 			},
 		}
 	}
@@ -406,6 +447,10 @@ func (h *TripHandler) UpdateTransports(c echo.Context) error {
 
 	transports := make([]*model.TripTransport, len(req))
 	for i, transport := range req {
+		currency, err := parseCurrencyParam(transport.Currency, "currency")
+		if err != nil {
+			return err
+		}
 		transports[i] = &model.TripTransport{
 			TripID:               uint(id),
 			Mode:                 transport.Mode,
@@ -432,10 +477,11 @@ func (h *TripHandler) UpdateTransports(c echo.Context) error {
 			HighwayCostYen:       transport.HighwayCostYen,
 			RentalFeeYen:         transport.RentalFeeYen,
 			FareYen:              transport.FareYen,
+			Currency:             currency,
 		}
 	}
 
-	if err := h.tripUsecase.UpdateTransports(uint(id), transports, groupID); err != nil {
+	if err := h.tripUsecase.UpdateTransports(c.Request().Context(), uint(id), transports, groupID); err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 	return c.NoContent(http.StatusNoContent)
@@ -452,7 +498,7 @@ func (h *TripHandler) GetLodgings(c echo.Context) error {
 		return err
 	}
 
-	lodgings, err := h.tripUsecase.GetLodgings(uint(id), groupID)
+	lodgings, err := h.tripUsecase.GetLodgings(c.Request().Context(), uint(id), groupID)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
@@ -470,6 +516,7 @@ func (h *TripHandler) GetLodgings(c echo.Context) error {
 				CheckOut:          lodging.CheckOut,
 				ReservationNumber: lodging.ReservationNumber,
 				CostYen:           lodging.CostYen,
+				Currency:          lodging.Currency,
 			},
 		}
 	}
@@ -494,6 +541,10 @@ func (h *TripHandler) UpdateLodgings(c echo.Context) error {
 
 	lodgings := make([]*model.TripLodging, len(req))
 	for i, lodging := range req {
+		currency, err := parseCurrencyParam(lodging.Currency, "currency")
+		if err != nil {
+			return err
+		}
 		lodgings[i] = &model.TripLodging{
 			TripID:            uint(id),
 			Date:              lodging.Date,
@@ -504,10 +555,11 @@ func (h *TripHandler) UpdateLodgings(c echo.Context) error {
 			CheckOut:          lodging.CheckOut,
 			ReservationNumber: lodging.ReservationNumber,
 			CostYen:           lodging.CostYen,
+			Currency:          currency,
 		}
 	}
 
-	if err := h.tripUsecase.UpdateLodgings(uint(id), lodgings, groupID); err != nil {
+	if err := h.tripUsecase.UpdateLodgings(c.Request().Context(), uint(id), lodgings, groupID); err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 	return c.NoContent(http.StatusNoContent)
@@ -524,27 +576,37 @@ func (h *TripHandler) GetBudget(c echo.Context) error {
 		return err
 	}
 
-	items, transportTotal, lodgingTotal, total, err := h.tripUsecase.GetBudget(uint(id), groupID)
+	budget, err := h.tripUsecase.GetBudget(c.Request().Context(), uint(id), groupID)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
-	responseItems := make([]TripBudgetItemResponse, len(items))
-	for i, item := range items {
+	responseItems := make([]TripBudgetItemResponse, len(budget.Items))
+	for i, line := range budget.Items {
 		responseItems[i] = TripBudgetItemResponse{
-			ID: item.ID,
+			ID: line.Item.ID,
 			TripBudgetItemPayload: TripBudgetItemPayload{
-				Name:    item.Name,
-				CostYen: item.CostYen,
+				Name:     line.Item.Name,
+				CostYen:  line.Item.CostYen,
+				Currency: line.Item.Currency,
 			},
 		}
+		if line.Converted {
+			convertedCost := line.ConvertedCost
+			responseItems[i].ConvertedCost = &convertedCost
+		}
 	}
 
 	return c.JSON(http.StatusOK, TripBudgetResponse{
-		TransportTotal: transportTotal,
-		LodgingTotal:   lodgingTotal,
-		Total:          total,
-		Items:          responseItems,
+		BaseCurrency:       budget.BaseCurrency,
+		TransportTotal:     budget.Transport.Total,
+		TransportOriginals: buildCurrencyAmountResponses(budget.Transport.Originals),
+		LodgingTotal:       budget.Lodging.Total,
+		LodgingOriginals:   buildCurrencyAmountResponses(budget.Lodging.Originals),
+		Total:              budget.Total,
+		Items:              responseItems,
+		Actual:             buildTripActualResponse(budget.Actual),
+		MissingRates:       budget.MissingRates,
 	})
 }
 
@@ -566,14 +628,19 @@ func (h *TripHandler) UpdateBudget(c echo.Context) error {
 
 	items := make([]*model.TripBudgetItem, len(req))
 	for i, item := range req {
+		currency, err := parseCurrencyParam(item.Currency, "currency")
+		if err != nil {
+			return err
+		}
 		items[i] = &model.TripBudgetItem{
-			TripID:  uint(id),
-			Name:    item.Name,
-			CostYen: item.CostYen,
+			TripID:   uint(id),
+			Name:     item.Name,
+			CostYen:  item.CostYen,
+			Currency: currency,
 		}
 	}
 
-	if err := h.tripUsecase.UpdateBudget(uint(id), items, groupID); err != nil {
+	if err := h.tripUsecase.UpdateBudget(c.Request().Context(), uint(id), items, groupID); err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 	return c.NoContent(http.StatusNoContent)
@@ -585,13 +652,13 @@ func (h *TripHandler) DeleteTrip(c echo.Context) error {
 		return err
 	}
 
-	groupID, err := getGroupIDFromContext(c)
+	member, err := getGroupMemberFromContext(c)
 	if err != nil {
 		return err
 	}
 
-	if err := h.tripUsecase.DeleteTrip(uint(id), groupID); err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	if err := h.tripUsecase.DeleteTrip(c.Request().Context(), uint(id), member); err != nil {
+		return forbiddenOr(err, echo.NewHTTPError(http.StatusInternalServerError, err.Error()))
 	}
 
 	return c.NoContent(http.StatusNoContent)
@@ -603,7 +670,7 @@ func (h *TripHandler) UpdateTrip(c echo.Context) error {
 		return err
 	}
 
-	groupID, err := getGroupIDFromContext(c)
+	member, err := getGroupMemberFromContext(c)
 	if err != nil {
 		return err
 	}
@@ -634,12 +701,17 @@ func (h *TripHandler) UpdateTrip(c echo.Context) error {
 		notifyAt = &parsed
 	}
 
-	trip, err := h.tripUsecase.UpdateTrip(uint(id), req.Title, startAt, endAt, req.Note, notifyAt, groupID)
+	baseCurrency, err := parseCurrencyParam(req.BaseCurrency, "base_currency")
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
-	albums, posts, err := h.tripUsecase.GetTripRelations(trip.ID, groupID)
+	trip, err := h.tripUsecase.UpdateTrip(c.Request().Context(), uint(id), req.Title, startAt, endAt, req.Note, notifyAt, baseCurrency, member)
+	if err != nil {
+		return tripError(err, "trip not found")
+	}
+
+	albums, posts, err := h.tripUsecase.GetTripRelations(c.Request().Context(), trip.ID, member.GroupID)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
@@ -674,16 +746,17 @@ func buildTripResponse(trip *model.Trip, albums []*model.Album, posts []*model.P
 	}
 
 	return TripResponse{
-		ID:        trip.ID,
-		Title:     trip.Title,
-		StartAt:   trip.StartAt.Format("2006-01-02T15:04:05Z07:00"),
-		EndAt:     trip.EndAt.Format("2006-01-02T15:04:05Z07:00"),
-		Note:      trip.Note,
-		CreatedBy: trip.CreatedBy,
-		NotifyAt:  notifyAtStr,
-		CreatedAt: trip.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		Albums:    albumResponses,
-		Posts:     postResponses,
+		ID:           trip.ID,
+		Title:        trip.Title,
+		StartAt:      trip.StartAt.Format("2006-01-02T15:04:05Z07:00"),
+		EndAt:        trip.EndAt.Format("2006-01-02T15:04:05Z07:00"),
+		Note:         trip.Note,
+		CreatedBy:    trip.CreatedBy,
+		NotifyAt:     notifyAtStr,
+		BaseCurrency: trip.BaseCurrency,
+		CreatedAt:    trip.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		Albums:       albumResponses,
+		Posts:        postResponses,
 	}
 }
 
@@ -694,3 +767,713 @@ func parseTripID(c echo.Context) (uint, error) {
 	}
 	return uint(id), nil
 }
+
+// tripError maps a trip or trip item that is missing from the group to 404,
+// rejected input to 400 and a policy denial to 403. Anything else is a 500.
+func tripError(err error, notFound string) error {
+	var inputErr *usecase.TripInputError
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, notFound)
+	case errors.As(err, &inputErr):
+		return echo.NewHTTPError(http.StatusBadRequest, inputErr.Message)
+	}
+	return forbiddenOr(err, echo.NewHTTPError(http.StatusInternalServerError, err.Error()))
+}
+
+type TripItineraryRequest struct {
+	Title    string `json:"title" validate:"required"`
+	StartAt  string `json:"start_at" validate:"required"`
+	EndAt    string `json:"end_at" validate:"required"`
+	Location string `json:"location"`
+	Note     string `json:"note"`
+}
+
+type TripItineraryResponse struct {
+	ID       uint   `json:"id"`
+	TripID   uint   `json:"trip_id"`
+	Title    string `json:"title"`
+	StartAt  string `json:"start_at"`
+	EndAt    string `json:"end_at"`
+	Location string `json:"location"`
+	Note     string `json:"note"`
+}
+
+type TripWishlistRequest struct {
+	Title    string `json:"title" validate:"required"`
+	Location string `json:"location"`
+	Note     string `json:"note"`
+	Priority int    `json:"priority"`
+}
+
+type TripWishlistResponse struct {
+	ID       uint   `json:"id"`
+	TripID   uint   `json:"trip_id"`
+	Title    string `json:"title"`
+	Location string `json:"location"`
+	Note     string `json:"note"`
+	Priority int    `json:"priority"`
+}
+
+type TripExpenseParticipantPayload struct {
+	UserID uint  `json:"user_id"`
+	Shares int   `json:"shares"`
+	Amount int64 `json:"amount"`
+}
+
+type TripExpenseRequest struct {
+	Title        string                          `json:"title" validate:"required"`
+	Category     string                          `json:"category" validate:"required"`
+	Amount       int64                           `json:"amount"`
+	Currency     string                          `json:"currency"`
+	IsActual     bool                            `json:"is_actual"`
+	Note         string                          `json:"note"`
+	PaidBy       *uint                           `json:"paid_by"`
+	SplitType    string                          `json:"split_type"`
+	Participants []TripExpenseParticipantPayload `json:"participants"`
+}
+
+type TripExpenseResponse struct {
+	ID           uint                            `json:"id"`
+	TripID       uint                            `json:"trip_id"`
+	Title        string                          `json:"title"`
+	Category     string                          `json:"category"`
+	Amount       int64                           `json:"amount"`
+	Currency     string                          `json:"currency"`
+	IsActual     bool                            `json:"is_actual"`
+	Note         string                          `json:"note"`
+	PaidBy       *uint                           `json:"paid_by"`
+	SplitType    string                          `json:"split_type"`
+	Participants []TripExpenseParticipantPayload `json:"participants"`
+}
+
+type TripSettleTransferRequest struct {
+	FromUserID uint  `json:"from_user_id" validate:"required"`
+	ToUserID   uint  `json:"to_user_id" validate:"required"`
+	Amount     int64 `json:"amount" validate:"required"`
+}
+
+type TripMemberBalanceResponse struct {
+	UserID   uint  `json:"user_id"`
+	Paid     int64 `json:"paid"`
+	Owed     int64 `json:"owed"`
+	Sent     int64 `json:"sent"`
+	Received int64 `json:"received"`
+	Balance  int64 `json:"balance"`
+}
+
+type TripTransferResponse struct {
+	FromUserID uint  `json:"from_user_id"`
+	ToUserID   uint  `json:"to_user_id"`
+	Amount     int64 `json:"amount"`
+}
+
+type TripSettledTransferResponse struct {
+	ID         uint   `json:"id"`
+	FromUserID uint   `json:"from_user_id"`
+	ToUserID   uint   `json:"to_user_id"`
+	Amount     int64  `json:"amount"`
+	SettledBy  uint   `json:"settled_by"`
+	SettledAt  string `json:"settled_at"`
+}
+
+type TripSettlementResponse struct {
+	BaseCurrency string                        `json:"base_currency"`
+	Balances     []TripMemberBalanceResponse   `json:"balances"`
+	Transfers    []TripTransferResponse        `json:"transfers"`
+	Settled      []TripSettledTransferResponse `json:"settled"`
+	MissingRates []string                      `json:"missing_rates"`
+}
+
+type TripExpenseCategoryTotalResponse struct {
+	Category string `json:"category"`
+	Total    int64  `json:"total"`
+}
+
+type TripActualResponse struct {
+	Total      int64                              `json:"total"`
+	Categories []TripExpenseCategoryTotalResponse `json:"categories"`
+	Originals  []CurrencyAmountResponse           `json:"originals"`
+}
+
+func (h *TripHandler) GetItineraries(c echo.Context) error {
+	id, err := parseTripID(c)
+	if err != nil {
+		return err
+	}
+
+	groupID, err := getGroupIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	itineraries, err := h.tripUsecase.GetItineraries(c.Request().Context(), id, groupID)
+	if err != nil {
+		return tripError(err, "trip not found")
+	}
+
+	response := make([]TripItineraryResponse, len(itineraries))
+	for i, itinerary := range itineraries {
+		response[i] = buildTripItineraryResponse(itinerary)
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *TripHandler) CreateItinerary(c echo.Context) error {
+	id, err := parseTripID(c)
+	if err != nil {
+		return err
+	}
+
+	groupID, err := getGroupIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var req TripItineraryRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	startAt, endAt, err := validateTripItineraryRequest(&req)
+	if err != nil {
+		return err
+	}
+
+	itinerary, err := h.tripUsecase.CreateItinerary(c.Request().Context(), id, req.Title, startAt, endAt, req.Location, req.Note, groupID)
+	if err != nil {
+		return tripError(err, "trip not found")
+	}
+
+	return c.JSON(http.StatusCreated, buildTripItineraryResponse(itinerary))
+}
+
+func (h *TripHandler) UpdateItinerary(c echo.Context) error {
+	id, err := parseTripID(c)
+	if err != nil {
+		return err
+	}
+
+	itineraryID, err := strconv.ParseUint(c.Param("itineraryId"), 10, 32)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid itinerary ID")
+	}
+
+	groupID, err := getGroupIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var req TripItineraryRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	startAt, endAt, err := validateTripItineraryRequest(&req)
+	if err != nil {
+		return err
+	}
+
+	itinerary, err := h.tripUsecase.UpdateItinerary(c.Request().Context(), id, uint(itineraryID), req.Title, startAt, endAt, req.Location, req.Note, groupID)
+	if err != nil {
+		return tripError(err, "itinerary not found")
+	}
+
+	return c.JSON(http.StatusOK, buildTripItineraryResponse(itinerary))
+}
+
+func (h *TripHandler) DeleteItinerary(c echo.Context) error {
+	id, err := parseTripID(c)
+	if err != nil {
+		return err
+	}
+
+	itineraryID, err := strconv.ParseUint(c.Param("itineraryId"), 10, 32)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid itinerary ID")
+	}
+
+	groupID, err := getGroupIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.tripUsecase.DeleteItinerary(c.Request().Context(), id, uint(itineraryID), groupID); err != nil {
+		return tripError(err, "itinerary not found")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *TripHandler) GetWishlists(c echo.Context) error {
+	id, err := parseTripID(c)
+	if err != nil {
+		return err
+	}
+
+	groupID, err := getGroupIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	wishlists, err := h.tripUsecase.GetWishlists(c.Request().Context(), id, groupID)
+	if err != nil {
+		return tripError(err, "trip not found")
+	}
+
+	response := make([]TripWishlistResponse, len(wishlists))
+	for i, wishlist := range wishlists {
+		response[i] = buildTripWishlistResponse(wishlist)
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *TripHandler) CreateWishlist(c echo.Context) error {
+	id, err := parseTripID(c)
+	if err != nil {
+		return err
+	}
+
+	groupID, err := getGroupIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var req TripWishlistRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := validateTripWishlistRequest(&req); err != nil {
+		return err
+	}
+
+	wishlist, err := h.tripUsecase.CreateWishlist(c.Request().Context(), id, req.Title, req.Location, req.Note, req.Priority, groupID)
+	if err != nil {
+		return tripError(err, "trip not found")
+	}
+
+	return c.JSON(http.StatusCreated, buildTripWishlistResponse(wishlist))
+}
+
+func (h *TripHandler) UpdateWishlist(c echo.Context) error {
+	id, err := parseTripID(c)
+	if err != nil {
+		return err
+	}
+
+	wishlistID, err := strconv.ParseUint(c.Param("wishlistId"), 10, 32)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid wishlist ID")
+	}
+
+	groupID, err := getGroupIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var req TripWishlistRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := validateTripWishlistRequest(&req); err != nil {
+		return err
+	}
+
+	wishlist, err := h.tripUsecase.UpdateWishlist(c.Request().Context(), id, uint(wishlistID), req.Title, req.Location, req.Note, req.Priority, groupID)
+	if err != nil {
+		return tripError(err, "wishlist item not found")
+	}
+
+	return c.JSON(http.StatusOK, buildTripWishlistResponse(wishlist))
+}
+
+func (h *TripHandler) DeleteWishlist(c echo.Context) error {
+	id, err := parseTripID(c)
+	if err != nil {
+		return err
+	}
+
+	wishlistID, err := strconv.ParseUint(c.Param("wishlistId"), 10, 32)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid wishlist ID")
+	}
+
+	groupID, err := getGroupIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.tripUsecase.DeleteWishlist(c.Request().Context(), id, uint(wishlistID), groupID); err != nil {
+		return tripError(err, "wishlist item not found")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *TripHandler) GetExpenses(c echo.Context) error {
+	id, err := parseTripID(c)
+	if err != nil {
+		return err
+	}
+
+	groupID, err := getGroupIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	expenses, participants, err := h.tripUsecase.GetExpenses(c.Request().Context(), id, groupID)
+	if err != nil {
+		return tripError(err, "trip not found")
+	}
+
+	response := make([]TripExpenseResponse, len(expenses))
+	for i, expense := range expenses {
+		response[i] = buildTripExpenseResponse(expense, participants[expense.ID])
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *TripHandler) CreateExpense(c echo.Context) error {
+	id, err := parseTripID(c)
+	if err != nil {
+		return err
+	}
+
+	groupID, err := getGroupIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var req TripExpenseRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := validateTripExpenseRequest(&req); err != nil {
+		return err
+	}
+
+	expense, participants, err := h.tripUsecase.CreateExpense(c.Request().Context(), id, req.Title, req.Category, req.Amount, req.Currency, req.IsActual, req.Note, req.PaidBy, req.SplitType, buildTripExpenseParticipants(req.Participants), groupID)
+	if err != nil {
+		return tripError(err, "trip not found")
+	}
+
+	return c.JSON(http.StatusCreated, buildTripExpenseResponse(expense, participants))
+}
+
+func (h *TripHandler) UpdateExpense(c echo.Context) error {
+	id, err := parseTripID(c)
+	if err != nil {
+		return err
+	}
+
+	expenseID, err := strconv.ParseUint(c.Param("expenseId"), 10, 32)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid expense ID")
+	}
+
+	groupID, err := getGroupIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var req TripExpenseRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := validateTripExpenseRequest(&req); err != nil {
+		return err
+	}
+
+	expense, participants, err := h.tripUsecase.UpdateExpense(c.Request().Context(), id, uint(expenseID), req.Title, req.Category, req.Amount, req.Currency, req.IsActual, req.Note, req.PaidBy, req.SplitType, buildTripExpenseParticipants(req.Participants), groupID)
+	if err != nil {
+		return tripError(err, "expense not found")
+	}
+
+	return c.JSON(http.StatusOK, buildTripExpenseResponse(expense, participants))
+}
+
+func (h *TripHandler) DeleteExpense(c echo.Context) error {
+	id, err := parseTripID(c)
+	if err != nil {
+		return err
+	}
+
+	expenseID, err := strconv.ParseUint(c.Param("expenseId"), 10, 32)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid expense ID")
+	}
+
+	groupID, err := getGroupIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.tripUsecase.DeleteExpense(c.Request().Context(), id, uint(expenseID), groupID); err != nil {
+		return tripError(err, "expense not found")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *TripHandler) GetSettlement(c echo.Context) error {
+	id, err := parseTripID(c)
+	if err != nil {
+		return err
+	}
+
+	groupID, err := getGroupIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	summary, err := h.tripUsecase.GetSettlement(c.Request().Context(), id, groupID)
+	if err != nil {
+		return tripError(err, "trip not found")
+	}
+
+	response := TripSettlementResponse{
+		BaseCurrency: summary.BaseCurrency,
+		Balances:     make([]TripMemberBalanceResponse, len(summary.Balances)),
+		Transfers:    make([]TripTransferResponse, len(summary.Transfers)),
+		Settled:      make([]TripSettledTransferResponse, len(summary.Settled)),
+		MissingRates: summary.MissingRates,
+	}
+	for i, balance := range summary.Balances {
+		response.Balances[i] = TripMemberBalanceResponse{
+			UserID:   balance.UserID,
+			Paid:     balance.Paid,
+			Owed:     balance.Owed,
+			Sent:     balance.Sent,
+			Received: balance.Received,
+			Balance:  balance.Balance,
+		}
+	}
+	for i, transfer := range summary.Transfers {
+		response.Transfers[i] = TripTransferResponse{
+			FromUserID: transfer.FromUserID,
+			ToUserID:   transfer.ToUserID,
+			Amount:     transfer.Amount,
+		}
+	}
+	for i, settlement := range summary.Settled {
+		response.Settled[i] = buildTripSettledTransferResponse(settlement)
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *TripHandler) SettleTransfer(c echo.Context) error {
+	userVal := c.Get("user")
+	user, ok := userVal.(*model.User)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid user")
+	}
+
+	id, err := parseTripID(c)
+	if err != nil {
+		return err
+	}
+
+	groupID, err := getGroupIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var req TripSettleTransferRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	settlement, err := h.tripUsecase.SettleTransfer(c.Request().Context(), id, req.FromUserID, req.ToUserID, req.Amount, user.ID, groupID)
+	if err != nil {
+		return tripError(err, "trip not found")
+	}
+
+	return c.JSON(http.StatusCreated, buildTripSettledTransferResponse(settlement))
+}
+
+func (h *TripHandler) DeleteSettlement(c echo.Context) error {
+	userVal := c.Get("user")
+	user, ok := userVal.(*model.User)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid user")
+	}
+
+	id, err := parseTripID(c)
+	if err != nil {
+		return err
+	}
+
+	transferID, err := strconv.ParseUint(c.Param("transferId"), 10, 32)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid transfer ID")
+	}
+
+	groupID, err := getGroupIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.tripUsecase.DeleteSettlement(c.Request().Context(), id, uint(transferID), user.ID, groupID); err != nil {
+		return tripError(err, "transfer not found")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func validateTripItineraryRequest(req *TripItineraryRequest) (time.Time, time.Time, error) {
+	req.Title = strings.TrimSpace(req.Title)
+	if req.Title == "" {
+		return time.Time{}, time.Time{}, echo.NewHTTPError(http.StatusBadRequest, "title is required")
+	}
+	startAt, err := time.Parse(time.RFC3339, req.StartAt)
+	if err != nil {
+		return time.Time{}, time.Time{}, echo.NewHTTPError(http.StatusBadRequest, "invalid start_at format")
+	}
+	endAt, err := time.Parse(time.RFC3339, req.EndAt)
+	if err != nil {
+		return time.Time{}, time.Time{}, echo.NewHTTPError(http.StatusBadRequest, "invalid end_at format")
+	}
+	if endAt.Before(startAt) {
+		return time.Time{}, time.Time{}, echo.NewHTTPError(http.StatusBadRequest, "end_at must not be before start_at")
+	}
+	return startAt, endAt, nil
+}
+
+func validateTripWishlistRequest(req *TripWishlistRequest) error {
+	req.Title = strings.TrimSpace(req.Title)
+	if req.Title == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "title is required")
+	}
+	return nil
+}
+
+func validateTripExpenseRequest(req *TripExpenseRequest) error {
+	req.Title = strings.TrimSpace(req.Title)
+	if req.Title == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "title is required")
+	}
+	req.Category = strings.TrimSpace(req.Category)
+	if req.Category == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "category is required")
+	}
+	if req.Amount < 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "amount must not be negative")
+	}
+	currency, err := parseCurrencyParam(req.Currency, "currency")
+	if err != nil {
+		return err
+	}
+	req.Currency = currency
+	return nil
+}
+
+// parseCurrencyParam upper-cases an ISO 4217 code. An empty value is kept so
+// the trip's base currency applies.
+func parseCurrencyParam(code, field string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return "", nil
+	}
+	if len(code) != 3 || strings.Trim(code, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return "", echo.NewHTTPError(http.StatusBadRequest, "invalid "+field)
+	}
+	return code, nil
+}
+
+func buildTripActualResponse(actual *usecase.TripActualExpenses) TripActualResponse {
+	categories := make([]TripExpenseCategoryTotalResponse, len(actual.Categories))
+	for i, category := range actual.Categories {
+		categories[i] = TripExpenseCategoryTotalResponse{
+			Category: category.Category,
+			Total:    category.Total,
+		}
+	}
+	return TripActualResponse{
+		Total:      actual.Total,
+		Categories: categories,
+		Originals:  buildCurrencyAmountResponses(actual.Originals),
+	}
+}
+
+func buildCurrencyAmountResponses(amounts []usecase.CurrencyAmount) []CurrencyAmountResponse {
+	responses := make([]CurrencyAmountResponse, len(amounts))
+	for i, amount := range amounts {
+		responses[i] = CurrencyAmountResponse{
+			Currency: amount.Currency,
+			Amount:   amount.Amount,
+		}
+	}
+	return responses
+}
+
+func buildTripItineraryResponse(itinerary *model.TripItinerary) TripItineraryResponse {
+	return TripItineraryResponse{
+		ID:       itinerary.ID,
+		TripID:   itinerary.TripID,
+		Title:    itinerary.Title,
+		StartAt:  itinerary.StartAt.Format("2006-01-02T15:04:05Z07:00"),
+		EndAt:    itinerary.EndAt.Format("2006-01-02T15:04:05Z07:00"),
+		Location: itinerary.Location,
+		Note:     itinerary.Note,
+	}
+}
+
+func buildTripWishlistResponse(wishlist *model.TripWishlist) TripWishlistResponse {
+	return TripWishlistResponse{
+		ID:       wishlist.ID,
+		TripID:   wishlist.TripID,
+		Title:    wishlist.Title,
+		Location: wishlist.Location,
+		Note:     wishlist.Note,
+		Priority: wishlist.Priority,
+	}
+}
+
+func buildTripExpenseResponse(expense *model.TripExpense, participants []*model.TripExpenseParticipant) TripExpenseResponse {
+	participantResponses := make([]TripExpenseParticipantPayload, len(participants))
+	for i, participant := range participants {
+		participantResponses[i] = TripExpenseParticipantPayload{
+			UserID: participant.UserID,
+			Shares: participant.Shares,
+			Amount: participant.Amount,
+		}
+	}
+	return TripExpenseResponse{
+		ID:           expense.ID,
+		TripID:       expense.TripID,
+		Title:        expense.Title,
+		Category:     expense.Category,
+		Amount:       expense.Amount,
+		Currency:     expense.Currency,
+		IsActual:     expense.IsActual,
+		Note:         expense.Note,
+		PaidBy:       expense.PaidBy,
+		SplitType:    expense.SplitType,
+		Participants: participantResponses,
+	}
+}
+
+func buildTripExpenseParticipants(payloads []TripExpenseParticipantPayload) []*model.TripExpenseParticipant {
+	participants := make([]*model.TripExpenseParticipant, len(payloads))
+	for i, payload := range payloads {
+		shares := payload.Shares
+		if shares == 0 {
+			shares = 1
+		}
+		participants[i] = &model.TripExpenseParticipant{
+			UserID: payload.UserID,
+			Shares: shares,
+			Amount: payload.Amount,
+		}
+	}
+	return participants
+}
+
+func buildTripSettledTransferResponse(settlement *model.TripSettlement) TripSettledTransferResponse {
+	return TripSettledTransferResponse{
+		ID:         settlement.ID,
+		FromUserID: settlement.FromUserID,
+		ToUserID:   settlement.ToUserID,
+		Amount:     settlement.Amount,
+		SettledBy:  settlement.SettledBy,
+		SettledAt:  settlement.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
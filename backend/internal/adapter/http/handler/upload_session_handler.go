@@ -0,0 +1,247 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"memoria/internal/domain/model"
+	"memoria/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+type UploadSessionHandler struct {
+	uploadSessionUsecase *usecase.UploadSessionUsecase
+	photoUsecase         *usecase.PhotoUsecase
+}
+
+func NewUploadSessionHandler(uploadSessionUsecase *usecase.UploadSessionUsecase, photoUsecase *usecase.PhotoUsecase) *UploadSessionHandler {
+	return &UploadSessionHandler{
+		uploadSessionUsecase: uploadSessionUsecase,
+		photoUsecase:         photoUsecase,
+	}
+}
+
+type UploadSessionPartResponse struct {
+	PartNumber int    `json:"part_number"`
+	ETag       string `json:"etag"`
+}
+
+type UploadSessionResponse struct {
+	ID          uint   `json:"id"`
+	S3Key       string `json:"s3_key"`
+	ContentType string `json:"content_type"`
+	SizeBytes   int64  `json:"size_bytes"`
+	PartSize    int64  `json:"part_size"`
+	PartCount   int    `json:"part_count"`
+	// Parts are the parts reported so far, in part number order.
+	Parts     []UploadSessionPartResponse `json:"parts"`
+	Completed bool                        `json:"completed"`
+	ExpiresAt string                      `json:"expires_at"`
+}
+
+type PartURLsRequest struct {
+	PartNumbers []int `json:"part_numbers" validate:"required"`
+}
+
+type PartURLResponse struct {
+	PartNumber int    `json:"part_number"`
+	URL        string `json:"url"`
+	SizeBytes  int64  `json:"size_bytes"`
+}
+
+type PartURLsResponse struct {
+	Parts     []PartURLResponse `json:"parts"`
+	ExpiresAt string            `json:"expires_at"`
+}
+
+type ReportPartRequest struct {
+	ETag string `json:"etag" validate:"required"`
+}
+
+// CreateSession starts a multipart upload to the album. The request is the
+// same as for a presigned single upload.
+func (h *UploadSessionHandler) CreateSession(c echo.Context) error {
+	userVal := c.Get("user")
+	user, ok := userVal.(*model.User)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "invalid user")
+	}
+
+	groupID, err := getGroupIDFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	albumID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid album ID")
+	}
+
+	var req PresignRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	session, err := h.uploadSessionUsecase.CreateSession(c.Request().Context(), uint(albumID), req.Filename, req.ContentType, req.SizeBytes, user.ID, groupID)
+	if err != nil {
+		return uploadErrorOr(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, buildUploadSessionResponse(session, nil))
+}
+
+func (h *UploadSessionHandler) GetSession(c echo.Context) error {
+	user, groupID, id, err := uploadSessionParams(c)
+	if err != nil {
+		return err
+	}
+
+	session, parts, err := h.uploadSessionUsecase.GetSession(c.Request().Context(), id, user.ID, groupID)
+	if err != nil {
+		return uploadSessionErrorOr(c, err)
+	}
+
+	return c.JSON(http.StatusOK, buildUploadSessionResponse(session, parts))
+}
+
+// SignPartURLs returns presigned URLs to PUT the requested parts to. The
+// response's ETag header of each PUT is reported with ReportPart.
+func (h *UploadSessionHandler) SignPartURLs(c echo.Context) error {
+	user, groupID, id, err := uploadSessionParams(c)
+	if err != nil {
+		return err
+	}
+
+	var req PartURLsRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	urls, expiresAt, err := h.uploadSessionUsecase.SignPartURLs(c.Request().Context(), id, req.PartNumbers, user.ID, groupID)
+	if err != nil {
+		return uploadSessionErrorOr(c, err)
+	}
+
+	response := PartURLsResponse{
+		Parts:     make([]PartURLResponse, len(urls)),
+		ExpiresAt: expiresAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	for i, url := range urls {
+		response.Parts[i] = PartURLResponse{
+			PartNumber: url.PartNumber,
+			URL:        url.URL,
+			SizeBytes:  url.SizeBytes,
+		}
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *UploadSessionHandler) ReportPart(c echo.Context) error {
+	user, groupID, id, err := uploadSessionParams(c)
+	if err != nil {
+		return err
+	}
+
+	partNumber, err := strconv.Atoi(c.Param("number"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid part number")
+	}
+
+	var req ReportPartRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := h.uploadSessionUsecase.ReportPart(c.Request().Context(), id, partNumber, req.ETag, user.ID, groupID); err != nil {
+		return uploadSessionErrorOr(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Complete joins the parts and registers the file as a photo. The response
+// is the same as for POST /albums/:id/photos.
+func (h *UploadSessionHandler) Complete(c echo.Context) error {
+	user, groupID, id, err := uploadSessionParams(c)
+	if err != nil {
+		return err
+	}
+
+	photo, err := h.uploadSessionUsecase.Complete(c.Request().Context(), id, user.ID, groupID)
+	if err != nil {
+		return uploadSessionErrorOr(c, err)
+	}
+
+	urls, err := h.photoUsecase.SignURLs(photo, nil)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusCreated, buildPhotoResponse(photo, nil, urls))
+}
+
+func (h *UploadSessionHandler) Abort(c echo.Context) error {
+	user, groupID, id, err := uploadSessionParams(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.uploadSessionUsecase.Abort(c.Request().Context(), id, user.ID, groupID); err != nil {
+		return uploadSessionErrorOr(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func uploadSessionParams(c echo.Context) (*model.User, uint, uint, error) {
+	user, ok := c.Get("user").(*model.User)
+	if !ok {
+		return nil, 0, 0, echo.NewHTTPError(http.StatusUnauthorized, "invalid user")
+	}
+
+	groupID, err := getGroupIDFromContext(c)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return nil, 0, 0, echo.NewHTTPError(http.StatusBadRequest, "invalid upload session ID")
+	}
+	return user, groupID, uint(id), nil
+}
+
+// uploadSessionErrorOr answers an unknown or expired session with 404, a
+// completed one with 409 and anything else like uploadErrorOr.
+func uploadSessionErrorOr(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, usecase.ErrUploadSessionNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, usecase.ErrUploadSessionCompleted):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+	return uploadErrorOr(c, err)
+}
+
+func buildUploadSessionResponse(session *model.UploadSession, parts []*model.UploadSessionPart) UploadSessionResponse {
+	response := UploadSessionResponse{
+		ID:          session.ID,
+		S3Key:       session.S3Key,
+		ContentType: session.ContentType,
+		SizeBytes:   session.SizeBytes,
+		PartSize:    session.PartSize,
+		PartCount:   usecase.UploadPartCount(session),
+		Parts:       make([]UploadSessionPartResponse, len(parts)),
+		Completed:   session.CompletedAt != nil,
+		ExpiresAt:   session.ExpiresAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	for i, part := range parts {
+		response.Parts[i] = UploadSessionPartResponse{
+			PartNumber: part.PartNumber,
+			ETag:       part.ETag,
+		}
+	}
+	return response
+}
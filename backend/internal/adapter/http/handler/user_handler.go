@@ -58,7 +58,7 @@ func (h *UserHandler) UpdateMe(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	updatedUser, err := h.userUsecase.UpdateDisplayName(user.ID, req.DisplayName)
+	updatedUser, err := h.userUsecase.UpdateDisplayName(c.Request().Context(), user.ID, req.DisplayName)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
@@ -82,7 +82,7 @@ type UserResponse struct {
 }
 
 func (h *UserHandler) GetAllUsers(c echo.Context) error {
-	users, err := h.userUsecase.GetAllUsers()
+	users, err := h.userUsecase.GetAllUsers(c.Request().Context())
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
@@ -110,7 +110,7 @@ func (h *UserHandler) GetUser(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid user ID")
 	}
 
-	user, err := h.userUsecase.GetUserByID(id)
+	user, err := h.userUsecase.GetUserByID(c.Request().Context(), id)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusNotFound, "user not found")
 	}
@@ -142,7 +142,7 @@ func (h *UserHandler) UpdateUserRole(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	user, err := h.userUsecase.UpdateUserRole(id, req.Role)
+	user, err := h.userUsecase.UpdateUserRole(c.Request().Context(), id, req.Role)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
@@ -171,7 +171,7 @@ func (h *UserHandler) DeleteUser(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "cannot delete yourself")
 	}
 
-	if err := h.userUsecase.DeleteUser(id); err != nil {
+	if err := h.userUsecase.DeleteUser(c.Request().Context(), id); err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
@@ -0,0 +1,238 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"memoria/internal/domain/model"
+	"memoria/internal/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+type WebhookHandler struct {
+	webhookUsecase *usecase.WebhookUsecase
+}
+
+func NewWebhookHandler(webhookUsecase *usecase.WebhookUsecase) *WebhookHandler {
+	return &WebhookHandler{
+		webhookUsecase: webhookUsecase,
+	}
+}
+
+type CreateWebhookRequest struct {
+	Platform string   `json:"platform" validate:"required"`
+	Name     string   `json:"name"`
+	URL      string   `json:"url" validate:"required"`
+	Events   []string `json:"events" validate:"required"`
+}
+
+type UpdateWebhookRequest struct {
+	Name    *string  `json:"name"`
+	URL     *string  `json:"url"`
+	Events  []string `json:"events"`
+	Enabled *bool    `json:"enabled"`
+}
+
+type WebhookResponse struct {
+	ID        uint     `json:"id"`
+	Platform  string   `json:"platform"`
+	Name      string   `json:"name"`
+	URL       string   `json:"url"`
+	Events    []string `json:"events"`
+	Enabled   bool     `json:"enabled"`
+	CreatedBy uint     `json:"created_by"`
+	CreatedAt string   `json:"created_at"`
+	UpdatedAt string   `json:"updated_at"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID             uint    `json:"id"`
+	Event          string  `json:"event"`
+	Status         string  `json:"status"`
+	Attempts       int     `json:"attempts"`
+	ResponseStatus int     `json:"response_status,omitempty"`
+	Error          string  `json:"error,omitempty"`
+	NextAttemptAt  *string `json:"next_attempt_at,omitempty"`
+	DeliveredAt    *string `json:"delivered_at,omitempty"`
+	CreatedAt      string  `json:"created_at"`
+}
+
+func (h *WebhookHandler) GetWebhooks(c echo.Context) error {
+	member, err := getGroupMemberFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	hooks, err := h.webhookUsecase.GetWebhooks(c.Request().Context(), member)
+	if err != nil {
+		return forbiddenOr(err, echo.NewHTTPError(http.StatusInternalServerError, err.Error()))
+	}
+
+	response := make([]WebhookResponse, len(hooks))
+	for i, hook := range hooks {
+		response[i] = buildWebhookResponse(hook)
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *WebhookHandler) CreateWebhook(c echo.Context) error {
+	member, err := getGroupMemberFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var req CreateWebhookRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	hook, err := h.webhookUsecase.CreateWebhook(c.Request().Context(), req.Platform, req.Name, req.URL, req.Events, member)
+	if err != nil {
+		return forbiddenOr(err, echo.NewHTTPError(http.StatusBadRequest, err.Error()))
+	}
+
+	return c.JSON(http.StatusCreated, buildWebhookResponse(hook))
+}
+
+func (h *WebhookHandler) UpdateWebhook(c echo.Context) error {
+	id, err := parseWebhookID(c)
+	if err != nil {
+		return err
+	}
+
+	member, err := getGroupMemberFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	var req UpdateWebhookRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	hook, err := h.webhookUsecase.UpdateWebhook(c.Request().Context(), id, req.Name, req.URL, req.Events, req.Enabled, member)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidWebhookURL) || errors.Is(err, usecase.ErrInvalidWebhookEvents) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return forbiddenOr(err, echo.NewHTTPError(http.StatusNotFound, "webhook not found"))
+	}
+
+	return c.JSON(http.StatusOK, buildWebhookResponse(hook))
+}
+
+func (h *WebhookHandler) DeleteWebhook(c echo.Context) error {
+	id, err := parseWebhookID(c)
+	if err != nil {
+		return err
+	}
+
+	member, err := getGroupMemberFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.webhookUsecase.DeleteWebhook(c.Request().Context(), id, member); err != nil {
+		return forbiddenOr(err, echo.NewHTTPError(http.StatusNotFound, "webhook not found"))
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *WebhookHandler) GetDeliveries(c echo.Context) error {
+	id, err := parseWebhookID(c)
+	if err != nil {
+		return err
+	}
+
+	member, err := getGroupMemberFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	deliveries, err := h.webhookUsecase.GetDeliveries(c.Request().Context(), id, member)
+	if err != nil {
+		return forbiddenOr(err, echo.NewHTTPError(http.StatusNotFound, "webhook not found"))
+	}
+
+	response := make([]WebhookDeliveryResponse, len(deliveries))
+	for i, delivery := range deliveries {
+		response[i] = buildWebhookDeliveryResponse(delivery)
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+func (h *WebhookHandler) SendTest(c echo.Context) error {
+	id, err := parseWebhookID(c)
+	if err != nil {
+		return err
+	}
+
+	member, err := getGroupMemberFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	delivery, err := h.webhookUsecase.SendTest(c.Request().Context(), id, member)
+	if err != nil {
+		return forbiddenOr(err, echo.NewHTTPError(http.StatusNotFound, "webhook not found"))
+	}
+
+	return c.JSON(http.StatusOK, buildWebhookDeliveryResponse(delivery))
+}
+
+func parseWebhookID(c echo.Context) (uint, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "invalid webhook ID")
+	}
+	return uint(id), nil
+}
+
+func buildWebhookResponse(hook *model.GroupWebhook) WebhookResponse {
+	return WebhookResponse{
+		ID:        hook.ID,
+		Platform:  hook.Platform,
+		Name:      hook.Name,
+		URL:       maskWebhookURL(hook.URL),
+		Events:    strings.Split(hook.Events, ","),
+		Enabled:   hook.Enabled,
+		CreatedBy: hook.CreatedBy,
+		CreatedAt: hook.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt: hook.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+// maskWebhookURL hides the secret part of the URL; anyone holding the full
+// URL can post to the channel.
+func maskWebhookURL(raw string) string {
+	cut := strings.LastIndex(raw, "/")
+	if cut < 0 || len(raw)-cut-1 <= 4 {
+		return raw
+	}
+	return raw[:cut+1] + "****" + raw[len(raw)-4:]
+}
+
+func buildWebhookDeliveryResponse(delivery *model.WebhookDelivery) WebhookDeliveryResponse {
+	response := WebhookDeliveryResponse{
+		ID:             delivery.ID,
+		Event:          delivery.Event,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		ResponseStatus: delivery.ResponseStatus,
+		Error:          delivery.Error,
+		CreatedAt:      delivery.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if delivery.Status == "pending" && delivery.NextAttemptAt != nil {
+		nextAttemptAt := delivery.NextAttemptAt.Format("2006-01-02T15:04:05Z07:00")
+		response.NextAttemptAt = &nextAttemptAt
+	}
+	if delivery.DeliveredAt != nil {
+		deliveredAt := delivery.DeliveredAt.Format("2006-01-02T15:04:05Z07:00")
+		response.DeliveredAt = &deliveredAt
+	}
+	return response
+}
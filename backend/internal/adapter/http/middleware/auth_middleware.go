@@ -59,13 +59,13 @@ func (m *AuthMiddleware) RequireAuth(next echo.HandlerFunc) echo.HandlerFunc {
 			return echo.NewHTTPError(http.StatusUnauthorized, "invalid session")
 		}
 
-		user, err := m.userRepo.FindByFirebaseUID(token.UID)
+		user, err := m.userRepo.FindByFirebaseUID(c.Request().Context(), token.UID)
 		if err != nil {
 			email, _ := token.Claims["email"].(string)
 			if email == "" {
 				return echo.NewHTTPError(http.StatusUnauthorized, "user not found")
 			}
-			user, err = m.userRepo.FindByEmail(email)
+			user, err = m.userRepo.FindByEmail(c.Request().Context(), email)
 			if err != nil {
 				return echo.NewHTTPError(http.StatusUnauthorized, "user not found")
 			}
@@ -80,7 +80,7 @@ func (m *AuthMiddleware) RequireAuth(next echo.HandlerFunc) echo.HandlerFunc {
 
 		if user.LastAccessAt == nil || now.Sub(*user.LastAccessAt) > 24*time.Hour {
 			user.LastAccessAt = &now
-			_ = m.userRepo.Update(user)
+			_ = m.userRepo.Update(c.Request().Context(), user)
 		}
 
 		c.Set("user_id", user.ID)
@@ -138,6 +138,16 @@ func (m *AuthMiddleware) RequireAdmin(next echo.HandlerFunc) echo.HandlerFunc {
 }
 
 func (m *AuthMiddleware) RequireGroup(next echo.HandlerFunc) echo.HandlerFunc {
+	return m.requireGroup(next, false)
+}
+
+// RequireGroupStream is RequireGroup for EventSource connections, which
+// cannot set headers: the group may also be given as ?group_id=.
+func (m *AuthMiddleware) RequireGroupStream(next echo.HandlerFunc) echo.HandlerFunc {
+	return m.requireGroup(next, true)
+}
+
+func (m *AuthMiddleware) requireGroup(next echo.HandlerFunc, allowQuery bool) echo.HandlerFunc {
 	return m.RequireAuth(func(c echo.Context) error {
 		userVal := c.Get("user")
 		user, ok := userVal.(*model.User)
@@ -146,6 +156,9 @@ func (m *AuthMiddleware) RequireGroup(next echo.HandlerFunc) echo.HandlerFunc {
 		}
 
 		groupIDHeader := c.Request().Header.Get("X-Group-ID")
+		if groupIDHeader == "" && allowQuery {
+			groupIDHeader = c.QueryParam("group_id")
+		}
 		if groupIDHeader == "" {
 			return echo.NewHTTPError(http.StatusBadRequest, "missing X-Group-ID header")
 		}
@@ -154,7 +167,7 @@ func (m *AuthMiddleware) RequireGroup(next echo.HandlerFunc) echo.HandlerFunc {
 			return echo.NewHTTPError(http.StatusBadRequest, "invalid X-Group-ID header")
 		}
 
-		member, err := m.groupMemberRepo.FindByGroupAndUser(groupID, user.ID)
+		member, err := m.groupMemberRepo.FindActiveMembership(c.Request().Context(), groupID, user.ID)
 		if err != nil || member == nil {
 			return echo.NewHTTPError(http.StatusForbidden, "group access required")
 		}
@@ -1,9 +1,12 @@
 package http
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"memoria/internal/adapter/http/handler"
 	customMiddleware "memoria/internal/adapter/http/middleware"
@@ -20,16 +23,43 @@ func RegisterRoutes(
 	inviteHandler *handler.InviteHandler,
 	albumHandler *handler.AlbumHandler,
 	photoHandler *handler.PhotoHandler,
+	uploadSessionHandler *handler.UploadSessionHandler,
 	postHandler *handler.PostHandler,
 	tripHandler *handler.TripHandler,
+	exchangeRateHandler *handler.ExchangeRateHandler,
+	calendarHandler *handler.CalendarHandler,
+	notificationHandler *handler.NotificationHandler,
+	webhookHandler *handler.WebhookHandler,
+	liveEventHandler *handler.LiveEventHandler,
+	trashHandler *handler.TrashHandler,
+	searchHandler *handler.SearchHandler,
+	storageHandler *handler.StorageHandler,
 	authMiddleware *customMiddleware.AuthMiddleware,
 	frontendBaseURL string,
 	allowedOriginsRaw string,
 	allowedOriginSuffixesRaw string,
+	requestTimeout time.Duration,
 ) {
 	// Middleware
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
+	e.Use(middleware.ContextTimeoutWithConfig(middleware.ContextTimeoutConfig{
+		Timeout: requestTimeout,
+		// Event streams stay open for as long as the client listens, and
+		// photo uploads and downloads take as long as the client's
+		// connection needs.
+		Skipper: func(c echo.Context) bool {
+			return c.Path() == "/api/events" || c.Path() == "/api/photos/:id/content" || c.Path() == "/api/storage/*"
+		},
+		// Handlers wrap errors in their own HTTP errors, so check the
+		// deadline itself rather than the returned error.
+		ErrorHandler: func(err error, c echo.Context) error {
+			if errors.Is(c.Request().Context().Err(), context.DeadlineExceeded) {
+				return echo.NewHTTPError(http.StatusServiceUnavailable, "request timed out")
+			}
+			return err
+		},
+	}))
 	allowedOrigins := []string{
 		"http://localhost:3000",
 		"http://127.0.0.1:3000",
@@ -95,7 +125,8 @@ func RegisterRoutes(
 			return false, nil
 		},
 		AllowMethods:     []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions},
-		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization, "X-Group-ID"},
+		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization, "X-Group-ID", "Last-Event-ID"},
+		ExposeHeaders:    []string{"ETag"}, // multipart uploads read each part's ETag
 		AllowCredentials: true,
 	}))
 
@@ -117,6 +148,16 @@ func RegisterRoutes(
 	api.GET("/invites/:token", inviteHandler.VerifyInvite)
 	api.POST("/invites/:token/signup", inviteHandler.SignupInvite)
 
+	// Calendar subscription feed (authenticated by the token in the path)
+	api.GET("/calendar/:token/trips.ics", calendarHandler.GetFeed)
+
+	// Signed photo URLs of the local storage driver (authenticated by the
+	// signature in the query)
+	if storageHandler != nil {
+		api.GET("/storage/*", storageHandler.GetObject)
+		api.PUT("/storage/*", storageHandler.PutObject)
+	}
+
 	// Protected routes
 	protected := api.Group("", authMiddleware.RequireAuth)
 	protected.GET("/me", userHandler.GetMe)
@@ -125,7 +166,35 @@ func RegisterRoutes(
 	protected.POST("/invites/:token/decline", inviteHandler.DeclineInvite)
 	protected.GET("/groups", groupHandler.GetMyGroups)
 	protected.POST("/groups", groupHandler.CreateGroup)
+	protected.GET("/groups/deleted", groupHandler.GetDeletedGroups)
+	protected.PATCH("/groups/:id", groupHandler.UpdateGroup)
+	protected.DELETE("/groups/:id", groupHandler.DeleteGroup)
+	protected.POST("/groups/:id/restore", groupHandler.RestoreGroup)
+	protected.POST("/groups/:id/transfer", groupHandler.TransferGroup)
 	protected.GET("/groups/:id/members", groupHandler.GetGroupMembers)
+	protected.DELETE("/groups/:id/members/me", groupHandler.LeaveGroup)
+	protected.PATCH("/groups/:id/members/:userId", groupHandler.UpdateMemberRole)
+	protected.DELETE("/groups/:id/members/:userId", groupHandler.RemoveMember)
+	protected.GET("/me/calendar-feed", calendarHandler.GetFeedSettings)
+	protected.POST("/me/calendar-feed", calendarHandler.CreateFeed)
+	protected.DELETE("/me/calendar-feed", calendarHandler.DeleteFeed)
+
+	// Notifications (always scoped to the signed-in user)
+	protected.GET("/notifications", notificationHandler.GetNotifications)
+	protected.PATCH("/notifications/read", notificationHandler.MarkAllAsRead)
+	protected.PATCH("/notifications/:id/read", notificationHandler.MarkAsRead)
+	protected.DELETE("/notifications/:id", notificationHandler.DeleteNotification)
+	protected.GET("/notification-settings", notificationHandler.GetSettings)
+	protected.PUT("/notification-settings", notificationHandler.UpdateSettings)
+	protected.GET("/web-push/vapid-public-key", notificationHandler.GetVAPIDPublicKey)
+	protected.GET("/web-push/subscriptions", notificationHandler.GetPushSubscriptions)
+	protected.POST("/web-push/subscriptions", notificationHandler.CreatePushSubscription)
+	protected.DELETE("/web-push/subscriptions/:id", notificationHandler.DeletePushSubscription)
+
+	// Live group events (EventSource cannot send X-Group-ID, so ?group_id= is accepted)
+	api.GET("/events", liveEventHandler.Stream, authMiddleware.RequireGroupStream)
+	// Photo content for <img> tags, which cannot send X-Group-ID either
+	api.GET("/photos/:id/content", photoHandler.GetPhotoContent, authMiddleware.RequireGroupStream)
 
 	// Group-scoped routes (require group membership)
 	group := api.Group("", authMiddleware.RequireGroup)
@@ -146,6 +215,13 @@ func RegisterRoutes(
 	group.GET("/albums/:id/photos", albumHandler.GetAlbumPhotos)
 	group.POST("/albums/:id/photos/presign", photoHandler.GeneratePresignedURL)
 	group.POST("/albums/:id/photos", photoHandler.CreatePhoto)
+	group.POST("/albums/:id/photos/upload-sessions", uploadSessionHandler.CreateSession)
+	group.GET("/upload-sessions/:id", uploadSessionHandler.GetSession)
+	group.POST("/upload-sessions/:id/part-urls", uploadSessionHandler.SignPartURLs)
+	group.PUT("/upload-sessions/:id/parts/:number", uploadSessionHandler.ReportPart)
+	group.POST("/upload-sessions/:id/complete", uploadSessionHandler.Complete)
+	group.DELETE("/upload-sessions/:id", uploadSessionHandler.Abort)
+	group.GET("/photos/:id", photoHandler.GetPhoto)
 	group.DELETE("/photos/:id", photoHandler.DeletePhoto)
 
 	// Post routes
@@ -185,6 +261,43 @@ func RegisterRoutes(
 	group.PUT("/trips/:id/lodgings", tripHandler.UpdateLodgings)
 	group.GET("/trips/:id/budget", tripHandler.GetBudget)
 	group.PUT("/trips/:id/budget", tripHandler.UpdateBudget)
+	group.GET("/trips/:id/itineraries", tripHandler.GetItineraries)
+	group.POST("/trips/:id/itineraries", tripHandler.CreateItinerary)
+	group.PATCH("/trips/:id/itineraries/:itineraryId", tripHandler.UpdateItinerary)
+	group.DELETE("/trips/:id/itineraries/:itineraryId", tripHandler.DeleteItinerary)
+	group.GET("/trips/:id/wishlists", tripHandler.GetWishlists)
+	group.POST("/trips/:id/wishlists", tripHandler.CreateWishlist)
+	group.PATCH("/trips/:id/wishlists/:wishlistId", tripHandler.UpdateWishlist)
+	group.DELETE("/trips/:id/wishlists/:wishlistId", tripHandler.DeleteWishlist)
+	group.GET("/trips/:id/expenses", tripHandler.GetExpenses)
+	group.POST("/trips/:id/expenses", tripHandler.CreateExpense)
+	group.PATCH("/trips/:id/expenses/:expenseId", tripHandler.UpdateExpense)
+	group.DELETE("/trips/:id/expenses/:expenseId", tripHandler.DeleteExpense)
+	group.GET("/trips/:id/settlement", tripHandler.GetSettlement)
+	group.POST("/trips/:id/settlement/transfers", tripHandler.SettleTransfer)
+	group.DELETE("/trips/:id/settlement/transfers/:transferId", tripHandler.DeleteSettlement)
+	group.GET("/trips/:id/calendar.ics", calendarHandler.ExportTrip)
+
+	// Exchange rates (writes are manager only)
+	group.GET("/exchange-rates", exchangeRateHandler.GetExchangeRates)
+	group.POST("/exchange-rates", exchangeRateHandler.SetExchangeRate)
+	group.POST("/exchange-rates/import", exchangeRateHandler.ImportExchangeRates)
+	group.DELETE("/exchange-rates/:id", exchangeRateHandler.DeleteExchangeRate)
+
+	// Discord/Slack webhooks (manager only)
+	group.GET("/webhooks", webhookHandler.GetWebhooks)
+	group.POST("/webhooks", webhookHandler.CreateWebhook)
+	group.PATCH("/webhooks/:id", webhookHandler.UpdateWebhook)
+	group.DELETE("/webhooks/:id", webhookHandler.DeleteWebhook)
+	group.GET("/webhooks/:id/deliveries", webhookHandler.GetDeliveries)
+	group.POST("/webhooks/:id/test", webhookHandler.SendTest)
+
+	// Trash (restoring needs the same permission as deleting)
+	group.GET("/trash", trashHandler.GetTrash)
+	group.POST("/trash/:type/:id/restore", trashHandler.Restore)
+
+	// Search
+	group.GET("/search", searchHandler.Search)
 
 	// Admin routes
 	admin := api.Group("", authMiddleware.RequireAdmin)
@@ -0,0 +1,132 @@
+package imaging
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var errNoSize = errors.New("image size not found")
+
+// webpSize reads the canvas size from the first chunk of a WebP file.
+func webpSize(data []byte) (int, int, error) {
+	if len(data) < 30 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return 0, 0, errNoSize
+	}
+	switch string(data[12:16]) {
+	case "VP8 ": // lossy: frame tag, start code, then 14-bit sizes
+		if data[23] != 0x9d || data[24] != 0x01 || data[25] != 0x2a {
+			return 0, 0, errNoSize
+		}
+		return int(binary.LittleEndian.Uint16(data[26:]) & 0x3fff), int(binary.LittleEndian.Uint16(data[28:]) & 0x3fff), nil
+	case "VP8L": // lossless: signature, then 14-bit sizes minus one
+		if data[20] != 0x2f {
+			return 0, 0, errNoSize
+		}
+		bits := binary.LittleEndian.Uint32(data[21:])
+		return int(bits&0x3fff) + 1, int(bits>>14&0x3fff) + 1, nil
+	case "VP8X": // extended: flags, then 24-bit sizes minus one
+		return int(uint24(data[24:])) + 1, int(uint24(data[27:])) + 1, nil
+	}
+	return 0, 0, errNoSize
+}
+
+func uint24(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+}
+
+// heicSize reads the size of a HEIF/HEIC file from the image spatial
+// extents ('ispe') in its item properties. Thumbnails and grid tiles have
+// their own, so the largest is taken, and a 90° or 270° rotation ('irot')
+// swaps width and height.
+func heicSize(data []byte) (int, int, error) {
+	ftyp, ok := findBox(data, "ftyp")
+	if !ok || !heifBrand(ftyp) {
+		return 0, 0, errNoSize
+	}
+	meta, ok := findBox(data, "meta")
+	if !ok || len(meta) < 4 {
+		return 0, 0, errNoSize
+	}
+	iprp, ok := findBox(meta[4:], "iprp") // meta is a full box
+	if !ok {
+		return 0, 0, errNoSize
+	}
+	ipco, ok := findBox(iprp, "ipco")
+	if !ok {
+		return 0, 0, errNoSize
+	}
+
+	width, height, rotated := 0, 0, false
+	eachBox(ipco, func(typ string, body []byte) bool {
+		switch {
+		case typ == "ispe" && len(body) >= 12:
+			w, h := int(binary.BigEndian.Uint32(body[4:])), int(binary.BigEndian.Uint32(body[8:]))
+			if w*h > width*height {
+				width, height = w, h
+			}
+		case typ == "irot" && len(body) >= 1:
+			rotated = rotated || body[0]&3 == 1 || body[0]&3 == 3
+		}
+		return true
+	})
+	if width == 0 || height == 0 {
+		return 0, 0, errNoSize
+	}
+	if rotated {
+		return height, width, nil
+	}
+	return width, height, nil
+}
+
+func heifBrand(ftyp []byte) bool {
+	for i := 0; i+4 <= len(ftyp); i += 4 {
+		if i == 4 { // minor version
+			continue
+		}
+		switch string(ftyp[i : i+4]) {
+		case "heic", "heix", "heim", "heis", "mif1":
+			return true
+		}
+	}
+	return false
+}
+
+// findBox returns the body of the first ISO BMFF box of the type.
+func findBox(data []byte, typ string) ([]byte, bool) {
+	var found []byte
+	eachBox(data, func(t string, body []byte) bool {
+		if t == typ {
+			found = body
+			return false
+		}
+		return true
+	})
+	return found, found != nil
+}
+
+// eachBox calls fn with the type and body of each box in data until fn
+// returns false. A box cut off by the end of data ends the walk.
+func eachBox(data []byte, fn func(typ string, body []byte) bool) {
+	for len(data) >= 8 {
+		size := uint64(binary.BigEndian.Uint32(data))
+		typ := string(data[4:8])
+		header := uint64(8)
+		switch size {
+		case 0: // to the end
+			size = uint64(len(data))
+		case 1: // 64-bit size
+			if len(data) < 16 {
+				return
+			}
+			size = binary.BigEndian.Uint64(data[8:])
+			header = 16
+		}
+		if size < header || size > uint64(len(data)) {
+			return
+		}
+		if !fn(typ, data[header:size]) {
+			return
+		}
+		data = data[size:]
+	}
+}
@@ -0,0 +1,126 @@
+// Package imaging decodes uploaded photos and renders resized copies of
+// them. It only uses the standard library, so it reads JPEG, PNG and GIF
+// and writes JPEG. The size of WebP and HEIC files can be read from their
+// headers, but their pixels cannot.
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+
+	"memoria/internal/usecase"
+)
+
+// maxPixels rejects images that would take too much memory to decode.
+const maxPixels = 100_000_000
+
+// Codec is the usecase.ImageCodec. Data that is not a readable JPEG, PNG
+// or GIF image fails to decode with usecase.ErrUnreadableImage.
+type Codec struct{}
+
+// Image is a decoded photo together with the EXIF orientation it is meant
+// to be shown in.
+type Image struct {
+	src         image.Image
+	orientation int
+}
+
+// Decode reads a JPEG, PNG or GIF file.
+func (Codec) Decode(data []byte) (usecase.DecodedImage, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", usecase.ErrUnreadableImage, err)
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, fmt.Errorf("%w: %dx%d is too large", usecase.ErrUnreadableImage, cfg.Width, cfg.Height)
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", usecase.ErrUnreadableImage, err)
+	}
+
+	img := &Image{src: src, orientation: 1}
+	if format == "jpeg" {
+		img.orientation = jpegOrientation(data)
+	}
+	return img, nil
+}
+
+// ReadSize reads the format ("jpeg", "png", "gif", "webp" or "heic") and
+// the width and height as shown, after orientation, from the start of an
+// image file. data only needs to reach the image header. WebP and HEIC
+// files can be measured but not decoded.
+func (Codec) ReadSize(data []byte) (format string, width, height int, err error) {
+	if w, h, err := webpSize(data); err == nil {
+		return "webp", w, h, nil
+	}
+	if w, h, err := heicSize(data); err == nil {
+		return "heic", w, h, nil
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("%w: %v", usecase.ErrUnreadableImage, err)
+	}
+	if format == "jpeg" && jpegOrientation(data) >= 5 {
+		return format, cfg.Height, cfg.Width, nil
+	}
+	return format, cfg.Width, cfg.Height, nil
+}
+
+// Size is the width and height of the image as shown, after orientation.
+func (i *Image) Size() (int, int) {
+	b := i.src.Bounds()
+	if i.orientation >= 5 {
+		return b.Dy(), b.Dx()
+	}
+	return b.Dx(), b.Dy()
+}
+
+// Fit scales the image down so that its longer side is at most size,
+// averaging the pixels each output pixel covers. Smaller images keep their
+// size. Transparent areas are flattened onto white.
+func (i *Image) Fit(size int) usecase.DecodedImage {
+	b := i.src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	switch {
+	case w >= h && w > size:
+		w, h = size, max(h*size/w, 1)
+	case h > w && h > size:
+		w, h = max(w*size/h, 1), size
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		sy0, sy1 := b.Min.Y+y*b.Dy()/h, b.Min.Y+(y+1)*b.Dy()/h
+		for x := 0; x < w; x++ {
+			sx0, sx1 := b.Min.X+x*b.Dx()/w, b.Min.X+(x+1)*b.Dx()/w
+			var r, g, bl, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					cr, cg, cb, ca := i.src.At(sx, sy).RGBA()
+					white := 0xffff - ca
+					r += uint64(cr + white)
+					g += uint64(cg + white)
+					bl += uint64(cb + white)
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{uint8(r / n >> 8), uint8(g / n >> 8), uint8(bl / n >> 8), 0xff})
+		}
+	}
+	return &Image{src: dst, orientation: i.orientation}
+}
+
+// EncodeJPEG writes the image upright as a JPEG.
+func (i *Image) EncodeJPEG(quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, orient(i.src, i.orientation), &jpeg.Options{Quality: quality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -1,6 +1,9 @@
 package persistence
 
 import (
+	"context"
+	"time"
+
 	"memoria/internal/domain/model"
 	"memoria/internal/domain/repository"
 
@@ -15,30 +18,43 @@ func NewAlbumRepository(db *gorm.DB) repository.AlbumRepository {
 	return &albumRepositoryImpl{db: db}
 }
 
-func (r *albumRepositoryImpl) Create(album *model.Album) error {
-	return r.db.Create(album).Error
+func (r *albumRepositoryImpl) Create(ctx context.Context, album *model.Album) error {
+	return r.db.WithContext(ctx).Create(album).Error
 }
 
-func (r *albumRepositoryImpl) FindByID(id uint, groupID uint) (*model.Album, error) {
+func (r *albumRepositoryImpl) FindByID(ctx context.Context, id uint, groupID uint) (*model.Album, error) {
 	var album model.Album
-	if err := r.db.Where("id = ? AND group_id = ?", id, groupID).First(&album).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("id = ? AND group_id = ? AND deleted_at IS NULL", id, groupID).First(&album).Error; err != nil {
 		return nil, err
 	}
 	return &album, nil
 }
 
-func (r *albumRepositoryImpl) FindAll(groupID uint) ([]*model.Album, error) {
-	var albums []*model.Album
-	if err := r.db.Where("group_id = ?", groupID).Order("created_at DESC").Find(&albums).Error; err != nil {
-		return nil, err
+func (r *albumRepositoryImpl) FindAll(ctx context.Context, groupID uint, opts repository.ListOptions) (*repository.Page[*model.Album], error) {
+	query := r.db.WithContext(ctx).Where("group_id = ? AND deleted_at IS NULL", groupID)
+	if opts.AuthorID != 0 {
+		query = query.Where("created_by = ?", opts.AuthorID)
 	}
-	return albums, nil
+	ks := keyset{table: "albums", column: "created_at", desc: true}.order(opts.Sort)
+	return findPage(query, ks, opts, func(album *model.Album) cursor {
+		return cursor{Key: album.CreatedAt, ID: album.ID}
+	})
 }
 
-func (r *albumRepositoryImpl) Update(album *model.Album) error {
-	return r.db.Save(album).Error
+func (r *albumRepositoryImpl) Update(ctx context.Context, album *model.Album) error {
+	return r.db.WithContext(ctx).Save(album).Error
 }
 
-func (r *albumRepositoryImpl) Delete(id uint) error {
-	return r.db.Delete(&model.Album{}, id).Error
+func (r *albumRepositoryImpl) Delete(ctx context.Context, id uint) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The photos share the album's timestamp so restoring the album
+		// brings back exactly these and not ones trashed on their own.
+		if err := tx.Model(&model.Photo{}).
+			Where("album_id = ? AND deleted_at IS NULL", id).
+			Update("deleted_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.Album{}).Where("id = ?", id).Update("deleted_at", now).Error
+	})
 }
@@ -0,0 +1,58 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"memoria/internal/domain/model"
+	"memoria/internal/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+type calendarFeedTokenRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewCalendarFeedTokenRepository(db *gorm.DB) repository.CalendarFeedTokenRepository {
+	return &calendarFeedTokenRepositoryImpl{db: db}
+}
+
+func (r *calendarFeedTokenRepositoryImpl) Create(ctx context.Context, token *model.CalendarFeedToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *calendarFeedTokenRepositoryImpl) FindActiveByUserID(ctx context.Context, userID uint) (*model.CalendarFeedToken, error) {
+	var tokens []*model.CalendarFeedToken
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("id DESC").
+		Limit(1).
+		Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	return tokens[0], nil
+}
+
+func (r *calendarFeedTokenRepositoryImpl) FindActiveByHash(ctx context.Context, tokenHash string) (*model.CalendarFeedToken, error) {
+	var token model.CalendarFeedToken
+	if err := r.db.WithContext(ctx).Where("token_hash = ? AND revoked_at IS NULL", tokenHash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *calendarFeedTokenRepositoryImpl) RevokeByUserID(ctx context.Context, userID uint, revokedAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.CalendarFeedToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", revokedAt).Error
+}
+
+func (r *calendarFeedTokenRepositoryImpl) UpdateLastUsed(ctx context.Context, id uint, usedAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.CalendarFeedToken{}).
+		Where("id = ?", id).
+		Update("last_used_at", usedAt).Error
+}
@@ -0,0 +1,120 @@
+package persistence
+
+import (
+	"time"
+
+	"memoria/internal/domain/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Hard deletes of albums, photos, posts and trips go through these helpers
+// so that no dependent row is left behind. ids is a []uint or a subquery
+// selecting the IDs; every helper must run inside a transaction.
+
+type deleteStep struct {
+	model any
+	query string
+	arg   any
+}
+
+// runDeleteSteps deletes in order, so list children before the rows they
+// point at.
+func runDeleteSteps(tx *gorm.DB, steps []deleteStep) error {
+	for _, step := range steps {
+		if err := tx.Where(step.query, step.arg).Delete(step.model).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteTrips removes trips with their schedule, expenses, settlements,
+// reminders and links to albums and posts. The albums and posts stay.
+func deleteTrips(tx *gorm.DB, ids any) error {
+	expenses := tx.Model(&model.TripExpense{}).Select("id").Where("trip_id IN (?)", ids)
+	return runDeleteSteps(tx, []deleteStep{
+		{&model.TripExpenseParticipant{}, "expense_id IN (?)", expenses},
+		{&model.TripExpense{}, "trip_id IN (?)", ids},
+		{&model.TripItinerary{}, "trip_id IN (?)", ids},
+		{&model.TripWishlist{}, "trip_id IN (?)", ids},
+		{&model.TripSettlement{}, "trip_id IN (?)", ids},
+		{&model.TripAlbum{}, "trip_id IN (?)", ids},
+		{&model.TripPost{}, "trip_id IN (?)", ids},
+		{&model.TripScheduleItem{}, "trip_id IN (?)", ids},
+		{&model.TripTransport{}, "trip_id IN (?)", ids},
+		{&model.TripLodging{}, "trip_id IN (?)", ids},
+		{&model.TripBudgetItem{}, "trip_id IN (?)", ids},
+		{&model.TripReminder{}, "trip_id IN (?)", ids},
+		{&model.Trip{}, "id IN (?)", ids},
+	})
+}
+
+// deletePosts removes posts with their tags, likes, comments and links to
+// photos, albums and trips.
+func deletePosts(tx *gorm.DB, ids any) error {
+	return runDeleteSteps(tx, []deleteStep{
+		{&model.PostTag{}, "post_id IN (?)", ids},
+		{&model.PostPhoto{}, "post_id IN (?)", ids},
+		{&model.PostLike{}, "post_id IN (?)", ids},
+		{&model.PostComment{}, "post_id IN (?)", ids},
+		{&model.AlbumPost{}, "post_id IN (?)", ids},
+		{&model.TripPost{}, "post_id IN (?)", ids},
+		{&model.Post{}, "id IN (?)", ids},
+	})
+}
+
+// deletePhotos removes photos, unlinks them from posts and album covers and
+// queues their S3 objects for the object-cleanup job.
+func deletePhotos(tx *gorm.DB, ids any) error {
+	if err := queuePhotoObjects(tx, ids); err != nil {
+		return err
+	}
+	if err := tx.Model(&model.Album{}).Where("cover_photo_id IN (?)", ids).Update("cover_photo_id", nil).Error; err != nil {
+		return err
+	}
+	return runDeleteSteps(tx, []deleteStep{
+		{&model.PostPhoto{}, "photo_id IN (?)", ids},
+		{&model.PhotoVariant{}, "photo_id IN (?)", ids},
+		{&model.Photo{}, "id IN (?)", ids},
+	})
+}
+
+// deleteAlbums removes albums with their photos and links to posts and
+// trips. The posts and trips stay.
+func deleteAlbums(tx *gorm.DB, ids any) error {
+	photos := tx.Model(&model.Photo{}).Select("id").Where("album_id IN (?)", ids)
+	if err := deletePhotos(tx, photos); err != nil {
+		return err
+	}
+	return runDeleteSteps(tx, []deleteStep{
+		{&model.AlbumPost{}, "album_id IN (?)", ids},
+		{&model.TripAlbum{}, "album_id IN (?)", ids},
+		{&model.Album{}, "id IN (?)", ids},
+	})
+}
+
+// queuePhotoObjects records the S3 keys of the photos and their variants in
+// object_deletions. It commits or rolls back with the delete, so an object
+// is only removed once nothing points at it.
+func queuePhotoObjects(tx *gorm.DB, ids any) error {
+	return tx.Exec(`INSERT INTO object_deletions (created_at, updated_at, s3_key, attempts, next_attempt_at)
+SELECT now(), now(), s3_key, 0, now() FROM photos WHERE id IN (?)
+UNION ALL
+SELECT now(), now(), s3_key, 0, now() FROM photo_variants WHERE photo_id IN (?)
+ON CONFLICT (s3_key) DO NOTHING`, ids, ids).Error
+}
+
+// queueObjects records S3 keys in object_deletions.
+func queueObjects(tx *gorm.DB, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	now := time.Now()
+	deletions := make([]*model.ObjectDeletion, len(keys))
+	for i, key := range keys {
+		deletions[i] = &model.ObjectDeletion{S3Key: key, NextAttemptAt: now}
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(deletions).Error
+}
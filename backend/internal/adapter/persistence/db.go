@@ -3,83 +3,101 @@ package persistence
 import (
 	"fmt"
 	"log"
+	"os"
+	"time"
 
 	"memoria/internal/config"
-	"memoria/internal/domain/model"
+	"memoria/migrations"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
 )
 
+// NewDB opens the database and brings the schema up to date. Pending
+// migrations are applied when AUTO_MIGRATE is enabled; otherwise the server
+// refuses to start in production until they are applied with cmd/migrate.
 func NewDB(cfg config.Config) (*gorm.DB, error) {
-	sslMode := cfg.DBSSLMode
-	if sslMode == "" {
-		sslMode = "disable"
+	db, err := OpenDB(cfg)
+	if err != nil {
+		return nil, err
 	}
 
-	dsn := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s TimeZone=Asia/Tokyo",
-		cfg.DBHost,
-		cfg.DBPort,
-		cfg.DBUser,
-		cfg.DBPassword,
-		cfg.DBName,
-		sslMode,
-	)
-
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	migrator, err := NewMigrator(db, migrations.FS)
 	if err != nil {
 		return nil, err
 	}
 
-	// Auto-migrate all models (can be disabled via AUTO_MIGRATE=false)
+	// Apply versioned migrations (can be disabled via AUTO_MIGRATE=false)
 	if cfg.AutoMigrate {
-		if err := autoMigrate(db); err != nil {
+		if err := runMigrations(migrator); err != nil {
 			return nil, err
 		}
 	} else {
 		log.Println("Auto-migration is disabled")
 	}
 
+	pending, err := migrator.Pending()
+	if err != nil {
+		return nil, err
+	}
+	if len(pending) > 0 {
+		if cfg.AppEnv == "production" {
+			return nil, fmt.Errorf("%d pending migration(s), run cmd/migrate up before starting the server", len(pending))
+		}
+		log.Printf("Warning: %d pending migration(s), run cmd/migrate up", len(pending))
+	}
+
 	return db, nil
 }
 
-func autoMigrate(db *gorm.DB) error {
-	log.Println("Running auto-migration...")
+// OpenDB connects to the database without touching the schema.
+func OpenDB(cfg config.Config) (*gorm.DB, error) {
+	return gorm.Open(postgres.Open(DSN(cfg)), &gorm.Config{
+		Logger: newQueryLogger(cfg.DBSlowQueryThreshold),
+	})
+}
+
+// newQueryLogger logs failed queries and those slower than slowThreshold.
+// Missing records are an expected outcome, not an error.
+func newQueryLogger(slowThreshold time.Duration) logger.Interface {
+	return logger.New(log.New(os.Stdout, "", log.LstdFlags), logger.Config{
+		SlowThreshold:             slowThreshold,
+		LogLevel:                  logger.Warn,
+		IgnoreRecordNotFoundError: true,
+	})
+}
 
-	models := []interface{}{
-		&model.User{},
-		&model.Group{},
-		&model.GroupMember{},
-		&model.Invite{},
-		&model.Album{},
-		&model.Photo{},
-		&model.Post{},
-		&model.AlbumPost{},
-		&model.Tag{},
-		&model.PostTag{},
-		&model.PostPhoto{},
-		&model.PostLike{},
-		&model.PostComment{},
-		&model.NotificationSetting{},
-		&model.Notification{},
-		&model.WebPushSubscription{},
-		&model.Trip{},
-		&model.TripItinerary{},
-		&model.TripWishlist{},
-		&model.TripExpense{},
-		&model.TripAlbum{},
-		&model.TripPost{},
-		&model.TripScheduleItem{},
-		&model.TripTransport{},
-		&model.TripLodging{},
-		&model.TripBudgetItem{},
+// DSN returns the connection string for cfg, for connections that live
+// outside the GORM pool.
+func DSN(cfg config.Config) string {
+	sslMode := cfg.DBSSLMode
+	if sslMode == "" {
+		sslMode = "disable"
 	}
 
-	if err := db.AutoMigrate(models...); err != nil {
-		return fmt.Errorf("failed to auto-migrate: %w", err)
+	return fmt.Sprintf(
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s TimeZone=Asia/Tokyo",
+		cfg.DBHost,
+		cfg.DBPort,
+		cfg.DBUser,
+		cfg.DBPassword,
+		cfg.DBName,
+		sslMode,
+	)
+}
+
+func runMigrations(migrator *Migrator) error {
+	log.Println("Running migrations...")
+
+	applied, err := migrator.Up()
+	if err != nil {
+		return fmt.Errorf("failed to migrate: %w", err)
+	}
+	for _, migration := range applied {
+		log.Printf("Applied migration %06d_%s", migration.Version, migration.Name)
 	}
 
-	log.Println("Auto-migration completed successfully")
+	log.Println("Migrations completed successfully")
 	return nil
 }
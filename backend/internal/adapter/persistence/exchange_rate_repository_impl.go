@@ -0,0 +1,55 @@
+package persistence
+
+import (
+	"context"
+
+	"memoria/internal/domain/model"
+	"memoria/internal/domain/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type exchangeRateRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewExchangeRateRepository(db *gorm.DB) repository.ExchangeRateRepository {
+	return &exchangeRateRepositoryImpl{db: db}
+}
+
+func (r *exchangeRateRepositoryImpl) FindByGroupID(ctx context.Context, groupID uint) ([]*model.ExchangeRate, error) {
+	var rates []*model.ExchangeRate
+	if err := r.db.WithContext(ctx).
+		Where("group_id = ?", groupID).
+		Order("from_currency ASC, to_currency ASC, effective_date ASC").
+		Find(&rates).Error; err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+func (r *exchangeRateRepositoryImpl) FindByID(ctx context.Context, id uint, groupID uint) (*model.ExchangeRate, error) {
+	var rate model.ExchangeRate
+	if err := r.db.WithContext(ctx).Where("id = ? AND group_id = ?", id, groupID).First(&rate).Error; err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+// Upsert replaces the rate for the same pair and effective date.
+func (r *exchangeRateRepositoryImpl) Upsert(ctx context.Context, rate *model.ExchangeRate) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{
+			{Name: "group_id"},
+			{Name: "from_currency"},
+			{Name: "to_currency"},
+			{Name: "effective_date"},
+		},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "created_by", "updated_at"}),
+	}).Create(rate).Error
+}
+
+func (r *exchangeRateRepositoryImpl) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&model.ExchangeRate{}, id).Error
+}
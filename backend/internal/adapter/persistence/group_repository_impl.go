@@ -1,10 +1,14 @@
 package persistence
 
 import (
+	"context"
+	"time"
+
 	"memoria/internal/domain/model"
 	"memoria/internal/domain/repository"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type groupRepositoryImpl struct {
@@ -15,23 +19,23 @@ func NewGroupRepository(db *gorm.DB) repository.GroupRepository {
 	return &groupRepositoryImpl{db: db}
 }
 
-func (r *groupRepositoryImpl) Create(group *model.Group) error {
-	return r.db.Create(group).Error
+func (r *groupRepositoryImpl) Create(ctx context.Context, group *model.Group) error {
+	return r.db.WithContext(ctx).Create(group).Error
 }
 
-func (r *groupRepositoryImpl) FindByID(id uint) (*model.Group, error) {
+func (r *groupRepositoryImpl) FindByID(ctx context.Context, id uint) (*model.Group, error) {
 	var group model.Group
-	if err := r.db.First(&group, id).Error; err != nil {
+	if err := r.db.WithContext(ctx).First(&group, id).Error; err != nil {
 		return nil, err
 	}
 	return &group, nil
 }
 
-func (r *groupRepositoryImpl) FindByUserID(userID uint) ([]*model.Group, error) {
+func (r *groupRepositoryImpl) FindByUserID(ctx context.Context, userID uint) ([]*model.Group, error) {
 	var groups []*model.Group
-	if err := r.db.
+	if err := r.db.WithContext(ctx).
 		Joins("JOIN group_members ON group_members.group_id = groups.id").
-		Where("group_members.user_id = ?", userID).
+		Where("group_members.user_id = ? AND groups.deleted_at IS NULL", userID).
 		Order("groups.created_at DESC").
 		Find(&groups).Error; err != nil {
 		return nil, err
@@ -39,6 +43,81 @@ func (r *groupRepositoryImpl) FindByUserID(userID uint) ([]*model.Group, error)
 	return groups, nil
 }
 
+func (r *groupRepositoryImpl) FindDeletedByCreator(ctx context.Context, userID uint) ([]*model.Group, error) {
+	var groups []*model.Group
+	if err := r.db.WithContext(ctx).
+		Where("created_by = ? AND deleted_at IS NOT NULL", userID).
+		Order("purge_after ASC").
+		Find(&groups).Error; err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+func (r *groupRepositoryImpl) FindDueForPurge(ctx context.Context, now time.Time, limit int) ([]*model.Group, error) {
+	var groups []*model.Group
+	if err := r.db.WithContext(ctx).
+		Where("deleted_at IS NOT NULL AND purge_after <= ?", now).
+		Order("purge_after ASC").
+		Limit(limit).
+		Find(&groups).Error; err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+func (r *groupRepositoryImpl) Update(ctx context.Context, group *model.Group) error {
+	return r.db.WithContext(ctx).Save(group).Error
+}
+
+func (r *groupRepositoryImpl) TransferOwnership(ctx context.Context, groupID, userID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.GroupMember{}).
+			Where("group_id = ? AND user_id = ?", groupID, userID).
+			Update("role", model.RoleManager)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Model(&model.Group{}).Where("id = ?", groupID).Update("created_by", userID).Error
+	})
+}
+
+func (r *groupRepositoryImpl) Purge(ctx context.Context, groupID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		trips := tx.Model(&model.Trip{}).Select("id").Where("group_id = ?", groupID)
+		posts := tx.Model(&model.Post{}).Select("id").Where("group_id = ?", groupID)
+		photos := tx.Model(&model.Photo{}).Select("id").Where("group_id = ?", groupID)
+		albums := tx.Model(&model.Album{}).Select("id").Where("group_id = ?", groupID)
+		webhooks := tx.Model(&model.GroupWebhook{}).Select("id").Where("group_id = ?", groupID)
+
+		if err := deleteTrips(tx, trips); err != nil {
+			return err
+		}
+		if err := deletePosts(tx, posts); err != nil {
+			return err
+		}
+		if err := deletePhotos(tx, photos); err != nil {
+			return err
+		}
+		if err := deleteAlbums(tx, albums); err != nil {
+			return err
+		}
+		if err := runDeleteSteps(tx, []deleteStep{
+			{&model.WebhookDelivery{}, "webhook_id IN (?)", webhooks},
+			{&model.GroupWebhook{}, "group_id = ?", groupID},
+			{&model.ExchangeRate{}, "group_id = ?", groupID},
+			{&model.Invite{}, "group_id = ?", groupID},
+			{&model.GroupMember{}, "group_id = ?", groupID},
+		}); err != nil {
+			return err
+		}
+		return tx.Delete(&model.Group{}, groupID).Error
+	})
+}
+
 type groupMemberRepositoryImpl struct {
 	db *gorm.DB
 }
@@ -47,30 +126,99 @@ func NewGroupMemberRepository(db *gorm.DB) repository.GroupMemberRepository {
 	return &groupMemberRepositoryImpl{db: db}
 }
 
-func (r *groupMemberRepositoryImpl) Add(member *model.GroupMember) error {
-	return r.db.Create(member).Error
+func (r *groupMemberRepositoryImpl) Add(ctx context.Context, member *model.GroupMember) error {
+	return r.db.WithContext(ctx).Create(member).Error
 }
 
-func (r *groupMemberRepositoryImpl) FindByGroupID(groupID uint) ([]*model.GroupMember, error) {
+func (r *groupMemberRepositoryImpl) FindByGroupID(ctx context.Context, groupID uint) ([]*model.GroupMember, error) {
 	var members []*model.GroupMember
-	if err := r.db.Where("group_id = ?", groupID).Order("joined_at ASC").Find(&members).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("group_id = ?", groupID).Order("joined_at ASC").Find(&members).Error; err != nil {
 		return nil, err
 	}
 	return members, nil
 }
 
-func (r *groupMemberRepositoryImpl) FindByGroupAndUser(groupID, userID uint) (*model.GroupMember, error) {
+func (r *groupMemberRepositoryImpl) FindByGroupAndUser(ctx context.Context, groupID, userID uint) (*model.GroupMember, error) {
 	var member model.GroupMember
-	if err := r.db.Where("group_id = ? AND user_id = ?", groupID, userID).First(&member).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("group_id = ? AND user_id = ?", groupID, userID).First(&member).Error; err != nil {
 		return nil, err
 	}
 	return &member, nil
 }
 
-func (r *groupMemberRepositoryImpl) Update(member *model.GroupMember) error {
-	return r.db.Save(member).Error
+func (r *groupMemberRepositoryImpl) FindActiveMembership(ctx context.Context, groupID, userID uint) (*model.GroupMember, error) {
+	var member model.GroupMember
+	if err := r.db.WithContext(ctx).
+		Joins("JOIN groups ON groups.id = group_members.group_id").
+		Where("group_members.group_id = ? AND group_members.user_id = ? AND groups.deleted_at IS NULL", groupID, userID).
+		First(&member).Error; err != nil {
+		return nil, err
+	}
+	return &member, nil
 }
 
-func (r *groupMemberRepositoryImpl) Delete(groupID, userID uint) error {
-	return r.db.Where("group_id = ? AND user_id = ?", groupID, userID).Delete(&model.GroupMember{}).Error
+func (r *groupMemberRepositoryImpl) Update(ctx context.Context, member *model.GroupMember) error {
+	return r.db.WithContext(ctx).Save(member).Error
+}
+
+func (r *groupMemberRepositoryImpl) UpdateRole(ctx context.Context, groupID, userID uint, role string) (bool, error) {
+	updated := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Locking the managers makes concurrent demotions wait for each
+		// other, so two managers cannot both step down at once.
+		var managers []*model.GroupMember
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("group_id = ? AND role = ?", groupID, model.RoleManager).
+			Find(&managers).Error; err != nil {
+			return err
+		}
+		if role != model.RoleManager && len(managers) == 1 && managers[0].UserID == userID {
+			return nil
+		}
+
+		result := tx.Model(&model.GroupMember{}).
+			Where("group_id = ? AND user_id = ?", groupID, userID).
+			Update("role", role)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		updated = true
+		return nil
+	})
+	return updated, err
+}
+
+func (r *groupMemberRepositoryImpl) Delete(ctx context.Context, groupID, userID uint) error {
+	return r.db.WithContext(ctx).Where("group_id = ? AND user_id = ?", groupID, userID).Delete(&model.GroupMember{}).Error
+}
+
+func (r *groupMemberRepositoryImpl) Remove(ctx context.Context, groupID, userID uint) (bool, error) {
+	removed := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Same lock as UpdateRole, so a removal and a demotion cannot
+		// together leave the group without a manager.
+		var managers []*model.GroupMember
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("group_id = ? AND role = ?", groupID, model.RoleManager).
+			Find(&managers).Error; err != nil {
+			return err
+		}
+		if len(managers) == 1 && managers[0].UserID == userID {
+			return nil
+		}
+
+		result := tx.Where("group_id = ? AND user_id = ?", groupID, userID).Delete(&model.GroupMember{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		removed = true
+		return nil
+	})
+	return removed, err
 }
@@ -1,6 +1,8 @@
 package persistence
 
 import (
+	"context"
+
 	"memoria/internal/domain/model"
 	"memoria/internal/domain/repository"
 
@@ -15,38 +17,38 @@ func NewInviteRepository(db *gorm.DB) repository.InviteRepository {
 	return &inviteRepositoryImpl{db: db}
 }
 
-func (r *inviteRepositoryImpl) Create(invite *model.Invite) error {
-	return r.db.Create(invite).Error
+func (r *inviteRepositoryImpl) Create(ctx context.Context, invite *model.Invite) error {
+	return r.db.WithContext(ctx).Create(invite).Error
 }
 
-func (r *inviteRepositoryImpl) FindByToken(token string) (*model.Invite, error) {
+func (r *inviteRepositoryImpl) FindByToken(ctx context.Context, token string) (*model.Invite, error) {
 	var invite model.Invite
-	if err := r.db.Where("token = ?", token).First(&invite).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("token = ?", token).First(&invite).Error; err != nil {
 		return nil, err
 	}
 	return &invite, nil
 }
 
-func (r *inviteRepositoryImpl) FindByID(id uint) (*model.Invite, error) {
+func (r *inviteRepositoryImpl) FindByID(ctx context.Context, id uint) (*model.Invite, error) {
 	var invite model.Invite
-	if err := r.db.First(&invite, id).Error; err != nil {
+	if err := r.db.WithContext(ctx).First(&invite, id).Error; err != nil {
 		return nil, err
 	}
 	return &invite, nil
 }
 
-func (r *inviteRepositoryImpl) FindByGroupID(groupID uint) ([]*model.Invite, error) {
+func (r *inviteRepositoryImpl) FindByGroupID(ctx context.Context, groupID uint) ([]*model.Invite, error) {
 	var invites []*model.Invite
-	if err := r.db.Where("group_id = ?", groupID).Order("created_at DESC").Find(&invites).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("group_id = ?", groupID).Order("created_at DESC").Find(&invites).Error; err != nil {
 		return nil, err
 	}
 	return invites, nil
 }
 
-func (r *inviteRepositoryImpl) Update(invite *model.Invite) error {
-	return r.db.Save(invite).Error
+func (r *inviteRepositoryImpl) Update(ctx context.Context, invite *model.Invite) error {
+	return r.db.WithContext(ctx).Save(invite).Error
 }
 
-func (r *inviteRepositoryImpl) Delete(id uint) error {
-	return r.db.Delete(&model.Invite{}, id).Error
+func (r *inviteRepositoryImpl) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&model.Invite{}, id).Error
 }
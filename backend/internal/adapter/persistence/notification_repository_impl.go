@@ -1,11 +1,15 @@
 package persistence
 
 import (
+	"context"
+
 	"memoria/internal/domain/model"
 	"memoria/internal/domain/repository"
+	"sort"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type notificationRepositoryImpl struct {
@@ -16,21 +20,96 @@ func NewNotificationRepository(db *gorm.DB) repository.NotificationRepository {
 	return &notificationRepositoryImpl{db: db}
 }
 
-func (r *notificationRepositoryImpl) Create(notification *model.Notification) error {
-	return r.db.Create(notification).Error
+func (r *notificationRepositoryImpl) Create(ctx context.Context, notification *model.Notification) error {
+	return r.db.WithContext(ctx).Create(notification).Error
+}
+
+func (r *notificationRepositoryImpl) CreateGrouped(ctx context.Context, notifications []*model.Notification) error {
+	// Lock in a fixed order so concurrent fan-outs cannot deadlock.
+	ordered := make([]*model.Notification, len(notifications))
+	copy(ordered, notifications)
+	sort.Slice(ordered, func(i, j int) bool {
+		if ordered[i].UserID != ordered[j].UserID {
+			return ordered[i].UserID < ordered[j].UserID
+		}
+		return ordered[i].GroupKey < ordered[j].GroupKey
+	})
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, notification := range ordered {
+			if notification.Count == 0 {
+				notification.Count = 1
+			}
+			if notification.GroupKey != "" {
+				// Serialises folding per recipient and key until commit.
+				if err := tx.Exec("SELECT pg_advisory_xact_lock(?::int, hashtext(?))", notification.UserID, notification.GroupKey).Error; err != nil {
+					return err
+				}
+				var existing []*model.Notification
+				if err := tx.
+					Where("user_id = ? AND group_key = ? AND read_at IS NULL", notification.UserID, notification.GroupKey).
+					Find(&existing).Error; err != nil {
+					return err
+				}
+				for _, previous := range existing {
+					notification.Count += previous.Count
+					if err := tx.Delete(previous).Error; err != nil {
+						return err
+					}
+				}
+			}
+			if err := tx.Create(notification).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
-func (r *notificationRepositoryImpl) FindByUserID(userID uint) ([]*model.Notification, error) {
+func (r *notificationRepositoryImpl) FindByUserID(ctx context.Context, userID uint, beforeID uint, limit int, unreadOnly bool) ([]*model.Notification, error) {
 	var notifications []*model.Notification
-	if err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&notifications).Error; err != nil {
+	query := r.db.WithContext(ctx).Where("user_id = ?", userID)
+	if beforeID > 0 {
+		query = query.Where("id < ?", beforeID)
+	}
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	if err := query.Order("id DESC").Limit(limit).Find(&notifications).Error; err != nil {
 		return nil, err
 	}
 	return notifications, nil
 }
 
-func (r *notificationRepositoryImpl) MarkAsRead(id uint) error {
+func (r *notificationRepositoryImpl) FindByID(ctx context.Context, id uint, userID uint) (*model.Notification, error) {
+	var notification model.Notification
+	if err := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&notification).Error; err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
+
+func (r *notificationRepositoryImpl) CountUnread(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&model.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *notificationRepositoryImpl) MarkAsRead(ctx context.Context, id uint) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).Model(&model.Notification{}).Where("id = ? AND read_at IS NULL", id).Update("read_at", now).Error
+}
+
+func (r *notificationRepositoryImpl) MarkAllAsRead(ctx context.Context, userID uint) (int64, error) {
 	now := time.Now()
-	return r.db.Model(&model.Notification{}).Where("id = ?", id).Update("read_at", now).Error
+	result := r.db.WithContext(ctx).Model(&model.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Update("read_at", now)
+	return result.RowsAffected, result.Error
+}
+
+func (r *notificationRepositoryImpl) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&model.Notification{}, id).Error
 }
 
 type notificationSettingRepositoryImpl struct {
@@ -41,16 +120,31 @@ func NewNotificationSettingRepository(db *gorm.DB) repository.NotificationSettin
 	return &notificationSettingRepositoryImpl{db: db}
 }
 
-func (r *notificationSettingRepositoryImpl) FindByUserID(userID uint) ([]*model.NotificationSetting, error) {
+func (r *notificationSettingRepositoryImpl) FindByUserID(ctx context.Context, userID uint) ([]*model.NotificationSetting, error) {
 	var settings []*model.NotificationSetting
-	if err := r.db.Where("user_id = ?", userID).Find(&settings).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Find(&settings).Error; err != nil {
 		return nil, err
 	}
 	return settings, nil
 }
 
-func (r *notificationSettingRepositoryImpl) Upsert(setting *model.NotificationSetting) error {
-	return r.db.Save(setting).Error
+// Upsert replaces the user's setting for the same category.
+func (r *notificationSettingRepositoryImpl) Upsert(ctx context.Context, setting *model.NotificationSetting) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "category"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+	}).Create(setting).Error
+}
+
+func (r *notificationSettingRepositoryImpl) FindByCategory(ctx context.Context, category string, userIDs []uint) ([]*model.NotificationSetting, error) {
+	var settings []*model.NotificationSetting
+	if len(userIDs) == 0 {
+		return settings, nil
+	}
+	if err := r.db.WithContext(ctx).Where("category = ? AND user_id IN ?", category, userIDs).Find(&settings).Error; err != nil {
+		return nil, err
+	}
+	return settings, nil
 }
 
 type webPushSubscriptionRepositoryImpl struct {
@@ -61,18 +155,101 @@ func NewWebPushSubscriptionRepository(db *gorm.DB) repository.WebPushSubscriptio
 	return &webPushSubscriptionRepositoryImpl{db: db}
 }
 
-func (r *webPushSubscriptionRepositoryImpl) Create(subscription *model.WebPushSubscription) error {
-	return r.db.Create(subscription).Error
+// Upsert registers the endpoint for the subscription's user. An endpoint
+// belongs to one browser, so registering it again moves it to the new user
+// and refreshes its keys.
+func (r *webPushSubscriptionRepositoryImpl) Upsert(ctx context.Context, subscription *model.WebPushSubscription) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "endpoint"}},
+		DoUpdates: clause.AssignmentColumns([]string{"user_id", "auth", "p256dh", "updated_at"}),
+	}).Create(subscription).Error
 }
 
-func (r *webPushSubscriptionRepositoryImpl) FindByUserID(userID uint) ([]*model.WebPushSubscription, error) {
+func (r *webPushSubscriptionRepositoryImpl) FindByUserID(ctx context.Context, userID uint) ([]*model.WebPushSubscription, error) {
 	var subscriptions []*model.WebPushSubscription
-	if err := r.db.Where("user_id = ?", userID).Find(&subscriptions).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Find(&subscriptions).Error; err != nil {
 		return nil, err
 	}
 	return subscriptions, nil
 }
 
-func (r *webPushSubscriptionRepositoryImpl) Delete(id uint) error {
-	return r.db.Delete(&model.WebPushSubscription{}, id).Error
+func (r *webPushSubscriptionRepositoryImpl) FindByIDs(ctx context.Context, ids []uint) ([]*model.WebPushSubscription, error) {
+	var subscriptions []*model.WebPushSubscription
+	if len(ids) == 0 {
+		return subscriptions, nil
+	}
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+func (r *webPushSubscriptionRepositoryImpl) FindByID(ctx context.Context, id uint, userID uint) (*model.WebPushSubscription, error) {
+	var subscription model.WebPushSubscription
+	if err := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&subscription).Error; err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+func (r *webPushSubscriptionRepositoryImpl) FindByEndpoint(ctx context.Context, endpoint string, userID uint) (*model.WebPushSubscription, error) {
+	var subscription model.WebPushSubscription
+	if err := r.db.WithContext(ctx).Where("endpoint = ? AND user_id = ?", endpoint, userID).First(&subscription).Error; err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+func (r *webPushSubscriptionRepositoryImpl) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&model.WebPushSubscription{}, id).Error
+}
+
+type pushDeliveryRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewPushDeliveryRepository(db *gorm.DB) repository.PushDeliveryRepository {
+	return &pushDeliveryRepositoryImpl{db: db}
+}
+
+func (r *pushDeliveryRepositoryImpl) Create(ctx context.Context, deliveries []*model.PushDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&deliveries).Error
+}
+
+func (r *pushDeliveryRepositoryImpl) ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*model.PushDelivery, error) {
+	var deliveries []*model.PushDelivery
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("next_attempt_at <= ?", now).
+			Order("next_attempt_at ASC").
+			Limit(limit).
+			Find(&deliveries).Error; err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+		ids := make([]uint, len(deliveries))
+		for i, delivery := range deliveries {
+			ids[i] = delivery.ID
+			delivery.NextAttemptAt = leaseUntil
+		}
+		return tx.Model(&model.PushDelivery{}).Where("id IN ?", ids).Update("next_attempt_at", leaseUntil).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *pushDeliveryRepositoryImpl) Update(ctx context.Context, delivery *model.PushDelivery) error {
+	return r.db.WithContext(ctx).Save(delivery).Error
+}
+
+func (r *pushDeliveryRepositoryImpl) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&model.PushDelivery{}, id).Error
 }
@@ -0,0 +1,55 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"memoria/internal/domain/model"
+	"memoria/internal/domain/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type objectDeletionRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewObjectDeletionRepository(db *gorm.DB) repository.ObjectDeletionRepository {
+	return &objectDeletionRepositoryImpl{db: db}
+}
+
+func (r *objectDeletionRepositoryImpl) ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*model.ObjectDeletion, error) {
+	var deletions []*model.ObjectDeletion
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("next_attempt_at <= ?", now).
+			Order("next_attempt_at ASC").
+			Limit(limit).
+			Find(&deletions).Error; err != nil {
+			return err
+		}
+		if len(deletions) == 0 {
+			return nil
+		}
+		ids := make([]uint, len(deletions))
+		for i, deletion := range deletions {
+			ids[i] = deletion.ID
+			deletion.NextAttemptAt = leaseUntil
+		}
+		return tx.Model(&model.ObjectDeletion{}).Where("id IN ?", ids).Update("next_attempt_at", leaseUntil).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deletions, nil
+}
+
+func (r *objectDeletionRepositoryImpl) Update(ctx context.Context, deletion *model.ObjectDeletion) error {
+	return r.db.WithContext(ctx).Save(deletion).Error
+}
+
+func (r *objectDeletionRepositoryImpl) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&model.ObjectDeletion{}, id).Error
+}
@@ -0,0 +1,155 @@
+package persistence
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// ForeignKey is a reference enforced by migration 000012. The constraints
+// were added NOT VALID, so rows orphaned before then may still exist;
+// cmd/orphans finds and repairs them.
+type ForeignKey struct {
+	Table  string
+	Column string
+	Parent string
+	// Detach clears the column instead of deleting the row.
+	Detach bool
+}
+
+// Constraint is the name the migration gave the constraint.
+func (fk ForeignKey) Constraint() string {
+	return "fk_" + fk.Table + "_" + fk.Column
+}
+
+// orphanCondition matches the rows whose parent is missing.
+func (fk ForeignKey) orphanCondition() string {
+	return fmt.Sprintf("%[1]s.%[2]s IS NOT NULL AND NOT EXISTS (SELECT 1 FROM %[3]s WHERE %[3]s.id = %[1]s.%[2]s)",
+		fk.Table, fk.Column, fk.Parent)
+}
+
+// ForeignKeys lists parents before children, so repairing them in order
+// also catches rows orphaned by an earlier step.
+var ForeignKeys = []ForeignKey{
+	{Table: "group_members", Column: "group_id", Parent: "groups"},
+	{Table: "invites", Column: "group_id", Parent: "groups"},
+	{Table: "albums", Column: "group_id", Parent: "groups"},
+	{Table: "photos", Column: "group_id", Parent: "groups"},
+	{Table: "photos", Column: "album_id", Parent: "albums"},
+	{Table: "albums", Column: "cover_photo_id", Parent: "photos", Detach: true},
+	{Table: "posts", Column: "group_id", Parent: "groups"},
+	{Table: "album_posts", Column: "album_id", Parent: "albums"},
+	{Table: "album_posts", Column: "post_id", Parent: "posts"},
+	{Table: "post_tags", Column: "post_id", Parent: "posts"},
+	{Table: "post_tags", Column: "tag_id", Parent: "tags"},
+	{Table: "post_photos", Column: "post_id", Parent: "posts"},
+	{Table: "post_photos", Column: "photo_id", Parent: "photos"},
+	{Table: "post_likes", Column: "post_id", Parent: "posts"},
+	{Table: "post_comments", Column: "post_id", Parent: "posts"},
+	{Table: "group_webhooks", Column: "group_id", Parent: "groups"},
+	{Table: "webhook_deliveries", Column: "webhook_id", Parent: "group_webhooks"},
+	{Table: "exchange_rates", Column: "group_id", Parent: "groups"},
+	{Table: "trips", Column: "group_id", Parent: "groups"},
+	{Table: "trip_reminders", Column: "trip_id", Parent: "trips"},
+	{Table: "trip_itineraries", Column: "trip_id", Parent: "trips"},
+	{Table: "trip_wishlists", Column: "trip_id", Parent: "trips"},
+	{Table: "trip_expenses", Column: "trip_id", Parent: "trips"},
+	{Table: "trip_expense_participants", Column: "expense_id", Parent: "trip_expenses"},
+	{Table: "trip_settlements", Column: "trip_id", Parent: "trips"},
+	{Table: "trip_albums", Column: "trip_id", Parent: "trips"},
+	{Table: "trip_albums", Column: "album_id", Parent: "albums"},
+	{Table: "trip_posts", Column: "trip_id", Parent: "trips"},
+	{Table: "trip_posts", Column: "post_id", Parent: "posts"},
+	{Table: "trip_schedule_items", Column: "trip_id", Parent: "trips"},
+	{Table: "trip_transports", Column: "trip_id", Parent: "trips"},
+	{Table: "trip_lodgings", Column: "trip_id", Parent: "trips"},
+	{Table: "trip_budget_items", Column: "trip_id", Parent: "trips"},
+}
+
+// OrphanCount is how many rows of a foreign key point at a missing parent.
+type OrphanCount struct {
+	ForeignKey
+	Count int64
+}
+
+// FindOrphans counts orphaned rows for every foreign key without changing
+// anything. Rows that would only become orphans once their parent is
+// repaired are not counted.
+func FindOrphans(db *gorm.DB) ([]OrphanCount, error) {
+	counts := make([]OrphanCount, 0, len(ForeignKeys))
+	for _, fk := range ForeignKeys {
+		var count int64
+		if err := db.Table(fk.Table).Where(fk.orphanCondition()).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		counts = append(counts, OrphanCount{ForeignKey: fk, Count: count})
+	}
+	return counts, nil
+}
+
+// RepairOrphans deletes or detaches orphaned rows in one transaction and
+// returns how many it handled per foreign key. Deleted rows take their own
+// dependents with them, and photo objects are queued for deletion.
+func RepairOrphans(db *gorm.DB) ([]OrphanCount, error) {
+	counts := make([]OrphanCount, 0, len(ForeignKeys))
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, fk := range ForeignKeys {
+			var count int64
+			if err := tx.Table(fk.Table).Where(fk.orphanCondition()).Count(&count).Error; err != nil {
+				return err
+			}
+			counts = append(counts, OrphanCount{ForeignKey: fk, Count: count})
+			if count == 0 {
+				continue
+			}
+			if err := removeOrphans(tx, fk); err != nil {
+				return fmt.Errorf("%s: %w", fk.Constraint(), err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// ValidateForeignKeys checks every existing row against the constraints,
+// turning them from NOT VALID into fully validated ones. It fails while
+// orphans remain.
+func ValidateForeignKeys(db *gorm.DB) error {
+	for _, fk := range ForeignKeys {
+		if err := db.Exec(fmt.Sprintf("ALTER TABLE %s VALIDATE CONSTRAINT %s", fk.Table, fk.Constraint())).Error; err != nil {
+			return fmt.Errorf("%s: %w", fk.Constraint(), err)
+		}
+	}
+	return nil
+}
+
+func removeOrphans(tx *gorm.DB, fk ForeignKey) error {
+	condition := fk.orphanCondition()
+	if fk.Detach {
+		return tx.Table(fk.Table).Where(condition).Update(fk.Column, nil).Error
+	}
+
+	ids := tx.Table(fk.Table).Select("id").Where(condition)
+	switch fk.Table {
+	case "albums":
+		return deleteAlbums(tx, ids)
+	case "photos":
+		return deletePhotos(tx, ids)
+	case "posts":
+		return deletePosts(tx, ids)
+	case "trips":
+		return deleteTrips(tx, ids)
+	case "group_webhooks":
+		if err := tx.Exec("DELETE FROM webhook_deliveries WHERE webhook_id IN (?)", ids).Error; err != nil {
+			return err
+		}
+	case "trip_expenses":
+		if err := tx.Exec("DELETE FROM trip_expense_participants WHERE expense_id IN (?)", ids).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Exec("DELETE FROM " + fk.Table + " WHERE " + condition).Error
+}
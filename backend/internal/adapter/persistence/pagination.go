@@ -0,0 +1,99 @@
+package persistence
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"memoria/internal/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+// keyset is the order of a list: column, then id in the same direction.
+// Pages continue after the (column, id) pair of the last row, so rows
+// added or removed meanwhile do not shift later pages.
+type keyset struct {
+	table  string
+	column string
+	desc   bool
+}
+
+// order resolves the requested sort against the list's default.
+func (k keyset) order(sort repository.SortOrder) keyset {
+	switch sort {
+	case repository.SortNewest:
+		k.desc = true
+	case repository.SortOldest:
+		k.desc = false
+	}
+	return k
+}
+
+// cursor is the position after which the next page starts. It is handed
+// out base64-encoded and is opaque to clients.
+type cursor struct {
+	Key time.Time `json:"k"`
+	ID  uint      `json:"i"`
+}
+
+func encodeCursor(c cursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, repository.ErrInvalidCursor
+	}
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID == 0 {
+		return c, repository.ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// findPage applies the date range, cursor, order and limit of opts to query
+// and loads one page. position returns the sort key and ID of a row.
+func findPage[T any](query *gorm.DB, k keyset, opts repository.ListOptions, position func(T) cursor) (*repository.Page[T], error) {
+	column := k.table + "." + k.column
+	id := k.table + ".id"
+	if opts.From != nil {
+		query = query.Where(column+" >= ?", *opts.From)
+	}
+	if opts.To != nil {
+		query = query.Where(column+" < ?", *opts.To)
+	}
+
+	direction, compare := "ASC", ">"
+	if k.desc {
+		direction, compare = "DESC", "<"
+	}
+	if opts.Cursor != "" {
+		after, err := decodeCursor(opts.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where(fmt.Sprintf("(%s, %s) %s (?, ?)", column, id, compare), after.Key, after.ID)
+	}
+
+	// Fetch one extra row to know whether another page exists.
+	limit := opts.PageSize()
+	var items []T
+	if err := query.
+		Order(column + " " + direction).
+		Order(id + " " + direction).
+		Limit(limit + 1).
+		Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	page := &repository.Page[T]{Items: items}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		page.NextCursor = encodeCursor(position(items[limit-1]))
+	}
+	return page, nil
+}
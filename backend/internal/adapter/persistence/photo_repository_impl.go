@@ -1,10 +1,14 @@
 package persistence
 
 import (
+	"context"
+	"time"
+
 	"memoria/internal/domain/model"
 	"memoria/internal/domain/repository"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type photoRepositoryImpl struct {
@@ -15,26 +19,132 @@ func NewPhotoRepository(db *gorm.DB) repository.PhotoRepository {
 	return &photoRepositoryImpl{db: db}
 }
 
-func (r *photoRepositoryImpl) Create(photo *model.Photo) error {
-	return r.db.Create(photo).Error
+func (r *photoRepositoryImpl) Create(ctx context.Context, photo *model.Photo) error {
+	return r.db.WithContext(ctx).Create(photo).Error
 }
 
-func (r *photoRepositoryImpl) FindByID(id uint, groupID uint) (*model.Photo, error) {
+func (r *photoRepositoryImpl) FindByID(ctx context.Context, id uint, groupID uint) (*model.Photo, error) {
 	var photo model.Photo
-	if err := r.db.Where("id = ? AND group_id = ?", id, groupID).First(&photo).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("id = ? AND group_id = ? AND deleted_at IS NULL", id, groupID).First(&photo).Error; err != nil {
 		return nil, err
 	}
 	return &photo, nil
 }
 
-func (r *photoRepositoryImpl) FindByAlbumID(albumID uint, groupID uint) ([]*model.Photo, error) {
+func (r *photoRepositoryImpl) FindByAlbumID(ctx context.Context, albumID uint, groupID uint, opts repository.ListOptions) (*repository.Page[*model.Photo], error) {
+	query := r.db.WithContext(ctx).Where("album_id = ? AND group_id = ? AND deleted_at IS NULL", albumID, groupID)
+	if opts.AuthorID != 0 {
+		query = query.Where("uploaded_by = ?", opts.AuthorID)
+	}
+	ks := keyset{table: "photos", column: "created_at", desc: true}.order(opts.Sort)
+	return findPage(query, ks, opts, func(photo *model.Photo) cursor {
+		return cursor{Key: photo.CreatedAt, ID: photo.ID}
+	})
+}
+
+func (r *photoRepositoryImpl) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Model(&model.Photo{}).Where("id = ?", id).Update("deleted_at", time.Now()).Error
+}
+
+func (r *photoRepositoryImpl) FindVariants(ctx context.Context, photoIDs []uint) ([]*model.PhotoVariant, error) {
+	var variants []*model.PhotoVariant
+	if len(photoIDs) == 0 {
+		return variants, nil
+	}
+	if err := r.db.WithContext(ctx).Where("photo_id IN ?", photoIDs).Order("photo_id, width").Find(&variants).Error; err != nil {
+		return nil, err
+	}
+	return variants, nil
+}
+
+func (r *photoRepositoryImpl) ClaimPending(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*model.Photo, error) {
 	var photos []*model.Photo
-	if err := r.db.Where("album_id = ? AND group_id = ?", albumID, groupID).Order("created_at DESC").Find(&photos).Error; err != nil {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND deleted_at IS NULL AND process_after <= ?", model.PhotoPending, now).
+			Order("process_after ASC").
+			Limit(limit).
+			Find(&photos).Error; err != nil {
+			return err
+		}
+		if len(photos) == 0 {
+			return nil
+		}
+		ids := make([]uint, len(photos))
+		for i, photo := range photos {
+			ids[i] = photo.ID
+			photo.ProcessAfter = leaseUntil
+		}
+		return tx.Model(&model.Photo{}).Where("id IN ?", ids).Update("process_after", leaseUntil).Error
+	})
+	if err != nil {
 		return nil, err
 	}
 	return photos, nil
 }
 
-func (r *photoRepositoryImpl) Delete(id uint) error {
-	return r.db.Delete(&model.Photo{}, id).Error
+func (r *photoRepositoryImpl) SaveVariants(ctx context.Context, photo *model.Photo, variants []*model.PhotoVariant) error {
+	keys := make([]string, len(variants))
+	for i, variant := range variants {
+		variant.PhotoID = photo.ID
+		keys[i] = variant.S3Key
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var found []*model.Photo
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", photo.ID).Find(&found).Error; err != nil {
+			return err
+		}
+		if len(found) == 0 {
+			// Purged while the variants were being made.
+			return queueObjects(tx, keys)
+		}
+
+		var oldKeys []string
+		if err := tx.Model(&model.PhotoVariant{}).Where("photo_id = ?", photo.ID).Pluck("s3_key", &oldKeys).Error; err != nil {
+			return err
+		}
+		kept := make(map[string]bool, len(keys))
+		for _, key := range keys {
+			kept[key] = true
+		}
+		var stale []string
+		for _, key := range oldKeys {
+			if !kept[key] {
+				stale = append(stale, key)
+			}
+		}
+		if err := queueObjects(tx, stale); err != nil {
+			return err
+		}
+		if err := tx.Where("photo_id = ?", photo.ID).Delete(&model.PhotoVariant{}).Error; err != nil {
+			return err
+		}
+		if len(variants) > 0 {
+			if err := tx.Create(variants).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(photo).
+			Select("width", "height", "status", "processing_attempts", "processing_error", "process_after").
+			Updates(photo).Error
+	})
+}
+
+func (r *photoRepositoryImpl) UpdateProcessing(ctx context.Context, photo *model.Photo) error {
+	return r.db.WithContext(ctx).Model(photo).
+		Select("status", "processing_attempts", "processing_error", "process_after").
+		Updates(photo).Error
+}
+
+func (r *photoRepositoryImpl) Requeue(ctx context.Context, statuses []string, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&model.Photo{}).
+		Where("status IN ? AND deleted_at IS NULL", statuses).
+		Updates(map[string]any{
+			"status":              model.PhotoPending,
+			"processing_attempts": 0,
+			"processing_error":    "",
+			"process_after":       now,
+		})
+	return result.RowsAffected, result.Error
 }
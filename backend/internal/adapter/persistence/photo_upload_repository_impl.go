@@ -0,0 +1,72 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"memoria/internal/domain/model"
+	"memoria/internal/domain/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type photoUploadRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewPhotoUploadRepository(db *gorm.DB) repository.PhotoUploadRepository {
+	return &photoUploadRepositoryImpl{db: db}
+}
+
+func (r *photoUploadRepositoryImpl) Create(ctx context.Context, upload *model.PhotoUpload) error {
+	return r.db.WithContext(ctx).Create(upload).Error
+}
+
+func (r *photoUploadRepositoryImpl) FindByKey(ctx context.Context, s3Key string) (*model.PhotoUpload, error) {
+	var upload model.PhotoUpload
+	if err := r.db.WithContext(ctx).Where("s3_key = ?", s3Key).First(&upload).Error; err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+func (r *photoUploadRepositoryImpl) Consume(ctx context.Context, id uint, now time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Where("id = ? AND expires_at > ?", id, now).Delete(&model.PhotoUpload{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *photoUploadRepositoryImpl) ExpireDue(ctx context.Context, now time.Time, limit int) (int, error) {
+	var uploads []*model.PhotoUpload
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Rows a concurrent Consume is deleting are skipped.
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("expires_at <= ?", now).
+			Order("expires_at ASC").
+			Limit(limit).
+			Find(&uploads).Error; err != nil {
+			return err
+		}
+		if len(uploads) == 0 {
+			return nil
+		}
+		ids := make([]uint, len(uploads))
+		keys := make([]string, len(uploads))
+		for i, upload := range uploads {
+			ids[i] = upload.ID
+			keys[i] = upload.S3Key
+		}
+		if err := queueObjects(tx, keys); err != nil {
+			return err
+		}
+		return tx.Delete(&model.PhotoUpload{}, ids).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(uploads), nil
+}
@@ -1,11 +1,14 @@
 package persistence
 
 import (
+	"context"
+
 	"memoria/internal/domain/model"
 	"memoria/internal/domain/repository"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type postRepositoryImpl struct {
@@ -16,110 +19,138 @@ func NewPostRepository(db *gorm.DB) repository.PostRepository {
 	return &postRepositoryImpl{db: db}
 }
 
-func (r *postRepositoryImpl) Create(post *model.Post) error {
-	return r.db.Create(post).Error
+func (r *postRepositoryImpl) Create(ctx context.Context, post *model.Post) error {
+	return r.db.WithContext(ctx).Create(post).Error
 }
 
-func (r *postRepositoryImpl) FindByID(id uint, groupID uint) (*model.Post, error) {
+func (r *postRepositoryImpl) FindByID(ctx context.Context, id uint, groupID uint) (*model.Post, error) {
 	var post model.Post
-	if err := r.db.Where("id = ? AND group_id = ?", id, groupID).First(&post).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("id = ? AND group_id = ? AND deleted_at IS NULL", id, groupID).First(&post).Error; err != nil {
 		return nil, err
 	}
 	return &post, nil
 }
 
-func (r *postRepositoryImpl) FindAll(groupID uint) ([]*model.Post, error) {
-	var posts []*model.Post
-	if err := r.db.Where("group_id = ?", groupID).Order("published_at DESC").Find(&posts).Error; err != nil {
-		return nil, err
+func (r *postRepositoryImpl) FindAll(ctx context.Context, groupID uint, opts repository.ListOptions) (*repository.Page[*model.Post], error) {
+	query := r.db.WithContext(ctx).Where("posts.group_id = ? AND posts.deleted_at IS NULL", groupID)
+	if opts.Type != "" {
+		query = query.Where("posts.type = ?", opts.Type)
 	}
-	return posts, nil
-}
-
-func (r *postRepositoryImpl) FindByTagID(tagID uint, groupID uint) ([]*model.Post, error) {
-	var posts []*model.Post
-	if err := r.db.
-		Joins("JOIN post_tags ON post_tags.post_id = posts.id").
-		Where("post_tags.tag_id = ? AND posts.group_id = ?", tagID, groupID).
-		Order("published_at DESC").
-		Find(&posts).Error; err != nil {
-		return nil, err
+	if opts.AuthorID != 0 {
+		query = query.Where("posts.author_id = ?", opts.AuthorID)
+	}
+	if opts.TagID != 0 {
+		query = query.Where("EXISTS (SELECT 1 FROM post_tags WHERE post_tags.post_id = posts.id AND post_tags.tag_id = ?)", opts.TagID)
 	}
-	return posts, nil
+	ks := keyset{table: "posts", column: "published_at", desc: true}.order(opts.Sort)
+	return findPage(query, ks, opts, func(post *model.Post) cursor {
+		return cursor{Key: post.PublishedAt, ID: post.ID}
+	})
 }
 
-func (r *postRepositoryImpl) Update(post *model.Post) error {
-	return r.db.Save(post).Error
+func (r *postRepositoryImpl) Update(ctx context.Context, post *model.Post) error {
+	return r.db.WithContext(ctx).Save(post).Error
 }
 
-func (r *postRepositoryImpl) Delete(id uint) error {
-	return r.db.Delete(&model.Post{}, id).Error
+func (r *postRepositoryImpl) Delete(ctx context.Context, id uint) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Comments share the post's timestamp, see albumRepositoryImpl.Delete.
+		if err := tx.Model(&model.PostComment{}).
+			Where("post_id = ? AND deleted_at IS NULL", id).
+			Update("deleted_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.Post{}).Where("id = ?", id).Update("deleted_at", now).Error
+	})
 }
 
-func (r *postRepositoryImpl) AddAlbum(postID, albumID uint) error {
+func (r *postRepositoryImpl) AddAlbum(ctx context.Context, postID, albumID uint) error {
 	albumPost := &model.AlbumPost{
 		AlbumID:   albumID,
 		PostID:    postID,
 		CreatedAt: time.Now(),
 	}
-	return r.db.Create(albumPost).Error
+	return r.db.WithContext(ctx).Create(albumPost).Error
 }
 
-func (r *postRepositoryImpl) RemoveAlbum(postID, albumID uint) error {
-	return r.db.Where("post_id = ? AND album_id = ?", postID, albumID).Delete(&model.AlbumPost{}).Error
+func (r *postRepositoryImpl) RemoveAlbum(ctx context.Context, postID, albumID uint) error {
+	return r.db.WithContext(ctx).Where("post_id = ? AND album_id = ?", postID, albumID).Delete(&model.AlbumPost{}).Error
 }
 
-func (r *postRepositoryImpl) AddPhoto(postID, photoID uint) error {
+func (r *postRepositoryImpl) AddPhoto(ctx context.Context, postID, photoID uint) error {
 	postPhoto := &model.PostPhoto{
 		PostID:    postID,
 		PhotoID:   photoID,
 		CreatedAt: time.Now(),
 	}
-	return r.db.Create(postPhoto).Error
+	return r.db.WithContext(ctx).Create(postPhoto).Error
 }
 
-func (r *postRepositoryImpl) RemovePhoto(postID, photoID uint) error {
-	return r.db.Where("post_id = ? AND photo_id = ?", postID, photoID).Delete(&model.PostPhoto{}).Error
+func (r *postRepositoryImpl) RemovePhoto(ctx context.Context, postID, photoID uint) error {
+	return r.db.WithContext(ctx).Where("post_id = ? AND photo_id = ?", postID, photoID).Delete(&model.PostPhoto{}).Error
 }
 
-func (r *postRepositoryImpl) AddTag(postID, tagID uint) error {
+func (r *postRepositoryImpl) AddTag(ctx context.Context, postID, tagID uint) error {
 	postTag := &model.PostTag{
 		PostID:    postID,
 		TagID:     tagID,
 		CreatedAt: time.Now(),
 	}
-	return r.db.Create(postTag).Error
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(postTag).Error
 }
 
-func (r *postRepositoryImpl) RemoveTag(postID, tagID uint) error {
-	return r.db.Where("post_id = ? AND tag_id = ?", postID, tagID).Delete(&model.PostTag{}).Error
+func (r *postRepositoryImpl) RemoveTag(ctx context.Context, postID, tagID uint) error {
+	return r.db.WithContext(ctx).Where("post_id = ? AND tag_id = ?", postID, tagID).Delete(&model.PostTag{}).Error
 }
 
-func (r *postRepositoryImpl) AddLike(postID, userID uint) error {
+func (r *postRepositoryImpl) AddLike(ctx context.Context, postID, userID uint) error {
 	like := &model.PostLike{
 		PostID:    postID,
 		UserID:    userID,
 		CreatedAt: time.Now(),
 	}
-	return r.db.Create(like).Error
+	return r.db.WithContext(ctx).Create(like).Error
 }
 
-func (r *postRepositoryImpl) RemoveLike(postID, userID uint) error {
-	return r.db.Where("post_id = ? AND user_id = ?", postID, userID).Delete(&model.PostLike{}).Error
+func (r *postRepositoryImpl) RemoveLike(ctx context.Context, postID, userID uint) error {
+	return r.db.WithContext(ctx).Where("post_id = ? AND user_id = ?", postID, userID).Delete(&model.PostLike{}).Error
 }
 
-func (r *postRepositoryImpl) CreateComment(comment *model.PostComment) error {
-	return r.db.Create(comment).Error
+func (r *postRepositoryImpl) CreateComment(ctx context.Context, comment *model.PostComment) error {
+	return r.db.WithContext(ctx).Create(comment).Error
 }
 
-func (r *postRepositoryImpl) DeleteComment(id uint) error {
-	return r.db.Delete(&model.PostComment{}, id).Error
+func (r *postRepositoryImpl) FindCommentByID(ctx context.Context, id uint) (*model.PostComment, error) {
+	var comment model.PostComment
+	if err := r.db.WithContext(ctx).Where("deleted_at IS NULL").First(&comment, id).Error; err != nil {
+		return nil, err
+	}
+	return &comment, nil
 }
 
-func (r *postRepositoryImpl) FindCommentsByPostID(postID uint) ([]*model.PostComment, error) {
-	var comments []*model.PostComment
-	if err := r.db.Where("post_id = ?", postID).Order("created_at ASC").Find(&comments).Error; err != nil {
+func (r *postRepositoryImpl) DeleteComment(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Model(&model.PostComment{}).Where("id = ?", id).Update("deleted_at", time.Now()).Error
+}
+
+func (r *postRepositoryImpl) FindCommentsByPostID(ctx context.Context, postID uint, opts repository.ListOptions) (*repository.Page[*model.PostComment], error) {
+	query := r.db.WithContext(ctx).Where("post_id = ? AND deleted_at IS NULL", postID)
+	if opts.AuthorID != 0 {
+		query = query.Where("user_id = ?", opts.AuthorID)
+	}
+	ks := keyset{table: "post_comments", column: "created_at"}.order(opts.Sort)
+	return findPage(query, ks, opts, func(comment *model.PostComment) cursor {
+		return cursor{Key: comment.CreatedAt, ID: comment.ID}
+	})
+}
+
+func (r *postRepositoryImpl) FindCommenterIDs(ctx context.Context, postID uint) ([]uint, error) {
+	var userIDs []uint
+	if err := r.db.WithContext(ctx).Model(&model.PostComment{}).
+		Where("post_id = ? AND deleted_at IS NULL", postID).
+		Distinct().
+		Pluck("user_id", &userIDs).Error; err != nil {
 		return nil, err
 	}
-	return comments, nil
+	return userIDs, nil
 }
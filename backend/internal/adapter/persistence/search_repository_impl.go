@@ -0,0 +1,157 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"memoria/internal/domain/model"
+	"memoria/internal/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+// searchSource is one kind of content the search covers. Each searched
+// column has a trigram index (migration 000014) and a weight that scales
+// how much a match in it counts towards the rank.
+type searchSource struct {
+	kind    string
+	table   string
+	joins   string
+	where   string
+	parent  string
+	title   string
+	text    string
+	date    string
+	columns []searchColumn
+}
+
+type searchColumn struct {
+	name   string
+	weight float64
+}
+
+var searchSources = []searchSource{
+	{
+		kind:   model.SearchPost,
+		table:  "posts",
+		where:  "posts.group_id = @group AND posts.deleted_at IS NULL",
+		parent: "0",
+		title:  "posts.title",
+		text:   "posts.body",
+		date:   "posts.published_at",
+		columns: []searchColumn{
+			{"posts.title", 1},
+			{"posts.body", 0.8},
+		},
+	},
+	{
+		kind:    model.SearchComment,
+		table:   "post_comments",
+		joins:   "JOIN posts ON posts.id = post_comments.post_id",
+		where:   "posts.group_id = @group AND posts.deleted_at IS NULL AND post_comments.deleted_at IS NULL",
+		parent:  "post_comments.post_id",
+		title:   "posts.title",
+		text:    "post_comments.body",
+		date:    "post_comments.created_at",
+		columns: []searchColumn{{"post_comments.body", 0.7}},
+	},
+	{
+		kind:   model.SearchAlbum,
+		table:  "albums",
+		where:  "albums.group_id = @group AND albums.deleted_at IS NULL",
+		parent: "0",
+		title:  "albums.title",
+		text:   "albums.description",
+		date:   "albums.created_at",
+		columns: []searchColumn{
+			{"albums.title", 1},
+			{"albums.description", 0.8},
+		},
+	},
+	{
+		kind:   model.SearchTrip,
+		table:  "trips",
+		where:  "trips.group_id = @group AND trips.deleted_at IS NULL",
+		parent: "0",
+		title:  "trips.title",
+		text:   "trips.note",
+		date:   "trips.start_at",
+		columns: []searchColumn{
+			{"trips.title", 1},
+			{"trips.note", 0.8},
+		},
+	},
+	{
+		kind:    model.SearchScheduleItem,
+		table:   "trip_schedule_items",
+		joins:   "JOIN trips ON trips.id = trip_schedule_items.trip_id",
+		where:   "trips.group_id = @group AND trips.deleted_at IS NULL",
+		parent:  "trip_schedule_items.trip_id",
+		title:   "trips.title",
+		text:    "trip_schedule_items.content",
+		date:    "trip_schedule_items.created_at",
+		columns: []searchColumn{{"trip_schedule_items.content", 0.7}},
+	},
+}
+
+type searchRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewSearchRepository(db *gorm.DB) repository.SearchRepository {
+	return &searchRepositoryImpl{db: db}
+}
+
+func (r *searchRepositoryImpl) Search(ctx context.Context, groupID uint, terms []string, limit int) ([]*model.SearchResult, error) {
+	args := map[string]any{
+		"group": groupID,
+		"query": strings.Join(terms, " "),
+		"limit": limit,
+	}
+	patterns := make([]string, len(terms))
+	for i, term := range terms {
+		patterns[i] = fmt.Sprintf("term%d", i)
+		args[patterns[i]] = "%" + escapeLike(term) + "%"
+	}
+
+	selects := make([]string, len(searchSources))
+	for i, source := range searchSources {
+		selects[i] = source.query(patterns)
+	}
+	query := "SELECT * FROM (" + strings.Join(selects, " UNION ALL ") + ") AS results " +
+		"ORDER BY rank DESC, date DESC, type, id LIMIT @limit"
+
+	var results []*model.SearchResult
+	if err := r.db.WithContext(ctx).Raw(query, args).Scan(&results).Error; err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// query selects the source's rows containing every pattern in one of the
+// searched columns. The rank is the best trigram word similarity between
+// the whole query and a column, scaled by the column's weight.
+func (s searchSource) query(patterns []string) string {
+	ranks := make([]string, len(s.columns))
+	for i, column := range s.columns {
+		ranks[i] = fmt.Sprintf("word_similarity(@query, COALESCE(%s, '')) * %g", column.name, column.weight)
+	}
+	conditions := []string{s.where}
+	for _, pattern := range patterns {
+		matches := make([]string, len(s.columns))
+		for i, column := range s.columns {
+			matches[i] = fmt.Sprintf("%s ILIKE @%s", column.name, pattern)
+		}
+		conditions = append(conditions, "("+strings.Join(matches, " OR ")+")")
+	}
+	return fmt.Sprintf(
+		"SELECT '%s' AS type, %s.id AS id, %s AS parent_id, COALESCE(%s, '') AS title, COALESCE(%s, '') AS text, GREATEST(%s) AS rank, %s AS date FROM %s %s WHERE %s",
+		s.kind, s.table, s.parent, s.title, s.text, strings.Join(ranks, ", "), s.date, s.table, s.joins, strings.Join(conditions, " AND "),
+	)
+}
+
+// escapeLike makes term match literally inside a LIKE pattern.
+func escapeLike(term string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(term)
+}
@@ -1,6 +1,8 @@
 package persistence
 
 import (
+	"context"
+
 	"memoria/internal/domain/model"
 	"memoria/internal/domain/repository"
 
@@ -15,29 +17,29 @@ func NewTagRepository(db *gorm.DB) repository.TagRepository {
 	return &tagRepositoryImpl{db: db}
 }
 
-func (r *tagRepositoryImpl) Create(tag *model.Tag) error {
-	return r.db.Create(tag).Error
+func (r *tagRepositoryImpl) Create(ctx context.Context, tag *model.Tag) error {
+	return r.db.WithContext(ctx).Create(tag).Error
 }
 
-func (r *tagRepositoryImpl) FindByID(id uint) (*model.Tag, error) {
+func (r *tagRepositoryImpl) FindByID(ctx context.Context, id uint) (*model.Tag, error) {
 	var tag model.Tag
-	if err := r.db.First(&tag, id).Error; err != nil {
+	if err := r.db.WithContext(ctx).First(&tag, id).Error; err != nil {
 		return nil, err
 	}
 	return &tag, nil
 }
 
-func (r *tagRepositoryImpl) FindByName(name string) (*model.Tag, error) {
+func (r *tagRepositoryImpl) FindByName(ctx context.Context, name string) (*model.Tag, error) {
 	var tag model.Tag
-	if err := r.db.Where("name = ?", name).First(&tag).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("name = ?", name).First(&tag).Error; err != nil {
 		return nil, err
 	}
 	return &tag, nil
 }
 
-func (r *tagRepositoryImpl) FindAll() ([]*model.Tag, error) {
+func (r *tagRepositoryImpl) FindAll(ctx context.Context) ([]*model.Tag, error) {
 	var tags []*model.Tag
-	if err := r.db.Order("name ASC").Find(&tags).Error; err != nil {
+	if err := r.db.WithContext(ctx).Order("name ASC").Find(&tags).Error; err != nil {
 		return nil, err
 	}
 	return tags, nil
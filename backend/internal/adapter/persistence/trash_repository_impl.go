@@ -0,0 +1,322 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"memoria/internal/domain/model"
+	"memoria/internal/domain/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type trashRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewTrashRepository(db *gorm.DB) repository.TrashRepository {
+	return &trashRepositoryImpl{db: db}
+}
+
+func (r *trashRepositoryImpl) FindByGroupID(ctx context.Context, groupID uint) ([]*model.TrashItem, error) {
+	var albums []*model.Album
+	if err := r.db.WithContext(ctx).Where("group_id = ? AND deleted_at IS NOT NULL", groupID).Find(&albums).Error; err != nil {
+		return nil, err
+	}
+	var photos []*model.Photo
+	if err := r.db.WithContext(ctx).
+		Where("group_id = ? AND deleted_at IS NOT NULL", groupID).
+		Where("NOT EXISTS (SELECT 1 FROM albums WHERE albums.id = photos.album_id AND albums.deleted_at = photos.deleted_at)").
+		Find(&photos).Error; err != nil {
+		return nil, err
+	}
+	var posts []*model.Post
+	if err := r.db.WithContext(ctx).Where("group_id = ? AND deleted_at IS NOT NULL", groupID).Find(&posts).Error; err != nil {
+		return nil, err
+	}
+	var comments []*model.PostComment
+	if err := r.db.WithContext(ctx).
+		Joins("JOIN posts ON posts.id = post_comments.post_id").
+		Where("posts.group_id = ? AND post_comments.deleted_at IS NOT NULL", groupID).
+		Where("posts.deleted_at IS NULL OR posts.deleted_at <> post_comments.deleted_at").
+		Find(&comments).Error; err != nil {
+		return nil, err
+	}
+	var trips []*model.Trip
+	if err := r.db.WithContext(ctx).Where("group_id = ? AND deleted_at IS NOT NULL", groupID).Find(&trips).Error; err != nil {
+		return nil, err
+	}
+
+	deletedAlbums := make(map[uint]bool, len(albums))
+	deletedPosts := make(map[uint]bool, len(posts))
+	items := make([]*model.TrashItem, 0, len(albums)+len(photos)+len(posts)+len(comments)+len(trips))
+	for _, album := range albums {
+		deletedAlbums[album.ID] = true
+		items = append(items, albumTrashItem(album))
+	}
+	for _, post := range posts {
+		deletedPosts[post.ID] = true
+		items = append(items, postTrashItem(post))
+	}
+	for _, photo := range photos {
+		item := photoTrashItem(photo)
+		item.ParentDeleted = deletedAlbums[photo.AlbumID]
+		items = append(items, item)
+	}
+	for _, comment := range comments {
+		item := commentTrashItem(comment, groupID)
+		item.ParentDeleted = deletedPosts[comment.PostID]
+		items = append(items, item)
+	}
+	for _, trip := range trips {
+		items = append(items, tripTrashItem(trip))
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+	return items, nil
+}
+
+func (r *trashRepositoryImpl) FindItem(ctx context.Context, itemType string, id, groupID uint) (*model.TrashItem, error) {
+	switch itemType {
+	case model.TrashAlbum:
+		var album model.Album
+		if err := r.db.WithContext(ctx).Where("id = ? AND group_id = ? AND deleted_at IS NOT NULL", id, groupID).First(&album).Error; err != nil {
+			return nil, err
+		}
+		return albumTrashItem(&album), nil
+	case model.TrashPhoto:
+		var photo model.Photo
+		if err := r.db.WithContext(ctx).Where("id = ? AND group_id = ? AND deleted_at IS NOT NULL", id, groupID).First(&photo).Error; err != nil {
+			return nil, err
+		}
+		item := photoTrashItem(&photo)
+		deleted, err := r.isDeleted(ctx, &model.Album{}, photo.AlbumID)
+		if err != nil {
+			return nil, err
+		}
+		item.ParentDeleted = deleted
+		return item, nil
+	case model.TrashPost:
+		var post model.Post
+		if err := r.db.WithContext(ctx).Where("id = ? AND group_id = ? AND deleted_at IS NOT NULL", id, groupID).First(&post).Error; err != nil {
+			return nil, err
+		}
+		return postTrashItem(&post), nil
+	case model.TrashComment:
+		var comment model.PostComment
+		if err := r.db.WithContext(ctx).
+			Joins("JOIN posts ON posts.id = post_comments.post_id").
+			Where("post_comments.id = ? AND posts.group_id = ? AND post_comments.deleted_at IS NOT NULL", id, groupID).
+			First(&comment).Error; err != nil {
+			return nil, err
+		}
+		item := commentTrashItem(&comment, groupID)
+		deleted, err := r.isDeleted(ctx, &model.Post{}, comment.PostID)
+		if err != nil {
+			return nil, err
+		}
+		item.ParentDeleted = deleted
+		return item, nil
+	case model.TrashTrip:
+		var trip model.Trip
+		if err := r.db.WithContext(ctx).Where("id = ? AND group_id = ? AND deleted_at IS NOT NULL", id, groupID).First(&trip).Error; err != nil {
+			return nil, err
+		}
+		return tripTrashItem(&trip), nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *trashRepositoryImpl) Restore(ctx context.Context, item *model.TrashItem) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		switch item.Type {
+		case model.TrashAlbum:
+			if err := tx.Model(&model.Photo{}).
+				Where("album_id = ? AND deleted_at = ?", item.ID, item.DeletedAt).
+				Update("deleted_at", nil).Error; err != nil {
+				return err
+			}
+		case model.TrashPost:
+			if err := tx.Model(&model.PostComment{}).
+				Where("post_id = ? AND deleted_at = ?", item.ID, item.DeletedAt).
+				Update("deleted_at", nil).Error; err != nil {
+				return err
+			}
+		}
+		target, err := trashModel(item.Type)
+		if err != nil {
+			return err
+		}
+		return tx.Model(target).Where("id = ?", item.ID).Update("deleted_at", nil).Error
+	})
+}
+
+func (r *trashRepositoryImpl) FindExpired(ctx context.Context, before time.Time, limit int) ([]*model.TrashItem, error) {
+	items := make([]*model.TrashItem, 0, limit)
+
+	var photos []*model.Photo
+	if err := r.db.WithContext(ctx).Where("deleted_at < ?", before).Order("deleted_at ASC").Limit(limit).Find(&photos).Error; err != nil {
+		return nil, err
+	}
+	for _, photo := range photos {
+		items = append(items, photoTrashItem(photo))
+	}
+
+	if len(items) < limit {
+		var comments []*model.PostComment
+		if err := r.db.WithContext(ctx).Where("deleted_at < ?", before).Order("deleted_at ASC").Limit(limit - len(items)).Find(&comments).Error; err != nil {
+			return nil, err
+		}
+		for _, comment := range comments {
+			items = append(items, commentTrashItem(comment, 0))
+		}
+	}
+	if len(items) < limit {
+		var posts []*model.Post
+		if err := r.db.WithContext(ctx).Where("deleted_at < ?", before).Order("deleted_at ASC").Limit(limit - len(items)).Find(&posts).Error; err != nil {
+			return nil, err
+		}
+		for _, post := range posts {
+			items = append(items, postTrashItem(post))
+		}
+	}
+	if len(items) < limit {
+		var albums []*model.Album
+		if err := r.db.WithContext(ctx).Where("deleted_at < ?", before).Order("deleted_at ASC").Limit(limit - len(items)).Find(&albums).Error; err != nil {
+			return nil, err
+		}
+		for _, album := range albums {
+			items = append(items, albumTrashItem(album))
+		}
+	}
+	if len(items) < limit {
+		var trips []*model.Trip
+		if err := r.db.WithContext(ctx).Where("deleted_at < ?", before).Order("deleted_at ASC").Limit(limit - len(items)).Find(&trips).Error; err != nil {
+			return nil, err
+		}
+		for _, trip := range trips {
+			items = append(items, tripTrashItem(trip))
+		}
+	}
+	return items, nil
+}
+
+func (r *trashRepositoryImpl) Purge(ctx context.Context, item *model.TrashItem) error {
+	target, err := trashModel(item.Type)
+	if err != nil {
+		return err
+	}
+	ids := []uint{item.ID}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the row so a concurrent restore either wins or waits.
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND deleted_at IS NOT NULL", item.ID).
+			Limit(1).
+			Find(target)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil // restored in the meantime
+		}
+
+		switch item.Type {
+		case model.TrashAlbum:
+			return deleteAlbums(tx, ids)
+		case model.TrashPhoto:
+			return deletePhotos(tx, ids)
+		case model.TrashPost:
+			return deletePosts(tx, ids)
+		case model.TrashTrip:
+			return deleteTrips(tx, ids)
+		}
+		return tx.Where("id IN ?", ids).Delete(target).Error
+	})
+}
+
+func (r *trashRepositoryImpl) isDeleted(ctx context.Context, target any, id uint) (bool, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(target).Where("id = ? AND deleted_at IS NOT NULL", id).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func trashModel(itemType string) (any, error) {
+	switch itemType {
+	case model.TrashAlbum:
+		return &model.Album{}, nil
+	case model.TrashPhoto:
+		return &model.Photo{}, nil
+	case model.TrashPost:
+		return &model.Post{}, nil
+	case model.TrashComment:
+		return &model.PostComment{}, nil
+	case model.TrashTrip:
+		return &model.Trip{}, nil
+	}
+	return nil, errors.New("unknown trash item type: " + itemType)
+}
+
+func albumTrashItem(album *model.Album) *model.TrashItem {
+	return &model.TrashItem{
+		Type:      model.TrashAlbum,
+		ID:        album.ID,
+		GroupID:   album.GroupID,
+		Title:     album.Title,
+		AuthorID:  album.CreatedBy,
+		DeletedAt: *album.DeletedAt,
+	}
+}
+
+func photoTrashItem(photo *model.Photo) *model.TrashItem {
+	return &model.TrashItem{
+		Type:      model.TrashPhoto,
+		ID:        photo.ID,
+		GroupID:   photo.GroupID,
+		ParentID:  photo.AlbumID,
+		AuthorID:  photo.UploadedBy,
+		DeletedAt: *photo.DeletedAt,
+	}
+}
+
+func postTrashItem(post *model.Post) *model.TrashItem {
+	return &model.TrashItem{
+		Type:      model.TrashPost,
+		ID:        post.ID,
+		GroupID:   post.GroupID,
+		Title:     post.Title,
+		AuthorID:  post.AuthorID,
+		DeletedAt: *post.DeletedAt,
+	}
+}
+
+// commentTrashItem takes groupID from the caller because comments only
+// know their post.
+func commentTrashItem(comment *model.PostComment, groupID uint) *model.TrashItem {
+	return &model.TrashItem{
+		Type:      model.TrashComment,
+		ID:        comment.ID,
+		GroupID:   groupID,
+		ParentID:  comment.PostID,
+		Title:     comment.Body,
+		AuthorID:  comment.UserID,
+		DeletedAt: *comment.DeletedAt,
+	}
+}
+
+func tripTrashItem(trip *model.Trip) *model.TrashItem {
+	return &model.TrashItem{
+		Type:      model.TrashTrip,
+		ID:        trip.ID,
+		GroupID:   trip.GroupID,
+		Title:     trip.Title,
+		AuthorID:  trip.CreatedBy,
+		DeletedAt: *trip.DeletedAt,
+	}
+}
@@ -0,0 +1,71 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"memoria/internal/domain/model"
+	"memoria/internal/domain/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type tripReminderRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewTripReminderRepository(db *gorm.DB) repository.TripReminderRepository {
+	return &tripReminderRepositoryImpl{db: db}
+}
+
+func (r *tripReminderRepositoryImpl) FindDue(ctx context.Context, now, since time.Time, limit int) ([]*model.Trip, error) {
+	var trips []*model.Trip
+	if err := r.db.WithContext(ctx).
+		Where("notify_at IS NOT NULL AND notify_at <= ? AND notify_at > ? AND trips.deleted_at IS NULL", now, since).
+		Where("NOT EXISTS (SELECT 1 FROM trip_reminders WHERE trip_reminders.trip_id = trips.id AND trip_reminders.notify_at = trips.notify_at)").
+		Where("NOT EXISTS (SELECT 1 FROM groups WHERE groups.id = trips.group_id AND groups.deleted_at IS NOT NULL)").
+		Order("notify_at ASC").
+		Limit(limit).
+		Find(&trips).Error; err != nil {
+		return nil, err
+	}
+	return trips, nil
+}
+
+func (r *tripReminderRepositoryImpl) Record(ctx context.Context, reminder *model.TripReminder, notifications []*model.Notification) (bool, error) {
+	recorded := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Replicas racing for the same trip skip it instead of waiting, and the
+		// notify_at check drops reminders whose time was edited meanwhile.
+		var trips []*model.Trip
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("id = ? AND notify_at = ?", reminder.TripID, reminder.NotifyAt).
+			Limit(1).
+			Find(&trips).Error; err != nil {
+			return err
+		}
+		if len(trips) == 0 {
+			return nil
+		}
+
+		// The unique index on (trip_id, notify_at) is what finally guarantees
+		// a reminder is never sent twice.
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(reminder)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		if len(notifications) > 0 {
+			if err := tx.Create(&notifications).Error; err != nil {
+				return err
+			}
+		}
+		recorded = true
+		return nil
+	})
+	return recorded, err
+}
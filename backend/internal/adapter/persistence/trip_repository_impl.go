@@ -1,6 +1,9 @@
 package persistence
 
 import (
+	"context"
+	"time"
+
 	"memoria/internal/domain/model"
 	"memoria/internal/domain/repository"
 
@@ -15,32 +18,43 @@ func NewTripRepository(db *gorm.DB) repository.TripRepository {
 	return &tripRepositoryImpl{db: db}
 }
 
-func (r *tripRepositoryImpl) Create(trip *model.Trip) error {
-	return r.db.Create(trip).Error
+func (r *tripRepositoryImpl) Create(ctx context.Context, trip *model.Trip) error {
+	return r.db.WithContext(ctx).Create(trip).Error
 }
 
-func (r *tripRepositoryImpl) FindByID(id uint, groupID uint) (*model.Trip, error) {
+func (r *tripRepositoryImpl) FindByID(ctx context.Context, id uint, groupID uint) (*model.Trip, error) {
 	var trip model.Trip
-	if err := r.db.Where("id = ? AND group_id = ?", id, groupID).First(&trip).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("id = ? AND group_id = ? AND deleted_at IS NULL", id, groupID).First(&trip).Error; err != nil {
 		return nil, err
 	}
 	return &trip, nil
 }
 
-func (r *tripRepositoryImpl) FindAll(groupID uint) ([]*model.Trip, error) {
-	var trips []*model.Trip
-	if err := r.db.Where("group_id = ?", groupID).Order("start_at DESC").Find(&trips).Error; err != nil {
-		return nil, err
+func (r *tripRepositoryImpl) FindAll(ctx context.Context, groupID uint, opts repository.ListOptions) (*repository.Page[*model.Trip], error) {
+	query := r.db.WithContext(ctx).Where("group_id = ? AND deleted_at IS NULL", groupID)
+	if opts.AuthorID != 0 {
+		query = query.Where("created_by = ?", opts.AuthorID)
 	}
-	return trips, nil
+	ks := keyset{table: "trips", column: "start_at", desc: true}
+	switch opts.Timing {
+	case repository.TripUpcoming:
+		// The next trip comes first.
+		query = query.Where("end_at >= ?", time.Now())
+		ks.desc = false
+	case repository.TripPast:
+		query = query.Where("end_at < ?", time.Now())
+	}
+	return findPage(query, ks.order(opts.Sort), opts, func(trip *model.Trip) cursor {
+		return cursor{Key: trip.StartAt, ID: trip.ID}
+	})
 }
 
-func (r *tripRepositoryImpl) Update(trip *model.Trip) error {
-	return r.db.Save(trip).Error
+func (r *tripRepositoryImpl) Update(ctx context.Context, trip *model.Trip) error {
+	return r.db.WithContext(ctx).Save(trip).Error
 }
 
-func (r *tripRepositoryImpl) Delete(id uint) error {
-	return r.db.Delete(&model.Trip{}, id).Error
+func (r *tripRepositoryImpl) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Model(&model.Trip{}).Where("id = ?", id).Update("deleted_at", time.Now()).Error
 }
 
 type tripItineraryRepositoryImpl struct {
@@ -51,24 +65,32 @@ func NewTripItineraryRepository(db *gorm.DB) repository.TripItineraryRepository
 	return &tripItineraryRepositoryImpl{db: db}
 }
 
-func (r *tripItineraryRepositoryImpl) Create(itinerary *model.TripItinerary) error {
-	return r.db.Create(itinerary).Error
+func (r *tripItineraryRepositoryImpl) Create(ctx context.Context, itinerary *model.TripItinerary) error {
+	return r.db.WithContext(ctx).Create(itinerary).Error
 }
 
-func (r *tripItineraryRepositoryImpl) FindByTripID(tripID uint) ([]*model.TripItinerary, error) {
+func (r *tripItineraryRepositoryImpl) FindByID(ctx context.Context, id uint, tripID uint) (*model.TripItinerary, error) {
+	var itinerary model.TripItinerary
+	if err := r.db.WithContext(ctx).Where("id = ? AND trip_id = ?", id, tripID).First(&itinerary).Error; err != nil {
+		return nil, err
+	}
+	return &itinerary, nil
+}
+
+func (r *tripItineraryRepositoryImpl) FindByTripID(ctx context.Context, tripID uint) ([]*model.TripItinerary, error) {
 	var itineraries []*model.TripItinerary
-	if err := r.db.Where("trip_id = ?", tripID).Order("start_at ASC").Find(&itineraries).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("trip_id = ?", tripID).Order("start_at ASC").Find(&itineraries).Error; err != nil {
 		return nil, err
 	}
 	return itineraries, nil
 }
 
-func (r *tripItineraryRepositoryImpl) Update(itinerary *model.TripItinerary) error {
-	return r.db.Save(itinerary).Error
+func (r *tripItineraryRepositoryImpl) Update(ctx context.Context, itinerary *model.TripItinerary) error {
+	return r.db.WithContext(ctx).Save(itinerary).Error
 }
 
-func (r *tripItineraryRepositoryImpl) Delete(id uint) error {
-	return r.db.Delete(&model.TripItinerary{}, id).Error
+func (r *tripItineraryRepositoryImpl) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&model.TripItinerary{}, id).Error
 }
 
 type tripWishlistRepositoryImpl struct {
@@ -79,24 +101,32 @@ func NewTripWishlistRepository(db *gorm.DB) repository.TripWishlistRepository {
 	return &tripWishlistRepositoryImpl{db: db}
 }
 
-func (r *tripWishlistRepositoryImpl) Create(wishlist *model.TripWishlist) error {
-	return r.db.Create(wishlist).Error
+func (r *tripWishlistRepositoryImpl) Create(ctx context.Context, wishlist *model.TripWishlist) error {
+	return r.db.WithContext(ctx).Create(wishlist).Error
+}
+
+func (r *tripWishlistRepositoryImpl) FindByID(ctx context.Context, id uint, tripID uint) (*model.TripWishlist, error) {
+	var wishlist model.TripWishlist
+	if err := r.db.WithContext(ctx).Where("id = ? AND trip_id = ?", id, tripID).First(&wishlist).Error; err != nil {
+		return nil, err
+	}
+	return &wishlist, nil
 }
 
-func (r *tripWishlistRepositoryImpl) FindByTripID(tripID uint) ([]*model.TripWishlist, error) {
+func (r *tripWishlistRepositoryImpl) FindByTripID(ctx context.Context, tripID uint) ([]*model.TripWishlist, error) {
 	var wishlists []*model.TripWishlist
-	if err := r.db.Where("trip_id = ?", tripID).Order("priority DESC").Find(&wishlists).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("trip_id = ?", tripID).Order("priority DESC").Find(&wishlists).Error; err != nil {
 		return nil, err
 	}
 	return wishlists, nil
 }
 
-func (r *tripWishlistRepositoryImpl) Update(wishlist *model.TripWishlist) error {
-	return r.db.Save(wishlist).Error
+func (r *tripWishlistRepositoryImpl) Update(ctx context.Context, wishlist *model.TripWishlist) error {
+	return r.db.WithContext(ctx).Save(wishlist).Error
 }
 
-func (r *tripWishlistRepositoryImpl) Delete(id uint) error {
-	return r.db.Delete(&model.TripWishlist{}, id).Error
+func (r *tripWishlistRepositoryImpl) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&model.TripWishlist{}, id).Error
 }
 
 type tripExpenseRepositoryImpl struct {
@@ -107,24 +137,98 @@ func NewTripExpenseRepository(db *gorm.DB) repository.TripExpenseRepository {
 	return &tripExpenseRepositoryImpl{db: db}
 }
 
-func (r *tripExpenseRepositoryImpl) Create(expense *model.TripExpense) error {
-	return r.db.Create(expense).Error
+func (r *tripExpenseRepositoryImpl) Create(ctx context.Context, expense *model.TripExpense) error {
+	return r.db.WithContext(ctx).Create(expense).Error
+}
+
+func (r *tripExpenseRepositoryImpl) FindByID(ctx context.Context, id uint, tripID uint) (*model.TripExpense, error) {
+	var expense model.TripExpense
+	if err := r.db.WithContext(ctx).Where("id = ? AND trip_id = ?", id, tripID).First(&expense).Error; err != nil {
+		return nil, err
+	}
+	return &expense, nil
 }
 
-func (r *tripExpenseRepositoryImpl) FindByTripID(tripID uint) ([]*model.TripExpense, error) {
+func (r *tripExpenseRepositoryImpl) FindByTripID(ctx context.Context, tripID uint) ([]*model.TripExpense, error) {
 	var expenses []*model.TripExpense
-	if err := r.db.Where("trip_id = ?", tripID).Find(&expenses).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("trip_id = ?", tripID).Order("id ASC").Find(&expenses).Error; err != nil {
 		return nil, err
 	}
 	return expenses, nil
 }
 
-func (r *tripExpenseRepositoryImpl) Update(expense *model.TripExpense) error {
-	return r.db.Save(expense).Error
+func (r *tripExpenseRepositoryImpl) Update(ctx context.Context, expense *model.TripExpense) error {
+	return r.db.WithContext(ctx).Save(expense).Error
+}
+
+func (r *tripExpenseRepositoryImpl) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("expense_id = ?", id).Delete(&model.TripExpenseParticipant{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.TripExpense{}, id).Error
+	})
+}
+
+func (r *tripExpenseRepositoryImpl) ReplaceParticipants(ctx context.Context, expenseID uint, participants []*model.TripExpenseParticipant) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("expense_id = ?", expenseID).Delete(&model.TripExpenseParticipant{}).Error; err != nil {
+			return err
+		}
+		if len(participants) == 0 {
+			return nil
+		}
+		for _, participant := range participants {
+			participant.ExpenseID = expenseID
+		}
+		return tx.Create(&participants).Error
+	})
+}
+
+func (r *tripExpenseRepositoryImpl) FindParticipantsByTripID(ctx context.Context, tripID uint) ([]*model.TripExpenseParticipant, error) {
+	var participants []*model.TripExpenseParticipant
+	if err := r.db.WithContext(ctx).
+		Table("trip_expense_participants").
+		Select("trip_expense_participants.*").
+		Joins("JOIN trip_expenses ON trip_expenses.id = trip_expense_participants.expense_id").
+		Where("trip_expenses.trip_id = ?", tripID).
+		Order("trip_expense_participants.expense_id ASC, trip_expense_participants.user_id ASC").
+		Find(&participants).Error; err != nil {
+		return nil, err
+	}
+	return participants, nil
+}
+
+type tripSettlementRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewTripSettlementRepository(db *gorm.DB) repository.TripSettlementRepository {
+	return &tripSettlementRepositoryImpl{db: db}
+}
+
+func (r *tripSettlementRepositoryImpl) Create(ctx context.Context, settlement *model.TripSettlement) error {
+	return r.db.WithContext(ctx).Create(settlement).Error
 }
 
-func (r *tripExpenseRepositoryImpl) Delete(id uint) error {
-	return r.db.Delete(&model.TripExpense{}, id).Error
+func (r *tripSettlementRepositoryImpl) FindByID(ctx context.Context, id uint, tripID uint) (*model.TripSettlement, error) {
+	var settlement model.TripSettlement
+	if err := r.db.WithContext(ctx).Where("id = ? AND trip_id = ?", id, tripID).First(&settlement).Error; err != nil {
+		return nil, err
+	}
+	return &settlement, nil
+}
+
+func (r *tripSettlementRepositoryImpl) FindByTripID(ctx context.Context, tripID uint) ([]*model.TripSettlement, error) {
+	var settlements []*model.TripSettlement
+	if err := r.db.WithContext(ctx).Where("trip_id = ?", tripID).Order("created_at ASC").Find(&settlements).Error; err != nil {
+		return nil, err
+	}
+	return settlements, nil
+}
+
+func (r *tripSettlementRepositoryImpl) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&model.TripSettlement{}, id).Error
 }
 
 type tripRelationRepositoryImpl struct {
@@ -135,7 +239,7 @@ func NewTripRelationRepository(db *gorm.DB) repository.TripRelationRepository {
 	return &tripRelationRepositoryImpl{db: db}
 }
 
-func (r *tripRelationRepositoryImpl) AddAlbums(tripID uint, albumIDs []uint) error {
+func (r *tripRelationRepositoryImpl) AddAlbums(ctx context.Context, tripID uint, albumIDs []uint) error {
 	if len(albumIDs) == 0 {
 		return nil
 	}
@@ -148,10 +252,10 @@ func (r *tripRelationRepositoryImpl) AddAlbums(tripID uint, albumIDs []uint) err
 		})
 	}
 
-	return r.db.Create(&relations).Error
+	return r.db.WithContext(ctx).Create(&relations).Error
 }
 
-func (r *tripRelationRepositoryImpl) AddPosts(tripID uint, postIDs []uint) error {
+func (r *tripRelationRepositoryImpl) AddPosts(ctx context.Context, tripID uint, postIDs []uint) error {
 	if len(postIDs) == 0 {
 		return nil
 	}
@@ -164,16 +268,16 @@ func (r *tripRelationRepositoryImpl) AddPosts(tripID uint, postIDs []uint) error
 		})
 	}
 
-	return r.db.Create(&relations).Error
+	return r.db.WithContext(ctx).Create(&relations).Error
 }
 
-func (r *tripRelationRepositoryImpl) FindAlbumsByTripID(tripID uint) ([]*model.Album, error) {
+func (r *tripRelationRepositoryImpl) FindAlbumsByTripID(ctx context.Context, tripID uint) ([]*model.Album, error) {
 	var albums []*model.Album
-	if err := r.db.
+	if err := r.db.WithContext(ctx).
 		Table("trip_albums").
 		Select("albums.*").
 		Joins("JOIN albums ON albums.id = trip_albums.album_id").
-		Where("trip_albums.trip_id = ?", tripID).
+		Where("trip_albums.trip_id = ? AND albums.deleted_at IS NULL", tripID).
 		Order("albums.created_at DESC").
 		Find(&albums).Error; err != nil {
 		return nil, err
@@ -181,13 +285,13 @@ func (r *tripRelationRepositoryImpl) FindAlbumsByTripID(tripID uint) ([]*model.A
 	return albums, nil
 }
 
-func (r *tripRelationRepositoryImpl) FindPostsByTripID(tripID uint) ([]*model.Post, error) {
+func (r *tripRelationRepositoryImpl) FindPostsByTripID(ctx context.Context, tripID uint) ([]*model.Post, error) {
 	var posts []*model.Post
-	if err := r.db.
+	if err := r.db.WithContext(ctx).
 		Table("trip_posts").
 		Select("posts.*").
 		Joins("JOIN posts ON posts.id = trip_posts.post_id").
-		Where("trip_posts.trip_id = ?", tripID).
+		Where("trip_posts.trip_id = ? AND posts.deleted_at IS NULL", tripID).
 		Order("posts.published_at DESC").
 		Find(&posts).Error; err != nil {
 		return nil, err
@@ -203,8 +307,8 @@ func NewTripDetailRepository(db *gorm.DB) repository.TripDetailRepository {
 	return &tripDetailRepositoryImpl{db: db}
 }
 
-func (r *tripDetailRepositoryImpl) ReplaceScheduleItems(tripID uint, items []*model.TripScheduleItem) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
+func (r *tripDetailRepositoryImpl) ReplaceScheduleItems(ctx context.Context, tripID uint, items []*model.TripScheduleItem) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		if err := tx.Where("trip_id = ?", tripID).Delete(&model.TripScheduleItem{}).Error; err != nil {
 			return err
 		}
@@ -215,16 +319,16 @@ func (r *tripDetailRepositoryImpl) ReplaceScheduleItems(tripID uint, items []*mo
 	})
 }
 
-func (r *tripDetailRepositoryImpl) FindScheduleItems(tripID uint) ([]*model.TripScheduleItem, error) {
+func (r *tripDetailRepositoryImpl) FindScheduleItems(ctx context.Context, tripID uint) ([]*model.TripScheduleItem, error) {
 	var items []*model.TripScheduleItem
-	if err := r.db.Where("trip_id = ?", tripID).Order("date ASC, time ASC").Find(&items).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("trip_id = ?", tripID).Order("date ASC, time ASC").Find(&items).Error; err != nil {
 		return nil, err
 	}
 	return items, nil
 }
 
-func (r *tripDetailRepositoryImpl) ReplaceTransports(tripID uint, transports []*model.TripTransport) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
+func (r *tripDetailRepositoryImpl) ReplaceTransports(ctx context.Context, tripID uint, transports []*model.TripTransport) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		if err := tx.Where("trip_id = ?", tripID).Delete(&model.TripTransport{}).Error; err != nil {
 			return err
 		}
@@ -235,16 +339,16 @@ func (r *tripDetailRepositoryImpl) ReplaceTransports(tripID uint, transports []*
 	})
 }
 
-func (r *tripDetailRepositoryImpl) FindTransports(tripID uint) ([]*model.TripTransport, error) {
+func (r *tripDetailRepositoryImpl) FindTransports(ctx context.Context, tripID uint) ([]*model.TripTransport, error) {
 	var transports []*model.TripTransport
-	if err := r.db.Where("trip_id = ?", tripID).Order("date ASC, id ASC").Find(&transports).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("trip_id = ?", tripID).Order("date ASC, id ASC").Find(&transports).Error; err != nil {
 		return nil, err
 	}
 	return transports, nil
 }
 
-func (r *tripDetailRepositoryImpl) ReplaceLodgings(tripID uint, lodgings []*model.TripLodging) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
+func (r *tripDetailRepositoryImpl) ReplaceLodgings(ctx context.Context, tripID uint, lodgings []*model.TripLodging) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		if err := tx.Where("trip_id = ?", tripID).Delete(&model.TripLodging{}).Error; err != nil {
 			return err
 		}
@@ -255,16 +359,16 @@ func (r *tripDetailRepositoryImpl) ReplaceLodgings(tripID uint, lodgings []*mode
 	})
 }
 
-func (r *tripDetailRepositoryImpl) FindLodgings(tripID uint) ([]*model.TripLodging, error) {
+func (r *tripDetailRepositoryImpl) FindLodgings(ctx context.Context, tripID uint) ([]*model.TripLodging, error) {
 	var lodgings []*model.TripLodging
-	if err := r.db.Where("trip_id = ?", tripID).Order("date ASC, id ASC").Find(&lodgings).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("trip_id = ?", tripID).Order("date ASC, id ASC").Find(&lodgings).Error; err != nil {
 		return nil, err
 	}
 	return lodgings, nil
 }
 
-func (r *tripDetailRepositoryImpl) ReplaceBudgetItems(tripID uint, items []*model.TripBudgetItem) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
+func (r *tripDetailRepositoryImpl) ReplaceBudgetItems(ctx context.Context, tripID uint, items []*model.TripBudgetItem) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		if err := tx.Where("trip_id = ?", tripID).Delete(&model.TripBudgetItem{}).Error; err != nil {
 			return err
 		}
@@ -275,42 +379,10 @@ func (r *tripDetailRepositoryImpl) ReplaceBudgetItems(tripID uint, items []*mode
 	})
 }
 
-func (r *tripDetailRepositoryImpl) FindBudgetItems(tripID uint) ([]*model.TripBudgetItem, error) {
+func (r *tripDetailRepositoryImpl) FindBudgetItems(ctx context.Context, tripID uint) ([]*model.TripBudgetItem, error) {
 	var items []*model.TripBudgetItem
-	if err := r.db.Where("trip_id = ?", tripID).Order("id ASC").Find(&items).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("trip_id = ?", tripID).Order("id ASC").Find(&items).Error; err != nil {
 		return nil, err
 	}
 	return items, nil
 }
-
-func (r *tripDetailRepositoryImpl) SumTransportCosts(tripID uint) (int64, error) {
-	var total int64
-	err := r.db.
-		Table("trip_transports").
-		Select(`COALESCE(SUM(
-			CASE
-				WHEN mode = 'car' THEN gasoline_cost_yen + highway_cost_yen
-				WHEN mode = 'rental' THEN gasoline_cost_yen + rental_fee_yen
-				ELSE fare_yen
-			END
-		), 0) AS total`).
-		Where("trip_id = ?", tripID).
-		Scan(&total).Error
-	if err != nil {
-		return 0, err
-	}
-	return total, nil
-}
-
-func (r *tripDetailRepositoryImpl) SumLodgingCosts(tripID uint) (int64, error) {
-	var total int64
-	err := r.db.
-		Table("trip_lodgings").
-		Select("COALESCE(SUM(cost_yen), 0) AS total").
-		Where("trip_id = ?", tripID).
-		Scan(&total).Error
-	if err != nil {
-		return 0, err
-	}
-	return total, nil
-}
@@ -0,0 +1,38 @@
+package persistence
+
+import (
+	"context"
+
+	"memoria/internal/domain/repository"
+
+	"gorm.io/gorm"
+)
+
+type unitOfWorkImpl struct {
+	db *gorm.DB
+}
+
+func NewUnitOfWork(db *gorm.DB) repository.UnitOfWork {
+	return &unitOfWorkImpl{db: db}
+}
+
+func (u *unitOfWorkImpl) Do(ctx context.Context, fn func(repos repository.Repositories) error) error {
+	return u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(repository.Repositories{
+			Groups:             NewGroupRepository(tx),
+			GroupMembers:       NewGroupMemberRepository(tx),
+			Invites:            NewInviteRepository(tx),
+			Albums:             NewAlbumRepository(tx),
+			Photos:             NewPhotoRepository(tx),
+			PhotoUploads:       NewPhotoUploadRepository(tx),
+			UploadSessions:     NewUploadSessionRepository(tx),
+			Posts:              NewPostRepository(tx),
+			Tags:               NewTagRepository(tx),
+			Trips:              NewTripRepository(tx),
+			TripRelations:      NewTripRelationRepository(tx),
+			TripExpenses:       NewTripExpenseRepository(tx),
+			ExchangeRates:      NewExchangeRateRepository(tx),
+			CalendarFeedTokens: NewCalendarFeedTokenRepository(tx),
+		})
+	})
+}
@@ -0,0 +1,72 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"memoria/internal/domain/model"
+	"memoria/internal/domain/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type uploadSessionRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewUploadSessionRepository(db *gorm.DB) repository.UploadSessionRepository {
+	return &uploadSessionRepositoryImpl{db: db}
+}
+
+func (r *uploadSessionRepositoryImpl) Create(ctx context.Context, session *model.UploadSession) error {
+	return r.db.WithContext(ctx).Create(session).Error
+}
+
+func (r *uploadSessionRepositoryImpl) FindByID(ctx context.Context, id uint, groupID uint) (*model.UploadSession, error) {
+	var session model.UploadSession
+	if err := r.db.WithContext(ctx).Where("id = ? AND group_id = ?", id, groupID).First(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// SavePart replaces the ETag of a part that was uploaded again.
+func (r *uploadSessionRepositoryImpl) SavePart(ctx context.Context, part *model.UploadSessionPart) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "session_id"}, {Name: "part_number"}},
+		DoUpdates: clause.AssignmentColumns([]string{"e_tag", "created_at"}),
+	}).Create(part).Error
+}
+
+func (r *uploadSessionRepositoryImpl) FindParts(ctx context.Context, sessionID uint) ([]*model.UploadSessionPart, error) {
+	var parts []*model.UploadSessionPart
+	if err := r.db.WithContext(ctx).Where("session_id = ?", sessionID).Order("part_number ASC").Find(&parts).Error; err != nil {
+		return nil, err
+	}
+	return parts, nil
+}
+
+func (r *uploadSessionRepositoryImpl) MarkCompleted(ctx context.Context, id uint, now time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.UploadSession{}).Where("id = ?", id).Updates(map[string]any{
+		"completed_at": now,
+		"updated_at":   now,
+	}).Error
+}
+
+// Delete removes the parts along with the session (ON DELETE CASCADE).
+func (r *uploadSessionRepositoryImpl) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&model.UploadSession{}, id).Error
+}
+
+func (r *uploadSessionRepositoryImpl) FindExpired(ctx context.Context, now time.Time, limit int) ([]*model.UploadSession, error) {
+	var sessions []*model.UploadSession
+	if err := r.db.WithContext(ctx).
+		Where("expires_at <= ?", now).
+		Order("expires_at ASC").
+		Limit(limit).
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
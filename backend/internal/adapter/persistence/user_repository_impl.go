@@ -1,6 +1,8 @@
 package persistence
 
 import (
+	"context"
+
 	"memoria/internal/domain/model"
 	"memoria/internal/domain/repository"
 
@@ -15,46 +17,46 @@ func NewUserRepository(db *gorm.DB) repository.UserRepository {
 	return &userRepositoryImpl{db: db}
 }
 
-func (r *userRepositoryImpl) Create(user *model.User) error {
-	return r.db.Create(user).Error
+func (r *userRepositoryImpl) Create(ctx context.Context, user *model.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
 }
 
-func (r *userRepositoryImpl) FindByFirebaseUID(firebaseUID string) (*model.User, error) {
+func (r *userRepositoryImpl) FindByFirebaseUID(ctx context.Context, firebaseUID string) (*model.User, error) {
 	var user model.User
-	if err := r.db.Where("firebase_uid = ?", firebaseUID).First(&user).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("firebase_uid = ?", firebaseUID).First(&user).Error; err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *userRepositoryImpl) FindByEmail(email string) (*model.User, error) {
+func (r *userRepositoryImpl) FindByEmail(ctx context.Context, email string) (*model.User, error) {
 	var user model.User
-	if err := r.db.Where("email = ?", email).First(&user).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *userRepositoryImpl) FindByID(id uint) (*model.User, error) {
+func (r *userRepositoryImpl) FindByID(ctx context.Context, id uint) (*model.User, error) {
 	var user model.User
-	if err := r.db.First(&user, id).Error; err != nil {
+	if err := r.db.WithContext(ctx).First(&user, id).Error; err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *userRepositoryImpl) FindAll() ([]*model.User, error) {
+func (r *userRepositoryImpl) FindAll(ctx context.Context) ([]*model.User, error) {
 	var users []*model.User
-	if err := r.db.Order("created_at DESC").Find(&users).Error; err != nil {
+	if err := r.db.WithContext(ctx).Order("created_at DESC").Find(&users).Error; err != nil {
 		return nil, err
 	}
 	return users, nil
 }
 
-func (r *userRepositoryImpl) Update(user *model.User) error {
-	return r.db.Save(user).Error
+func (r *userRepositoryImpl) Update(ctx context.Context, user *model.User) error {
+	return r.db.WithContext(ctx).Save(user).Error
 }
 
-func (r *userRepositoryImpl) Delete(id uint) error {
-	return r.db.Delete(&model.User{}, id).Error
+func (r *userRepositoryImpl) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&model.User{}, id).Error
 }
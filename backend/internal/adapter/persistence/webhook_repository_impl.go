@@ -0,0 +1,126 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"memoria/internal/domain/model"
+	"memoria/internal/domain/repository"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type webhookRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) repository.WebhookRepository {
+	return &webhookRepositoryImpl{db: db}
+}
+
+func (r *webhookRepositoryImpl) Create(ctx context.Context, webhook *model.GroupWebhook) error {
+	return r.db.WithContext(ctx).Create(webhook).Error
+}
+
+func (r *webhookRepositoryImpl) FindByGroupID(ctx context.Context, groupID uint) ([]*model.GroupWebhook, error) {
+	var webhooks []*model.GroupWebhook
+	if err := r.db.WithContext(ctx).Where("group_id = ?", groupID).Order("id ASC").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (r *webhookRepositoryImpl) FindByID(ctx context.Context, id uint, groupID uint) (*model.GroupWebhook, error) {
+	var webhook model.GroupWebhook
+	if err := r.db.WithContext(ctx).Where("id = ? AND group_id = ?", id, groupID).First(&webhook).Error; err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (r *webhookRepositoryImpl) FindByIDs(ctx context.Context, ids []uint) ([]*model.GroupWebhook, error) {
+	var webhooks []*model.GroupWebhook
+	if len(ids) == 0 {
+		return webhooks, nil
+	}
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (r *webhookRepositoryImpl) FindEnabledByEvent(ctx context.Context, groupID uint, event string) ([]*model.GroupWebhook, error) {
+	var webhooks []*model.GroupWebhook
+	if err := r.db.WithContext(ctx).
+		Where("group_id = ? AND enabled = ?", groupID, true).
+		Where("',' || events || ',' LIKE ?", "%,"+event+",%").
+		Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (r *webhookRepositoryImpl) Update(ctx context.Context, webhook *model.GroupWebhook) error {
+	return r.db.WithContext(ctx).Save(webhook).Error
+}
+
+func (r *webhookRepositoryImpl) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", id).Delete(&model.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.GroupWebhook{}, id).Error
+	})
+}
+
+type webhookDeliveryRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewWebhookDeliveryRepository(db *gorm.DB) repository.WebhookDeliveryRepository {
+	return &webhookDeliveryRepositoryImpl{db: db}
+}
+
+func (r *webhookDeliveryRepositoryImpl) Create(ctx context.Context, delivery *model.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Create(delivery).Error
+}
+
+func (r *webhookDeliveryRepositoryImpl) Update(ctx context.Context, delivery *model.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Save(delivery).Error
+}
+
+func (r *webhookDeliveryRepositoryImpl) FindByWebhookID(ctx context.Context, webhookID uint, limit int) ([]*model.WebhookDelivery, error) {
+	var deliveries []*model.WebhookDelivery
+	if err := r.db.WithContext(ctx).Where("webhook_id = ?", webhookID).Order("id DESC").Limit(limit).Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *webhookDeliveryRepositoryImpl) ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*model.WebhookDelivery, error) {
+	var deliveries []*model.WebhookDelivery
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", "pending", now).
+			Order("next_attempt_at ASC").
+			Limit(limit).
+			Find(&deliveries).Error; err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+		ids := make([]uint, len(deliveries))
+		for i, delivery := range deliveries {
+			ids[i] = delivery.ID
+			delivery.NextAttemptAt = &leaseUntil
+		}
+		return tx.Model(&model.WebhookDelivery{}).Where("id IN ?", ids).Update("next_attempt_at", leaseUntil).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
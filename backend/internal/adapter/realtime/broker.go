@@ -0,0 +1,218 @@
+// Package realtime fans live group events out to every server instance
+// through Postgres LISTEN/NOTIFY and keeps a short replay buffer per group,
+// so a client that reconnects can resume from its Last-Event-ID.
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+
+	"memoria/internal/usecase"
+)
+
+const (
+	// Channel is the NOTIFY channel shared by all instances.
+	Channel = "memoria_live_events"
+	// ReplaySize is how many recent events each group keeps for clients
+	// that resume with Last-Event-ID.
+	ReplaySize = 200
+	// subscriberBuffer is how far a client may fall behind before it is
+	// disconnected and has to resume from the replay buffer.
+	subscriberBuffer = 64
+	// maxReconnectDelay caps the wait between LISTEN reconnects.
+	maxReconnectDelay = 30 * time.Second
+)
+
+// Event is one message on a group's live stream. Data stays small (IDs
+// only) because NOTIFY payloads are limited to 8000 bytes; clients fetch
+// the changed resource themselves.
+type Event = usecase.LiveEvent
+
+// subscriber is the broker's side of one open stream.
+type subscriber struct {
+	groupID uint
+	events  chan Event
+}
+
+type groupState struct {
+	replay      []Event // oldest first
+	subscribers map[*subscriber]struct{}
+}
+
+// Broker implements usecase.LiveEventBroker.
+type Broker struct {
+	db  *gorm.DB
+	dsn string
+
+	mu     sync.Mutex
+	groups map[uint]*groupState
+}
+
+// NewBroker publishes through db and listens on a dedicated connection
+// opened from dsn, outside the GORM pool.
+func NewBroker(db *gorm.DB, dsn string) *Broker {
+	return &Broker{
+		db:     db,
+		dsn:    dsn,
+		groups: make(map[uint]*groupState),
+	}
+}
+
+// Publish sends an event to the group's streams on every instance,
+// including this one once the notification comes back.
+func (b *Broker) Publish(groupID uint, eventType string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return b.db.Exec(
+		`SELECT pg_notify(?, json_build_object('id', nextval('live_event_id_seq')::text, 'group_id', ?::bigint, 'type', ?::text, 'data', ?::json)::text)`,
+		Channel, groupID, eventType, string(payload),
+	).Error
+}
+
+// Subscribe opens a stream for the group. With a lastEventID the events
+// buffered after it are returned in Replay; events are buffered in the
+// order NOTIFY delivered them, which is the same on every instance.
+func (b *Broker) Subscribe(groupID uint, lastEventID string) *usecase.LiveSubscription {
+	s := &subscriber{groupID: groupID, events: make(chan Event, subscriberBuffer)}
+	sub := &usecase.LiveSubscription{
+		Events: s.events,
+		Close: func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			b.removeLocked(s)
+		},
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	state := b.groupLocked(groupID)
+	if lastEventID != "" {
+		found := false
+		for i, e := range state.replay {
+			if e.ID == lastEventID {
+				sub.Replay = append([]Event(nil), state.replay[i+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			sub.Resync = true
+			if len(state.replay) > 0 {
+				sub.LatestID = state.replay[len(state.replay)-1].ID
+			}
+		}
+	}
+	state.subscribers[s] = struct{}{}
+	return sub
+}
+
+// Run listens for events until ctx is done, reconnecting with backoff.
+func (b *Broker) Run(ctx context.Context) {
+	delay := time.Second
+	for {
+		started := time.Now()
+		err := b.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("realtime: listener stopped: %v", err)
+
+		if time.Since(started) > maxReconnectDelay {
+			delay = time.Second
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		if delay < maxReconnectDelay {
+			delay *= 2
+		}
+	}
+}
+
+func (b *Broker) listen(ctx context.Context) error {
+	conn, err := pgx.Connect(ctx, b.dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+Channel); err != nil {
+		return err
+	}
+	// Anything published while no listener was up is lost, so every open
+	// stream has to resync.
+	b.reset()
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		var e Event
+		if err := json.Unmarshal([]byte(notification.Payload), &e); err != nil {
+			log.Printf("realtime: invalid payload: %v", err)
+			continue
+		}
+		b.dispatch(e)
+	}
+}
+
+func (b *Broker) dispatch(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	state := b.groupLocked(e.GroupID)
+	state.replay = append(state.replay, e)
+	if len(state.replay) > ReplaySize {
+		state.replay = append([]Event(nil), state.replay[len(state.replay)-ReplaySize:]...)
+	}
+	for sub := range state.subscribers {
+		select {
+		case sub.events <- e:
+		default:
+			// Too slow; it resumes from the replay buffer on reconnect.
+			b.removeLocked(sub)
+		}
+	}
+}
+
+func (b *Broker) reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, state := range b.groups {
+		for sub := range state.subscribers {
+			b.removeLocked(sub)
+		}
+	}
+	b.groups = make(map[uint]*groupState)
+}
+
+func (b *Broker) groupLocked(groupID uint) *groupState {
+	state, ok := b.groups[groupID]
+	if !ok {
+		state = &groupState{subscribers: make(map[*subscriber]struct{})}
+		b.groups[groupID] = state
+	}
+	return state
+}
+
+func (b *Broker) removeLocked(sub *subscriber) {
+	state, ok := b.groups[sub.groupID]
+	if !ok {
+		return
+	}
+	if _, ok := state.subscribers[sub]; !ok {
+		return
+	}
+	delete(state.subscribers, sub)
+	close(sub.events)
+}
@@ -0,0 +1,598 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"memoria/internal/usecase"
+)
+
+// LocalPathPrefix is where the server serves LocalStorage's signed URLs.
+const LocalPathPrefix = "/api/storage/"
+
+var (
+	// ErrInvalidSignature is returned for a signed URL that was not issued
+	// by LocalStorage, was issued for another request or has expired.
+	ErrInvalidSignature = errors.New("invalid or expired signature")
+	// ErrSizeMismatch is returned by WriteObject when the body is not the
+	// expected size.
+	ErrSizeMismatch = errors.New("body size does not match")
+)
+
+// LocalStorage keeps objects on disk for local development and stands in
+// for S3's presigned URLs with URLs signed for the server's own
+// /api/storage/ routes. Object data lives under <dir>/objects and each
+// object's content type and ETag under <dir>/meta. Parts of multipart
+// uploads wait under <dir>/uploads until the upload is completed.
+type LocalStorage struct {
+	dir     string
+	baseURL string
+	secret  []byte
+}
+
+type localUpload struct {
+	Key         string `json:"key"`
+	ContentType string `json:"content_type"`
+}
+
+type localMeta struct {
+	ContentType string `json:"content_type"`
+	ETag        string `json:"etag"`
+}
+
+// NewLocalStorage stores objects under dir. baseURL is the server's public
+// URL, and secret signs the URLs; every server must share it.
+func NewLocalStorage(dir, baseURL, secret string) (*LocalStorage, error) {
+	for _, sub := range []string{"objects", "meta", "uploads"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, err
+		}
+	}
+	return &LocalStorage{
+		dir:     dir,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		secret:  []byte(secret),
+	}, nil
+}
+
+// PresignPut signs a PUT of exactly sizeBytes bytes of the content type to
+// the server; see VerifyPut.
+func (s *LocalStorage) PresignPut(key string, contentType string, sizeBytes int64, expiresIn time.Duration) (string, error) {
+	return s.signedURL("PUT", key, contentType, sizeBytes, "", 0, expiresIn)
+}
+
+// PresignGet signs a GET of the object from the server; see VerifyGet.
+func (s *LocalStorage) PresignGet(key string, expiresIn time.Duration) (string, error) {
+	return s.signedURL("GET", key, "", 0, "", 0, expiresIn)
+}
+
+// PresignUploadPart signs a PUT of exactly sizeBytes bytes as the numbered
+// part; see VerifyPart. The response's ETag header is the part's ETag.
+func (s *LocalStorage) PresignUploadPart(key, uploadID string, partNumber int, sizeBytes int64, expiresIn time.Duration) (string, error) {
+	return s.signedURL("PUT", key, "", sizeBytes, uploadID, partNumber, expiresIn)
+}
+
+// signedURL signs a request for the object, or for a part of a multipart
+// upload when uploadID is set. The query mirrors S3's uploadId and
+// partNumber.
+func (s *LocalStorage) signedURL(method, key, contentType string, sizeBytes int64, uploadID string, partNumber int, expiresIn time.Duration) (string, error) {
+	if _, err := s.objectPath(key); err != nil {
+		return "", err
+	}
+	expires := strconv.FormatInt(time.Now().Add(expiresIn).Unix(), 10)
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	query := url.Values{
+		"expires":   {expires},
+		"signature": {s.sign(method, key, contentType, sizeBytes, uploadID, partNumber, expires)},
+	}
+	if uploadID != "" {
+		query.Set("uploadId", uploadID)
+		query.Set("partNumber", strconv.Itoa(partNumber))
+	}
+	return s.baseURL + LocalPathPrefix + strings.Join(segments, "/") + "?" + query.Encode(), nil
+}
+
+func (s *LocalStorage) sign(method, key, contentType string, sizeBytes int64, uploadID string, partNumber int, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%d\n%s\n%d\n%s", method, key, contentType, sizeBytes, uploadID, partNumber, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyGet checks the query of a URL made by PresignGet.
+func (s *LocalStorage) VerifyGet(key, expires, signature string) error {
+	return s.verify("GET", key, "", 0, "", 0, expires, signature)
+}
+
+// VerifyPut checks the query of a URL made by PresignPut against the
+// request's Content-Type and Content-Length.
+func (s *LocalStorage) VerifyPut(key, contentType string, sizeBytes int64, expires, signature string) error {
+	return s.verify("PUT", key, contentType, sizeBytes, "", 0, expires, signature)
+}
+
+// VerifyPart checks the query of a URL made by PresignUploadPart against
+// the request's Content-Length.
+func (s *LocalStorage) VerifyPart(key, uploadID string, partNumber int, sizeBytes int64, expires, signature string) error {
+	return s.verify("PUT", key, "", sizeBytes, uploadID, partNumber, expires, signature)
+}
+
+func (s *LocalStorage) verify(method, key, contentType string, sizeBytes int64, uploadID string, partNumber int, expires, signature string) error {
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > unix {
+		return ErrInvalidSignature
+	}
+	want := s.sign(method, key, contentType, sizeBytes, uploadID, partNumber, expires)
+	if !hmac.Equal([]byte(want), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func (s *LocalStorage) HeadObject(ctx context.Context, key string) (*usecase.ObjectInfo, error) {
+	_, info, meta, err := s.stat(key)
+	if err != nil {
+		return nil, err
+	}
+	return &usecase.ObjectInfo{
+		Key:          key,
+		Size:         info.Size(),
+		ContentType:  meta.ContentType,
+		ETag:         meta.ETag,
+		LastModified: info.ModTime(),
+	}, nil
+}
+
+// GetObject reads a whole object, failing once it is larger than maxBytes.
+func (s *LocalStorage) GetObject(ctx context.Context, key string, maxBytes int64) ([]byte, error) {
+	p, info, _, err := s.stat(key)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > maxBytes {
+		return nil, fmt.Errorf("object %s is larger than %d bytes", key, maxBytes)
+	}
+	return os.ReadFile(p)
+}
+
+// GetObjectPrefix reads up to the first n bytes of an object.
+func (s *LocalStorage) GetObjectPrefix(ctx context.Context, key string, n int64) ([]byte, error) {
+	p, _, _, err := s.stat(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(io.LimitReader(file, n))
+}
+
+// OpenObject streams an object, answering byteRange and ifNoneMatch like
+// S3 does. The caller closes Body.
+func (s *LocalStorage) OpenObject(ctx context.Context, key, byteRange, ifNoneMatch string) (*usecase.Object, error) {
+	p, info, meta, err := s.stat(key)
+	if err != nil {
+		return nil, err
+	}
+	if ifNoneMatch != "" && etagMatches(ifNoneMatch, meta.ETag) {
+		return nil, usecase.ErrNotModified
+	}
+	start, length, partial, err := parseRange(byteRange, info.Size())
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+
+	object := &usecase.Object{
+		Body:          readCloser{io.NewSectionReader(file, start, length), file},
+		ContentType:   meta.ContentType,
+		ContentLength: length,
+		ETag:          meta.ETag,
+		LastModified:  info.ModTime(),
+	}
+	if partial {
+		object.ContentRange = fmt.Sprintf("bytes %d-%d/%d", start, start+length-1, info.Size())
+	}
+	return object, nil
+}
+
+// PutObject writes a whole object, replacing any object under the key.
+func (s *LocalStorage) PutObject(ctx context.Context, key, contentType string, data []byte) error {
+	return s.WriteObject(ctx, key, contentType, int64(len(data)), bytes.NewReader(data))
+}
+
+// WriteObject stores exactly sizeBytes bytes read from body, replacing any
+// object under the key. A body of another length stores nothing.
+func (s *LocalStorage) WriteObject(ctx context.Context, key, contentType string, sizeBytes int64, body io.Reader) error {
+	p, err := s.objectPath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := md5.New()
+	written, err := io.Copy(io.MultiWriter(tmp, hash), io.LimitReader(body, sizeBytes+1))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if written != sizeBytes {
+		return fmt.Errorf("%w: expected %d bytes, got %d", ErrSizeMismatch, sizeBytes, written)
+	}
+
+	// The metadata goes first, so an object that can be read always has it.
+	meta := localMeta{ContentType: contentType, ETag: `"` + hex.EncodeToString(hash.Sum(nil)) + `"`}
+	if err := s.writeMeta(key, meta); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+// DeleteObject succeeds when the object is already gone.
+func (s *LocalStorage) DeleteObject(ctx context.Context, key string) error {
+	p, err := s.objectPath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := os.Remove(s.metaPath(p)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) CopyObject(ctx context.Context, srcKey, dstKey string) error {
+	p, info, meta, err := s.stat(srcKey)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return s.WriteObject(ctx, dstKey, meta.ContentType, info.Size(), file)
+}
+
+// ListObjects returns up to limit objects whose keys start with prefix, in
+// key order after startAfter.
+func (s *LocalStorage) ListObjects(ctx context.Context, prefix, startAfter string, limit int) ([]usecase.ObjectInfo, error) {
+	root := filepath.Join(s.dir, "objects")
+	var objects []usecase.ObjectInfo
+	err := filepath.WalkDir(root, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || strings.HasPrefix(entry.Name(), ".tmp-") {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) || key <= startAfter {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		meta, _ := s.readMeta(p)
+		objects = append(objects, usecase.ObjectInfo{
+			Key:          key,
+			Size:         info.Size(),
+			ETag:         meta.ETag,
+			LastModified: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	if len(objects) > limit {
+		objects = objects[:limit]
+	}
+	return objects, nil
+}
+
+// CreateMultipartUpload starts an upload of the object in parts and returns
+// its upload ID.
+func (s *LocalStorage) CreateMultipartUpload(ctx context.Context, key, contentType string) (string, error) {
+	if _, err := s.objectPath(key); err != nil {
+		return "", err
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	uploadID := hex.EncodeToString(id)
+	dir := filepath.Join(s.dir, "uploads", uploadID)
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(localUpload{Key: key, ContentType: contentType})
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "upload.json"), data, 0o644); err != nil {
+		return "", err
+	}
+	return uploadID, nil
+}
+
+// WritePart stores exactly sizeBytes bytes read from body as the numbered
+// part, replacing an earlier upload of it, and returns the part's ETag.
+func (s *LocalStorage) WritePart(ctx context.Context, key, uploadID string, partNumber int, sizeBytes int64, body io.Reader) (string, error) {
+	dir, _, err := s.upload(key, uploadID)
+	if err != nil {
+		return "", err
+	}
+	if partNumber < 1 || partNumber > usecase.MaxParts {
+		return "", fmt.Errorf("%w: part number %d", usecase.ErrInvalidPart, partNumber)
+	}
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := md5.New()
+	written, err := io.Copy(io.MultiWriter(tmp, hash), io.LimitReader(body, sizeBytes+1))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+	if written != sizeBytes {
+		return "", fmt.Errorf("%w: expected %d bytes, got %d", ErrSizeMismatch, sizeBytes, written)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, strconv.Itoa(partNumber))); err != nil {
+		return "", err
+	}
+	return `"` + hex.EncodeToString(hash.Sum(nil)) + `"`, nil
+}
+
+// CompleteMultipartUpload joins the parts, in part number order, into the
+// object. Like S3, every part but the last must be at least
+// usecase.MinPartSize.
+func (s *LocalStorage) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []usecase.CompletedPart) error {
+	dir, upload, err := s.upload(key, uploadID)
+	if err != nil {
+		return err
+	}
+	if len(parts) == 0 {
+		return fmt.Errorf("%w: no parts", usecase.ErrInvalidPart)
+	}
+
+	var (
+		readers []io.Reader
+		size    int64
+	)
+	for i, part := range parts {
+		if i > 0 && part.PartNumber <= parts[i-1].PartNumber {
+			return fmt.Errorf("%w: parts are not in order", usecase.ErrInvalidPart)
+		}
+		file, err := os.Open(filepath.Join(dir, strconv.Itoa(part.PartNumber)))
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("%w: part %d has not been uploaded", usecase.ErrInvalidPart, part.PartNumber)
+		}
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		hash := md5.New()
+		n, err := io.Copy(hash, file)
+		if err != nil {
+			return err
+		}
+		if `"`+hex.EncodeToString(hash.Sum(nil))+`"` != part.ETag {
+			return fmt.Errorf("%w: part %d has another ETag", usecase.ErrInvalidPart, part.PartNumber)
+		}
+		if n < usecase.MinPartSize && i < len(parts)-1 {
+			return fmt.Errorf("%w: part %d is smaller than %d bytes", usecase.ErrInvalidPart, part.PartNumber, usecase.MinPartSize)
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		readers = append(readers, file)
+		size += n
+	}
+
+	if err := s.WriteObject(ctx, key, upload.ContentType, size, io.MultiReader(readers...)); err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+// AbortMultipartUpload discards the uploaded parts. It succeeds when the
+// upload is already gone.
+func (s *LocalStorage) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	dir, _, err := s.upload(key, uploadID)
+	if errors.Is(err, usecase.ErrUploadNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+// upload finds the directory of a multipart upload of the key.
+func (s *LocalStorage) upload(key, uploadID string) (string, localUpload, error) {
+	var upload localUpload
+	if _, err := hex.DecodeString(uploadID); err != nil || uploadID == "" {
+		return "", upload, fmt.Errorf("%w: %s", usecase.ErrUploadNotFound, uploadID)
+	}
+	dir := filepath.Join(s.dir, "uploads", uploadID)
+	data, err := os.ReadFile(filepath.Join(dir, "upload.json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", upload, fmt.Errorf("%w: %s", usecase.ErrUploadNotFound, uploadID)
+	}
+	if err != nil {
+		return "", upload, err
+	}
+	if err := json.Unmarshal(data, &upload); err != nil {
+		return "", upload, err
+	}
+	if upload.Key != key {
+		return "", upload, fmt.Errorf("%w: %s", usecase.ErrUploadNotFound, uploadID)
+	}
+	return dir, upload, nil
+}
+
+// objectPath maps a key to its data file, refusing keys that would leave
+// the storage directory.
+func (s *LocalStorage) objectPath(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || strings.HasPrefix(key, "../") || key == ".." {
+		return "", fmt.Errorf("invalid object key %q", key)
+	}
+	return filepath.Join(s.dir, "objects", filepath.FromSlash(key)), nil
+}
+
+func (s *LocalStorage) metaPath(objectPath string) string {
+	rel, _ := filepath.Rel(filepath.Join(s.dir, "objects"), objectPath)
+	return filepath.Join(s.dir, "meta", rel+".json")
+}
+
+func (s *LocalStorage) stat(key string) (string, fs.FileInfo, localMeta, error) {
+	p, err := s.objectPath(key)
+	if err != nil {
+		return "", nil, localMeta{}, err
+	}
+	info, err := os.Stat(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil, localMeta{}, fmt.Errorf("%w: %s", usecase.ErrObjectNotFound, key)
+	}
+	if err != nil {
+		return "", nil, localMeta{}, err
+	}
+	meta, err := s.readMeta(p)
+	if err != nil {
+		return "", nil, localMeta{}, err
+	}
+	return p, info, meta, nil
+}
+
+func (s *LocalStorage) readMeta(objectPath string) (localMeta, error) {
+	var meta localMeta
+	data, err := os.ReadFile(s.metaPath(objectPath))
+	if err != nil {
+		return meta, err
+	}
+	err = json.Unmarshal(data, &meta)
+	return meta, err
+}
+
+func (s *LocalStorage) writeMeta(key string, meta localMeta) error {
+	p, err := s.objectPath(key)
+	if err != nil {
+		return err
+	}
+	metaPath := s.metaPath(p)
+	if err := os.MkdirAll(filepath.Dir(metaPath), 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	tmp := metaPath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, metaPath)
+}
+
+// etagMatches reports whether an If-None-Match header lists the ETag.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRange reads a single "bytes=" range. Like S3, it serves the whole
+// object for a header it does not understand.
+func parseRange(header string, size int64) (start, length int64, partial bool, err error) {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return 0, size, false, nil
+	}
+	first, last, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, size, false, nil
+	}
+	switch {
+	case first == "": // the last n bytes
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n <= 0 {
+			return 0, size, false, nil
+		}
+		if size == 0 {
+			return 0, 0, false, usecase.ErrRangeNotSatisfiable
+		}
+		start = max(size-n, 0)
+		return start, size - start, true, nil
+	default:
+		start, err := strconv.ParseInt(first, 10, 64)
+		if err != nil || start < 0 {
+			return 0, size, false, nil
+		}
+		end := size - 1
+		if last != "" {
+			end, err = strconv.ParseInt(last, 10, 64)
+			if err != nil || end < start {
+				return 0, size, false, nil
+			}
+			end = min(end, size-1)
+		}
+		if start >= size {
+			return 0, 0, false, usecase.ErrRangeNotSatisfiable
+		}
+		return start, end - start + 1, true, nil
+	}
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
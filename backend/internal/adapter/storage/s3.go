@@ -1,15 +1,26 @@
 package storage
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
+
+	"memoria/internal/usecase"
 )
 
+// S3Service stores objects in an S3 bucket, or an S3-compatible server
+// such as MinIO when endpoint is set. It implements usecase.ObjectStorage.
 type S3Service struct {
 	client *s3.S3
 	bucket string
@@ -37,11 +48,198 @@ func NewS3Service(region, bucket, endpoint, accessKey, secretKey string) (*S3Ser
 	}, nil
 }
 
-func (s *S3Service) GeneratePresignedURL(key string, contentType string, expiresIn time.Duration) (string, error) {
+// PresignPut signs a PUT of exactly sizeBytes bytes of the content type; S3
+// rejects an upload with a different Content-Type or Content-Length.
+func (s *S3Service) PresignPut(key string, contentType string, sizeBytes int64, expiresIn time.Duration) (string, error) {
 	req, _ := s.client.PutObjectRequest(&s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(key),
+		ContentType:   aws.String(contentType),
+		ContentLength: aws.Int64(sizeBytes),
+	})
+
+	url, err := req.Presign(expiresIn)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate presigned URL: %w", err)
+	}
+
+	return url, nil
+}
+
+// PresignGet signs a GET of the object.
+func (s *S3Service) PresignGet(key string, expiresIn time.Duration) (string, error) {
+	req, _ := s.client.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+
+	url, err := req.Presign(expiresIn)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate presigned URL: %w", err)
+	}
+
+	return url, nil
+}
+
+// DeleteObject succeeds when the object is already gone.
+func (s *S3Service) DeleteObject(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	return err
+}
+
+func (s *S3Service) CopyObject(ctx context.Context, srcKey, dstKey string) error {
+	_, err := s.client.CopyObjectWithContext(ctx, &s3.CopyObjectInput{
+		Bucket:     aws.String(s.bucket),
+		Key:        aws.String(dstKey),
+		CopySource: aws.String(url.PathEscape(s.bucket + "/" + srcKey)),
+	})
+	return objectError(err)
+}
+
+// ListObjects returns up to limit objects whose keys start with prefix, in
+// key order after startAfter.
+func (s *S3Service) ListObjects(ctx context.Context, prefix, startAfter string, limit int) ([]usecase.ObjectInfo, error) {
+	input := &s3.ListObjectsV2Input{
+		Bucket:  aws.String(s.bucket),
+		Prefix:  aws.String(prefix),
+		MaxKeys: aws.Int64(int64(limit)),
+	}
+	if startAfter != "" {
+		input.StartAfter = aws.String(startAfter)
+	}
+	out, err := s.client.ListObjectsV2WithContext(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	objects := make([]usecase.ObjectInfo, len(out.Contents))
+	for i, item := range out.Contents {
+		objects[i] = usecase.ObjectInfo{
+			Key:          aws.StringValue(item.Key),
+			Size:         aws.Int64Value(item.Size),
+			ETag:         aws.StringValue(item.ETag),
+			LastModified: aws.TimeValue(item.LastModified),
+		}
+	}
+	return objects, nil
+}
+
+// GetObject reads a whole object, failing once it is larger than maxBytes.
+func (s *S3Service) GetObject(ctx context.Context, key string, maxBytes int64) ([]byte, error) {
+	out, err := s.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, objectError(err)
+	}
+	defer out.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(out.Body, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, fmt.Errorf("object %s is larger than %d bytes", key, maxBytes)
+	}
+	return data, nil
+}
+
+// PutObject writes a whole object, replacing any object under the key.
+func (s *S3Service) PutObject(ctx context.Context, key, contentType string, data []byte) error {
+	_, err := s.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
 		Bucket:      aws.String(s.bucket),
 		Key:         aws.String(key),
 		ContentType: aws.String(contentType),
+		Body:        bytes.NewReader(data),
+	})
+	return err
+}
+
+func (s *S3Service) HeadObject(ctx context.Context, key string) (*usecase.ObjectInfo, error) {
+	out, err := s.client.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, objectError(err)
+	}
+	return &usecase.ObjectInfo{
+		Key:          key,
+		Size:         aws.Int64Value(out.ContentLength),
+		ContentType:  aws.StringValue(out.ContentType),
+		ETag:         aws.StringValue(out.ETag),
+		LastModified: aws.TimeValue(out.LastModified),
+	}, nil
+}
+
+// GetObjectPrefix reads up to the first n bytes of an object.
+func (s *S3Service) GetObjectPrefix(ctx context.Context, key string, n int64) ([]byte, error) {
+	out, err := s.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+		Range:  aws.String(fmt.Sprintf("bytes=0-%d", n-1)),
+	})
+	if err != nil {
+		return nil, objectError(err)
+	}
+	defer out.Body.Close()
+	return io.ReadAll(io.LimitReader(out.Body, n))
+}
+
+// OpenObject streams an object. byteRange and ifNoneMatch are the request's
+// Range and If-None-Match headers, passed on to S3 when set. The caller
+// closes Body.
+func (s *S3Service) OpenObject(ctx context.Context, key, byteRange, ifNoneMatch string) (*usecase.Object, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	}
+	if byteRange != "" {
+		input.Range = aws.String(byteRange)
+	}
+	if ifNoneMatch != "" {
+		input.IfNoneMatch = aws.String(ifNoneMatch)
+	}
+	out, err := s.client.GetObjectWithContext(ctx, input)
+	if err != nil {
+		return nil, objectError(err)
+	}
+	return &usecase.Object{
+		Body:          out.Body,
+		ContentType:   aws.StringValue(out.ContentType),
+		ContentLength: aws.Int64Value(out.ContentLength),
+		ContentRange:  aws.StringValue(out.ContentRange),
+		ETag:          aws.StringValue(out.ETag),
+		LastModified:  aws.TimeValue(out.LastModified),
+	}, nil
+}
+
+// CreateMultipartUpload starts an upload of the object in parts and returns
+// its upload ID.
+func (s *S3Service) CreateMultipartUpload(ctx context.Context, key, contentType string) (string, error) {
+	out, err := s.client.CreateMultipartUploadWithContext(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.StringValue(out.UploadId), nil
+}
+
+// PresignUploadPart signs a PUT of exactly sizeBytes bytes as the numbered
+// part. S3 returns the part's ETag in the response's ETag header.
+func (s *S3Service) PresignUploadPart(key, uploadID string, partNumber int, sizeBytes int64, expiresIn time.Duration) (string, error) {
+	req, _ := s.client.UploadPartRequest(&s3.UploadPartInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(key),
+		UploadId:      aws.String(uploadID),
+		PartNumber:    aws.Int64(int64(partNumber)),
+		ContentLength: aws.Int64(sizeBytes),
 	})
 
 	url, err := req.Presign(expiresIn)
@@ -52,10 +250,72 @@ func (s *S3Service) GeneratePresignedURL(key string, contentType string, expires
 	return url, nil
 }
 
-func (s *S3Service) DeleteObject(key string) error {
-	_, err := s.client.DeleteObject(&s3.DeleteObjectInput{
-		Bucket: aws.String(s.bucket),
-		Key:    aws.String(key),
+// CompleteMultipartUpload joins the parts, in part number order, into the
+// object.
+func (s *S3Service) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []usecase.CompletedPart) error {
+	completed := make([]*s3.CompletedPart, len(parts))
+	for i, part := range parts {
+		completed[i] = &s3.CompletedPart{
+			PartNumber: aws.Int64(int64(part.PartNumber)),
+			ETag:       aws.String(part.ETag),
+		}
+	}
+	_, err := s.client.CompleteMultipartUploadWithContext(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s.bucket),
+		Key:             aws.String(key),
+		UploadId:        aws.String(uploadID),
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: completed},
+	})
+	return uploadError(err)
+}
+
+// AbortMultipartUpload discards the uploaded parts. It succeeds when the
+// upload is already gone.
+func (s *S3Service) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	_, err := s.client.AbortMultipartUploadWithContext(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(s.bucket),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
 	})
+	if errors.Is(uploadError(err), usecase.ErrUploadNotFound) {
+		return nil
+	}
+	return err
+}
+
+// uploadError turns S3's NoSuchUpload into usecase.ErrUploadNotFound and
+// its complaints about the parts into usecase.ErrInvalidPart.
+func uploadError(err error) error {
+	var awsErr awserr.Error
+	if !errors.As(err, &awsErr) {
+		return err
+	}
+	switch awsErr.Code() {
+	case s3.ErrCodeNoSuchUpload:
+		return fmt.Errorf("%w: %v", usecase.ErrUploadNotFound, err)
+	case "InvalidPart", "InvalidPartOrder", "EntityTooSmall":
+		return fmt.Errorf("%w: %v", usecase.ErrInvalidPart, err)
+	}
+	return err
+}
+
+// objectError turns S3's 404, 304 and 416 into usecase.ErrObjectNotFound,
+// usecase.ErrNotModified and usecase.ErrRangeNotSatisfiable.
+func objectError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var failure awserr.RequestFailure
+	if !errors.As(err, &failure) {
+		return err
+	}
+	switch failure.StatusCode() {
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %v", usecase.ErrObjectNotFound, err)
+	case http.StatusNotModified:
+		return usecase.ErrNotModified
+	case http.StatusRequestedRangeNotSatisfiable:
+		return usecase.ErrRangeNotSatisfiable
+	}
 	return err
 }
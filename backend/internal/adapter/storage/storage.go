@@ -0,0 +1,4 @@
+// Package storage keeps photo files in S3 (S3Service) or, for local
+// development, on disk (LocalStorage). Both implement usecase.ObjectStorage
+// and report its errors, so callers do not depend on the driver.
+package storage
@@ -0,0 +1,161 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"memoria/internal/usecase"
+)
+
+// Discord rejects embed descriptions over 4096 characters and Slack section
+// text over 3000; bodies are cut well below both.
+const maxBodyLength = 1000
+
+// Client is the usecase.WebhookClient and usecase.WebhookURLValidator for
+// Discord and Slack incoming webhooks.
+type Client struct {
+	http *http.Client
+}
+
+func NewClient() *Client {
+	return &Client{http: &http.Client{
+		Timeout: 10 * time.Second,
+		// A redirect could lead anywhere, past the host check in
+		// ValidateURL; it is reported as the response instead.
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+// ValidateURL accepts only the platform's own incoming webhook hosts, so a
+// group manager cannot make the server post to arbitrary addresses.
+func (c *Client) ValidateURL(platform, raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Scheme != "https" {
+		return usecase.ErrInvalidWebhookURL
+	}
+	host := strings.ToLower(parsed.Hostname())
+	switch platform {
+	case usecase.WebhookPlatformDiscord:
+		if (host == "discord.com" || host == "discordapp.com" || host == "ptb.discord.com" || host == "canary.discord.com") &&
+			strings.HasPrefix(parsed.Path, "/api/webhooks/") {
+			return nil
+		}
+	case usecase.WebhookPlatformSlack:
+		if host == "hooks.slack.com" && strings.HasPrefix(parsed.Path, "/services/") {
+			return nil
+		}
+	}
+	return usecase.ErrInvalidWebhookURL
+}
+
+// Format renders the message as a Discord embed or Slack blocks payload.
+func (c *Client) Format(platform string, msg usecase.WebhookMessage) ([]byte, error) {
+	body := truncate(msg.Body, maxBodyLength)
+	switch platform {
+	case usecase.WebhookPlatformDiscord:
+		return json.Marshal(discordPayload(msg, body))
+	case usecase.WebhookPlatformSlack:
+		return json.Marshal(slackPayload(msg, body))
+	default:
+		return nil, fmt.Errorf("unknown webhook platform %q", platform)
+	}
+}
+
+func discordPayload(msg usecase.WebhookMessage, body string) map[string]interface{} {
+	embed := map[string]interface{}{
+		"title":       msg.Title,
+		"description": body,
+		"color":       0xE8859A,
+		"timestamp":   msg.Timestamp.UTC().Format(time.RFC3339),
+	}
+	if msg.URL != "" {
+		embed["url"] = msg.URL
+	}
+	if msg.GroupName != "" {
+		embed["footer"] = map[string]string{"text": msg.GroupName}
+	}
+	return map[string]interface{}{
+		"username": "memoria",
+		"embeds":   []interface{}{embed},
+	}
+}
+
+func slackPayload(msg usecase.WebhookMessage, body string) map[string]interface{} {
+	blocks := []interface{}{
+		map[string]interface{}{
+			"type": "header",
+			"text": map[string]string{"type": "plain_text", "text": truncate(msg.Title, 150)},
+		},
+	}
+	if body != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "section",
+			"text": map[string]string{"type": "mrkdwn", "text": escapeSlack(body)},
+		})
+	}
+	if msg.URL != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "actions",
+			"elements": []interface{}{
+				map[string]interface{}{
+					"type": "button",
+					"text": map[string]string{"type": "plain_text", "text": "memoria で開く"},
+					"url":  msg.URL,
+				},
+			},
+		})
+	}
+	if msg.GroupName != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type":     "context",
+			"elements": []interface{}{map[string]string{"type": "mrkdwn", "text": escapeSlack(msg.GroupName)}},
+		})
+	}
+	return map[string]interface{}{
+		// text is the fallback shown in notifications.
+		"text":   msg.Title,
+		"blocks": blocks,
+	}
+}
+
+// Post sends payload and returns the response status. A status of 0 means
+// the request never got a response.
+func (c *Client) Post(ctx context.Context, webhookURL string, payload []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook returned %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return resp.StatusCode, nil
+}
+
+func truncate(value string, limit int) string {
+	runes := []rune(value)
+	if len(runes) <= limit {
+		return value
+	}
+	return string(runes[:limit-1]) + "…"
+}
+
+// escapeSlack escapes the three characters Slack treats as control sequences.
+func escapeSlack(value string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(value)
+}
@@ -0,0 +1,202 @@
+package webpush
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// subscriber is the browser side of a push subscription.
+type subscriber struct {
+	privateKey *ecdh.PrivateKey
+	authSecret []byte
+}
+
+func newSubscriber(t *testing.T) *subscriber {
+	t.Helper()
+	privateKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authSecret := make([]byte, 16)
+	if _, err := rand.Read(authSecret); err != nil {
+		t.Fatal(err)
+	}
+	return &subscriber{privateKey: privateKey, authSecret: authSecret}
+}
+
+// keys returns p256dh and auth as a browser's PushSubscription.toJSON does.
+func (s *subscriber) keys() (p256dh, auth string) {
+	return base64.RawURLEncoding.EncodeToString(s.privateKey.PublicKey().Bytes()),
+		base64.RawURLEncoding.EncodeToString(s.authSecret)
+}
+
+// decrypt reverses the aes128gcm content coding as a user agent does
+// (RFC 8291 section 3 and RFC 8188 section 2).
+func (s *subscriber) decrypt(t *testing.T, body []byte) []byte {
+	t.Helper()
+	if len(body) < 21 {
+		t.Fatalf("body is %d bytes, too short for the header", len(body))
+	}
+	salt := body[:16]
+	rs := binary.BigEndian.Uint32(body[16:20])
+	idlen := int(body[20])
+	if rs < 18 || len(body) < 21+idlen {
+		t.Fatalf("invalid header: rs=%d idlen=%d", rs, idlen)
+	}
+	keyID := body[21 : 21+idlen]
+	ciphertext := body[21+idlen:]
+	if len(ciphertext) > int(rs) {
+		t.Fatalf("ciphertext is %d bytes, more than one %d byte record", len(ciphertext), rs)
+	}
+
+	asPublic, err := ecdh.P256().NewPublicKey(keyID)
+	if err != nil {
+		t.Fatalf("keyid is not the application server's P-256 key: %v", err)
+	}
+	ecdhSecret, err := s.privateKey.ECDH(asPublic)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyInfo := append([]byte("WebPush: info\x00"), s.privateKey.PublicKey().Bytes()...)
+	keyInfo = append(keyInfo, keyID...)
+	ikm := testHKDF(s.authSecret, ecdhSecret, keyInfo, 32)
+	cek := testHKDF(salt, ikm, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	nonce := testHKDF(salt, ikm, []byte("Content-Encoding: nonce\x00"), 12)
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		t.Fatalf("failed to decrypt the record: %v", err)
+	}
+
+	// Strip the padding: the last non-zero byte is the 0x02 delimiter of
+	// the last record.
+	end := len(record) - 1
+	for end >= 0 && record[end] == 0 {
+		end--
+	}
+	if end < 0 || record[end] != 0x02 {
+		t.Fatalf("record does not end with the last-record delimiter")
+	}
+	return record[:end]
+}
+
+// testHKDF is HKDF-SHA256 (RFC 5869) for outputs of up to 32 bytes, written
+// out here so the test does not share the code it checks.
+func testHKDF(salt, ikm, info []byte, length int) []byte {
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(ikm)
+	expand := hmac.New(sha256.New, extract.Sum(nil))
+	expand.Write(info)
+	expand.Write([]byte{0x01})
+	return expand.Sum(nil)[:length]
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		plaintext []byte
+	}{
+		{"empty", []byte{}},
+		{"notification", []byte(`{"id":1,"category":"new_post","title":"旅行記","body":"新しい投稿"}`)},
+		{"largest", bytes.Repeat([]byte("a"), MaxPayloadSize)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := newSubscriber(t)
+			p256dh, auth := sub.keys()
+			body, err := encrypt(tt.plaintext, p256dh, auth)
+			if err != nil {
+				t.Fatalf("encrypt: %v", err)
+			}
+			if len(body) > 4096 {
+				t.Errorf("body is %d bytes, more than push services must accept", len(body))
+			}
+			if got := sub.decrypt(t, body); !bytes.Equal(got, tt.plaintext) {
+				t.Errorf("decrypted %q, want %q", got, tt.plaintext)
+			}
+		})
+	}
+}
+
+func TestEncryptUsesFreshKeys(t *testing.T) {
+	sub := newSubscriber(t)
+	p256dh, auth := sub.keys()
+	first, err := encrypt([]byte("same"), p256dh, auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := encrypt([]byte("same"), p256dh, auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(first[:16], second[:16]) {
+		t.Error("salt was reused")
+	}
+	if bytes.Equal(first[21:86], second[21:86]) {
+		t.Error("application server key was reused")
+	}
+}
+
+func TestEncryptAcceptsPaddedStandardBase64(t *testing.T) {
+	sub := newSubscriber(t)
+	p256dh := base64.StdEncoding.EncodeToString(sub.privateKey.PublicKey().Bytes())
+	auth := base64.StdEncoding.EncodeToString(sub.authSecret)
+	body, err := encrypt([]byte("hello"), p256dh, auth)
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	if got := sub.decrypt(t, body); string(got) != "hello" {
+		t.Errorf("decrypted %q, want %q", got, "hello")
+	}
+}
+
+func TestEncryptRejectsInvalidInput(t *testing.T) {
+	sub := newSubscriber(t)
+	p256dh, auth := sub.keys()
+	tests := []struct {
+		name      string
+		plaintext []byte
+		p256dh    string
+		auth      string
+		wantErr   error
+	}{
+		{"payload too large", bytes.Repeat([]byte("a"), MaxPayloadSize+1), p256dh, auth, ErrPayloadTooLarge},
+		{"p256dh not base64", []byte("x"), "not base64!", auth, nil},
+		{"p256dh not a point", []byte("x"), base64.RawURLEncoding.EncodeToString(make([]byte, 65)), auth, nil},
+		{"auth too short", []byte("x"), p256dh, base64.RawURLEncoding.EncodeToString(make([]byte, 8)), nil},
+		{"auth missing", []byte("x"), p256dh, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := encrypt(tt.plaintext, tt.p256dh, tt.auth)
+			if err == nil {
+				t.Fatal("encrypt succeeded")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && !strings.Contains(err.Error(), "invalid") {
+				t.Errorf("err = %v, want an invalid key error", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package webpush
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// ErrInvalidEndpoint is returned for an endpoint that is not on a known push
+// service.
+var ErrInvalidEndpoint = errors.New("push endpoint is not on a known push service")
+
+// pushServiceHosts are the push services of the browsers memoria supports:
+// Chrome and other Chromium browsers, Firefox and Safari.
+var pushServiceHosts = map[string]bool{
+	"fcm.googleapis.com":                true,
+	"updates.push.services.mozilla.com": true,
+	"web.push.apple.com":                true,
+}
+
+// pushServiceSuffixes cover push services that give each client its own
+// host, such as Edge's Windows Push Notification Services.
+var pushServiceSuffixes = []string{
+	".notify.windows.com",
+	".push.apple.com",
+}
+
+// EndpointValidator is the usecase.PushEndpointValidator for the push
+// services below.
+type EndpointValidator struct{}
+
+// ValidateEndpoint accepts only https endpoints on known push services, so a
+// client cannot make the server post to arbitrary addresses.
+func (EndpointValidator) ValidateEndpoint(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Scheme != "https" || parsed.User != nil || parsed.Port() != "" {
+		return ErrInvalidEndpoint
+	}
+	host := strings.ToLower(parsed.Hostname())
+	if pushServiceHosts[host] {
+		return nil
+	}
+	for _, suffix := range pushServiceSuffixes {
+		if strings.HasSuffix(host, suffix) {
+			return nil
+		}
+	}
+	return ErrInvalidEndpoint
+}
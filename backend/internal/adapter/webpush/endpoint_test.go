@@ -0,0 +1,31 @@
+package webpush
+
+import "testing"
+
+func TestValidateEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		valid    bool
+	}{
+		{"https://fcm.googleapis.com/fcm/send/abc:def", true},
+		{"https://updates.push.services.mozilla.com/wpush/v2/gAAAA", true},
+		{"https://web.push.apple.com/QGx3", true},
+		{"https://wns2-db5p.notify.windows.com/w/?token=BQYAAA", true},
+		{"https://FCM.googleapis.com/fcm/send/abc", true},
+		{"http://fcm.googleapis.com/fcm/send/abc", false},
+		{"https://fcm.googleapis.com:8443/fcm/send/abc", false},
+		{"https://user@fcm.googleapis.com/fcm/send/abc", false},
+		{"https://fcm.googleapis.com.example.com/fcm/send/abc", false},
+		{"https://notify.windows.com.example.com/w", false},
+		{"https://169.254.169.254/latest/meta-data", false},
+		{"https://localhost/push", false},
+		{"not a url", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		err := EndpointValidator{}.ValidateEndpoint(tt.endpoint)
+		if (err == nil) != tt.valid {
+			t.Errorf("ValidateEndpoint(%q) = %v, want valid=%v", tt.endpoint, err, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package webpush
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"memoria/internal/usecase"
+)
+
+// Sender is the usecase.PushSender for the standard Web Push protocol.
+type Sender struct {
+	vapid  *VAPID
+	client *http.Client
+}
+
+func NewSender(publicKey, privateKey, subject string) (*Sender, error) {
+	vapid, err := NewVAPID(publicKey, privateKey, subject)
+	if err != nil {
+		return nil, err
+	}
+	return &Sender{
+		vapid:  vapid,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (s *Sender) PublicKey() string {
+	return s.vapid.PublicKey()
+}
+
+// Send encrypts payload for the subscription and posts it to the endpoint.
+// ttl is how long the push service may hold the message for an offline
+// browser.
+func (s *Sender) Send(ctx context.Context, endpoint, p256dh, auth string, payload []byte, ttl time.Duration) error {
+	body, err := encrypt(payload, p256dh, auth)
+	if err != nil {
+		return err
+	}
+	authorization, err := s.vapid.authorization(endpoint, time.Now())
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", strconv.Itoa(int(ttl.Seconds())))
+	req.Header.Set("Urgency", "normal")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return &usecase.PushTemporaryError{Err: err}
+	}
+	defer resp.Body.Close()
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return usecase.ErrPushSubscriptionGone
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return &usecase.PushTemporaryError{
+			Err:        fmt.Errorf("push service returned %d: %s", resp.StatusCode, detail),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	default:
+		return fmt.Errorf("push service returned %d: %s", resp.StatusCode, detail)
+	}
+}
+
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(at); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
@@ -0,0 +1,186 @@
+package webpush
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"memoria/internal/usecase"
+)
+
+// pushService is a fake push service. It answers every request with status
+// and records what it received.
+type pushService struct {
+	*httptest.Server
+	status     int
+	retryAfter string
+
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func newPushService(t *testing.T, status int) *pushService {
+	t.Helper()
+	service := &pushService{status: status}
+	service.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("failed to read the request body: %v", err)
+		}
+		service.mu.Lock()
+		service.requests = append(service.requests, r)
+		service.bodies = append(service.bodies, body)
+		service.mu.Unlock()
+		if service.retryAfter != "" {
+			w.Header().Set("Retry-After", service.retryAfter)
+		}
+		w.WriteHeader(service.status)
+	}))
+	t.Cleanup(service.Close)
+	return service
+}
+
+func newTestSender(t *testing.T) *Sender {
+	t.Helper()
+	publicKey, privateKey, err := GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender, err := NewSender(publicKey, privateKey, "mailto:ops@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sender
+}
+
+func TestSendDelivers(t *testing.T) {
+	service := newPushService(t, http.StatusCreated)
+	sender := newTestSender(t)
+	sub := newSubscriber(t)
+	p256dh, auth := sub.keys()
+	endpoint := service.URL + "/push/abc"
+
+	payload := []byte(`{"id":7,"title":"こんにちは"}`)
+	if err := sender.Send(context.Background(), endpoint, p256dh, auth, payload, 90*time.Minute); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	service.mu.Lock()
+	defer service.mu.Unlock()
+	if len(service.requests) != 1 {
+		t.Fatalf("push service got %d requests, want 1", len(service.requests))
+	}
+
+	req := service.requests[0]
+	if req.Method != http.MethodPost || req.URL.Path != "/push/abc" {
+		t.Errorf("request = %s %s, want POST /push/abc", req.Method, req.URL.Path)
+	}
+	for header, want := range map[string]string{
+		"Content-Encoding": "aes128gcm",
+		"Content-Type":     "application/octet-stream",
+		"TTL":              "5400",
+		"Urgency":          "normal",
+	} {
+		if got := req.Header.Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+
+	claims, key := verifyVAPID(t, req.Header.Get("Authorization"))
+	if key != sender.PublicKey() {
+		t.Errorf("k = %s, want the sender's public key", key)
+	}
+	if claims.Aud != service.URL {
+		t.Errorf("aud = %q, want the push service origin %q", claims.Aud, service.URL)
+	}
+	if exp := time.Unix(claims.Exp, 0); !exp.After(time.Now()) || exp.After(time.Now().Add(24*time.Hour)) {
+		t.Errorf("exp = %v, want within the next 24h", exp)
+	}
+
+	if got := sub.decrypt(t, service.bodies[0]); string(got) != string(payload) {
+		t.Errorf("push service got %q, want %q", got, payload)
+	}
+}
+
+func TestSendClassifiesResponses(t *testing.T) {
+	tests := []struct {
+		name           string
+		status         int
+		retryAfter     string
+		wantGone       bool
+		wantTemporary  bool
+		wantRetryAfter time.Duration
+	}{
+		{name: "not found", status: http.StatusNotFound, wantGone: true},
+		{name: "gone", status: http.StatusGone, wantGone: true},
+		{name: "rate limited", status: http.StatusTooManyRequests, retryAfter: "120", wantTemporary: true, wantRetryAfter: 2 * time.Minute},
+		{name: "server error", status: http.StatusServiceUnavailable, wantTemporary: true},
+		{name: "bad request", status: http.StatusBadRequest},
+		{name: "payload too large", status: http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newPushService(t, tt.status)
+			service.retryAfter = tt.retryAfter
+			sub := newSubscriber(t)
+			p256dh, auth := sub.keys()
+
+			err := newTestSender(t).Send(context.Background(), service.URL, p256dh, auth, []byte("x"), time.Hour)
+			if err == nil {
+				t.Fatal("Send succeeded")
+			}
+			if got := errors.Is(err, usecase.ErrPushSubscriptionGone); got != tt.wantGone {
+				t.Errorf("errors.Is(err, usecase.ErrPushSubscriptionGone) = %v, want %v (err: %v)", got, tt.wantGone, err)
+			}
+			var temporary *usecase.PushTemporaryError
+			if got := errors.As(err, &temporary); got != tt.wantTemporary {
+				t.Fatalf("TemporaryError = %v, want %v (err: %v)", got, tt.wantTemporary, err)
+			}
+			if temporary != nil && temporary.RetryAfter != tt.wantRetryAfter {
+				t.Errorf("RetryAfter = %v, want %v", temporary.RetryAfter, tt.wantRetryAfter)
+			}
+		})
+	}
+}
+
+func TestSendNetworkErrorIsTemporary(t *testing.T) {
+	service := newPushService(t, http.StatusCreated)
+	service.Close()
+	sub := newSubscriber(t)
+	p256dh, auth := sub.keys()
+
+	err := newTestSender(t).Send(context.Background(), service.URL, p256dh, auth, []byte("x"), time.Hour)
+	var temporary *usecase.PushTemporaryError
+	if !errors.As(err, &temporary) {
+		t.Errorf("err = %v, want a PushTemporaryError", err)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"30", 30 * time.Second},
+		{"0", 0},
+		{"-5", 0},
+		{"soon", 0},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+
+	at := time.Now().Add(10 * time.Minute).UTC().Format(http.TimeFormat)
+	if got := parseRetryAfter(at); got < 9*time.Minute || got > 10*time.Minute {
+		t.Errorf("parseRetryAfter(%q) = %v, want about 10m", at, got)
+	}
+}
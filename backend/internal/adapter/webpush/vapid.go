@@ -0,0 +1,99 @@
+package webpush
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"time"
+)
+
+// vapidTokenTTL is how long a VAPID JWT is valid; RFC 8292 allows up to 24h.
+const vapidTokenTTL = 12 * time.Hour
+
+// VAPID signs push requests (RFC 8292) with the application server key.
+type VAPID struct {
+	privateKey *ecdsa.PrivateKey
+	publicKey  string
+	subject    string
+}
+
+// NewVAPID builds a signer from base64url keys: the 65-byte uncompressed
+// public key and the 32-byte private scalar. subject is a mailto: or https:
+// contact the push service can use.
+func NewVAPID(publicKey, privateKey, subject string) (*VAPID, error) {
+	if subject == "" {
+		return nil, errors.New("VAPID_SUBJECT is required")
+	}
+	publicBytes, err := decodeKey(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID public key: %w", err)
+	}
+	privateBytes, err := decodeKey(privateKey)
+	if err != nil || len(privateBytes) != 32 {
+		return nil, errors.New("invalid VAPID private key")
+	}
+
+	key := &ecdsa.PrivateKey{D: new(big.Int).SetBytes(privateBytes)}
+	key.Curve = elliptic.P256()
+	key.X, key.Y = key.Curve.ScalarBaseMult(privateBytes)
+	if string(elliptic.Marshal(key.Curve, key.X, key.Y)) != string(publicBytes) {
+		return nil, errors.New("VAPID public key does not match the private key")
+	}
+
+	return &VAPID{
+		privateKey: key,
+		publicKey:  base64.RawURLEncoding.EncodeToString(publicBytes),
+		subject:    subject,
+	}, nil
+}
+
+// GenerateKeys returns a new base64url encoded VAPID key pair.
+func GenerateKeys() (publicKey, privateKey string, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	publicBytes := elliptic.Marshal(key.Curve, key.X, key.Y)
+	privateBytes := key.D.FillBytes(make([]byte, 32))
+	return base64.RawURLEncoding.EncodeToString(publicBytes), base64.RawURLEncoding.EncodeToString(privateBytes), nil
+}
+
+// PublicKey is the applicationServerKey browsers subscribe with.
+func (v *VAPID) PublicKey() string {
+	return v.publicKey
+}
+
+// authorization returns the Authorization header for a push endpoint.
+func (v *VAPID) authorization(endpoint string, now time.Time) (string, error) {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	header, _ := json.Marshal(map[string]string{"typ": "JWT", "alg": "ES256"})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"aud": parsed.Scheme + "://" + parsed.Host,
+		"exp": now.Add(vapidTokenTTL).Unix(),
+		"sub": v.subject,
+	})
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(unsigned))
+	r, s, err := ecdsa.Sign(rand.Reader, v.privateKey, digest[:])
+	if err != nil {
+		return "", err
+	}
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+
+	token := unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)
+	return fmt.Sprintf("vapid t=%s, k=%s", token, v.publicKey), nil
+}
@@ -0,0 +1,157 @@
+package webpush
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+type vapidClaims struct {
+	Aud string `json:"aud"`
+	Exp int64  `json:"exp"`
+	Sub string `json:"sub"`
+}
+
+// verifyVAPID checks an Authorization header as a push service does
+// (RFC 8292): the k key must verify the ES256 JWT in t. It returns the
+// JWT's claims and the key.
+func verifyVAPID(t *testing.T, authorization string) (vapidClaims, string) {
+	t.Helper()
+	rest, ok := strings.CutPrefix(authorization, "vapid ")
+	if !ok {
+		t.Fatalf("authorization %q is not the vapid scheme", authorization)
+	}
+	params := make(map[string]string)
+	for _, param := range strings.Split(rest, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		params[name] = value
+	}
+	token, key := params["t"], params["k"]
+
+	keyBytes, err := base64.RawURLEncoding.DecodeString(key)
+	if err != nil {
+		t.Fatalf("k is not base64url: %v", err)
+	}
+	x, y := elliptic.Unmarshal(elliptic.P256(), keyBytes)
+	if x == nil {
+		t.Fatalf("k is not an uncompressed P-256 point")
+	}
+	publicKey := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("t has %d parts, want 3", len(parts))
+	}
+	var header map[string]string
+	decodeSegment(t, parts[0], &header)
+	if header["alg"] != "ES256" || header["typ"] != "JWT" {
+		t.Errorf("header = %v, want ES256 JWT", header)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || len(signature) != 64 {
+		t.Fatalf("signature is not a 64 byte r||s: %v", err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+	if !ecdsa.Verify(publicKey, digest[:], r, s) {
+		t.Fatal("JWT signature does not verify with k")
+	}
+
+	var claims vapidClaims
+	decodeSegment(t, parts[1], &claims)
+	return claims, key
+}
+
+func decodeSegment(t *testing.T, segment string, v interface{}) {
+	t.Helper()
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		t.Fatalf("segment is not base64url: %v", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("segment is not JSON: %v", err)
+	}
+}
+
+func newTestVAPID(t *testing.T) *VAPID {
+	t.Helper()
+	publicKey, privateKey, err := GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	vapid, err := NewVAPID(publicKey, privateKey, "mailto:ops@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return vapid
+}
+
+func TestVAPIDAuthorization(t *testing.T) {
+	vapid := newTestVAPID(t)
+	now := time.Unix(1_700_000_000, 0)
+	tests := []struct {
+		endpoint string
+		wantAud  string
+	}{
+		{"https://fcm.googleapis.com/fcm/send/abc:def", "https://fcm.googleapis.com"},
+		{"https://updates.push.services.mozilla.com/wpush/v2/gAAA?x=1", "https://updates.push.services.mozilla.com"},
+		{"https://push.example.com:8443/p/1", "https://push.example.com:8443"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.endpoint, func(t *testing.T) {
+			authorization, err := vapid.authorization(tt.endpoint, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			claims, key := verifyVAPID(t, authorization)
+			if key != vapid.PublicKey() {
+				t.Errorf("k = %s, want the public key %s", key, vapid.PublicKey())
+			}
+			if claims.Aud != tt.wantAud {
+				t.Errorf("aud = %q, want %q", claims.Aud, tt.wantAud)
+			}
+			if claims.Exp <= now.Unix() || claims.Exp > now.Add(24*time.Hour).Unix() {
+				t.Errorf("exp = %d, want within 24h after %d", claims.Exp, now.Unix())
+			}
+			if claims.Sub != "mailto:ops@example.com" {
+				t.Errorf("sub = %q", claims.Sub)
+			}
+		})
+	}
+}
+
+func TestNewVAPIDRejectsInvalidKeys(t *testing.T) {
+	publicKey, privateKey, err := GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPublicKey, _, err := GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		publicKey  string
+		privateKey string
+		subject    string
+	}{
+		{"no subject", publicKey, privateKey, ""},
+		{"public key not base64", "not base64!", privateKey, "mailto:a@example.com"},
+		{"private key too short", publicKey, base64.RawURLEncoding.EncodeToString(make([]byte, 16)), "mailto:a@example.com"},
+		{"keys do not match", otherPublicKey, privateKey, "mailto:a@example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewVAPID(tt.publicKey, tt.privateKey, tt.subject); err == nil {
+				t.Error("NewVAPID succeeded")
+			}
+		})
+	}
+}
@@ -4,7 +4,11 @@ import (
 	"log"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
+	"time"
+
+	"memoria/internal/domain/model"
 
 	"github.com/joho/godotenv"
 )
@@ -21,6 +25,12 @@ type Config struct {
 	DBPassword string
 	DBName     string
 	DBSSLMode  string
+	// Queries slower than this are logged.
+	DBSlowQueryThreshold time.Duration
+
+	// Deadline for a request's database and outbound calls. Live event
+	// streams are exempt.
+	RequestTimeout time.Duration
 
 	FirebaseProjectID   string
 	FirebaseClientEmail string
@@ -28,6 +38,10 @@ type Config struct {
 	FirebaseAPIKey      string
 
 	FrontendBaseURL string
+	AppBaseURL      string
+	// APIBaseURL is this API's public URL, which STORAGE_DRIVER=local signs
+	// its upload and download URLs for.
+	APIBaseURL      string
 	AllowedOrigins  string
 	AllowedOriginSuffixes string
 	CookieDomain    string
@@ -39,6 +53,40 @@ type Config struct {
 	S3Endpoint  string
 	S3AccessKey string
 	S3SecretKey string
+	// StorageDriver is where photo files are kept: "s3", or "local" for a
+	// directory served by this server. Every server and worker sharing a
+	// database must use the same storage.
+	StorageDriver      string
+	StorageLocalDir    string
+	StorageLocalSecret string
+	// Photo uploads: the largest file a group may upload unless it sets a
+	// smaller limit, and the accepted content types, which must be in
+	// model.PhotoFormats.
+	UploadMaxBytes     int64
+	UploadContentTypes []string
+
+	// Web Push (VAPID). Push delivery is disabled when the keys are empty.
+	VAPIDPublicKey  string
+	VAPIDPrivateKey string
+	VAPIDSubject    string
+
+	// Background jobs
+	RunWorkers                 bool
+	TripReminderInterval       time.Duration
+	TripReminderMaxDelay       time.Duration
+	PushRetryInterval          time.Duration
+	WebhookRetryInterval       time.Duration
+	GroupPurgeInterval         time.Duration
+	TrashPurgeInterval         time.Duration
+	ObjectCleanupInterval      time.Duration
+	PhotoVariantsInterval      time.Duration
+	UploadSweepInterval        time.Duration
+	UploadSessionSweepInterval time.Duration
+
+	// How long a deleted group can be restored before it is purged.
+	GroupDeletionGrace time.Duration
+	// How long deleted content stays in the trash.
+	TrashRetention time.Duration
 }
 
 func Load() Config {
@@ -52,12 +100,17 @@ func Load() Config {
 		AppPort: getEnv("APP_PORT", "8080"),
 		AutoMigrate: getEnv("AUTO_MIGRATE", "true") != "false",
 
+		DBSlowQueryThreshold: getDurationEnv("DB_SLOW_QUERY_THRESHOLD", 200*time.Millisecond),
+		RequestTimeout:       getDurationEnv("REQUEST_TIMEOUT", 30*time.Second),
+
 		FirebaseProjectID:   getEnv("FIREBASE_PROJECT_ID", ""),
 		FirebaseClientEmail: getEnv("FIREBASE_CLIENT_EMAIL", ""),
 		FirebasePrivateKey:  normalizePrivateKey(getEnv("FIREBASE_PRIVATE_KEY", "")),
 		FirebaseAPIKey:      getEnv("FIREBASE_API_KEY", ""),
 
 		FrontendBaseURL: getEnv("FRONTEND_BASE_URL", ""),
+		AppBaseURL:      getEnv("APP_BASE_URL", ""),
+		APIBaseURL:      getEnv("API_BASE_URL", ""),
 		AllowedOrigins:  getEnv("ALLOWED_ORIGINS", ""),
 		AllowedOriginSuffixes: getEnv("ALLOWED_ORIGIN_SUFFIXES", ""),
 		CookieDomain:    getEnv("COOKIE_DOMAIN", ""),
@@ -69,6 +122,31 @@ func Load() Config {
 		S3Endpoint:  getEnv("S3_ENDPOINT", ""),
 		S3AccessKey: getEnv("S3_ACCESS_KEY", ""),
 		S3SecretKey: getEnv("S3_SECRET_KEY", ""),
+
+		StorageDriver:      getEnv("STORAGE_DRIVER", "s3"),
+		StorageLocalDir:    getEnv("STORAGE_LOCAL_DIR", "./data/storage"),
+		StorageLocalSecret: getEnv("STORAGE_LOCAL_SECRET", ""),
+
+		UploadMaxBytes:     getInt64Env("UPLOAD_MAX_BYTES", 20<<20),
+		UploadContentTypes: getListEnv("UPLOAD_CONTENT_TYPES", "image/jpeg,image/png,image/heic,image/webp"),
+
+		VAPIDPublicKey:  getEnv("VAPID_PUBLIC_KEY", ""),
+		VAPIDPrivateKey: getEnv("VAPID_PRIVATE_KEY", ""),
+		VAPIDSubject:    getEnv("VAPID_SUBJECT", "mailto:no-reply@rikut0904.site"),
+
+		RunWorkers:                 getEnv("RUN_WORKERS", "true") != "false",
+		TripReminderInterval:       getDurationEnv("TRIP_REMINDER_INTERVAL", time.Minute),
+		TripReminderMaxDelay:       getDurationEnv("TRIP_REMINDER_MAX_DELAY", 24*time.Hour),
+		PushRetryInterval:          getDurationEnv("PUSH_RETRY_INTERVAL", 30*time.Second),
+		WebhookRetryInterval:       getDurationEnv("WEBHOOK_RETRY_INTERVAL", 30*time.Second),
+		GroupPurgeInterval:         getDurationEnv("GROUP_PURGE_INTERVAL", time.Hour),
+		TrashPurgeInterval:         getDurationEnv("TRASH_PURGE_INTERVAL", time.Hour),
+		ObjectCleanupInterval:      getDurationEnv("OBJECT_CLEANUP_INTERVAL", time.Minute),
+		PhotoVariantsInterval:      getDurationEnv("PHOTO_VARIANTS_INTERVAL", 10*time.Second),
+		UploadSweepInterval:        getDurationEnv("UPLOAD_SWEEP_INTERVAL", 5*time.Minute),
+		UploadSessionSweepInterval: getDurationEnv("UPLOAD_SESSION_SWEEP_INTERVAL", 15*time.Minute),
+		GroupDeletionGrace:         getDurationEnv("GROUP_DELETION_GRACE", 7*24*time.Hour),
+		TrashRetention:             getDurationEnv("TRASH_RETENTION", 30*24*time.Hour),
 	}
 
 	// Parse DATABASE_URL if available (Railway, Heroku style)
@@ -84,6 +162,19 @@ func Load() Config {
 		cfg.DBSSLMode = getEnv("DB_SSLMODE", "disable")
 	}
 
+	cfg.UploadContentTypes = photoContentTypes(cfg.UploadContentTypes)
+
+	switch cfg.StorageDriver {
+	case "s3":
+	case "local":
+		if cfg.AppEnv == "production" {
+			log.Println("STORAGE_DRIVER=local keeps photos on this server's disk; use s3 in production")
+		}
+	default:
+		log.Printf("Unknown STORAGE_DRIVER %q, using s3", cfg.StorageDriver)
+		cfg.StorageDriver = "s3"
+	}
+
 	return cfg
 }
 
@@ -135,3 +226,55 @@ func getEnv(key, fallback string) string {
 	}
 	return val
 }
+
+func getInt64Env(key string, fallback int64) int64 {
+	val := os.Getenv(key)
+	if val == "" {
+		return fallback
+	}
+	n, err := strconv.ParseInt(val, 10, 64)
+	if err != nil || n <= 0 {
+		log.Printf("Invalid %s=%q, using %d", key, val, fallback)
+		return fallback
+	}
+	return n
+}
+
+// getListEnv splits a comma-separated value, dropping blanks.
+// photoContentTypes drops the content types a photo cannot be registered
+// with, since their uploads could never be completed.
+func photoContentTypes(types []string) []string {
+	var supported []string
+	for _, contentType := range types {
+		contentType = strings.ToLower(contentType)
+		if _, ok := model.PhotoFormats[contentType]; !ok {
+			log.Printf("UPLOAD_CONTENT_TYPES: %q cannot be registered as a photo, ignoring it", contentType)
+			continue
+		}
+		supported = append(supported, contentType)
+	}
+	return supported
+}
+
+func getListEnv(key, fallback string) []string {
+	var list []string
+	for _, item := range strings.Split(getEnv(key, fallback), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func getDurationEnv(key string, fallback time.Duration) time.Duration {
+	val := os.Getenv(key)
+	if val == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(val)
+	if err != nil || d <= 0 {
+		log.Printf("Invalid %s=%q, using %s", key, val, fallback)
+		return fallback
+	}
+	return d
+}
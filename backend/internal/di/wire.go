@@ -1,14 +1,21 @@
 package di
 
 import (
+	"context"
 	"memoria/internal/adapter/auth"
+	"memoria/internal/adapter/calendar"
 	"memoria/internal/adapter/email"
 	"memoria/internal/adapter/http"
 	"memoria/internal/adapter/http/handler"
 	"memoria/internal/adapter/http/middleware"
+	"memoria/internal/adapter/imaging"
 	"memoria/internal/adapter/persistence"
+	"memoria/internal/adapter/realtime"
 	"memoria/internal/adapter/storage"
+	"memoria/internal/adapter/webhook"
+	"memoria/internal/adapter/webpush"
 	"memoria/internal/config"
+	"memoria/internal/domain/event"
 	"memoria/internal/usecase"
 	"time"
 
@@ -24,6 +31,15 @@ func BuildServer(cfg config.Config) (*echo.Echo, error) {
 		return nil, err
 	}
 
+	// Background jobs (can be moved to cmd/worker via RUN_WORKERS=false)
+	if cfg.RunWorkers {
+		runner, err := buildRunner(cfg, db)
+		if err != nil {
+			return nil, err
+		}
+		runner.Start(context.Background())
+	}
+
 	// Firebase Auth
 	firebaseAuth, err := auth.NewFirebaseAuth(
 		cfg.FirebaseProjectID,
@@ -34,14 +50,8 @@ func BuildServer(cfg config.Config) (*echo.Echo, error) {
 		return nil, err
 	}
 
-	// S3 Service
-	s3Service, err := storage.NewS3Service(
-		cfg.AWSRegion,
-		cfg.S3Bucket,
-		cfg.S3Endpoint,
-		cfg.S3AccessKey,
-		cfg.S3SecretKey,
-	)
+	// Object storage (S3, or local disk served by this server)
+	objectStorage, err := BuildObjectStorage(cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -59,6 +69,19 @@ func BuildServer(cfg config.Config) (*echo.Echo, error) {
 		return nil, err
 	}
 
+	// Web Push (disabled without VAPID keys)
+	pushSender, err := buildPushSender(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Discord / Slack incoming webhooks
+	webhookClient := webhook.NewClient()
+
+	// Live events (LISTEN/NOTIFY across instances)
+	broker := realtime.NewBroker(db, persistence.DSN(cfg))
+	go broker.Run(context.Background())
+
 	// Repositories
 	userRepo := persistence.NewUserRepository(db)
 	inviteRepo := persistence.NewInviteRepository(db)
@@ -66,26 +89,53 @@ func BuildServer(cfg config.Config) (*echo.Echo, error) {
 	groupMemberRepo := persistence.NewGroupMemberRepository(db)
 	albumRepo := persistence.NewAlbumRepository(db)
 	photoRepo := persistence.NewPhotoRepository(db)
+	photoUploadRepo := persistence.NewPhotoUploadRepository(db)
+	uploadSessionRepo := persistence.NewUploadSessionRepository(db)
 	postRepo := persistence.NewPostRepository(db)
 	tagRepo := persistence.NewTagRepository(db)
 	tripRepo := persistence.NewTripRepository(db)
 	itineraryRepo := persistence.NewTripItineraryRepository(db)
 	wishlistRepo := persistence.NewTripWishlistRepository(db)
 	expenseRepo := persistence.NewTripExpenseRepository(db)
+	settlementRepo := persistence.NewTripSettlementRepository(db)
 	tripRelationRepo := persistence.NewTripRelationRepository(db)
 	tripDetailRepo := persistence.NewTripDetailRepository(db)
+	exchangeRateRepo := persistence.NewExchangeRateRepository(db)
+	calendarFeedTokenRepo := persistence.NewCalendarFeedTokenRepository(db)
+	notificationRepo := persistence.NewNotificationRepository(db)
+	notificationSettingRepo := persistence.NewNotificationSettingRepository(db)
+	webPushSubscriptionRepo := persistence.NewWebPushSubscriptionRepository(db)
+	pushDeliveryRepo := persistence.NewPushDeliveryRepository(db)
+	webhookRepo := persistence.NewWebhookRepository(db)
+	webhookDeliveryRepo := persistence.NewWebhookDeliveryRepository(db)
+	trashRepo := persistence.NewTrashRepository(db)
+	searchRepo := persistence.NewSearchRepository(db)
+	uow := persistence.NewUnitOfWork(db)
 
 	// Usecases
+	events := event.NewBus()
+	pushUsecase := usecase.NewPushUsecase(webPushSubscriptionRepo, pushDeliveryRepo, pushSender)
+	webhookUsecase := usecase.NewWebhookUsecase(webhookRepo, webhookDeliveryRepo, groupRepo, userRepo, webhookClient, webhookClient, cfg.AppBaseURL)
+	notificationFanoutUsecase := usecase.NewNotificationFanoutUsecase(notificationRepo, notificationSettingRepo, groupMemberRepo, postRepo, userRepo, pushUsecase)
+	subscribeEventHandlers(events, webhookUsecase, notificationFanoutUsecase)
+	liveEventUsecase := usecase.NewLiveEventUsecase(broker, groupMemberRepo)
+	subscribeLiveEvents(events, liveEventUsecase)
 	userUsecase := usecase.NewUserUsecase(userRepo, firebaseAuth)
-	groupUsecase := usecase.NewGroupUsecase(groupRepo, groupMemberRepo)
+	groupUsecase := usecase.NewGroupUsecase(groupRepo, groupMemberRepo, uow, cfg.GroupDeletionGrace, cfg.UploadMaxBytes)
 	// Firebase Session Cookie の上限は 14 日
 	sessionTTL := 14 * 24 * time.Hour
 	authUsecase := usecase.NewAuthUsecase(firebaseAuth, userRepo, cfg.FirebaseAPIKey, sessionTTL, cfg.FrontendBaseURL, cfg.FirebaseProjectID)
-	inviteUsecase := usecase.NewInviteUsecase(inviteRepo, userRepo, groupRepo, groupMemberRepo, mailer)
+	inviteUsecase := usecase.NewInviteUsecase(inviteRepo, userRepo, groupRepo, groupMemberRepo, uow, mailer, events)
 	albumUsecase := usecase.NewAlbumUsecase(albumRepo, photoRepo)
-	photoUsecase := usecase.NewPhotoUsecase(photoRepo, albumRepo, s3Service)
-	postUsecase := usecase.NewPostUsecase(postRepo, tagRepo, albumRepo, photoRepo)
-	tripUsecase := usecase.NewTripUsecase(tripRepo, itineraryRepo, wishlistRepo, expenseRepo, tripRelationRepo, tripDetailRepo, albumRepo, postRepo)
+	photoUsecase := usecase.NewPhotoUsecase(photoRepo, albumRepo, groupRepo, photoUploadRepo, uow, objectStorage, imaging.Codec{}, events, cfg.UploadMaxBytes, cfg.UploadContentTypes)
+	uploadSessionUsecase := usecase.NewUploadSessionUsecase(uploadSessionRepo, uow, objectStorage, photoUsecase)
+	postUsecase := usecase.NewPostUsecase(postRepo, tagRepo, albumRepo, photoRepo, uow, events)
+	exchangeRateUsecase := usecase.NewExchangeRateUsecase(exchangeRateRepo, uow)
+	calendarUsecase := usecase.NewCalendarUsecase(tripRepo, tripDetailRepo, groupRepo, calendarFeedTokenRepo, uow, calendar.Encoder{})
+	notificationUsecase := usecase.NewNotificationUsecase(notificationRepo, notificationSettingRepo, webPushSubscriptionRepo, webpush.EndpointValidator{})
+	trashUsecase := usecase.NewTrashUsecase(trashRepo, events, cfg.TrashRetention)
+	searchUsecase := usecase.NewSearchUsecase(searchRepo)
+	tripUsecase := usecase.NewTripUsecase(tripRepo, itineraryRepo, wishlistRepo, expenseRepo, settlementRepo, exchangeRateRepo, tripRelationRepo, tripDetailRepo, albumRepo, postRepo, groupRepo, groupMemberRepo, uow, events)
 
 	// Handlers
 	userHandler := handler.NewUserHandler(userUsecase)
@@ -93,10 +143,22 @@ func BuildServer(cfg config.Config) (*echo.Echo, error) {
 	secureCookie := cfg.AppEnv == "production"
 	authHandler := handler.NewAuthHandler(authUsecase, secureCookie, sessionTTL, cfg.CookieDomain, cfg.EnableLocalStorageAuth)
 	inviteHandler := handler.NewInviteHandler(inviteUsecase, groupUsecase, userUsecase, authUsecase, secureCookie, sessionTTL, cfg.CookieDomain)
-	albumHandler := handler.NewAlbumHandler(albumUsecase)
+	albumHandler := handler.NewAlbumHandler(albumUsecase, photoUsecase)
 	photoHandler := handler.NewPhotoHandler(photoUsecase)
+	uploadSessionHandler := handler.NewUploadSessionHandler(uploadSessionUsecase, photoUsecase)
 	postHandler := handler.NewPostHandler(postUsecase)
 	tripHandler := handler.NewTripHandler(tripUsecase)
+	exchangeRateHandler := handler.NewExchangeRateHandler(exchangeRateUsecase)
+	calendarHandler := handler.NewCalendarHandler(calendarUsecase)
+	notificationHandler := handler.NewNotificationHandler(notificationUsecase, cfg.VAPIDPublicKey)
+	webhookHandler := handler.NewWebhookHandler(webhookUsecase)
+	liveEventHandler := handler.NewLiveEventHandler(liveEventUsecase)
+	trashHandler := handler.NewTrashHandler(trashUsecase)
+	searchHandler := handler.NewSearchHandler(searchUsecase)
+	var storageHandler *handler.StorageHandler
+	if localStorage, ok := objectStorage.(*storage.LocalStorage); ok {
+		storageHandler = handler.NewStorageHandler(localStorage)
+	}
 
 	// Middleware
 	authMiddleware := middleware.NewAuthMiddleware(firebaseAuth, userRepo, groupMemberRepo)
@@ -110,12 +172,34 @@ func BuildServer(cfg config.Config) (*echo.Echo, error) {
 		inviteHandler,
 		albumHandler,
 		photoHandler,
+		uploadSessionHandler,
 		postHandler,
 		tripHandler,
+		exchangeRateHandler,
+		calendarHandler,
+		notificationHandler,
+		webhookHandler,
+		liveEventHandler,
+		trashHandler,
+		searchHandler,
+		storageHandler,
 		authMiddleware,
 		cfg.FrontendBaseURL,
 		cfg.AllowedOrigins,
 		cfg.AllowedOriginSuffixes,
+		cfg.RequestTimeout,
 	)
 	return e, nil
 }
+
+// subscribeLiveEvents forwards content changes to open event streams. Only
+// the server has streams, so the worker does not subscribe these.
+func subscribeLiveEvents(events *event.Bus, liveEventUsecase *usecase.LiveEventUsecase) {
+	event.Subscribe(events, liveEventUsecase.PostCreated)
+	event.Subscribe(events, liveEventUsecase.PostChanged)
+	event.Subscribe(events, liveEventUsecase.CommentCreated)
+	event.Subscribe(events, liveEventUsecase.CommentDeleted)
+	event.Subscribe(events, liveEventUsecase.LikeChanged)
+	event.Subscribe(events, liveEventUsecase.PhotoChanged)
+	event.Subscribe(events, liveEventUsecase.TripChanged)
+}
@@ -0,0 +1,194 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"memoria/internal/adapter/imaging"
+	"memoria/internal/adapter/persistence"
+	"memoria/internal/adapter/storage"
+	"memoria/internal/adapter/webhook"
+	"memoria/internal/adapter/webpush"
+	"memoria/internal/config"
+	"memoria/internal/domain/event"
+	"memoria/internal/usecase"
+	"memoria/internal/worker"
+
+	"gorm.io/gorm"
+)
+
+// BuildWorker wires the background jobs for cmd/worker. Unlike the server it
+// never migrates the schema.
+func BuildWorker(cfg config.Config) (*worker.Runner, error) {
+	db, err := persistence.OpenDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return buildRunner(cfg, db)
+}
+
+func buildRunner(cfg config.Config, db *gorm.DB) (*worker.Runner, error) {
+	pushSender, err := buildPushSender(cfg)
+	if err != nil {
+		return nil, err
+	}
+	objectStorage, err := BuildObjectStorage(cfg)
+	if err != nil {
+		return nil, err
+	}
+	webhookClient := webhook.NewClient()
+
+	// Repositories
+	tripReminderRepo := persistence.NewTripReminderRepository(db)
+	groupMemberRepo := persistence.NewGroupMemberRepository(db)
+	notificationSettingRepo := persistence.NewNotificationSettingRepository(db)
+	webPushSubscriptionRepo := persistence.NewWebPushSubscriptionRepository(db)
+	pushDeliveryRepo := persistence.NewPushDeliveryRepository(db)
+	webhookRepo := persistence.NewWebhookRepository(db)
+	webhookDeliveryRepo := persistence.NewWebhookDeliveryRepository(db)
+	groupRepo := persistence.NewGroupRepository(db)
+	userRepo := persistence.NewUserRepository(db)
+	notificationRepo := persistence.NewNotificationRepository(db)
+	postRepo := persistence.NewPostRepository(db)
+	trashRepo := persistence.NewTrashRepository(db)
+	objectDeletionRepo := persistence.NewObjectDeletionRepository(db)
+	photoRepo := persistence.NewPhotoRepository(db)
+	albumRepo := persistence.NewAlbumRepository(db)
+	photoUploadRepo := persistence.NewPhotoUploadRepository(db)
+	uploadSessionRepo := persistence.NewUploadSessionRepository(db)
+	uow := persistence.NewUnitOfWork(db)
+
+	// Usecases
+	pushUsecase := usecase.NewPushUsecase(webPushSubscriptionRepo, pushDeliveryRepo, pushSender)
+	webhookUsecase := usecase.NewWebhookUsecase(webhookRepo, webhookDeliveryRepo, groupRepo, userRepo, webhookClient, webhookClient, cfg.AppBaseURL)
+	notificationFanoutUsecase := usecase.NewNotificationFanoutUsecase(notificationRepo, notificationSettingRepo, groupMemberRepo, postRepo, userRepo, pushUsecase)
+	events := event.NewBus()
+	subscribeEventHandlers(events, webhookUsecase, notificationFanoutUsecase)
+	tripReminderUsecase := usecase.NewTripReminderUsecase(tripReminderRepo, groupMemberRepo, notificationSettingRepo, pushUsecase, events, cfg.TripReminderMaxDelay)
+	groupUsecase := usecase.NewGroupUsecase(groupRepo, groupMemberRepo, uow, cfg.GroupDeletionGrace, cfg.UploadMaxBytes)
+	trashUsecase := usecase.NewTrashUsecase(trashRepo, events, cfg.TrashRetention)
+	objectCleanupUsecase := usecase.NewObjectCleanupUsecase(objectDeletionRepo, objectStorage)
+	photoVariantUsecase := usecase.NewPhotoVariantUsecase(photoRepo, objectStorage, imaging.Codec{})
+	photoUsecase := usecase.NewPhotoUsecase(photoRepo, albumRepo, groupRepo, photoUploadRepo, uow, objectStorage, imaging.Codec{}, events, cfg.UploadMaxBytes, cfg.UploadContentTypes)
+	uploadSessionUsecase := usecase.NewUploadSessionUsecase(uploadSessionRepo, uow, objectStorage, photoUsecase)
+
+	return worker.NewRunner(
+		worker.Job{
+			Name:     "trip-reminder",
+			Interval: cfg.TripReminderInterval,
+			Run: func(ctx context.Context) error {
+				_, err := tripReminderUsecase.SendDueReminders(ctx, time.Now())
+				return err
+			},
+		},
+		worker.Job{
+			Name:     "push-retry",
+			Interval: cfg.PushRetryInterval,
+			Run: func(ctx context.Context) error {
+				_, err := pushUsecase.RetryDue(ctx, time.Now())
+				return err
+			},
+		},
+		worker.Job{
+			Name:     "webhook-retry",
+			Interval: cfg.WebhookRetryInterval,
+			Run: func(ctx context.Context) error {
+				_, err := webhookUsecase.RetryDue(ctx, time.Now())
+				return err
+			},
+		},
+		worker.Job{
+			Name:     "group-purge",
+			Interval: cfg.GroupPurgeInterval,
+			Run: func(ctx context.Context) error {
+				_, err := groupUsecase.PurgeDueGroups(ctx, time.Now())
+				return err
+			},
+		},
+		worker.Job{
+			Name:     "trash-purge",
+			Interval: cfg.TrashPurgeInterval,
+			Run: func(ctx context.Context) error {
+				_, err := trashUsecase.PurgeExpired(ctx, time.Now())
+				return err
+			},
+		},
+		worker.Job{
+			Name:     "object-cleanup",
+			Interval: cfg.ObjectCleanupInterval,
+			Run: func(ctx context.Context) error {
+				_, err := objectCleanupUsecase.DeleteDue(ctx, time.Now())
+				return err
+			},
+		},
+		worker.Job{
+			Name:     "photo-variants",
+			Interval: cfg.PhotoVariantsInterval,
+			Run: func(ctx context.Context) error {
+				_, err := photoVariantUsecase.ProcessPending(ctx, time.Now())
+				return err
+			},
+		},
+		worker.Job{
+			Name:     "upload-sweep",
+			Interval: cfg.UploadSweepInterval,
+			Run: func(ctx context.Context) error {
+				_, err := photoUsecase.SweepExpiredUploads(ctx, time.Now())
+				return err
+			},
+		},
+		worker.Job{
+			Name:     "upload-session-sweep",
+			Interval: cfg.UploadSessionSweepInterval,
+			Run: func(ctx context.Context) error {
+				_, err := uploadSessionUsecase.SweepExpired(ctx, time.Now())
+				return err
+			},
+		},
+	), nil
+}
+
+// subscribeEventHandlers wires the usecases that react to domain events.
+func subscribeEventHandlers(events *event.Bus, webhookUsecase *usecase.WebhookUsecase, notificationFanoutUsecase *usecase.NotificationFanoutUsecase) {
+	event.Subscribe(events, notificationFanoutUsecase.PostCreated)
+	event.Subscribe(events, notificationFanoutUsecase.CommentCreated)
+	event.Subscribe(events, webhookUsecase.PostCreated)
+	event.Subscribe(events, webhookUsecase.CommentCreated)
+	event.Subscribe(events, webhookUsecase.TripReminderSent)
+	event.Subscribe(events, webhookUsecase.MemberJoined)
+}
+
+// buildPushSender returns nil when VAPID keys are not configured, which
+// turns push delivery off.
+func buildPushSender(cfg config.Config) (usecase.PushSender, error) {
+	if cfg.VAPIDPublicKey == "" || cfg.VAPIDPrivateKey == "" {
+		log.Println("VAPID keys are not set, web push is disabled")
+		return nil, nil
+	}
+	return webpush.NewSender(cfg.VAPIDPublicKey, cfg.VAPIDPrivateKey, cfg.VAPIDSubject)
+}
+
+// BuildObjectStorage returns the storage driver chosen by STORAGE_DRIVER.
+// The local driver's URLs point at this server's /api/storage routes.
+func BuildObjectStorage(cfg config.Config) (usecase.ObjectStorage, error) {
+	if cfg.StorageDriver == "local" {
+		// Signed URLs must verify on every server and survive restarts.
+		if cfg.StorageLocalSecret == "" {
+			return nil, errors.New("STORAGE_LOCAL_SECRET is required when STORAGE_DRIVER=local")
+		}
+		baseURL := cfg.APIBaseURL
+		if baseURL == "" {
+			baseURL = "http://localhost:" + cfg.AppPort
+		}
+		return storage.NewLocalStorage(cfg.StorageLocalDir, baseURL, cfg.StorageLocalSecret)
+	}
+	return storage.NewS3Service(
+		cfg.AWSRegion,
+		cfg.S3Bucket,
+		cfg.S3Endpoint,
+		cfg.S3AccessKey,
+		cfg.S3SecretKey,
+	)
+}
@@ -0,0 +1,146 @@
+// Package event carries domain events from the usecases that cause them to
+// the usecases that react to them, so a post does not need to know about
+// notifications or webhooks.
+package event
+
+import (
+	"context"
+	"log"
+	"reflect"
+	"sync"
+
+	"memoria/internal/domain/model"
+)
+
+// Event is anything published on a Bus. Handlers subscribe by type.
+type Event interface {
+	isEvent()
+}
+
+// PostCreated is published after a post and its tags are saved.
+type PostCreated struct {
+	Post *model.Post
+}
+
+// CommentCreated is published after a comment is saved.
+type CommentCreated struct {
+	Post    *model.Post
+	Comment *model.PostComment
+}
+
+// MemberJoined is published when a user joins a group through an invite.
+type MemberJoined struct {
+	GroupID uint
+	User    *model.User
+}
+
+// TripReminderSent is published once a trip's reminder has been recorded.
+type TripReminderSent struct {
+	Trip *model.Trip
+}
+
+// Action says what happened to the resource in a *Changed event.
+type Action string
+
+const (
+	ActionCreated Action = "created"
+	ActionUpdated Action = "updated"
+	ActionDeleted Action = "deleted"
+)
+
+// PostChanged is published when a post is edited, including its tags and
+// attached albums and photos, or deleted. New posts publish PostCreated.
+type PostChanged struct {
+	GroupID uint
+	PostID  uint
+	Action  Action
+}
+
+// CommentDeleted is published after a comment is removed.
+type CommentDeleted struct {
+	GroupID   uint
+	PostID    uint
+	CommentID uint
+}
+
+// LikeChanged is published when a user likes a post or takes the like back.
+type LikeChanged struct {
+	GroupID uint
+	PostID  uint
+	UserID  uint
+	Liked   bool
+}
+
+// PhotoChanged is published when a photo is added to or removed from an album.
+type PhotoChanged struct {
+	GroupID uint
+	AlbumID uint
+	PhotoID uint
+	Action  Action
+}
+
+// TripChanged is published when a trip or one of its sections changes.
+// Section is "trip" for the trip itself, otherwise the name of the detail
+// list such as "schedule" or "expenses".
+type TripChanged struct {
+	GroupID uint
+	TripID  uint
+	Section string
+	Action  Action
+}
+
+func (PostCreated) isEvent()      {}
+func (CommentCreated) isEvent()   {}
+func (MemberJoined) isEvent()     {}
+func (TripReminderSent) isEvent() {}
+func (PostChanged) isEvent()      {}
+func (CommentDeleted) isEvent()   {}
+func (LikeChanged) isEvent()      {}
+func (PhotoChanged) isEvent()     {}
+func (TripChanged) isEvent()      {}
+
+// Bus delivers each published event to its subscribers. Handlers run in
+// their own goroutine so publishing never waits on them.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[reflect.Type][]func(context.Context, Event)
+}
+
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[reflect.Type][]func(context.Context, Event))}
+}
+
+// Subscribe registers handler for events of type E.
+func Subscribe[E Event](b *Bus, handler func(context.Context, E)) {
+	var zero E
+	key := reflect.TypeOf(zero)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[key] = append(b.handlers[key], func(ctx context.Context, e Event) {
+		handler(ctx, e.(E))
+	})
+}
+
+// Publish hands e to every handler subscribed to its type. Handlers get
+// ctx without its cancellation: they outlive the request that published
+// the event but keep its values. A nil Bus drops events.
+func (b *Bus) Publish(ctx context.Context, e Event) {
+	if b == nil {
+		return
+	}
+	b.mu.RLock()
+	handlers := b.handlers[reflect.TypeOf(e)]
+	b.mu.RUnlock()
+
+	ctx = context.WithoutCancel(ctx)
+	for _, handler := range handlers {
+		go func(handler func(context.Context, Event)) {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("event: handler for %T panicked: %v", e, r)
+				}
+			}()
+			handler(ctx, e)
+		}(handler)
+	}
+}
@@ -18,12 +18,28 @@ type User struct {
 	LastAccessAt *time.Time `gorm:"index"`
 }
 
+// Group is deleted in two steps: DeletedAt hides it from its members and
+// PurgeAfter, a grace period later, is when its content is removed for good.
+// Until then the creator can restore it.
 type Group struct {
 	BaseModel
-	Name      string `gorm:"not null"`
-	CreatedBy uint   `gorm:"not null"`
+	Name            string `gorm:"not null"`
+	Description     string
+	DefaultCurrency string     `gorm:"not null;default:JPY"` // preset for new trips
+	CreatedBy       uint       `gorm:"not null"`
+	DeletedAt       *time.Time `gorm:"index"`
+	PurgeAfter      *time.Time `gorm:"index"`
+	// UploadMaxBytes lowers the server's upload limit for the group; nil
+	// uses the server's.
+	UploadMaxBytes *int64
 }
 
+// Group member roles.
+const (
+	RoleManager = "manager"
+	RoleMember  = "member"
+)
+
 type GroupMember struct {
 	GroupID  uint      `gorm:"primaryKey"`
 	UserID   uint      `gorm:"primaryKey"`
@@ -49,6 +65,7 @@ type Album struct {
 	Description string
 	CoverPhotoID *uint
 	CreatedBy   uint `gorm:"not null"`
+	DeletedAt   *time.Time `gorm:"index"` // in the trash since
 }
 
 type Photo struct {
@@ -61,6 +78,86 @@ type Photo struct {
 	Width       int
 	Height      int
 	UploadedBy  uint `gorm:"not null"`
+	DeletedAt   *time.Time `gorm:"index"` // in the trash since
+	// Status tracks the photo-variants job: Width and Height are the real
+	// dimensions once the photo is ready or original_only. original_only
+	// photos can be measured but not decoded, so they have no variants.
+	Status             string `gorm:"not null;default:pending"` // pending, ready, original_only, failed
+	ProcessingAttempts int    `gorm:"not null;default:0"`
+	ProcessingError    string
+	ProcessAfter       time.Time `gorm:"not null"` // next attempt while pending
+}
+
+// PhotoFormats maps the content types a photo can be registered with to
+// the image format its file has to be. Uploads of any other type could
+// never become photos.
+var PhotoFormats = map[string]string{
+	"image/jpeg": "jpeg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+	"image/webp": "webp",
+	"image/heic": "heic",
+}
+
+const (
+	PhotoPending      = "pending"
+	PhotoReady        = "ready"
+	PhotoOriginalOnly = "original_only"
+	PhotoFailed       = "failed"
+)
+
+// PhotoVariant is a resized JPEG copy of a photo, stored next to the
+// original in S3.
+type PhotoVariant struct {
+	BaseModel
+	PhotoID   uint   `gorm:"not null;uniqueIndex:idx_photo_variants_photo_name"`
+	Name      string `gorm:"not null;uniqueIndex:idx_photo_variants_photo_name"` // thumb, medium, large
+	S3Key     string `gorm:"uniqueIndex;not null"`
+	Width     int    `gorm:"not null"`
+	Height    int    `gorm:"not null"`
+	SizeBytes int64  `gorm:"not null"`
+}
+
+// PhotoUpload is an S3 key handed out for an upload that has not been
+// registered as a photo yet. Registering the photo consumes it; once it
+// expires the upload-sweep job queues the object for deletion.
+type PhotoUpload struct {
+	BaseModel
+	GroupID     uint      `gorm:"not null"`
+	AlbumID     uint      `gorm:"not null"`
+	UserID      uint      `gorm:"not null"`
+	S3Key       string    `gorm:"uniqueIndex;not null"`
+	ContentType string    `gorm:"not null"`
+	SizeBytes   int64     `gorm:"not null"` // signed into the upload URL
+	ExpiresAt   time.Time `gorm:"not null;index"`
+}
+
+// UploadSession is a multipart upload of one large file, such as a video,
+// that the client can resume part by part. It also issues a PhotoUpload for
+// its key, so completing it registers the photo the same way as a single
+// upload. Sessions not completed by ExpiresAt are aborted by the
+// upload-session-sweep job.
+type UploadSession struct {
+	BaseModel
+	GroupID     uint       `gorm:"not null"`
+	AlbumID     uint       `gorm:"not null"`
+	UserID      uint       `gorm:"not null"`
+	S3Key       string     `gorm:"uniqueIndex;not null"`
+	UploadID    string     `gorm:"not null"` // the storage's multipart upload ID
+	ContentType string     `gorm:"not null"`
+	SizeBytes   int64      `gorm:"not null"`
+	PartSize    int64      `gorm:"not null"` // every part but the last
+	CompletedAt *time.Time // the parts have been joined into the object
+	ExpiresAt   time.Time  `gorm:"not null;index"`
+}
+
+// UploadSessionPart is a part the client has uploaded, with the ETag the
+// storage returned for it.
+type UploadSessionPart struct {
+	SessionID  uint      `gorm:"primaryKey"`
+	PartNumber int       `gorm:"primaryKey"`
+	ETag       string    `gorm:"not null"`
+	CreatedAt  time.Time `gorm:"not null"`
 }
 
 type Post struct {
@@ -71,6 +168,7 @@ type Post struct {
 	Body        string    `gorm:"not null"`
 	AuthorID    uint      `gorm:"not null"`
 	PublishedAt time.Time `gorm:"not null"`
+	DeletedAt   *time.Time `gorm:"index"` // in the trash since
 }
 
 type AlbumPost struct {
@@ -107,32 +205,86 @@ type PostComment struct {
 	PostID   uint   `gorm:"not null;index"`
 	UserID   uint   `gorm:"not null"`
 	Body     string `gorm:"not null"`
+	DeletedAt *time.Time `gorm:"index"` // in the trash since
 }
 
 type NotificationSetting struct {
 	BaseModel
-	UserID   uint   `gorm:"not null;index"`
-	Category string `gorm:"not null"` // new_post, new_comment, trip
+	UserID   uint   `gorm:"not null;index;uniqueIndex:idx_notification_settings_user_category"`
+	Category string `gorm:"not null;uniqueIndex:idx_notification_settings_user_category"` // new_post, new_comment, anniversary, trip
 	Enabled  bool   `gorm:"not null"`
 }
 
+// Notification is an in-app notification. Unread notifications with the same
+// GroupKey are folded into one whose Count says how many events it covers.
 type Notification struct {
 	BaseModel
-	UserID   uint   `gorm:"not null;index"`
+	UserID   uint   `gorm:"not null;index;index:idx_notifications_user_group_key"`
 	Category string `gorm:"not null"`
 	Title    string `gorm:"not null"`
 	Body     string `gorm:"not null"`
+	GroupKey string `gorm:"index:idx_notifications_user_group_key"` // e.g. comments:<post id>; empty never groups
+	Count    int    `gorm:"not null;default:1"`
 	ReadAt   *time.Time
 }
 
 type WebPushSubscription struct {
 	BaseModel
 	UserID    uint   `gorm:"not null;index"`
-	Endpoint  string `gorm:"not null"`
+	Endpoint  string `gorm:"not null;uniqueIndex"`
 	Auth      string `gorm:"not null"`
 	P256dh    string `gorm:"not null"`
 }
 
+// PushDelivery is a web push message waiting to be sent to a subscription.
+// It is deleted once sent or given up on; until then the push-retry job
+// retries it when NextAttemptAt passes.
+type PushDelivery struct {
+	BaseModel
+	SubscriptionID uint      `gorm:"not null;index"`
+	Payload        string    `gorm:"not null"`
+	Attempts       int       `gorm:"not null;default:0"`
+	LastError      string
+	NextAttemptAt  time.Time `gorm:"not null;index"`
+}
+
+// GroupWebhook posts group events to a Discord or Slack incoming webhook.
+type GroupWebhook struct {
+	BaseModel
+	GroupID   uint   `gorm:"not null;index"`
+	Platform  string `gorm:"not null"` // discord, slack
+	Name      string `gorm:"not null"`
+	URL       string `gorm:"not null"`
+	Events    string `gorm:"not null"` // comma separated: new_post, new_comment, trip_reminder, new_member
+	Enabled   bool   `gorm:"not null;default:true"`
+	CreatedBy uint   `gorm:"not null"`
+}
+
+// WebhookDelivery is one message sent to a GroupWebhook. Pending rows are
+// retried once NextAttemptAt passes.
+type WebhookDelivery struct {
+	BaseModel
+	WebhookID      uint   `gorm:"not null;index"`
+	Event          string `gorm:"not null"`
+	Payload        string `gorm:"not null"`
+	Status         string `gorm:"not null;default:pending"` // pending, succeeded, failed
+	Attempts       int    `gorm:"not null;default:0"`
+	ResponseStatus int
+	Error          string
+	NextAttemptAt  *time.Time `gorm:"index"`
+	DeliveredAt    *time.Time
+}
+
+// CalendarFeedToken authenticates a user's iCalendar subscription URL.
+// Only the SHA-256 hash of the token is stored.
+type CalendarFeedToken struct {
+	BaseModel
+	UserID     uint   `gorm:"not null;index"`
+	TokenHash  string `gorm:"uniqueIndex;not null"`
+	RevokedAt  *time.Time
+	LastUsedAt *time.Time
+}
+
 type Trip struct {
 	BaseModel
 	GroupID     uint      `gorm:"not null;index"`
@@ -141,7 +293,19 @@ type Trip struct {
 	EndAt       time.Time `gorm:"not null"`
 	Note        string
 	CreatedBy   uint `gorm:"not null"`
-	NotifyAt    *time.Time
+	NotifyAt    *time.Time `gorm:"index"`
+	BaseCurrency string `gorm:"not null;default:JPY"` // currency budgets and expenses are totalled in
+	DeletedAt   *time.Time `gorm:"index"` // in the trash since
+}
+
+// TripReminder records that the reminder for a trip's NotifyAt was sent.
+// Editing NotifyAt schedules a new reminder; the same time never fires twice.
+type TripReminder struct {
+	BaseModel
+	TripID     uint      `gorm:"not null;uniqueIndex:idx_trip_reminders_trip_notify"`
+	NotifyAt   time.Time `gorm:"not null;uniqueIndex:idx_trip_reminders_trip_notify"`
+	SentAt     time.Time `gorm:"not null"`
+	Recipients int       `gorm:"not null"`
 }
 
 type TripItinerary struct {
@@ -165,13 +329,32 @@ type TripWishlist struct {
 
 type TripExpense struct {
 	BaseModel
-	TripID   uint   `gorm:"not null;index"`
-	Title    string `gorm:"not null"`
-	Category string `gorm:"not null"`
-	Amount   int64  `gorm:"not null"`
-	Currency string `gorm:"not null"`
-	IsActual bool   `gorm:"not null"`
-	Note     string
+	TripID    uint   `gorm:"not null;index"`
+	Title     string `gorm:"not null"`
+	Category  string `gorm:"not null"`
+	Amount    int64  `gorm:"not null"`
+	Currency  string `gorm:"not null"`
+	IsActual  bool   `gorm:"not null"`
+	Note      string
+	PaidBy    *uint  `gorm:"index"`                  // user who paid; nil for planned costs
+	SplitType string `gorm:"not null;default:equal"` // equal, shares, fixed
+}
+
+type TripExpenseParticipant struct {
+	ExpenseID uint  `gorm:"primaryKey"`
+	UserID    uint  `gorm:"primaryKey"`
+	Shares    int   `gorm:"not null;default:1"` // used by the shares split
+	Amount    int64 `gorm:"not null;default:0"` // used by the fixed split
+}
+
+// TripSettlement records a transfer between members that has been paid back.
+type TripSettlement struct {
+	BaseModel
+	TripID     uint  `gorm:"not null;index"`
+	FromUserID uint  `gorm:"not null"`
+	ToUserID   uint  `gorm:"not null"`
+	Amount     int64 `gorm:"not null"`
+	SettledBy  uint  `gorm:"not null"`
 }
 
 type TripAlbum struct {
@@ -221,6 +404,7 @@ type TripTransport struct {
 	HighwayCostYen         int64
 	RentalFeeYen           int64
 	FareYen                int64
+	Currency               string `gorm:"not null;default:JPY"` // currency of the ...Yen amounts
 }
 
 type TripLodging struct {
@@ -234,11 +418,80 @@ type TripLodging struct {
 	CheckOut          string // HH:MM
 	ReservationNumber string
 	CostYen           int64
+	Currency          string `gorm:"not null;default:JPY"` // currency of CostYen
 }
 
 type TripBudgetItem struct {
 	BaseModel
-	TripID  uint   `gorm:"not null;index"`
-	Name    string `gorm:"not null"`
-	CostYen int64  `gorm:"not null"`
+	TripID   uint   `gorm:"not null;index"`
+	Name     string `gorm:"not null"`
+	CostYen  int64  `gorm:"not null"`
+	Currency string `gorm:"not null;default:JPY"` // currency of CostYen
+}
+
+// ExchangeRate is a manually entered rate: 1 FromCurrency = Rate ToCurrency,
+// valid from EffectiveDate until a newer rate for the same pair.
+type ExchangeRate struct {
+	BaseModel
+	GroupID       uint    `gorm:"not null;uniqueIndex:idx_exchange_rates_pair"`
+	FromCurrency  string  `gorm:"not null;uniqueIndex:idx_exchange_rates_pair"`
+	ToCurrency    string  `gorm:"not null;uniqueIndex:idx_exchange_rates_pair"`
+	EffectiveDate string  `gorm:"not null;uniqueIndex:idx_exchange_rates_pair"` // YYYY-MM-DD
+	Rate          float64 `gorm:"not null"`
+	CreatedBy     uint    `gorm:"not null"`
+}
+
+// Kinds of content that go to the trash when deleted.
+const (
+	TrashAlbum   = "album"
+	TrashPhoto   = "photo"
+	TrashPost    = "post"
+	TrashComment = "comment"
+	TrashTrip    = "trip"
+)
+
+// TrashItem is a deleted album, photo, post, comment or trip as shown in the
+// group's trash. It is read from the content tables, not stored itself.
+type TrashItem struct {
+	Type     string
+	ID       uint
+	GroupID  uint
+	ParentID uint // album of a photo, post of a comment
+	// ParentDeleted is set when the parent is in the trash too; the item
+	// cannot be restored on its own then.
+	ParentDeleted bool
+	Title         string
+	AuthorID      uint
+	DeletedAt     time.Time
+}
+
+// ObjectDeletion queues an S3 object whose rows are gone. It is written in
+// the same transaction that deletes the rows, and the object-cleanup job
+// removes the object afterwards, retrying until it succeeds.
+type ObjectDeletion struct {
+	BaseModel
+	S3Key         string    `gorm:"uniqueIndex;not null"`
+	Attempts      int       `gorm:"not null;default:0"`
+	LastError     string
+	NextAttemptAt time.Time `gorm:"not null;index"`
+}
+
+const (
+	SearchPost         = "post"
+	SearchComment      = "comment"
+	SearchAlbum        = "album"
+	SearchTrip         = "trip"
+	SearchScheduleItem = "schedule_item"
+)
+
+// SearchResult is a post, comment, album, trip or schedule item matching a
+// search. Like TrashItem it is read from the content tables.
+type SearchResult struct {
+	Type     string
+	ID       uint
+	ParentID uint   // post of a comment, trip of a schedule item
+	Title    string // the parent's title for comments and schedule items
+	Text     string // body, description, note or content
+	Rank     float64
+	Date     time.Time // published_at for posts, created_at otherwise
 }
@@ -1,11 +1,18 @@
 package repository
 
-import "memoria/internal/domain/model"
+import (
+	"context"
+
+	"memoria/internal/domain/model"
+)
 
 type AlbumRepository interface {
-	Create(album *model.Album) error
-	FindByID(id uint, groupID uint) (*model.Album, error)
-	FindAll(groupID uint) ([]*model.Album, error)
-	Update(album *model.Album) error
-	Delete(id uint) error
+	Create(ctx context.Context, album *model.Album) error
+	FindByID(ctx context.Context, id uint, groupID uint) (*model.Album, error)
+	// FindAll lists the group's albums, newest first by default. It filters
+	// by creator and creation date.
+	FindAll(ctx context.Context, groupID uint, opts ListOptions) (*Page[*model.Album], error)
+	Update(ctx context.Context, album *model.Album) error
+	// Delete moves the album and its photos to the trash.
+	Delete(ctx context.Context, id uint) error
 }
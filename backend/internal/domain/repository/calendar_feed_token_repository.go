@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"memoria/internal/domain/model"
+)
+
+type CalendarFeedTokenRepository interface {
+	Create(ctx context.Context, token *model.CalendarFeedToken) error
+	// FindActiveByUserID returns nil without an error when the user has no active token.
+	FindActiveByUserID(ctx context.Context, userID uint) (*model.CalendarFeedToken, error)
+	FindActiveByHash(ctx context.Context, tokenHash string) (*model.CalendarFeedToken, error)
+	RevokeByUserID(ctx context.Context, userID uint, revokedAt time.Time) error
+	UpdateLastUsed(ctx context.Context, id uint, usedAt time.Time) error
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+
+	"memoria/internal/domain/model"
+)
+
+type ExchangeRateRepository interface {
+	FindByGroupID(ctx context.Context, groupID uint) ([]*model.ExchangeRate, error)
+	FindByID(ctx context.Context, id uint, groupID uint) (*model.ExchangeRate, error)
+	Upsert(ctx context.Context, rate *model.ExchangeRate) error
+	Delete(ctx context.Context, id uint) error
+}
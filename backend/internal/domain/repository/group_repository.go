@@ -1,17 +1,45 @@
 package repository
 
-import "memoria/internal/domain/model"
+import (
+	"context"
+	"time"
+
+	"memoria/internal/domain/model"
+)
 
 type GroupRepository interface {
-	Create(group *model.Group) error
-	FindByID(id uint) (*model.Group, error)
-	FindByUserID(userID uint) ([]*model.Group, error)
+	Create(ctx context.Context, group *model.Group) error
+	// FindByID also returns groups scheduled for deletion; check DeletedAt.
+	FindByID(ctx context.Context, id uint) (*model.Group, error)
+	// FindByUserID returns the user's groups that are not scheduled for
+	// deletion.
+	FindByUserID(ctx context.Context, userID uint) ([]*model.Group, error)
+	// FindDeletedByCreator returns the groups the user created that are
+	// scheduled for deletion and can still be restored.
+	FindDeletedByCreator(ctx context.Context, userID uint) ([]*model.Group, error)
+	FindDueForPurge(ctx context.Context, now time.Time, limit int) ([]*model.Group, error)
+	Update(ctx context.Context, group *model.Group) error
+	// TransferOwnership makes userID the group's creator and a manager.
+	TransferOwnership(ctx context.Context, groupID, userID uint) error
+	// Purge removes the group and everything in it, and queues its photo
+	// objects for deletion.
+	Purge(ctx context.Context, groupID uint) error
 }
 
 type GroupMemberRepository interface {
-	Add(member *model.GroupMember) error
-	FindByGroupID(groupID uint) ([]*model.GroupMember, error)
-	FindByGroupAndUser(groupID, userID uint) (*model.GroupMember, error)
-	Update(member *model.GroupMember) error
-	Delete(groupID, userID uint) error
+	Add(ctx context.Context, member *model.GroupMember) error
+	FindByGroupID(ctx context.Context, groupID uint) ([]*model.GroupMember, error)
+	FindByGroupAndUser(ctx context.Context, groupID, userID uint) (*model.GroupMember, error)
+	// FindActiveMembership is FindByGroupAndUser for groups that are not
+	// scheduled for deletion.
+	FindActiveMembership(ctx context.Context, groupID, userID uint) (*model.GroupMember, error)
+	Update(ctx context.Context, member *model.GroupMember) error
+	// UpdateRole changes a member's role. It returns false without changing
+	// anything when that would leave the group without a manager.
+	UpdateRole(ctx context.Context, groupID, userID uint, role string) (bool, error)
+	Delete(ctx context.Context, groupID, userID uint) error
+	// Remove takes a member out of the group. Like UpdateRole it returns
+	// false without changing anything when that would remove the last
+	// manager.
+	Remove(ctx context.Context, groupID, userID uint) (bool, error)
 }
@@ -1,12 +1,16 @@
 package repository
 
-import "memoria/internal/domain/model"
+import (
+	"context"
+
+	"memoria/internal/domain/model"
+)
 
 type InviteRepository interface {
-	Create(invite *model.Invite) error
-	FindByToken(token string) (*model.Invite, error)
-	FindByID(id uint) (*model.Invite, error)
-	FindByGroupID(groupID uint) ([]*model.Invite, error)
-	Update(invite *model.Invite) error
-	Delete(id uint) error
+	Create(ctx context.Context, invite *model.Invite) error
+	FindByToken(ctx context.Context, token string) (*model.Invite, error)
+	FindByID(ctx context.Context, id uint) (*model.Invite, error)
+	FindByGroupID(ctx context.Context, groupID uint) ([]*model.Invite, error)
+	Update(ctx context.Context, invite *model.Invite) error
+	Delete(ctx context.Context, id uint) error
 }
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// ErrInvalidCursor is returned for a cursor that was not issued by the list
+// it is passed to.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+type SortOrder string
+
+const (
+	// SortDefault uses the list's own order, noted on each method.
+	SortDefault SortOrder = ""
+	SortNewest  SortOrder = "newest"
+	SortOldest  SortOrder = "oldest"
+)
+
+type TripTiming string
+
+const (
+	// TripUpcoming matches trips that have not ended yet.
+	TripUpcoming TripTiming = "upcoming"
+	// TripPast matches trips that have ended.
+	TripPast TripTiming = "past"
+)
+
+// ListOptions pages and filters a list. Every list takes the same options
+// and ignores filters that do not apply to it. Lists are ordered by a date
+// column with ties broken by ID, so pages never skip or repeat a row.
+type ListOptions struct {
+	// Cursor is the NextCursor of the previous page, empty for the first.
+	Cursor string
+	// Limit is the page size, see PageSize.
+	Limit int
+	Sort  SortOrder
+
+	// Type filters posts by type.
+	Type string
+	// AuthorID filters by who created the row.
+	AuthorID uint
+	// TagID filters posts by tag.
+	TagID uint
+	// From and To bound the list's date column to [From, To).
+	From *time.Time
+	To   *time.Time
+	// Timing filters trips by whether they have ended.
+	Timing TripTiming
+}
+
+// PageSize is Limit clamped to 1..MaxPageSize, DefaultPageSize when unset.
+func (o ListOptions) PageSize() int {
+	if o.Limit <= 0 {
+		return DefaultPageSize
+	}
+	if o.Limit > MaxPageSize {
+		return MaxPageSize
+	}
+	return o.Limit
+}
+
+// Page is one page of a list. NextCursor is empty on the last page.
+type Page[T any] struct {
+	Items      []T
+	NextCursor string
+}
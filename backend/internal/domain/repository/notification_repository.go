@@ -1,20 +1,51 @@
 package repository
 
-import "memoria/internal/domain/model"
+import (
+	"context"
+	"time"
+
+	"memoria/internal/domain/model"
+)
 
 type NotificationRepository interface {
-	Create(notification *model.Notification) error
-	FindByUserID(userID uint) ([]*model.Notification, error)
-	MarkAsRead(id uint) error
+	Create(ctx context.Context, notification *model.Notification) error
+	// CreateGrouped stores notifications in one transaction. One with a
+	// GroupKey replaces the recipient's unread notification with the same
+	// key, adding its Count, so the group moves back to the top.
+	CreateGrouped(ctx context.Context, notifications []*model.Notification) error
+	// FindByUserID returns up to limit notifications, newest first, with an
+	// ID below beforeID (0 means from the newest).
+	FindByUserID(ctx context.Context, userID uint, beforeID uint, limit int, unreadOnly bool) ([]*model.Notification, error)
+	FindByID(ctx context.Context, id uint, userID uint) (*model.Notification, error)
+	CountUnread(ctx context.Context, userID uint) (int64, error)
+	MarkAsRead(ctx context.Context, id uint) error
+	MarkAllAsRead(ctx context.Context, userID uint) (int64, error)
+	Delete(ctx context.Context, id uint) error
 }
 
 type NotificationSettingRepository interface {
-	FindByUserID(userID uint) ([]*model.NotificationSetting, error)
-	Upsert(setting *model.NotificationSetting) error
+	FindByUserID(ctx context.Context, userID uint) ([]*model.NotificationSetting, error)
+	Upsert(ctx context.Context, setting *model.NotificationSetting) error
+	FindByCategory(ctx context.Context, category string, userIDs []uint) ([]*model.NotificationSetting, error)
 }
 
 type WebPushSubscriptionRepository interface {
-	Create(subscription *model.WebPushSubscription) error
-	FindByUserID(userID uint) ([]*model.WebPushSubscription, error)
-	Delete(id uint) error
+	Upsert(ctx context.Context, subscription *model.WebPushSubscription) error
+	FindByUserID(ctx context.Context, userID uint) ([]*model.WebPushSubscription, error)
+	// FindByIDs loads subscriptions across users; used by the retry worker.
+	FindByIDs(ctx context.Context, ids []uint) ([]*model.WebPushSubscription, error)
+	FindByID(ctx context.Context, id uint, userID uint) (*model.WebPushSubscription, error)
+	FindByEndpoint(ctx context.Context, endpoint string, userID uint) (*model.WebPushSubscription, error)
+	Delete(ctx context.Context, id uint) error
+}
+
+// PushDeliveryRepository is the queue of web push messages not sent yet.
+type PushDeliveryRepository interface {
+	Create(ctx context.Context, deliveries []*model.PushDelivery) error
+	// ClaimDue returns deliveries whose NextAttemptAt has passed and pushes
+	// their NextAttemptAt to leaseUntil, so other workers skip them while
+	// they are being sent.
+	ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*model.PushDelivery, error)
+	Update(ctx context.Context, delivery *model.PushDelivery) error
+	Delete(ctx context.Context, id uint) error
 }
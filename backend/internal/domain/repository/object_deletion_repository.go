@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"memoria/internal/domain/model"
+)
+
+// ObjectDeletionRepository is the queue of S3 objects left behind by
+// deleted rows. Deletes enqueue inside their own transaction; this
+// interface is for the worker that drains the queue.
+type ObjectDeletionRepository interface {
+	// ClaimDue returns deletions whose NextAttemptAt has passed and pushes
+	// their NextAttemptAt to leaseUntil, so other workers skip them while
+	// they are being processed.
+	ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*model.ObjectDeletion, error)
+	Update(ctx context.Context, deletion *model.ObjectDeletion) error
+	Delete(ctx context.Context, id uint) error
+}
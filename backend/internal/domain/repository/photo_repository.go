@@ -1,10 +1,36 @@
 package repository
 
-import "memoria/internal/domain/model"
+import (
+	"context"
+	"time"
+
+	"memoria/internal/domain/model"
+)
 
 type PhotoRepository interface {
-	Create(photo *model.Photo) error
-	FindByID(id uint, groupID uint) (*model.Photo, error)
-	FindByAlbumID(albumID uint, groupID uint) ([]*model.Photo, error)
-	Delete(id uint) error
+	Create(ctx context.Context, photo *model.Photo) error
+	FindByID(ctx context.Context, id uint, groupID uint) (*model.Photo, error)
+	// FindByAlbumID lists the album's photos, newest first by default. It
+	// filters by uploader and upload date.
+	FindByAlbumID(ctx context.Context, albumID uint, groupID uint, opts ListOptions) (*Page[*model.Photo], error)
+	// Delete moves the photo to the trash; the S3 object is kept until the
+	// trash is purged.
+	Delete(ctx context.Context, id uint) error
+	FindVariants(ctx context.Context, photoIDs []uint) ([]*model.PhotoVariant, error)
+
+	// Processing
+	// ClaimPending returns up to limit pending photos that are due and
+	// pushes their next attempt to leaseUntil, so other workers skip them
+	// meanwhile. Photos in the trash wait until they are restored.
+	ClaimPending(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*model.Photo, error)
+	// SaveVariants replaces the photo's variants and stores its status and
+	// dimensions. Objects of variants that are gone, or of all of them if
+	// the photo was purged meanwhile, are queued for deletion.
+	SaveVariants(ctx context.Context, photo *model.Photo, variants []*model.PhotoVariant) error
+	// UpdateProcessing stores the photo's status, attempts, error and next
+	// attempt.
+	UpdateProcessing(ctx context.Context, photo *model.Photo) error
+	// Requeue makes photos with one of the statuses pending again and
+	// returns how many there were.
+	Requeue(ctx context.Context, statuses []string, now time.Time) (int64, error)
 }
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"memoria/internal/domain/model"
+)
+
+// PhotoUploadRepository records the S3 keys handed out for uploads until
+// they are registered as photos or expire.
+type PhotoUploadRepository interface {
+	Create(ctx context.Context, upload *model.PhotoUpload) error
+	FindByKey(ctx context.Context, s3Key string) (*model.PhotoUpload, error)
+	// Consume deletes the upload unless it has expired by now and reports
+	// whether it did, so a key is registered at most once.
+	Consume(ctx context.Context, id uint, now time.Time) (bool, error)
+	// ExpireDue deletes up to limit uploads that expired by now, queues
+	// their objects for deletion and returns how many there were.
+	ExpireDue(ctx context.Context, now time.Time, limit int) (int, error)
+}
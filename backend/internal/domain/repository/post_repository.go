@@ -1,27 +1,40 @@
 package repository
 
-import "memoria/internal/domain/model"
+import (
+	"context"
+
+	"memoria/internal/domain/model"
+)
 
 type PostRepository interface {
-	Create(post *model.Post) error
-	FindByID(id uint, groupID uint) (*model.Post, error)
-	FindAll(groupID uint) ([]*model.Post, error)
-	FindByTagID(tagID uint, groupID uint) ([]*model.Post, error)
-	Update(post *model.Post) error
-	Delete(id uint) error
+	Create(ctx context.Context, post *model.Post) error
+	FindByID(ctx context.Context, id uint, groupID uint) (*model.Post, error)
+	// FindAll lists the group's posts, newest published first by default.
+	// It filters by type, author, tag and publish date.
+	FindAll(ctx context.Context, groupID uint, opts ListOptions) (*Page[*model.Post], error)
+	Update(ctx context.Context, post *model.Post) error
+	// Delete moves the post and its comments to the trash.
+	Delete(ctx context.Context, id uint) error
 
 	// Relations
-	AddAlbum(postID, albumID uint) error
-	RemoveAlbum(postID, albumID uint) error
-	AddPhoto(postID, photoID uint) error
-	RemovePhoto(postID, photoID uint) error
-	AddTag(postID, tagID uint) error
-	RemoveTag(postID, tagID uint) error
+	AddAlbum(ctx context.Context, postID, albumID uint) error
+	RemoveAlbum(ctx context.Context, postID, albumID uint) error
+	AddPhoto(ctx context.Context, postID, photoID uint) error
+	RemovePhoto(ctx context.Context, postID, photoID uint) error
+	// AddTag does nothing when the post already has the tag.
+	AddTag(ctx context.Context, postID, tagID uint) error
+	RemoveTag(ctx context.Context, postID, tagID uint) error
 
 	// Likes & Comments
-	AddLike(postID, userID uint) error
-	RemoveLike(postID, userID uint) error
-	CreateComment(comment *model.PostComment) error
-	DeleteComment(id uint) error
-	FindCommentsByPostID(postID uint) ([]*model.PostComment, error)
+	AddLike(ctx context.Context, postID, userID uint) error
+	RemoveLike(ctx context.Context, postID, userID uint) error
+	CreateComment(ctx context.Context, comment *model.PostComment) error
+	FindCommentByID(ctx context.Context, id uint) (*model.PostComment, error)
+	// DeleteComment moves the comment to the trash.
+	DeleteComment(ctx context.Context, id uint) error
+	// FindCommentsByPostID lists the post's comments, oldest first by
+	// default. It filters by author and date.
+	FindCommentsByPostID(ctx context.Context, postID uint, opts ListOptions) (*Page[*model.PostComment], error)
+	// FindCommenterIDs returns everyone who has a comment on the post.
+	FindCommenterIDs(ctx context.Context, postID uint) ([]uint, error)
 }
@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+
+	"memoria/internal/domain/model"
+)
+
+type SearchRepository interface {
+	// Search finds the group's content that contains every term, ignoring
+	// case, best match first. Trashed content is left out.
+	Search(ctx context.Context, groupID uint, terms []string, limit int) ([]*model.SearchResult, error)
+}
@@ -1,10 +1,14 @@
 package repository
 
-import "memoria/internal/domain/model"
+import (
+	"context"
+
+	"memoria/internal/domain/model"
+)
 
 type TagRepository interface {
-	Create(tag *model.Tag) error
-	FindByID(id uint) (*model.Tag, error)
-	FindByName(name string) (*model.Tag, error)
-	FindAll() ([]*model.Tag, error)
+	Create(ctx context.Context, tag *model.Tag) error
+	FindByID(ctx context.Context, id uint) (*model.Tag, error)
+	FindByName(ctx context.Context, name string) (*model.Tag, error)
+	FindAll(ctx context.Context) ([]*model.Tag, error)
 }
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"memoria/internal/domain/model"
+)
+
+type TrashRepository interface {
+	// FindByGroupID lists the group's trash, newest first. Photos and
+	// comments that were trashed together with their album or post are
+	// left out; they come back with it.
+	FindByGroupID(ctx context.Context, groupID uint) ([]*model.TrashItem, error)
+	FindItem(ctx context.Context, itemType string, id, groupID uint) (*model.TrashItem, error)
+	// Restore takes the item out of the trash, along with the photos or
+	// comments that were trashed with it.
+	Restore(ctx context.Context, item *model.TrashItem) error
+	// FindExpired returns items trashed before the cutoff, photos and
+	// comments first.
+	FindExpired(ctx context.Context, before time.Time, limit int) ([]*model.TrashItem, error)
+	// Purge deletes the item for good, together with every row that
+	// depends on it, and queues the S3 objects of its photos for deletion.
+	Purge(ctx context.Context, item *model.TrashItem) error
+}
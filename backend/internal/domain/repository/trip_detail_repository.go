@@ -1,20 +1,21 @@
 package repository
 
-import "memoria/internal/domain/model"
+import (
+	"context"
 
-type TripDetailRepository interface {
-	ReplaceScheduleItems(tripID uint, items []*model.TripScheduleItem) error
-	FindScheduleItems(tripID uint) ([]*model.TripScheduleItem, error)
+	"memoria/internal/domain/model"
+)
 
-	ReplaceTransports(tripID uint, transports []*model.TripTransport) error
-	FindTransports(tripID uint) ([]*model.TripTransport, error)
+type TripDetailRepository interface {
+	ReplaceScheduleItems(ctx context.Context, tripID uint, items []*model.TripScheduleItem) error
+	FindScheduleItems(ctx context.Context, tripID uint) ([]*model.TripScheduleItem, error)
 
-	ReplaceLodgings(tripID uint, lodgings []*model.TripLodging) error
-	FindLodgings(tripID uint) ([]*model.TripLodging, error)
+	ReplaceTransports(ctx context.Context, tripID uint, transports []*model.TripTransport) error
+	FindTransports(ctx context.Context, tripID uint) ([]*model.TripTransport, error)
 
-	ReplaceBudgetItems(tripID uint, items []*model.TripBudgetItem) error
-	FindBudgetItems(tripID uint) ([]*model.TripBudgetItem, error)
+	ReplaceLodgings(ctx context.Context, tripID uint, lodgings []*model.TripLodging) error
+	FindLodgings(ctx context.Context, tripID uint) ([]*model.TripLodging, error)
 
-	SumTransportCosts(tripID uint) (int64, error)
-	SumLodgingCosts(tripID uint) (int64, error)
+	ReplaceBudgetItems(ctx context.Context, tripID uint, items []*model.TripBudgetItem) error
+	FindBudgetItems(ctx context.Context, tripID uint) ([]*model.TripBudgetItem, error)
 }
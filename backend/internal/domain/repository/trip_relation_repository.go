@@ -1,10 +1,14 @@
 package repository
 
-import "memoria/internal/domain/model"
+import (
+	"context"
+
+	"memoria/internal/domain/model"
+)
 
 type TripRelationRepository interface {
-	AddAlbums(tripID uint, albumIDs []uint) error
-	AddPosts(tripID uint, postIDs []uint) error
-	FindAlbumsByTripID(tripID uint) ([]*model.Album, error)
-	FindPostsByTripID(tripID uint) ([]*model.Post, error)
+	AddAlbums(ctx context.Context, tripID uint, albumIDs []uint) error
+	AddPosts(ctx context.Context, tripID uint, postIDs []uint) error
+	FindAlbumsByTripID(ctx context.Context, tripID uint) ([]*model.Album, error)
+	FindPostsByTripID(ctx context.Context, tripID uint) ([]*model.Post, error)
 }
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"memoria/internal/domain/model"
+)
+
+type TripReminderRepository interface {
+	// FindDue returns trips whose NotifyAt is in (since, now] and whose
+	// reminder for that NotifyAt has not been recorded yet. Trips of groups
+	// scheduled for deletion are skipped.
+	FindDue(ctx context.Context, now, since time.Time, limit int) ([]*model.Trip, error)
+	// Record stores the reminder together with its notifications in one
+	// transaction. It returns false without error when another worker already
+	// holds or recorded the reminder, or the trip's NotifyAt has changed.
+	Record(ctx context.Context, reminder *model.TripReminder, notifications []*model.Notification) (bool, error)
+}
@@ -1,32 +1,54 @@
 package repository
 
-import "memoria/internal/domain/model"
+import (
+	"context"
+
+	"memoria/internal/domain/model"
+)
 
 type TripRepository interface {
-	Create(trip *model.Trip) error
-	FindByID(id uint, groupID uint) (*model.Trip, error)
-	FindAll(groupID uint) ([]*model.Trip, error)
-	Update(trip *model.Trip) error
-	Delete(id uint) error
+	Create(ctx context.Context, trip *model.Trip) error
+	FindByID(ctx context.Context, id uint, groupID uint) (*model.Trip, error)
+	// FindAll lists the group's trips by start date, latest first by
+	// default and soonest first for upcoming trips. It filters by creator,
+	// start date and timing.
+	FindAll(ctx context.Context, groupID uint, opts ListOptions) (*Page[*model.Trip], error)
+	Update(ctx context.Context, trip *model.Trip) error
+	// Delete moves the trip to the trash. Its details stay as they are.
+	Delete(ctx context.Context, id uint) error
 }
 
 type TripItineraryRepository interface {
-	Create(itinerary *model.TripItinerary) error
-	FindByTripID(tripID uint) ([]*model.TripItinerary, error)
-	Update(itinerary *model.TripItinerary) error
-	Delete(id uint) error
+	Create(ctx context.Context, itinerary *model.TripItinerary) error
+	FindByID(ctx context.Context, id uint, tripID uint) (*model.TripItinerary, error)
+	FindByTripID(ctx context.Context, tripID uint) ([]*model.TripItinerary, error)
+	Update(ctx context.Context, itinerary *model.TripItinerary) error
+	Delete(ctx context.Context, id uint) error
 }
 
 type TripWishlistRepository interface {
-	Create(wishlist *model.TripWishlist) error
-	FindByTripID(tripID uint) ([]*model.TripWishlist, error)
-	Update(wishlist *model.TripWishlist) error
-	Delete(id uint) error
+	Create(ctx context.Context, wishlist *model.TripWishlist) error
+	FindByID(ctx context.Context, id uint, tripID uint) (*model.TripWishlist, error)
+	FindByTripID(ctx context.Context, tripID uint) ([]*model.TripWishlist, error)
+	Update(ctx context.Context, wishlist *model.TripWishlist) error
+	Delete(ctx context.Context, id uint) error
 }
 
 type TripExpenseRepository interface {
-	Create(expense *model.TripExpense) error
-	FindByTripID(tripID uint) ([]*model.TripExpense, error)
-	Update(expense *model.TripExpense) error
-	Delete(id uint) error
+	Create(ctx context.Context, expense *model.TripExpense) error
+	FindByID(ctx context.Context, id uint, tripID uint) (*model.TripExpense, error)
+	FindByTripID(ctx context.Context, tripID uint) ([]*model.TripExpense, error)
+	Update(ctx context.Context, expense *model.TripExpense) error
+	Delete(ctx context.Context, id uint) error
+
+	// Participants
+	ReplaceParticipants(ctx context.Context, expenseID uint, participants []*model.TripExpenseParticipant) error
+	FindParticipantsByTripID(ctx context.Context, tripID uint) ([]*model.TripExpenseParticipant, error)
+}
+
+type TripSettlementRepository interface {
+	Create(ctx context.Context, settlement *model.TripSettlement) error
+	FindByID(ctx context.Context, id uint, tripID uint) (*model.TripSettlement, error)
+	FindByTripID(ctx context.Context, tripID uint) ([]*model.TripSettlement, error)
+	Delete(ctx context.Context, id uint) error
 }
@@ -0,0 +1,31 @@
+package repository
+
+import "context"
+
+// Repositories is what a unit of work hands to its callback: repositories
+// that all run in the same transaction.
+type Repositories struct {
+	Groups             GroupRepository
+	GroupMembers       GroupMemberRepository
+	Invites            InviteRepository
+	Albums             AlbumRepository
+	Photos             PhotoRepository
+	PhotoUploads       PhotoUploadRepository
+	UploadSessions     UploadSessionRepository
+	Posts              PostRepository
+	Tags               TagRepository
+	Trips              TripRepository
+	TripRelations      TripRelationRepository
+	TripExpenses       TripExpenseRepository
+	ExchangeRates      ExchangeRateRepository
+	CalendarFeedTokens CalendarFeedTokenRepository
+}
+
+// UnitOfWork runs several repository calls as one transaction, so a flow
+// that writes to more than one table either happens completely or not at
+// all.
+type UnitOfWork interface {
+	// Do commits when fn returns nil and rolls back otherwise. Only the
+	// repositories in repos take part; repositories held elsewhere do not.
+	Do(ctx context.Context, fn func(repos Repositories) error) error
+}
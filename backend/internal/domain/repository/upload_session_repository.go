@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"memoria/internal/domain/model"
+)
+
+// UploadSessionRepository records multipart uploads and the parts clients
+// report until the upload is completed, aborted or expires.
+type UploadSessionRepository interface {
+	Create(ctx context.Context, session *model.UploadSession) error
+	FindByID(ctx context.Context, id uint, groupID uint) (*model.UploadSession, error)
+	// SavePart records an uploaded part, replacing an earlier report of it.
+	SavePart(ctx context.Context, part *model.UploadSessionPart) error
+	// FindParts returns the session's parts in part number order.
+	FindParts(ctx context.Context, sessionID uint) ([]*model.UploadSessionPart, error)
+	MarkCompleted(ctx context.Context, id uint, now time.Time) error
+	// Delete removes the session and its parts.
+	Delete(ctx context.Context, id uint) error
+	// FindExpired returns up to limit sessions that expired by now, oldest
+	// first.
+	FindExpired(ctx context.Context, now time.Time, limit int) ([]*model.UploadSession, error)
+}
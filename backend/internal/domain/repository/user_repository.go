@@ -1,13 +1,17 @@
 package repository
 
-import "memoria/internal/domain/model"
+import (
+	"context"
+
+	"memoria/internal/domain/model"
+)
 
 type UserRepository interface {
-	Create(user *model.User) error
-	FindByFirebaseUID(firebaseUID string) (*model.User, error)
-	FindByEmail(email string) (*model.User, error)
-	FindByID(id uint) (*model.User, error)
-	FindAll() ([]*model.User, error)
-	Update(user *model.User) error
-	Delete(id uint) error
+	Create(ctx context.Context, user *model.User) error
+	FindByFirebaseUID(ctx context.Context, firebaseUID string) (*model.User, error)
+	FindByEmail(ctx context.Context, email string) (*model.User, error)
+	FindByID(ctx context.Context, id uint) (*model.User, error)
+	FindAll(ctx context.Context) ([]*model.User, error)
+	Update(ctx context.Context, user *model.User) error
+	Delete(ctx context.Context, id uint) error
 }
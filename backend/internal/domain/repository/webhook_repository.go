@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"memoria/internal/domain/model"
+)
+
+type WebhookRepository interface {
+	Create(ctx context.Context, webhook *model.GroupWebhook) error
+	FindByGroupID(ctx context.Context, groupID uint) ([]*model.GroupWebhook, error)
+	FindByID(ctx context.Context, id uint, groupID uint) (*model.GroupWebhook, error)
+	// FindByIDs loads webhooks across groups; used by the retry worker.
+	FindByIDs(ctx context.Context, ids []uint) ([]*model.GroupWebhook, error)
+	// FindEnabledByEvent returns the group's enabled webhooks subscribed to event.
+	FindEnabledByEvent(ctx context.Context, groupID uint, event string) ([]*model.GroupWebhook, error)
+	Update(ctx context.Context, webhook *model.GroupWebhook) error
+	// Delete removes the webhook together with its delivery log.
+	Delete(ctx context.Context, id uint) error
+}
+
+type WebhookDeliveryRepository interface {
+	Create(ctx context.Context, delivery *model.WebhookDelivery) error
+	Update(ctx context.Context, delivery *model.WebhookDelivery) error
+	FindByWebhookID(ctx context.Context, webhookID uint, limit int) ([]*model.WebhookDelivery, error)
+	// ClaimDue returns pending deliveries whose NextAttemptAt has passed and
+	// pushes their NextAttemptAt to leaseUntil, so other workers skip them
+	// while they are being sent.
+	ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*model.WebhookDelivery, error)
+}
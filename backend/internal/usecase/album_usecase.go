@@ -1,6 +1,8 @@
 package usecase
 
 import (
+	"context"
+
 	"memoria/internal/domain/model"
 	"memoria/internal/domain/repository"
 )
@@ -17,7 +19,7 @@ func NewAlbumUsecase(albumRepo repository.AlbumRepository, photoRepo repository.
 	}
 }
 
-func (u *AlbumUsecase) CreateAlbum(title, description string, createdBy uint, groupID uint) (*model.Album, error) {
+func (u *AlbumUsecase) CreateAlbum(ctx context.Context, title, description string, createdBy uint, groupID uint) (*model.Album, error) {
 	album := &model.Album{
 		GroupID:     groupID,
 		Title:       title,
@@ -25,26 +27,29 @@ func (u *AlbumUsecase) CreateAlbum(title, description string, createdBy uint, gr
 		CreatedBy:   createdBy,
 	}
 
-	if err := u.albumRepo.Create(album); err != nil {
+	if err := u.albumRepo.Create(ctx, album); err != nil {
 		return nil, err
 	}
 
 	return album, nil
 }
 
-func (u *AlbumUsecase) GetAlbum(id uint, groupID uint) (*model.Album, error) {
-	return u.albumRepo.FindByID(id, groupID)
+func (u *AlbumUsecase) GetAlbum(ctx context.Context, id uint, groupID uint) (*model.Album, error) {
+	return u.albumRepo.FindByID(ctx, id, groupID)
 }
 
-func (u *AlbumUsecase) GetAllAlbums(groupID uint) ([]*model.Album, error) {
-	return u.albumRepo.FindAll(groupID)
+func (u *AlbumUsecase) GetAllAlbums(ctx context.Context, groupID uint, opts repository.ListOptions) (*repository.Page[*model.Album], error) {
+	return u.albumRepo.FindAll(ctx, groupID, opts)
 }
 
-func (u *AlbumUsecase) UpdateAlbum(id uint, title, description string, coverPhotoID *uint, groupID uint) (*model.Album, error) {
-	album, err := u.albumRepo.FindByID(id, groupID)
+func (u *AlbumUsecase) UpdateAlbum(ctx context.Context, id uint, title, description string, coverPhotoID *uint, actor *model.GroupMember) (*model.Album, error) {
+	album, err := u.albumRepo.FindByID(ctx, id, actor.GroupID)
 	if err != nil {
 		return nil, err
 	}
+	if err := authorize(actor, actionEditAlbum, album.CreatedBy); err != nil {
+		return nil, err
+	}
 
 	album.Title = title
 	album.Description = description
@@ -52,17 +57,41 @@ func (u *AlbumUsecase) UpdateAlbum(id uint, title, description string, coverPhot
 		album.CoverPhotoID = coverPhotoID
 	}
 
-	if err := u.albumRepo.Update(album); err != nil {
+	if err := u.albumRepo.Update(ctx, album); err != nil {
 		return nil, err
 	}
 
 	return album, nil
 }
 
-func (u *AlbumUsecase) DeleteAlbum(id uint) error {
-	return u.albumRepo.Delete(id)
+func (u *AlbumUsecase) DeleteAlbum(ctx context.Context, id uint, actor *model.GroupMember) error {
+	album, err := u.albumRepo.FindByID(ctx, id, actor.GroupID)
+	if err != nil {
+		return err
+	}
+	if err := authorize(actor, actionDeleteAlbum, album.CreatedBy); err != nil {
+		return err
+	}
+	return u.albumRepo.Delete(ctx, id)
+}
+
+// GetPhotoVariants returns the variants of the photos by photo ID.
+func (u *AlbumUsecase) GetPhotoVariants(ctx context.Context, photos []*model.Photo) (map[uint][]*model.PhotoVariant, error) {
+	ids := make([]uint, len(photos))
+	for i, photo := range photos {
+		ids[i] = photo.ID
+	}
+	variants, err := u.photoRepo.FindVariants(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byPhoto := make(map[uint][]*model.PhotoVariant, len(photos))
+	for _, variant := range variants {
+		byPhoto[variant.PhotoID] = append(byPhoto[variant.PhotoID], variant)
+	}
+	return byPhoto, nil
 }
 
-func (u *AlbumUsecase) GetAlbumPhotos(albumID uint, groupID uint) ([]*model.Photo, error) {
-	return u.photoRepo.FindByAlbumID(albumID, groupID)
+func (u *AlbumUsecase) GetAlbumPhotos(ctx context.Context, albumID uint, groupID uint, opts repository.ListOptions) (*repository.Page[*model.Photo], error) {
+	return u.photoRepo.FindByAlbumID(ctx, albumID, groupID, opts)
 }
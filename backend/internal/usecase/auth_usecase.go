@@ -77,40 +77,40 @@ type firebaseSessionCookieResponse struct {
 	SessionCookie string `json:"sessionCookie"`
 }
 
-func (u *AuthUsecase) Login(email, password, backPath string) (*model.User, string, string, string, error) {
+func (u *AuthUsecase) Login(ctx context.Context, email, password, backPath string) (*model.User, string, string, string, error) {
 	if u.firebaseAPIKey == "" {
 		return nil, "", "", "", errors.New("firebase api key is required")
 	}
-	resp, err := u.signInWithPassword(email, password)
+	resp, err := u.signInWithPassword(ctx, email, password)
 	if err != nil {
 		return nil, "", "", "", err
 	}
-	verified, err := u.isEmailVerified(resp.IDToken)
+	verified, err := u.isEmailVerified(ctx, resp.IDToken)
 	if err != nil {
 		return nil, "", "", "", err
 	}
 	if !verified {
-		if err := u.sendVerifyEmail(resp.IDToken, backPath); err != nil {
+		if err := u.sendVerifyEmail(ctx, resp.IDToken, backPath); err != nil {
 			log.Printf("Failed to send verify email: %v", err)
 		}
 		return nil, "", "", "", &AuthError{Code: "EMAIL_NOT_VERIFIED", Message: "メール認証が完了していません。送信したメールをご確認ください。"}
 	}
-	user, err := u.ensureUser(resp.LocalID, resp.Email)
+	user, err := u.ensureUser(ctx, resp.LocalID, resp.Email)
 	if err != nil {
 		return nil, "", "", "", err
 	}
-	sessionCookie, err := u.createSessionCookie(resp.IDToken)
+	sessionCookie, err := u.createSessionCookie(ctx, resp.IDToken)
 	if err != nil {
 		return nil, "", "", "", err
 	}
 	return user, sessionCookie, resp.RefreshToken, resp.IDToken, nil
 }
 
-func (u *AuthUsecase) Signup(email, password, displayName, backPath string) (*model.User, string, error) {
+func (u *AuthUsecase) Signup(ctx context.Context, email, password, displayName, backPath string) (*model.User, string, error) {
 	if u.firebaseAPIKey == "" {
 		return nil, "", errors.New("firebase api key is required")
 	}
-	resp, err := u.signUpWithPassword(email, password)
+	resp, err := u.signUpWithPassword(ctx, email, password)
 	if err != nil {
 		return nil, "", err
 	}
@@ -124,38 +124,38 @@ func (u *AuthUsecase) Signup(email, password, displayName, backPath string) (*mo
 	if user.DisplayName == "" {
 		user.DisplayName = resp.Email
 	}
-	if err := u.userRepo.Create(user); err != nil {
+	if err := u.userRepo.Create(ctx, user); err != nil {
 		return nil, "", err
 	}
 
-	if err := u.sendVerifyEmail(resp.IDToken, backPath); err != nil {
+	if err := u.sendVerifyEmail(ctx, resp.IDToken, backPath); err != nil {
 		log.Printf("Failed to send verify email: %v", err)
 	}
 	return nil, "", &AuthError{Code: "EMAIL_NOT_VERIFIED", Message: "認証メールを送信しました。メール内のリンクをクリックしてからログインしてください。"}
 }
 
-func (u *AuthUsecase) RefreshSession(refreshToken string) (string, string, string, error) {
+func (u *AuthUsecase) RefreshSession(ctx context.Context, refreshToken string) (string, string, string, error) {
 	if u.firebaseAPIKey == "" {
 		return "", "", "", errors.New("firebase api key is required")
 	}
 	if refreshToken == "" {
 		return "", "", "", errors.New("refresh token is required")
 	}
-	resp, err := u.callFirebaseRefresh(refreshToken)
+	resp, err := u.callFirebaseRefresh(ctx, refreshToken)
 	if err != nil {
 		return "", "", "", err
 	}
 	if resp.IDToken == "" || resp.RefreshToken == "" {
 		return "", "", "", errors.New("invalid refresh response")
 	}
-	sessionCookie, err := u.createSessionCookie(resp.IDToken)
+	sessionCookie, err := u.createSessionCookie(ctx, resp.IDToken)
 	if err != nil {
 		return "", "", "", err
 	}
 	return sessionCookie, resp.RefreshToken, resp.IDToken, nil
 }
 
-func (u *AuthUsecase) createSessionCookie(idToken string) (string, error) {
+func (u *AuthUsecase) createSessionCookie(ctx context.Context, idToken string) (string, error) {
 	if u.firebaseAPIKey == "" {
 		return "", errors.New("firebase api key is required")
 	}
@@ -169,22 +169,22 @@ func (u *AuthUsecase) createSessionCookie(idToken string) (string, error) {
 		"idToken":       idToken,
 		"validDuration": int64(u.sessionTTL.Seconds()),
 	}
-	accessToken, err := u.firebaseAuth.GetAccessToken(context.Background())
+	accessToken, err := u.firebaseAuth.GetAccessToken(ctx)
 	if err != nil {
 		return "", err
 	}
 
 	endpoint := "https://www.googleapis.com/identitytoolkit/v3/relyingparty/createSessionCookie"
-	cookie, err := u.requestSessionCookie(endpoint, payload, accessToken)
+	cookie, err := u.requestSessionCookie(ctx, endpoint, payload, accessToken)
 	if err != nil {
 		return "", err
 	}
 	return cookie, nil
 }
 
-func (u *AuthUsecase) requestSessionCookie(endpoint string, payload map[string]any, accessToken string) (string, error) {
+func (u *AuthUsecase) requestSessionCookie(ctx context.Context, endpoint string, payload map[string]any, accessToken string) (string, error) {
 	body, _ := json.Marshal(payload)
-	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
 	if err != nil {
 		return "", err
 	}
@@ -213,11 +213,11 @@ func (u *AuthUsecase) requestSessionCookie(endpoint string, payload map[string]a
 	return resp.SessionCookie, nil
 }
 
-func (u *AuthUsecase) isEmailVerified(idToken string) (bool, error) {
+func (u *AuthUsecase) isEmailVerified(ctx context.Context, idToken string) (bool, error) {
 	payload := map[string]any{
 		"idToken": idToken,
 	}
-	resp, err := u.callFirebaseLookup("accounts:lookup", payload)
+	resp, err := u.callFirebaseLookup(ctx, "accounts:lookup", payload)
 	if err != nil {
 		return false, err
 	}
@@ -227,7 +227,7 @@ func (u *AuthUsecase) isEmailVerified(idToken string) (bool, error) {
 	return resp.Users[0].EmailVerified, nil
 }
 
-func (u *AuthUsecase) sendVerifyEmail(idToken string, backPath string) error {
+func (u *AuthUsecase) sendVerifyEmail(ctx context.Context, idToken string, backPath string) error {
 	if u.frontendBaseURL == "" {
 		return errors.New("frontend base url is required")
 	}
@@ -240,15 +240,15 @@ func (u *AuthUsecase) sendVerifyEmail(idToken string, backPath string) error {
 		"idToken":     idToken,
 		"continueUrl": continueURL,
 	}
-	_, err := u.callFirebaseOob("accounts:sendOobCode", payload)
+	_, err := u.callFirebaseOob(ctx, "accounts:sendOobCode", payload)
 	return err
 }
 
-func (u *AuthUsecase) ensureUser(firebaseUID, email string) (*model.User, error) {
-	if existing, err := u.userRepo.FindByFirebaseUID(firebaseUID); err == nil && existing != nil {
+func (u *AuthUsecase) ensureUser(ctx context.Context, firebaseUID, email string) (*model.User, error) {
+	if existing, err := u.userRepo.FindByFirebaseUID(ctx, firebaseUID); err == nil && existing != nil {
 		return existing, nil
 	}
-	if existing, err := u.userRepo.FindByEmail(email); err == nil && existing != nil {
+	if existing, err := u.userRepo.FindByEmail(ctx, email); err == nil && existing != nil {
 		if existing.FirebaseUID != firebaseUID {
 			return nil, errors.New("email already exists")
 		}
@@ -260,34 +260,34 @@ func (u *AuthUsecase) ensureUser(firebaseUID, email string) (*model.User, error)
 		DisplayName: email,
 		Role:        "member",
 	}
-	if err := u.userRepo.Create(user); err != nil {
+	if err := u.userRepo.Create(ctx, user); err != nil {
 		return nil, err
 	}
 	return user, nil
 }
 
-func (u *AuthUsecase) signInWithPassword(email, password string) (*firebaseAuthResponse, error) {
+func (u *AuthUsecase) signInWithPassword(ctx context.Context, email, password string) (*firebaseAuthResponse, error) {
 	payload := map[string]any{
 		"email":             email,
 		"password":          password,
 		"returnSecureToken": true,
 	}
-	return u.callFirebaseAuth("accounts:signInWithPassword", payload)
+	return u.callFirebaseAuth(ctx, "accounts:signInWithPassword", payload)
 }
 
-func (u *AuthUsecase) signUpWithPassword(email, password string) (*firebaseAuthResponse, error) {
+func (u *AuthUsecase) signUpWithPassword(ctx context.Context, email, password string) (*firebaseAuthResponse, error) {
 	payload := map[string]any{
 		"email":             email,
 		"password":          password,
 		"returnSecureToken": true,
 	}
-	return u.callFirebaseAuth("accounts:signUp", payload)
+	return u.callFirebaseAuth(ctx, "accounts:signUp", payload)
 }
 
-func (u *AuthUsecase) callFirebaseAuth(endpoint string, payload map[string]any) (*firebaseAuthResponse, error) {
+func (u *AuthUsecase) callFirebaseAuth(ctx context.Context, endpoint string, payload map[string]any) (*firebaseAuthResponse, error) {
 	url := fmt.Sprintf("https://identitytoolkit.googleapis.com/v1/%s?key=%s", endpoint, u.firebaseAPIKey)
 	body, _ := json.Marshal(payload)
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
@@ -313,10 +313,10 @@ func (u *AuthUsecase) callFirebaseAuth(endpoint string, payload map[string]any)
 	return &resp, nil
 }
 
-func (u *AuthUsecase) callFirebaseOob(endpoint string, payload map[string]any) (*firebaseOobResponse, error) {
+func (u *AuthUsecase) callFirebaseOob(ctx context.Context, endpoint string, payload map[string]any) (*firebaseOobResponse, error) {
 	url := fmt.Sprintf("https://identitytoolkit.googleapis.com/v1/%s?key=%s", endpoint, u.firebaseAPIKey)
 	body, _ := json.Marshal(payload)
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
@@ -339,12 +339,12 @@ func (u *AuthUsecase) callFirebaseOob(endpoint string, payload map[string]any) (
 	return &resp, nil
 }
 
-func (u *AuthUsecase) callFirebaseRefresh(refreshToken string) (*firebaseRefreshResponse, error) {
+func (u *AuthUsecase) callFirebaseRefresh(ctx context.Context, refreshToken string) (*firebaseRefreshResponse, error) {
 	endpointURL := fmt.Sprintf("https://securetoken.googleapis.com/v1/token?key=%s", u.firebaseAPIKey)
 	form := url.Values{}
 	form.Set("grant_type", "refresh_token")
 	form.Set("refresh_token", refreshToken)
-	req, err := http.NewRequest(http.MethodPost, endpointURL, strings.NewReader(form.Encode()))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpointURL, strings.NewReader(form.Encode()))
 	if err != nil {
 		return nil, err
 	}
@@ -366,10 +366,10 @@ func (u *AuthUsecase) callFirebaseRefresh(refreshToken string) (*firebaseRefresh
 	}
 	return &resp, nil
 }
-func (u *AuthUsecase) callFirebaseLookup(endpoint string, payload map[string]any) (*firebaseLookupResponse, error) {
+func (u *AuthUsecase) callFirebaseLookup(ctx context.Context, endpoint string, payload map[string]any) (*firebaseLookupResponse, error) {
 	url := fmt.Sprintf("https://identitytoolkit.googleapis.com/v1/%s?key=%s", endpoint, u.firebaseAPIKey)
 	body, _ := json.Marshal(payload)
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
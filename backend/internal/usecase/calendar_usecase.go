@@ -0,0 +1,315 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"memoria/internal/domain/model"
+	"memoria/internal/domain/repository"
+)
+
+var ErrCalendarFeedNotFound = errors.New("calendar feed not found")
+
+// CalendarTimeKind selects how an event's start and end are written.
+type CalendarTimeKind int
+
+const (
+	// CalendarTimeUTC is an absolute time.
+	CalendarTimeUTC CalendarTimeKind = iota
+	// CalendarTimeFloating is a wall-clock time without a zone, so it shows
+	// at the same local time wherever the calendar is opened.
+	CalendarTimeFloating
+	// CalendarTimeDate is an all-day date. End is exclusive.
+	CalendarTimeDate
+)
+
+type CalendarEvent struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	Kind        CalendarTimeKind
+	Start       time.Time
+	End         time.Time // optional
+	Stamp       time.Time
+}
+
+type Calendar struct {
+	Name   string
+	Events []CalendarEvent
+}
+
+// CalendarEncoder renders a calendar as an iCalendar (RFC 5545) document.
+type CalendarEncoder interface {
+	Encode(cal *Calendar) []byte
+}
+
+var transportModeLabels = map[string]string{
+	"car":        "車",
+	"rental":     "レンタカー",
+	"train":      "電車",
+	"shinkansen": "新幹線",
+	"ferry":      "フェリー",
+	"flight":     "飛行機",
+	"bus":        "バス",
+}
+
+type CalendarUsecase struct {
+	tripRepo      repository.TripRepository
+	detailRepo    repository.TripDetailRepository
+	groupRepo     repository.GroupRepository
+	feedTokenRepo repository.CalendarFeedTokenRepository
+	uow           repository.UnitOfWork
+	encoder       CalendarEncoder
+}
+
+func NewCalendarUsecase(
+	tripRepo repository.TripRepository,
+	detailRepo repository.TripDetailRepository,
+	groupRepo repository.GroupRepository,
+	feedTokenRepo repository.CalendarFeedTokenRepository,
+	uow repository.UnitOfWork,
+	encoder CalendarEncoder,
+) *CalendarUsecase {
+	return &CalendarUsecase{
+		tripRepo:      tripRepo,
+		detailRepo:    detailRepo,
+		groupRepo:     groupRepo,
+		feedTokenRepo: feedTokenRepo,
+		uow:           uow,
+		encoder:       encoder,
+	}
+}
+
+// ExportTrip renders one trip with its schedule, transports and lodgings.
+func (u *CalendarUsecase) ExportTrip(ctx context.Context, tripID uint, groupID uint) ([]byte, error) {
+	trip, err := u.tripRepo.FindByID(ctx, tripID, groupID)
+	if err != nil {
+		return nil, err
+	}
+	events, err := u.tripEvents(ctx, trip)
+	if err != nil {
+		return nil, err
+	}
+	cal := &Calendar{Name: trip.Title, Events: events}
+	return u.encoder.Encode(cal), nil
+}
+
+func (u *CalendarUsecase) GetFeedToken(ctx context.Context, userID uint) (*model.CalendarFeedToken, error) {
+	return u.feedTokenRepo.FindActiveByUserID(ctx, userID)
+}
+
+// IssueFeedToken revokes the user's current feed token and returns a new one.
+// The plain token is only available here. Both happen in one transaction,
+// and a user has at most one active token, so of two concurrent calls one
+// fails instead of leaving two tokens.
+func (u *CalendarUsecase) IssueFeedToken(ctx context.Context, userID uint) (string, *model.CalendarFeedToken, error) {
+	token, err := generateToken()
+	if err != nil {
+		return "", nil, err
+	}
+	feedToken := &model.CalendarFeedToken{
+		UserID:    userID,
+		TokenHash: hashFeedToken(token),
+	}
+	err = u.uow.Do(ctx, func(repos repository.Repositories) error {
+		if err := repos.CalendarFeedTokens.RevokeByUserID(ctx, userID, time.Now()); err != nil {
+			return err
+		}
+		return repos.CalendarFeedTokens.Create(ctx, feedToken)
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return token, feedToken, nil
+}
+
+func (u *CalendarUsecase) RevokeFeedToken(ctx context.Context, userID uint) error {
+	return u.feedTokenRepo.RevokeByUserID(ctx, userID, time.Now())
+}
+
+// RenderFeed renders every trip in every group the token's owner belongs to.
+func (u *CalendarUsecase) RenderFeed(ctx context.Context, token string) ([]byte, error) {
+	if token == "" {
+		return nil, ErrCalendarFeedNotFound
+	}
+	feedToken, err := u.feedTokenRepo.FindActiveByHash(ctx, hashFeedToken(token))
+	if err != nil {
+		return nil, ErrCalendarFeedNotFound
+	}
+
+	groups, err := u.groupRepo.FindByUserID(ctx, feedToken.UserID)
+	if err != nil {
+		return nil, err
+	}
+	cal := &Calendar{Name: "memoria"}
+	for _, group := range groups {
+		opts := repository.ListOptions{Limit: repository.MaxPageSize}
+		for {
+			page, err := u.tripRepo.FindAll(ctx, group.ID, opts)
+			if err != nil {
+				return nil, err
+			}
+			for _, trip := range page.Items {
+				events, err := u.tripEvents(ctx, trip)
+				if err != nil {
+					return nil, err
+				}
+				cal.Events = append(cal.Events, events...)
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			opts.Cursor = page.NextCursor
+		}
+	}
+
+	// Failing to record usage must not break the subscription.
+	_ = u.feedTokenRepo.UpdateLastUsed(ctx, feedToken.ID, time.Now())
+	return u.encoder.Encode(cal), nil
+}
+
+func (u *CalendarUsecase) tripEvents(ctx context.Context, trip *model.Trip) ([]CalendarEvent, error) {
+	scheduleItems, err := u.detailRepo.FindScheduleItems(ctx, trip.ID)
+	if err != nil {
+		return nil, err
+	}
+	transports, err := u.detailRepo.FindTransports(ctx, trip.ID)
+	if err != nil {
+		return nil, err
+	}
+	lodgings, err := u.detailRepo.FindLodgings(ctx, trip.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []CalendarEvent{{
+		UID:         fmt.Sprintf("trip-%d@memoria", trip.ID),
+		Summary:     trip.Title,
+		Description: trip.Note,
+		Kind:        CalendarTimeUTC,
+		Start:       trip.StartAt,
+		End:         trip.EndAt,
+		Stamp:       trip.UpdatedAt,
+	}}
+
+	// Detail rows hold wall-clock dates and times without a zone, so they
+	// are exported as floating times. Rows that do not parse are skipped.
+	for _, item := range scheduleItems {
+		event := CalendarEvent{
+			UID:     fmt.Sprintf("trip-%d-schedule-%d@memoria", trip.ID, item.ID),
+			Summary: trip.Title + ": " + item.Content,
+			Stamp:   item.UpdatedAt,
+		}
+		if !setEventTimes(&event, item.Date, item.Time, "", 0) {
+			continue
+		}
+		events = append(events, event)
+	}
+
+	for _, transport := range transports {
+		label := transportModeLabels[transport.Mode]
+		if label == "" {
+			label = transport.Mode
+		}
+		summary := label
+		if transport.FromLocation != "" || transport.ToLocation != "" {
+			summary += " " + transport.FromLocation + " → " + transport.ToLocation
+		}
+		event := CalendarEvent{
+			UID:         fmt.Sprintf("trip-%d-transport-%d@memoria", trip.ID, transport.ID),
+			Summary:     trip.Title + ": " + summary,
+			Description: transportDescription(transport),
+			Location:    transport.FromLocation,
+			Stamp:       transport.UpdatedAt,
+		}
+		if !setEventTimes(&event, transport.Date, transport.DepartureTime, transport.ArrivalTime, 0) {
+			continue
+		}
+		// An arrival earlier than the departure is an overnight trip.
+		if event.Kind == CalendarTimeFloating && !event.End.IsZero() && event.End.Before(event.Start) {
+			event.End = event.End.AddDate(0, 0, 1)
+		}
+		events = append(events, event)
+	}
+
+	for _, lodging := range lodgings {
+		description := []string{}
+		if lodging.ReservationNumber != "" {
+			description = append(description, "予約番号: "+lodging.ReservationNumber)
+		}
+		if lodging.ReservationURL != "" {
+			description = append(description, lodging.ReservationURL)
+		}
+		event := CalendarEvent{
+			UID:         fmt.Sprintf("trip-%d-lodging-%d@memoria", trip.ID, lodging.ID),
+			Summary:     trip.Title + ": 宿泊 " + lodging.Name,
+			Description: strings.Join(description, "\n"),
+			Location:    lodging.Address,
+			Stamp:       lodging.UpdatedAt,
+		}
+		// Date is the night of the stay; check-out is the next morning.
+		if !setEventTimes(&event, lodging.Date, lodging.CheckIn, lodging.CheckOut, 1) {
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// setEventTimes fills the event's start and end from a YYYY-MM-DD date and
+// optional HH:MM times. Without a start time the event becomes an all-day
+// event on that date. The end falls endDayOffset days after date.
+func setEventTimes(event *CalendarEvent, date, startTime, endTime string, endDayOffset int) bool {
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return false
+	}
+	if startTime == "" {
+		event.Kind = CalendarTimeDate
+		event.Start = day
+		event.End = day.AddDate(0, 0, 1)
+		return true
+	}
+	start, err := time.Parse("2006-01-02 15:04", date+" "+startTime)
+	if err != nil {
+		return false
+	}
+	event.Kind = CalendarTimeFloating
+	event.Start = start
+	if endTime != "" {
+		if end, err := time.Parse("2006-01-02 15:04", date+" "+endTime); err == nil {
+			event.End = end.AddDate(0, 0, endDayOffset)
+		}
+	}
+	return true
+}
+
+func transportDescription(transport *model.TripTransport) string {
+	lines := []string{}
+	for _, field := range []struct{ label, value string }{
+		{"路線", transport.RouteName},
+		{"列車", transport.TrainName},
+		{"船", transport.FerryName},
+		{"便名", transport.FlightNumber},
+		{"航空会社", transport.Airline},
+		{"ターミナル", transport.Terminal},
+		{"会社", transport.CompanyName},
+		{"メモ", transport.Note},
+	} {
+		if field.value != "" {
+			lines = append(lines, field.label+": "+field.value)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func hashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,148 @@
+package usecase
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+
+	"memoria/internal/domain/model"
+)
+
+const DefaultCurrency = "JPY"
+
+// Amounts are stored in each currency's minor unit (yen for JPY, cents for
+// USD), so JPY amounts keep their historical meaning.
+var currencyMinorUnits = map[string]int{
+	"JPY": 0,
+	"KRW": 0,
+	"VND": 0,
+	"IDR": 0,
+	"CLP": 0,
+	"ISK": 0,
+	"BHD": 3,
+	"KWD": 3,
+	"OMR": 3,
+	"JOD": 3,
+	"TND": 3,
+}
+
+func currencyMinorUnit(currency string) int {
+	if unit, ok := currencyMinorUnits[currency]; ok {
+		return unit
+	}
+	return 2
+}
+
+// normalizeCurrency upper-cases an ISO 4217 code, falling back to fallback when empty.
+func normalizeCurrency(code, fallback string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		code = fallback
+	}
+	if len(code) != 3 {
+		return "", errors.New("invalid currency: " + code)
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return "", errors.New("invalid currency: " + code)
+		}
+	}
+	return code, nil
+}
+
+type CurrencyAmount struct {
+	Currency string
+	Amount   int64
+}
+
+// currencyTotals accumulates amounts per original currency in insertion order.
+type currencyTotals struct {
+	order  []string
+	totals map[string]int64
+}
+
+func (t *currencyTotals) add(currency string, amount int64) {
+	if t.totals == nil {
+		t.totals = map[string]int64{}
+	}
+	if _, ok := t.totals[currency]; !ok {
+		t.order = append(t.order, currency)
+	}
+	t.totals[currency] += amount
+}
+
+func (t *currencyTotals) list() []CurrencyAmount {
+	amounts := make([]CurrencyAmount, 0, len(t.order))
+	for _, currency := range t.order {
+		amounts = append(amounts, CurrencyAmount{Currency: currency, Amount: t.totals[currency]})
+	}
+	return amounts
+}
+
+// currencyConverter converts between currencies with a group's stored rates.
+// It records every pair it could not convert so callers can report them.
+type currencyConverter struct {
+	rates   map[string][]*model.ExchangeRate // "FROM>TO", sorted by EffectiveDate
+	missing map[string]struct{}
+}
+
+func newCurrencyConverter(rates []*model.ExchangeRate) *currencyConverter {
+	byPair := map[string][]*model.ExchangeRate{}
+	for _, rate := range rates {
+		key := rate.FromCurrency + ">" + rate.ToCurrency
+		byPair[key] = append(byPair[key], rate)
+	}
+	for _, pairRates := range byPair {
+		sort.Slice(pairRates, func(i, j int) bool {
+			return pairRates[i].EffectiveDate < pairRates[j].EffectiveDate
+		})
+	}
+	return &currencyConverter{rates: byPair, missing: map[string]struct{}{}}
+}
+
+// rateOn returns the latest rate effective on date (YYYY-MM-DD). When date is
+// before every stored rate the earliest one is used.
+func (c *currencyConverter) rateOn(from, to, date string) (float64, bool) {
+	pairRates := c.rates[from+">"+to]
+	if len(pairRates) == 0 {
+		return 0, false
+	}
+	selected := pairRates[0]
+	for _, rate := range pairRates {
+		if date != "" && rate.EffectiveDate > date {
+			break
+		}
+		selected = rate
+	}
+	return selected.Rate, selected.Rate > 0
+}
+
+func (c *currencyConverter) convert(amount int64, from, to, date string) (int64, bool) {
+	if from == "" {
+		from = DefaultCurrency
+	}
+	if from == to {
+		return amount, true
+	}
+	rate, ok := c.rateOn(from, to, date)
+	if !ok {
+		inverse, inverseOK := c.rateOn(to, from, date)
+		if !inverseOK {
+			c.missing[from+"->"+to] = struct{}{}
+			return 0, false
+		}
+		rate = 1 / inverse
+	}
+	major := float64(amount) / math.Pow10(currencyMinorUnit(from))
+	return int64(math.Round(major * rate * math.Pow10(currencyMinorUnit(to)))), true
+}
+
+func (c *currencyConverter) missingPairs() []string {
+	pairs := make([]string, 0, len(c.missing))
+	for pair := range c.missing {
+		pairs = append(pairs, pair)
+	}
+	sort.Strings(pairs)
+	return pairs
+}
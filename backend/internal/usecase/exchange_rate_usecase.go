@@ -0,0 +1,130 @@
+package usecase
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"memoria/internal/domain/model"
+	"memoria/internal/domain/repository"
+)
+
+type ExchangeRateUsecase struct {
+	rateRepo repository.ExchangeRateRepository
+	uow      repository.UnitOfWork
+}
+
+func NewExchangeRateUsecase(rateRepo repository.ExchangeRateRepository, uow repository.UnitOfWork) *ExchangeRateUsecase {
+	return &ExchangeRateUsecase{
+		rateRepo: rateRepo,
+		uow:      uow,
+	}
+}
+
+func (u *ExchangeRateUsecase) GetRates(ctx context.Context, groupID uint) ([]*model.ExchangeRate, error) {
+	return u.rateRepo.FindByGroupID(ctx, groupID)
+}
+
+func (u *ExchangeRateUsecase) SetRate(ctx context.Context, fromCurrency, toCurrency, effectiveDate string, rate float64, actor *model.GroupMember) (*model.ExchangeRate, error) {
+	if err := authorize(actor, actionManageExchangeRates, 0); err != nil {
+		return nil, err
+	}
+	exchangeRate, err := buildExchangeRate(fromCurrency, toCurrency, effectiveDate, rate, actor.UserID, actor.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.rateRepo.Upsert(ctx, exchangeRate); err != nil {
+		return nil, err
+	}
+	return exchangeRate, nil
+}
+
+func (u *ExchangeRateUsecase) DeleteRate(ctx context.Context, id uint, actor *model.GroupMember) error {
+	if err := authorize(actor, actionManageExchangeRates, 0); err != nil {
+		return err
+	}
+	if _, err := u.rateRepo.FindByID(ctx, id, actor.GroupID); err != nil {
+		return err
+	}
+	return u.rateRepo.Delete(ctx, id)
+}
+
+// ImportCSV upserts rates from CSV rows of "date,from,to,rate". A header row
+// is skipped. Every row is validated before anything is written, and the rows
+// are written in one transaction.
+func (u *ExchangeRateUsecase) ImportCSV(ctx context.Context, r io.Reader, actor *model.GroupMember) ([]*model.ExchangeRate, error) {
+	if err := authorize(actor, actionManageExchangeRates, 0); err != nil {
+		return nil, err
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid csv: %w", err)
+	}
+
+	rates := make([]*model.ExchangeRate, 0, len(records))
+	for i, record := range records {
+		if i == 0 && strings.EqualFold(strings.TrimSpace(record[0]), "date") {
+			continue
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(record[3]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid rate", i+1)
+		}
+		exchangeRate, err := buildExchangeRate(record[1], record[2], record[0], rate, actor.UserID, actor.GroupID)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		rates = append(rates, exchangeRate)
+	}
+
+	err = u.uow.Do(ctx, func(repos repository.Repositories) error {
+		for _, rate := range rates {
+			if err := repos.ExchangeRates.Upsert(ctx, rate); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+func buildExchangeRate(fromCurrency, toCurrency, effectiveDate string, rate float64, userID uint, groupID uint) (*model.ExchangeRate, error) {
+	from, err := normalizeCurrency(fromCurrency, "")
+	if err != nil {
+		return nil, err
+	}
+	to, err := normalizeCurrency(toCurrency, "")
+	if err != nil {
+		return nil, err
+	}
+	if from == to {
+		return nil, errors.New("from and to currency must differ")
+	}
+	effectiveDate = strings.TrimSpace(effectiveDate)
+	if _, err := time.Parse("2006-01-02", effectiveDate); err != nil {
+		return nil, errors.New("invalid effective_date: must be YYYY-MM-DD")
+	}
+	if rate <= 0 {
+		return nil, errors.New("rate must be positive")
+	}
+	return &model.ExchangeRate{
+		GroupID:       groupID,
+		FromCurrency:  from,
+		ToCurrency:    to,
+		EffectiveDate: effectiveDate,
+		Rate:          rate,
+		CreatedBy:     userID,
+	}, nil
+}
@@ -1,63 +1,294 @@
 package usecase
 
 import (
+	"context"
 	"errors"
+	"log"
 	"time"
 
 	"memoria/internal/domain/model"
 	"memoria/internal/domain/repository"
 )
 
+// groupPurgeBatchSize caps how many groups one purge run removes.
+const groupPurgeBatchSize = 10
+
+var (
+	ErrInvalidRole         = errors.New("invalid role: must be 'member' or 'manager'")
+	ErrLastManager         = errors.New("a group needs at least one manager")
+	ErrGroupNameRequired   = errors.New("group name is required")
+	ErrCreatorCannotLeave  = errors.New("the group creator must transfer the group before leaving")
+	ErrCannotRemoveCreator = errors.New("the group creator cannot be removed")
+	ErrGroupNotDeleted     = errors.New("group is not scheduled for deletion")
+	ErrGroupRestoreExpired = errors.New("the group can no longer be restored")
+	ErrInvalidUploadLimit  = errors.New("upload_max_bytes must be positive and within the server's limit")
+)
+
 type GroupUsecase struct {
 	groupRepo       repository.GroupRepository
 	groupMemberRepo repository.GroupMemberRepository
+	uow             repository.UnitOfWork
+	deletionGrace   time.Duration
+	uploadMaxBytes  int64
 }
 
-func NewGroupUsecase(groupRepo repository.GroupRepository, groupMemberRepo repository.GroupMemberRepository) *GroupUsecase {
+// NewGroupUsecase keeps deleted groups restorable for deletionGrace before
+// PurgeDueGroups removes them. Groups can lower their upload limit below
+// uploadMaxBytes but not raise it.
+func NewGroupUsecase(
+	groupRepo repository.GroupRepository,
+	groupMemberRepo repository.GroupMemberRepository,
+	uow repository.UnitOfWork,
+	deletionGrace time.Duration,
+	uploadMaxBytes int64,
+) *GroupUsecase {
 	return &GroupUsecase{
 		groupRepo:       groupRepo,
 		groupMemberRepo: groupMemberRepo,
+		uow:             uow,
+		deletionGrace:   deletionGrace,
+		uploadMaxBytes:  uploadMaxBytes,
 	}
 }
 
-func (u *GroupUsecase) CreateGroup(name string, createdBy uint) (*model.Group, error) {
+func (u *GroupUsecase) CreateGroup(ctx context.Context, name string, createdBy uint) (*model.Group, error) {
 	if name == "" {
-		return nil, errors.New("group name is required")
+		return nil, ErrGroupNameRequired
 	}
 
 	group := &model.Group{
-		Name:      name,
-		CreatedBy: createdBy,
+		Name:            name,
+		DefaultCurrency: DefaultCurrency,
+		CreatedBy:       createdBy,
 	}
-	if err := u.groupRepo.Create(group); err != nil {
+	err := u.uow.Do(ctx, func(repos repository.Repositories) error {
+		if err := repos.Groups.Create(ctx, group); err != nil {
+			return err
+		}
+		return repos.GroupMembers.Add(ctx, &model.GroupMember{
+			GroupID:  group.ID,
+			UserID:   createdBy,
+			Role:     model.RoleManager,
+			JoinedAt: time.Now(),
+		})
+	})
+	if err != nil {
 		return nil, err
 	}
+	return group, nil
+}
+
+func (u *GroupUsecase) GetGroupsForUser(ctx context.Context, userID uint) ([]*model.Group, error) {
+	return u.groupRepo.FindByUserID(ctx, userID)
+}
+
+func (u *GroupUsecase) GetGroupMembers(ctx context.Context, groupID uint) ([]*model.GroupMember, error) {
+	return u.groupMemberRepo.FindByGroupID(ctx, groupID)
+}
+
+// GetDeletedGroups lists the groups the user deleted and can still restore.
+func (u *GroupUsecase) GetDeletedGroups(ctx context.Context, userID uint) ([]*model.Group, error) {
+	return u.groupRepo.FindDeletedByCreator(ctx, userID)
+}
 
-	member := &model.GroupMember{
-		GroupID:  group.ID,
-		UserID:   createdBy,
-		Role:     "manager",
-		JoinedAt: time.Now(),
+// GetMembership fails for groups scheduled for deletion, which members can
+// no longer use.
+func (u *GroupUsecase) GetMembership(ctx context.Context, groupID, userID uint) (*model.GroupMember, error) {
+	return u.groupMemberRepo.FindActiveMembership(ctx, groupID, userID)
+}
+
+func (u *GroupUsecase) GetGroup(ctx context.Context, groupID uint) (*model.Group, error) {
+	return u.groupRepo.FindByID(ctx, groupID)
+}
+
+// ChangeMemberRole promotes a member to manager or demotes a manager to
+// member. The group's last manager cannot be demoted, not even by themselves.
+func (u *GroupUsecase) ChangeMemberRole(ctx context.Context, userID uint, role string, actor *model.GroupMember) (*model.GroupMember, error) {
+	if err := authorize(actor, actionChangeMemberRole, 0); err != nil {
+		return nil, err
 	}
-	if err := u.groupMemberRepo.Add(member); err != nil {
+	if role != model.RoleManager && role != model.RoleMember {
+		return nil, ErrInvalidRole
+	}
+
+	member, err := u.groupMemberRepo.FindByGroupAndUser(ctx, actor.GroupID, userID)
+	if err != nil {
 		return nil, err
 	}
+	if member.Role == role {
+		return member, nil
+	}
 
+	updated, err := u.groupMemberRepo.UpdateRole(ctx, actor.GroupID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, ErrLastManager
+	}
+	member.Role = role
+	return member, nil
+}
+
+// UpdateGroup changes the group's name and settings. Managers only.
+func (u *GroupUsecase) UpdateGroup(ctx context.Context, name, description, defaultCurrency string, uploadMaxBytes *int64, actor *model.GroupMember) (*model.Group, error) {
+	if err := authorize(actor, actionEditGroup, 0); err != nil {
+		return nil, err
+	}
+	if name == "" {
+		return nil, ErrGroupNameRequired
+	}
+	if uploadMaxBytes != nil && (*uploadMaxBytes <= 0 || *uploadMaxBytes > u.uploadMaxBytes) {
+		return nil, ErrInvalidUploadLimit
+	}
+	defaultCurrency, err := normalizeCurrency(defaultCurrency, DefaultCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := u.groupRepo.FindByID(ctx, actor.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	group.Name = name
+	group.Description = description
+	group.DefaultCurrency = defaultCurrency
+	group.UploadMaxBytes = uploadMaxBytes
+	if err := u.groupRepo.Update(ctx, group); err != nil {
+		return nil, err
+	}
 	return group, nil
 }
 
-func (u *GroupUsecase) GetGroupsForUser(userID uint) ([]*model.Group, error) {
-	return u.groupRepo.FindByUserID(userID)
+// LeaveGroup removes the actor from the group. The creator has to transfer
+// the group first, and the last manager has to promote someone else.
+func (u *GroupUsecase) LeaveGroup(ctx context.Context, actor *model.GroupMember) error {
+	group, err := u.groupRepo.FindByID(ctx, actor.GroupID)
+	if err != nil {
+		return err
+	}
+	if group.CreatedBy == actor.UserID {
+		return ErrCreatorCannotLeave
+	}
+	return u.removeMember(ctx, actor.GroupID, actor.UserID)
 }
 
-func (u *GroupUsecase) GetGroupMembers(groupID uint) ([]*model.GroupMember, error) {
-	return u.groupMemberRepo.FindByGroupID(groupID)
+// RemoveMember takes another member out of the group. Managers only; the
+// creator cannot be removed.
+func (u *GroupUsecase) RemoveMember(ctx context.Context, userID uint, actor *model.GroupMember) error {
+	if err := authorize(actor, actionRemoveMember, 0); err != nil {
+		return err
+	}
+	group, err := u.groupRepo.FindByID(ctx, actor.GroupID)
+	if err != nil {
+		return err
+	}
+	if group.CreatedBy == userID {
+		return ErrCannotRemoveCreator
+	}
+	return u.removeMember(ctx, actor.GroupID, userID)
 }
 
-func (u *GroupUsecase) GetMembership(groupID, userID uint) (*model.GroupMember, error) {
-	return u.groupMemberRepo.FindByGroupAndUser(groupID, userID)
+func (u *GroupUsecase) removeMember(ctx context.Context, groupID, userID uint) error {
+	removed, err := u.groupMemberRepo.Remove(ctx, groupID, userID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrLastManager
+	}
+	return nil
+}
+
+// TransferGroup hands the creator role to another member, who is made a
+// manager as well. Only the current creator can do this.
+func (u *GroupUsecase) TransferGroup(ctx context.Context, userID uint, actor *model.GroupMember) (*model.Group, error) {
+	group, err := u.groupRepo.FindByID(ctx, actor.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorize(actor, actionTransferGroup, group.CreatedBy); err != nil {
+		return nil, err
+	}
+	if userID == group.CreatedBy {
+		return group, nil
+	}
+
+	if err := u.groupRepo.TransferOwnership(ctx, group.ID, userID); err != nil {
+		return nil, err
+	}
+	group.CreatedBy = userID
+	return group, nil
+}
+
+// DeleteGroup schedules the group for deletion. It disappears for every
+// member at once, and the creator can restore it until PurgeAfter.
+func (u *GroupUsecase) DeleteGroup(ctx context.Context, actor *model.GroupMember) (*model.Group, error) {
+	group, err := u.groupRepo.FindByID(ctx, actor.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorize(actor, actionDeleteGroup, group.CreatedBy); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	purgeAfter := now.Add(u.deletionGrace)
+	group.DeletedAt = &now
+	group.PurgeAfter = &purgeAfter
+	if err := u.groupRepo.Update(ctx, group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// RestoreGroup cancels a scheduled deletion before its grace period ends.
+// Members of a deleted group have no active membership, so the user is
+// looked up directly.
+func (u *GroupUsecase) RestoreGroup(ctx context.Context, groupID, userID uint) (*model.Group, error) {
+	actor, err := u.groupMemberRepo.FindByGroupAndUser(ctx, groupID, userID)
+	if err != nil {
+		return nil, err
+	}
+	group, err := u.groupRepo.FindByID(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorize(actor, actionRestoreGroup, group.CreatedBy); err != nil {
+		return nil, err
+	}
+	if group.DeletedAt == nil {
+		return nil, ErrGroupNotDeleted
+	}
+	// Past PurgeAfter the purge job may already be deleting photos.
+	if group.PurgeAfter != nil && !time.Now().Before(*group.PurgeAfter) {
+		return nil, ErrGroupRestoreExpired
+	}
+
+	group.DeletedAt = nil
+	group.PurgeAfter = nil
+	if err := u.groupRepo.Update(ctx, group); err != nil {
+		return nil, err
+	}
+	return group, nil
 }
 
-func (u *GroupUsecase) GetGroup(groupID uint) (*model.Group, error) {
-	return u.groupRepo.FindByID(groupID)
+// PurgeDueGroups removes groups whose grace period has passed, together
+// with their albums, photos, posts, trips and invites. Photo objects are
+// queued for the object-cleanup job.
+func (u *GroupUsecase) PurgeDueGroups(ctx context.Context, now time.Time) (int, error) {
+	groups, err := u.groupRepo.FindDueForPurge(ctx, now, groupPurgeBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, group := range groups {
+		if err := u.groupRepo.Purge(ctx, group.ID); err != nil {
+			return purged, err
+		}
+		purged++
+		log.Printf("group purged: group=%d", group.ID)
+	}
+	return purged, nil
 }
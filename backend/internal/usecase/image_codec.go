@@ -0,0 +1,29 @@
+package usecase
+
+import "errors"
+
+// ErrUnreadableImage is returned for data an ImageCodec cannot read.
+// Retrying will not help.
+var ErrUnreadableImage = errors.New("unsupported image")
+
+// ImageCodec measures uploaded photos and decodes them to make variants.
+type ImageCodec interface {
+	// ReadSize reads the format ("jpeg", "png", "gif", "webp" or "heic")
+	// and the width and height as shown, after orientation, from the start
+	// of an image file. Some formats can be measured but not decoded.
+	ReadSize(data []byte) (format string, width, height int, err error)
+	// Decode reads a whole image file.
+	Decode(data []byte) (DecodedImage, error)
+}
+
+// DecodedImage is a decoded photo together with the orientation it is
+// meant to be shown in.
+type DecodedImage interface {
+	// Size is the width and height as shown, after orientation.
+	Size() (width, height int)
+	// Fit scales the image down so that its longer side is at most size.
+	// Smaller images keep their size.
+	Fit(size int) DecodedImage
+	// EncodeJPEG writes the image upright as a JPEG.
+	EncodeJPEG(quality int) ([]byte, error)
+}
@@ -1,11 +1,13 @@
 package usecase
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"errors"
 	"time"
 
+	"memoria/internal/domain/event"
 	"memoria/internal/domain/model"
 	"memoria/internal/domain/repository"
 )
@@ -15,7 +17,9 @@ type InviteUsecase struct {
 	userRepo        repository.UserRepository
 	groupRepo       repository.GroupRepository
 	groupMemberRepo repository.GroupMemberRepository
+	uow             repository.UnitOfWork
 	mailer          InviteMailer
+	events          *event.Bus
 }
 
 type InviteMailer interface {
@@ -27,34 +31,42 @@ func NewInviteUsecase(
 	userRepo repository.UserRepository,
 	groupRepo repository.GroupRepository,
 	groupMemberRepo repository.GroupMemberRepository,
+	uow repository.UnitOfWork,
 	mailer InviteMailer,
+	events *event.Bus,
 ) *InviteUsecase {
 	return &InviteUsecase{
 		inviteRepo:      inviteRepo,
 		userRepo:        userRepo,
 		groupRepo:       groupRepo,
 		groupMemberRepo: groupMemberRepo,
+		uow:             uow,
 		mailer:          mailer,
+		events:          events,
 	}
 }
 
-func (u *InviteUsecase) CreateInvite(email, role string, invitedBy uint, groupID uint) (*model.Invite, error) {
+func (u *InviteUsecase) CreateInvite(ctx context.Context, email, role string, actor *model.GroupMember) (*model.Invite, error) {
+	if err := authorize(actor, actionManageInvites, 0); err != nil {
+		return nil, err
+	}
+	groupID := actor.GroupID
 	if role == "" {
-		role = "member"
+		role = model.RoleMember
 	}
-	if role != "member" && role != "manager" {
+	if role != model.RoleMember && role != model.RoleManager {
 		return nil, errors.New("invalid role: must be 'member' or 'manager'")
 	}
 
-	group, err := u.groupRepo.FindByID(groupID)
+	group, err := u.groupRepo.FindByID(ctx, groupID)
 	if err != nil {
 		return nil, errors.New("group not found")
 	}
 
 	isExisting := false
-	if user, err := u.userRepo.FindByEmail(email); err == nil && user != nil {
+	if user, err := u.userRepo.FindByEmail(ctx, email); err == nil && user != nil {
 		isExisting = true
-		if _, err := u.groupMemberRepo.FindByGroupAndUser(groupID, user.ID); err == nil {
+		if _, err := u.groupMemberRepo.FindByGroupAndUser(ctx, groupID, user.ID); err == nil {
 			return nil, errors.New("user already belongs to the group")
 		}
 	}
@@ -72,16 +84,16 @@ func (u *InviteUsecase) CreateInvite(email, role string, invitedBy uint, groupID
 		Status:    "pending",
 		Role:      role,
 		ExpiresAt: time.Now().Add(7 * 24 * time.Hour), // 7 days
-		InvitedBy: invitedBy,
+		InvitedBy: actor.UserID,
 	}
 
-	if err := u.inviteRepo.Create(invite); err != nil {
+	if err := u.inviteRepo.Create(ctx, invite); err != nil {
 		return nil, err
 	}
 
 	if u.mailer != nil {
 		if err := u.mailer.SendGroupInvite(invite.Email, invite.Role, invite.Token, group.Name, isExisting); err != nil {
-			_ = u.inviteRepo.Delete(invite.ID)
+			_ = u.inviteRepo.Delete(ctx, invite.ID)
 			return nil, err
 		}
 	}
@@ -89,8 +101,8 @@ func (u *InviteUsecase) CreateInvite(email, role string, invitedBy uint, groupID
 	return invite, nil
 }
 
-func (u *InviteUsecase) VerifyInvite(token string) (*model.Invite, error) {
-	invite, err := u.inviteRepo.FindByToken(token)
+func (u *InviteUsecase) VerifyInvite(ctx context.Context, token string) (*model.Invite, error) {
+	invite, err := u.inviteRepo.FindByToken(ctx, token)
 	if err != nil {
 		return nil, errors.New("invalid invite token")
 	}
@@ -101,15 +113,19 @@ func (u *InviteUsecase) VerifyInvite(token string) (*model.Invite, error) {
 
 	if time.Now().After(invite.ExpiresAt) {
 		invite.Status = "expired"
-		u.inviteRepo.Update(invite)
+		u.inviteRepo.Update(ctx, invite)
 		return nil, errors.New("invite expired")
 	}
 
+	if group, err := u.groupRepo.FindByID(ctx, invite.GroupID); err != nil || group.DeletedAt != nil {
+		return nil, errors.New("group not found")
+	}
+
 	return invite, nil
 }
 
-func (u *InviteUsecase) AcceptInviteForUser(token string, user *model.User) error {
-	invite, err := u.VerifyInvite(token)
+func (u *InviteUsecase) AcceptInviteForUser(ctx context.Context, token string, user *model.User) error {
+	invite, err := u.VerifyInvite(ctx, token)
 	if err != nil {
 		return err
 	}
@@ -118,26 +134,31 @@ func (u *InviteUsecase) AcceptInviteForUser(token string, user *model.User) erro
 		return errors.New("email does not match invite")
 	}
 
-	if _, err := u.groupMemberRepo.FindByGroupAndUser(invite.GroupID, user.ID); err == nil {
+	if _, err := u.groupMemberRepo.FindByGroupAndUser(ctx, invite.GroupID, user.ID); err == nil {
 		return errors.New("user already belongs to the group")
 	}
 
-	member := &model.GroupMember{
-		GroupID:  invite.GroupID,
-		UserID:   user.ID,
-		Role:     invite.Role,
-		JoinedAt: time.Now(),
-	}
-	if err := u.groupMemberRepo.Add(member); err != nil {
+	err = u.uow.Do(ctx, func(repos repository.Repositories) error {
+		if err := repos.GroupMembers.Add(ctx, &model.GroupMember{
+			GroupID:  invite.GroupID,
+			UserID:   user.ID,
+			Role:     invite.Role,
+			JoinedAt: time.Now(),
+		}); err != nil {
+			return err
+		}
+		invite.Status = "accepted"
+		return repos.Invites.Update(ctx, invite)
+	})
+	if err != nil {
 		return err
 	}
-
-	invite.Status = "accepted"
-	return u.inviteRepo.Update(invite)
+	u.events.Publish(ctx, event.MemberJoined{GroupID: invite.GroupID, User: user})
+	return nil
 }
 
-func (u *InviteUsecase) DeclineInviteForUser(token string, user *model.User) error {
-	invite, err := u.VerifyInvite(token)
+func (u *InviteUsecase) DeclineInviteForUser(ctx context.Context, token string, user *model.User) error {
+	invite, err := u.VerifyInvite(ctx, token)
 	if err != nil {
 		return err
 	}
@@ -147,22 +168,28 @@ func (u *InviteUsecase) DeclineInviteForUser(token string, user *model.User) err
 	}
 
 	invite.Status = "declined"
-	return u.inviteRepo.Update(invite)
+	return u.inviteRepo.Update(ctx, invite)
 }
 
-func (u *InviteUsecase) GetGroupInvites(groupID uint) ([]*model.Invite, error) {
-	return u.inviteRepo.FindByGroupID(groupID)
+func (u *InviteUsecase) GetGroupInvites(ctx context.Context, actor *model.GroupMember) ([]*model.Invite, error) {
+	if err := authorize(actor, actionManageInvites, 0); err != nil {
+		return nil, err
+	}
+	return u.inviteRepo.FindByGroupID(ctx, actor.GroupID)
 }
 
-func (u *InviteUsecase) DeleteInvite(inviteID, groupID uint) error {
-	invite, err := u.inviteRepo.FindByID(inviteID)
+func (u *InviteUsecase) DeleteInvite(ctx context.Context, inviteID uint, actor *model.GroupMember) error {
+	if err := authorize(actor, actionManageInvites, 0); err != nil {
+		return err
+	}
+	invite, err := u.inviteRepo.FindByID(ctx, inviteID)
 	if err != nil {
 		return err
 	}
-	if invite.GroupID != groupID {
+	if invite.GroupID != actor.GroupID {
 		return errors.New("invite does not belong to group")
 	}
-	return u.inviteRepo.Delete(inviteID)
+	return u.inviteRepo.Delete(ctx, inviteID)
 }
 
 func generateToken() (string, error) {
@@ -0,0 +1,121 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"memoria/internal/domain/event"
+	"memoria/internal/domain/repository"
+)
+
+// LiveEvent is one message on a group's live stream.
+type LiveEvent struct {
+	ID      string          `json:"id"`
+	GroupID uint            `json:"group_id"`
+	Type    string          `json:"type"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// LiveSubscription is one open stream. Events is closed when the client
+// falls behind or the broker loses events; the client should then reconnect
+// with its last event ID.
+type LiveSubscription struct {
+	// Replay holds the buffered events after the requested ID.
+	Replay []LiveEvent
+	// Resync is set when the requested ID is no longer buffered, so the
+	// client has to reload instead of relying on Replay.
+	Resync bool
+	// LatestID is the newest buffered event ID, for clients that resync.
+	LatestID string
+	Events   <-chan LiveEvent
+	// Close stops delivery to the subscription.
+	Close func()
+}
+
+// LiveEventBroker fans events out to every open stream of a group, on all
+// server instances.
+type LiveEventBroker interface {
+	Publish(groupID uint, eventType string, data any) error
+	Subscribe(groupID uint, lastEventID string) *LiveSubscription
+}
+
+// LiveEventUsecase forwards domain events to the group's open event
+// streams. Events only carry IDs; clients fetch what changed.
+type LiveEventUsecase struct {
+	broker          LiveEventBroker
+	groupMemberRepo repository.GroupMemberRepository
+}
+
+func NewLiveEventUsecase(broker LiveEventBroker, groupMemberRepo repository.GroupMemberRepository) *LiveEventUsecase {
+	return &LiveEventUsecase{
+		broker:          broker,
+		groupMemberRepo: groupMemberRepo,
+	}
+}
+
+type liveEventData struct {
+	PostID    uint   `json:"post_id,omitempty"`
+	CommentID uint   `json:"comment_id,omitempty"`
+	AlbumID   uint   `json:"album_id,omitempty"`
+	PhotoID   uint   `json:"photo_id,omitempty"`
+	TripID    uint   `json:"trip_id,omitempty"`
+	Section   string `json:"section,omitempty"`
+	UserID    uint   `json:"user_id,omitempty"`
+}
+
+// Subscribe opens the group's stream, resuming after lastEventID when it
+// is still buffered.
+func (u *LiveEventUsecase) Subscribe(ctx context.Context, groupID uint, lastEventID string) *LiveSubscription {
+	return u.broker.Subscribe(groupID, lastEventID)
+}
+
+// IsMember reports whether the user still belongs to the group and the group
+// is not being deleted. Streams outlive the request that opened them, so
+// they check this periodically.
+func (u *LiveEventUsecase) IsMember(ctx context.Context, groupID, userID uint) bool {
+	member, err := u.groupMemberRepo.FindActiveMembership(ctx, groupID, userID)
+	return err == nil && member != nil
+}
+
+func (u *LiveEventUsecase) PostCreated(ctx context.Context, e event.PostCreated) {
+	u.publish(e.Post.GroupID, "post.created", liveEventData{PostID: e.Post.ID, UserID: e.Post.AuthorID})
+}
+
+func (u *LiveEventUsecase) PostChanged(ctx context.Context, e event.PostChanged) {
+	u.publish(e.GroupID, "post."+string(e.Action), liveEventData{PostID: e.PostID})
+}
+
+func (u *LiveEventUsecase) CommentCreated(ctx context.Context, e event.CommentCreated) {
+	u.publish(e.Post.GroupID, "comment.created", liveEventData{
+		PostID:    e.Post.ID,
+		CommentID: e.Comment.ID,
+		UserID:    e.Comment.UserID,
+	})
+}
+
+func (u *LiveEventUsecase) CommentDeleted(ctx context.Context, e event.CommentDeleted) {
+	u.publish(e.GroupID, "comment.deleted", liveEventData{PostID: e.PostID, CommentID: e.CommentID})
+}
+
+func (u *LiveEventUsecase) LikeChanged(ctx context.Context, e event.LikeChanged) {
+	eventType := "like.removed"
+	if e.Liked {
+		eventType = "like.added"
+	}
+	u.publish(e.GroupID, eventType, liveEventData{PostID: e.PostID, UserID: e.UserID})
+}
+
+func (u *LiveEventUsecase) PhotoChanged(ctx context.Context, e event.PhotoChanged) {
+	u.publish(e.GroupID, "photo."+string(e.Action), liveEventData{AlbumID: e.AlbumID, PhotoID: e.PhotoID})
+}
+
+func (u *LiveEventUsecase) TripChanged(ctx context.Context, e event.TripChanged) {
+	u.publish(e.GroupID, "trip."+string(e.Action), liveEventData{TripID: e.TripID, Section: e.Section})
+}
+
+func (u *LiveEventUsecase) publish(groupID uint, eventType string, data liveEventData) {
+	if err := u.broker.Publish(groupID, eventType, data); err != nil {
+		log.Printf("live event: failed to publish %s to group %d: %v", eventType, groupID, err)
+	}
+}
@@ -0,0 +1,66 @@
+package usecase
+
+import (
+	"context"
+
+	"memoria/internal/domain/repository"
+)
+
+// Notification categories. They double as NotificationSetting.Category; a
+// user without a setting row for a category receives it.
+const (
+	NotificationCategoryNewPost     = "new_post"
+	NotificationCategoryNewComment  = "new_comment"
+	NotificationCategoryAnniversary = "anniversary"
+	NotificationCategoryTrip        = "trip"
+)
+
+var notificationCategories = []string{
+	NotificationCategoryNewPost,
+	NotificationCategoryNewComment,
+	NotificationCategoryAnniversary,
+	NotificationCategoryTrip,
+}
+
+func isNotificationCategory(category string) bool {
+	for _, known := range notificationCategories {
+		if category == known {
+			return true
+		}
+	}
+	return false
+}
+
+// notificationBodyLength keeps bodies short enough for the inbox and for a
+// web push payload.
+const notificationBodyLength = 200
+
+// enabledRecipients drops the users who turned category off.
+func enabledRecipients(ctx context.Context, settingRepo repository.NotificationSettingRepository, category string, userIDs []uint) ([]uint, error) {
+	settings, err := settingRepo.FindByCategory(ctx, category, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	disabled := make(map[uint]bool)
+	for _, setting := range settings {
+		if !setting.Enabled {
+			disabled[setting.UserID] = true
+		}
+	}
+
+	recipients := make([]uint, 0, len(userIDs))
+	for _, userID := range userIDs {
+		if !disabled[userID] {
+			recipients = append(recipients, userID)
+		}
+	}
+	return recipients, nil
+}
+
+func truncateNotificationBody(body string) string {
+	runes := []rune(body)
+	if len(runes) <= notificationBodyLength {
+		return body
+	}
+	return string(runes[:notificationBodyLength-1]) + "…"
+}
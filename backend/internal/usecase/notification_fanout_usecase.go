@@ -0,0 +1,154 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"memoria/internal/domain/event"
+	"memoria/internal/domain/model"
+	"memoria/internal/domain/repository"
+)
+
+// NotificationFanoutUsecase turns post and comment events into in-app
+// notifications for the people involved.
+type NotificationFanoutUsecase struct {
+	notificationRepo repository.NotificationRepository
+	settingRepo      repository.NotificationSettingRepository
+	groupMemberRepo  repository.GroupMemberRepository
+	postRepo         repository.PostRepository
+	userRepo         repository.UserRepository
+	pushUsecase      *PushUsecase
+}
+
+func NewNotificationFanoutUsecase(
+	notificationRepo repository.NotificationRepository,
+	settingRepo repository.NotificationSettingRepository,
+	groupMemberRepo repository.GroupMemberRepository,
+	postRepo repository.PostRepository,
+	userRepo repository.UserRepository,
+	pushUsecase *PushUsecase,
+) *NotificationFanoutUsecase {
+	return &NotificationFanoutUsecase{
+		notificationRepo: notificationRepo,
+		settingRepo:      settingRepo,
+		groupMemberRepo:  groupMemberRepo,
+		postRepo:         postRepo,
+		userRepo:         userRepo,
+		pushUsecase:      pushUsecase,
+	}
+}
+
+// PostCreated notifies every other member of the group. New posts in a
+// group are grouped into one unread notification.
+func (u *NotificationFanoutUsecase) PostCreated(ctx context.Context, e event.PostCreated) {
+	post := e.Post
+	memberIDs, err := u.memberIDs(ctx, post.GroupID)
+	if err != nil {
+		log.Printf("notification: failed to load members of group %d: %v", post.GroupID, err)
+		return
+	}
+	delete(memberIDs, post.AuthorID)
+
+	body := postTitle(post)
+	if post.Title == "" {
+		body = post.Body
+	}
+	u.notify(ctx, NotificationCategoryNewPost, memberIDs, &model.Notification{
+		Title:    "新しい投稿",
+		Body:     fmt.Sprintf("%s: %s", u.userName(ctx, post.AuthorID), body),
+		GroupKey: fmt.Sprintf("posts:%d", post.GroupID),
+	})
+}
+
+// CommentCreated notifies the post's author and everyone who commented
+// before, except the commenter. Comments on one post are grouped into one
+// unread notification.
+func (u *NotificationFanoutUsecase) CommentCreated(ctx context.Context, e event.CommentCreated) {
+	post, comment := e.Post, e.Comment
+	memberIDs, err := u.memberIDs(ctx, post.GroupID)
+	if err != nil {
+		log.Printf("notification: failed to load members of group %d: %v", post.GroupID, err)
+		return
+	}
+	commenterIDs, err := u.postRepo.FindCommenterIDs(ctx, post.ID)
+	if err != nil {
+		log.Printf("notification: failed to load comments of post %d: %v", post.ID, err)
+		return
+	}
+
+	// Only people still in the group hear about it.
+	involved := map[uint]bool{}
+	if memberIDs[post.AuthorID] {
+		involved[post.AuthorID] = true
+	}
+	for _, userID := range commenterIDs {
+		if memberIDs[userID] {
+			involved[userID] = true
+		}
+	}
+	delete(involved, comment.UserID)
+
+	u.notify(ctx, NotificationCategoryNewComment, involved, &model.Notification{
+		Title:    fmt.Sprintf("「%s」に新しいコメント", postTitle(post)),
+		Body:     fmt.Sprintf("%s: %s", u.userName(ctx, comment.UserID), comment.Body),
+		GroupKey: fmt.Sprintf("comments:%d", post.ID),
+	})
+}
+
+// notify creates a copy of template for every user in userIDs who has the
+// category enabled, then pushes them.
+func (u *NotificationFanoutUsecase) notify(ctx context.Context, category string, userIDs map[uint]bool, template *model.Notification) {
+	if len(userIDs) == 0 {
+		return
+	}
+	ids := make([]uint, 0, len(userIDs))
+	for userID := range userIDs {
+		ids = append(ids, userID)
+	}
+	recipients, err := enabledRecipients(ctx, u.settingRepo, category, ids)
+	if err != nil {
+		log.Printf("notification: failed to load %s settings: %v", category, err)
+		return
+	}
+	if len(recipients) == 0 {
+		return
+	}
+
+	notifications := make([]*model.Notification, len(recipients))
+	for i, userID := range recipients {
+		notifications[i] = &model.Notification{
+			UserID:   userID,
+			Category: category,
+			Title:    template.Title,
+			Body:     truncateNotificationBody(template.Body),
+			GroupKey: template.GroupKey,
+			Count:    1,
+		}
+	}
+	if err := u.notificationRepo.CreateGrouped(ctx, notifications); err != nil {
+		log.Printf("notification: failed to create %s notifications: %v", category, err)
+		return
+	}
+	u.pushUsecase.Deliver(ctx, notifications)
+}
+
+func (u *NotificationFanoutUsecase) memberIDs(ctx context.Context, groupID uint) (map[uint]bool, error) {
+	members, err := u.groupMemberRepo.FindByGroupID(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[uint]bool, len(members))
+	for _, member := range members {
+		ids[member.UserID] = true
+	}
+	return ids, nil
+}
+
+func (u *NotificationFanoutUsecase) userName(ctx context.Context, userID uint) string {
+	user, err := u.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return "メンバー"
+	}
+	return displayName(user)
+}
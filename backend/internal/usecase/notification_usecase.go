@@ -0,0 +1,179 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"memoria/internal/domain/model"
+	"memoria/internal/domain/repository"
+)
+
+const (
+	defaultNotificationPageSize = 20
+	maxNotificationPageSize     = 100
+)
+
+var (
+	ErrInvalidNotificationCategory = errors.New("invalid notification category")
+	ErrInvalidPushSubscription     = errors.New("invalid push subscription")
+)
+
+type NotificationUsecase struct {
+	notificationRepo repository.NotificationRepository
+	settingRepo      repository.NotificationSettingRepository
+	pushRepo         repository.WebPushSubscriptionRepository
+	pushEndpoints    PushEndpointValidator
+}
+
+func NewNotificationUsecase(
+	notificationRepo repository.NotificationRepository,
+	settingRepo repository.NotificationSettingRepository,
+	pushRepo repository.WebPushSubscriptionRepository,
+	pushEndpoints PushEndpointValidator,
+) *NotificationUsecase {
+	return &NotificationUsecase{
+		notificationRepo: notificationRepo,
+		settingRepo:      settingRepo,
+		pushRepo:         pushRepo,
+		pushEndpoints:    pushEndpoints,
+	}
+}
+
+type NotificationPage struct {
+	Notifications []*model.Notification
+	UnreadCount   int64
+	// NextCursor is the cursor for the following page, or 0 on the last page.
+	NextCursor uint
+}
+
+// NotificationPreference is a category's effective setting; categories the
+// user never changed are enabled.
+type NotificationPreference struct {
+	Category string
+	Enabled  bool
+}
+
+// GetNotifications returns the user's notifications newest first, starting
+// after cursor (the ID of the last notification of the previous page).
+func (u *NotificationUsecase) GetNotifications(ctx context.Context, userID uint, cursor uint, limit int, unreadOnly bool) (*NotificationPage, error) {
+	if limit <= 0 {
+		limit = defaultNotificationPageSize
+	}
+	if limit > maxNotificationPageSize {
+		limit = maxNotificationPageSize
+	}
+
+	// Fetch one extra row to know whether another page exists.
+	notifications, err := u.notificationRepo.FindByUserID(ctx, userID, cursor, limit+1, unreadOnly)
+	if err != nil {
+		return nil, err
+	}
+	unreadCount, err := u.notificationRepo.CountUnread(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &NotificationPage{UnreadCount: unreadCount}
+	if len(notifications) > limit {
+		notifications = notifications[:limit]
+		page.NextCursor = notifications[limit-1].ID
+	}
+	page.Notifications = notifications
+	return page, nil
+}
+
+func (u *NotificationUsecase) MarkAsRead(ctx context.Context, id uint, userID uint) (*model.Notification, error) {
+	notification, err := u.notificationRepo.FindByID(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if notification.ReadAt != nil {
+		return notification, nil
+	}
+	if err := u.notificationRepo.MarkAsRead(ctx, id); err != nil {
+		return nil, err
+	}
+	return u.notificationRepo.FindByID(ctx, id, userID)
+}
+
+// MarkAllAsRead marks every unread notification as read and returns how many
+// were updated.
+func (u *NotificationUsecase) MarkAllAsRead(ctx context.Context, userID uint) (int64, error) {
+	return u.notificationRepo.MarkAllAsRead(ctx, userID)
+}
+
+func (u *NotificationUsecase) DeleteNotification(ctx context.Context, id uint, userID uint) error {
+	if _, err := u.notificationRepo.FindByID(ctx, id, userID); err != nil {
+		return err
+	}
+	return u.notificationRepo.Delete(ctx, id)
+}
+
+func (u *NotificationUsecase) GetSettings(ctx context.Context, userID uint) ([]NotificationPreference, error) {
+	settings, err := u.settingRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	enabled := make(map[string]bool, len(settings))
+	for _, setting := range settings {
+		enabled[setting.Category] = setting.Enabled
+	}
+
+	preferences := make([]NotificationPreference, len(notificationCategories))
+	for i, category := range notificationCategories {
+		value, ok := enabled[category]
+		preferences[i] = NotificationPreference{Category: category, Enabled: !ok || value}
+	}
+	return preferences, nil
+}
+
+// UpdateSettings stores the given categories and returns every category's
+// effective setting. Categories that are not listed keep their value.
+func (u *NotificationUsecase) UpdateSettings(ctx context.Context, userID uint, preferences []NotificationPreference) ([]NotificationPreference, error) {
+	for _, preference := range preferences {
+		if !isNotificationCategory(preference.Category) {
+			return nil, ErrInvalidNotificationCategory
+		}
+	}
+	for _, preference := range preferences {
+		setting := &model.NotificationSetting{
+			UserID:   userID,
+			Category: preference.Category,
+			Enabled:  preference.Enabled,
+		}
+		if err := u.settingRepo.Upsert(ctx, setting); err != nil {
+			return nil, err
+		}
+	}
+	return u.GetSettings(ctx, userID)
+}
+
+func (u *NotificationUsecase) GetPushSubscriptions(ctx context.Context, userID uint) ([]*model.WebPushSubscription, error) {
+	return u.pushRepo.FindByUserID(ctx, userID)
+}
+
+// RegisterPushSubscription stores a browser's PushSubscription. Registering
+// an endpoint that is already known updates its keys and owner. Only
+// endpoints on known push services are accepted.
+func (u *NotificationUsecase) RegisterPushSubscription(ctx context.Context, userID uint, endpoint, auth, p256dh string) (*model.WebPushSubscription, error) {
+	if u.pushEndpoints.ValidateEndpoint(endpoint) != nil || auth == "" || p256dh == "" {
+		return nil, ErrInvalidPushSubscription
+	}
+	subscription := &model.WebPushSubscription{
+		UserID:   userID,
+		Endpoint: endpoint,
+		Auth:     auth,
+		P256dh:   p256dh,
+	}
+	if err := u.pushRepo.Upsert(ctx, subscription); err != nil {
+		return nil, err
+	}
+	return u.pushRepo.FindByEndpoint(ctx, endpoint, userID)
+}
+
+func (u *NotificationUsecase) DeletePushSubscription(ctx context.Context, id uint, userID uint) error {
+	if _, err := u.pushRepo.FindByID(ctx, id, userID); err != nil {
+		return err
+	}
+	return u.pushRepo.Delete(ctx, id)
+}
@@ -0,0 +1,78 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"memoria/internal/domain/repository"
+)
+
+const (
+	// objectCleanupBatchSize bounds objects deleted per run.
+	objectCleanupBatchSize = 100
+	// objectCleanupLease keeps other workers off a queued object while it
+	// is being deleted.
+	objectCleanupLease = 5 * time.Minute
+	// objectCleanupBaseDelay is multiplied by 4 after every failed attempt,
+	// up to objectCleanupMaxDelay. Objects are retried until they are gone.
+	objectCleanupBaseDelay = time.Minute
+	objectCleanupMaxDelay  = 24 * time.Hour
+)
+
+// ObjectCleanupUsecase removes stored objects whose rows were deleted. The
+// rows queue their objects in the same transaction, so a failed or slow
+// storage call never holds up or undoes a delete.
+type ObjectCleanupUsecase struct {
+	deletionRepo  repository.ObjectDeletionRepository
+	objectStorage ObjectStorage
+}
+
+func NewObjectCleanupUsecase(deletionRepo repository.ObjectDeletionRepository, objectStorage ObjectStorage) *ObjectCleanupUsecase {
+	return &ObjectCleanupUsecase{
+		deletionRepo:  deletionRepo,
+		objectStorage: objectStorage,
+	}
+}
+
+// DeleteDue deletes queued objects whose retry time has passed and returns
+// how many were removed. Several workers may run it at once.
+func (u *ObjectCleanupUsecase) DeleteDue(ctx context.Context, now time.Time) (int, error) {
+	deletions, err := u.deletionRepo.ClaimDue(ctx, now, now.Add(objectCleanupLease), objectCleanupBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, deletion := range deletions {
+		if err := u.objectStorage.DeleteObject(ctx, deletion.S3Key); err != nil {
+			deletion.Attempts++
+			deletion.LastError = err.Error()
+			deletion.NextAttemptAt = now.Add(objectCleanupDelay(deletion.Attempts))
+			log.Printf("object cleanup: failed to delete %s (attempt %d): %v", deletion.S3Key, deletion.Attempts, err)
+			if err := u.deletionRepo.Update(ctx, deletion); err != nil {
+				return deleted, err
+			}
+			continue
+		}
+		if err := u.deletionRepo.Delete(ctx, deletion.ID); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	if deleted > 0 {
+		log.Printf("object cleanup: deleted=%d", deleted)
+	}
+	return deleted, nil
+}
+
+func objectCleanupDelay(attempts int) time.Duration {
+	delay := objectCleanupBaseDelay
+	for i := 1; i < attempts && delay < objectCleanupMaxDelay; i++ {
+		delay *= 4
+	}
+	if delay > objectCleanupMaxDelay {
+		return objectCleanupMaxDelay
+	}
+	return delay
+}
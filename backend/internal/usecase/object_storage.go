@@ -0,0 +1,97 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+var (
+	// ErrObjectNotFound is returned when the key has no object.
+	ErrObjectNotFound = errors.New("object not found")
+	// ErrNotModified is returned by OpenObject when the object still has
+	// the ETag given as If-None-Match.
+	ErrNotModified = errors.New("object not modified")
+	// ErrRangeNotSatisfiable is returned by OpenObject for a range outside
+	// the object.
+	ErrRangeNotSatisfiable = errors.New("range not satisfiable")
+	// ErrUploadNotFound is returned when a multipart upload has been
+	// completed or aborted, or never existed.
+	ErrUploadNotFound = errors.New("multipart upload not found")
+	// ErrInvalidPart is returned by CompleteMultipartUpload when a part is
+	// missing, has another ETag or, except for the last, is smaller than
+	// MinPartSize.
+	ErrInvalidPart = errors.New("invalid multipart upload part")
+)
+
+// S3's limits on multipart uploads: every part but the last must be at
+// least MinPartSize, and part numbers run from 1 to MaxParts.
+const (
+	MinPartSize = 5 << 20
+	MaxParts    = 10000
+)
+
+// ObjectInfo describes a stored object. Listings leave ContentType empty.
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	ETag         string
+	LastModified time.Time
+}
+
+// Object is an open object, or the requested range of it.
+type Object struct {
+	Body          io.ReadCloser
+	ContentType   string
+	ContentLength int64
+	// ContentRange is set when only a range was returned.
+	ContentRange string
+	ETag         string
+	LastModified time.Time
+}
+
+// CompletedPart is an uploaded part of a multipart upload and the ETag the
+// storage returned for it.
+type CompletedPart struct {
+	PartNumber int
+	ETag       string
+}
+
+// ObjectStorage keeps photo files; storage.S3Service and
+// storage.LocalStorage implement it. Presigned URLs let clients upload and
+// download without going through the API.
+type ObjectStorage interface {
+	// PresignPut signs a PUT of exactly sizeBytes bytes of contentType.
+	PresignPut(key string, contentType string, sizeBytes int64, expiresIn time.Duration) (string, error)
+	PresignGet(key string, expiresIn time.Duration) (string, error)
+	HeadObject(ctx context.Context, key string) (*ObjectInfo, error)
+	// GetObject reads a whole object, failing once it is larger than
+	// maxBytes.
+	GetObject(ctx context.Context, key string, maxBytes int64) ([]byte, error)
+	// GetObjectPrefix reads up to the first n bytes of an object.
+	GetObjectPrefix(ctx context.Context, key string, n int64) ([]byte, error)
+	// OpenObject streams an object, or the single range given in byteRange,
+	// and returns ErrNotModified when ifNoneMatch lists its ETag.
+	OpenObject(ctx context.Context, key, byteRange, ifNoneMatch string) (*Object, error)
+	PutObject(ctx context.Context, key, contentType string, data []byte) error
+	CopyObject(ctx context.Context, srcKey, dstKey string) error
+	// ListObjects returns up to limit objects under prefix, in key order
+	// after startAfter.
+	ListObjects(ctx context.Context, prefix, startAfter string, limit int) ([]ObjectInfo, error)
+	// DeleteObject succeeds when the object is already gone.
+	DeleteObject(ctx context.Context, key string) error
+
+	// Multipart uploads let a client upload a large file in parts and
+	// retry a failed part alone.
+	CreateMultipartUpload(ctx context.Context, key, contentType string) (string, error)
+	// PresignUploadPart signs a PUT of exactly sizeBytes bytes as the
+	// numbered part; the response's ETag header is the part's ETag.
+	PresignUploadPart(key, uploadID string, partNumber int, sizeBytes int64, expiresIn time.Duration) (string, error)
+	// CompleteMultipartUpload returns ErrInvalidPart when a part is missing
+	// or does not match its ETag.
+	CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []CompletedPart) error
+	// AbortMultipartUpload succeeds when the upload is already gone.
+	AbortMultipartUpload(ctx context.Context, key, uploadID string) error
+}
@@ -0,0 +1,71 @@
+package usecase
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// photoURLTTL is how long presigned photo URLs work.
+	photoURLTTL = 15 * time.Minute
+	// photoURLRenewBefore is how much time a cached URL must have left to
+	// be handed out again, so clients can still load it.
+	photoURLRenewBefore = 5 * time.Minute
+	// photoURLCacheSize bounds the cache; past it, expired URLs are dropped.
+	photoURLCacheSize = 10000
+)
+
+// PhotoURLs are presigned GET URLs for a photo's original and variants.
+type PhotoURLs struct {
+	Original string
+	Variants map[string]string // by variant name
+	// ExpiresAt is when the first of the URLs stops working.
+	ExpiresAt time.Time
+}
+
+type signedURL struct {
+	url       string
+	expiresAt time.Time
+}
+
+// photoURLCache reuses the URL signed for an S3 key until it is about to
+// expire, so a photo keeps the same URL across requests and browsers can
+// cache the image.
+type photoURLCache struct {
+	mu   sync.Mutex
+	urls map[string]signedURL
+}
+
+func newPhotoURLCache() *photoURLCache {
+	return &photoURLCache{urls: map[string]signedURL{}}
+}
+
+func (c *photoURLCache) get(key string, now time.Time, sign func() (string, error)) (signedURL, error) {
+	c.mu.Lock()
+	cached, ok := c.urls[key]
+	c.mu.Unlock()
+	if ok && now.Add(photoURLRenewBefore).Before(cached.expiresAt) {
+		return cached, nil
+	}
+
+	url, err := sign()
+	if err != nil {
+		return signedURL{}, err
+	}
+	signed := signedURL{url: url, expiresAt: now.Add(photoURLTTL)}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.urls) >= photoURLCacheSize {
+		for k, v := range c.urls {
+			if !now.Before(v.expiresAt) {
+				delete(c.urls, k)
+			}
+		}
+		if len(c.urls) >= photoURLCacheSize {
+			clear(c.urls)
+		}
+	}
+	c.urls[key] = signed
+	return signed, nil
+}
@@ -1,86 +1,357 @@
 package usecase
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
 	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
-	"memoria/internal/adapter/storage"
+	"memoria/internal/domain/event"
 	"memoria/internal/domain/model"
 	"memoria/internal/domain/repository"
 )
 
+const (
+	// photoUploadURLExpiry is how long the presigned PUT URL works.
+	photoUploadURLExpiry = 15 * time.Minute
+	// photoUploadTTL is how long an issued key can be registered as a
+	// photo, long enough for a slow upload started just before the URL
+	// expired. The object of a key that was not registered is deleted.
+	photoUploadTTL = time.Hour
+	// photoHeaderBytes is read from the object to find the image size. A
+	// JPEG's EXIF and other metadata come before its size.
+	photoHeaderBytes = 1 << 20
+	// uploadSweepBatchSize bounds expired uploads handled per run.
+	uploadSweepBatchSize = 100
+)
+
+// Upload error codes, for clients to show their own message.
+const (
+	UploadContentTypeNotAllowed = "content_type_not_allowed"
+	UploadFileTooLarge          = "file_too_large"
+	UploadInvalidSize           = "invalid_size"
+	UploadNotIssued             = "upload_not_issued"
+	UploadMissing               = "upload_missing"
+	UploadMismatch              = "upload_mismatch"
+	UploadUnsupportedImage      = "unsupported_image"
+)
+
+// UploadError is a rejected upload. MaxBytes and AllowedTypes are set when
+// they explain the rejection.
+type UploadError struct {
+	Code         string
+	Message      string
+	MaxBytes     int64
+	AllowedTypes []string
+}
+
+func (e *UploadError) Error() string {
+	return e.Message
+}
+
+var (
+	ErrUploadNotIssued  = &UploadError{Code: UploadNotIssued, Message: "s3_key was not issued for this album or has expired"}
+	ErrUploadMissing    = &UploadError{Code: UploadMissing, Message: "the file has not been uploaded"}
+	ErrUploadMismatch   = &UploadError{Code: UploadMismatch, Message: "the uploaded file does not match the content type and size it was issued for"}
+	ErrUnsupportedImage = &UploadError{Code: UploadUnsupportedImage, Message: "the uploaded file is not an image that can be read"}
+
+	ErrPhotoVariantNotFound = errors.New("the photo has no such variant yet")
+)
+
 type PhotoUsecase struct {
-	photoRepo repository.PhotoRepository
-	albumRepo repository.AlbumRepository
-	s3Service *storage.S3Service
+	photoRepo          repository.PhotoRepository
+	albumRepo          repository.AlbumRepository
+	groupRepo          repository.GroupRepository
+	uploadRepo         repository.PhotoUploadRepository
+	uow                repository.UnitOfWork
+	objectStorage      ObjectStorage
+	images             ImageCodec
+	events             *event.Bus
+	uploadMaxBytes     int64
+	uploadContentTypes []string
+	urls               *photoURLCache
 }
 
-func NewPhotoUsecase(photoRepo repository.PhotoRepository, albumRepo repository.AlbumRepository, s3Service *storage.S3Service) *PhotoUsecase {
+func NewPhotoUsecase(photoRepo repository.PhotoRepository, albumRepo repository.AlbumRepository, groupRepo repository.GroupRepository, uploadRepo repository.PhotoUploadRepository, uow repository.UnitOfWork, objectStorage ObjectStorage, images ImageCodec, events *event.Bus, uploadMaxBytes int64, uploadContentTypes []string) *PhotoUsecase {
 	return &PhotoUsecase{
-		photoRepo: photoRepo,
-		albumRepo: albumRepo,
-		s3Service: s3Service,
+		photoRepo:          photoRepo,
+		albumRepo:          albumRepo,
+		groupRepo:          groupRepo,
+		uploadRepo:         uploadRepo,
+		uow:                uow,
+		objectStorage:      objectStorage,
+		images:             images,
+		events:             events,
+		uploadMaxBytes:     uploadMaxBytes,
+		uploadContentTypes: uploadContentTypes,
+		urls:               newPhotoURLCache(),
 	}
 }
 
-func (u *PhotoUsecase) GenerateUploadURL(albumID uint, filename, contentType string, userID uint, groupID uint) (string, string, error) {
-	if _, err := u.albumRepo.FindByID(albumID, groupID); err != nil {
+// GenerateUploadURL issues a key in the album and a presigned URL to PUT
+// the file to. The file's content type must be allowed and its size within
+// the group's limit; the URL only accepts that content type and size. Only
+// the user it was issued to can register the key as a photo, and only until
+// it expires.
+func (u *PhotoUsecase) GenerateUploadURL(ctx context.Context, albumID uint, filename, contentType string, sizeBytes int64, userID uint, groupID uint) (string, string, error) {
+	contentType, err := u.checkUpload(ctx, albumID, contentType, sizeBytes, groupID)
+	if err != nil {
+		return "", "", err
+	}
+
+	key := photoUploadKey(albumID, userID, filename)
+	url, err := u.objectStorage.PresignPut(key, contentType, sizeBytes, photoUploadURLExpiry)
+	if err != nil {
+		return "", "", err
+	}
+
+	upload := &model.PhotoUpload{
+		GroupID:     groupID,
+		AlbumID:     albumID,
+		UserID:      userID,
+		S3Key:       key,
+		ContentType: contentType,
+		SizeBytes:   sizeBytes,
+		ExpiresAt:   time.Now().Add(photoUploadTTL),
+	}
+	if err := u.uploadRepo.Create(ctx, upload); err != nil {
 		return "", "", err
 	}
 
-	// Generate unique key
+	return url, key, nil
+}
+
+// checkUpload checks that a file of the content type and size may be
+// uploaded to the album, and returns the content type as it is signed.
+func (u *PhotoUsecase) checkUpload(ctx context.Context, albumID uint, contentType string, sizeBytes int64, groupID uint) (string, error) {
+	if _, err := u.albumRepo.FindByID(ctx, albumID, groupID); err != nil {
+		return "", err
+	}
+
+	contentType = strings.ToLower(strings.TrimSpace(contentType))
+	if _, ok := model.PhotoFormats[contentType]; !ok || !slices.Contains(u.uploadContentTypes, contentType) {
+		return "", &UploadError{
+			Code:         UploadContentTypeNotAllowed,
+			Message:      fmt.Sprintf("content type %q is not allowed", contentType),
+			AllowedTypes: u.uploadContentTypes,
+		}
+	}
+	maxBytes, err := u.groupUploadMaxBytes(ctx, groupID)
+	if err != nil {
+		return "", err
+	}
+	if sizeBytes <= 0 {
+		return "", &UploadError{Code: UploadInvalidSize, Message: "size_bytes must be positive", MaxBytes: maxBytes}
+	}
+	if sizeBytes > maxBytes {
+		return "", &UploadError{
+			Code:     UploadFileTooLarge,
+			Message:  fmt.Sprintf("the file is larger than %d bytes", maxBytes),
+			MaxBytes: maxBytes,
+		}
+	}
+	return contentType, nil
+}
+
+// photoUploadKey makes a unique key in the album for the user's file.
+func photoUploadKey(albumID, userID uint, filename string) string {
 	timestamp := time.Now().UnixNano()
 	ext := filepath.Ext(filename)
-	key := fmt.Sprintf("albums/%d/%d-%d%s", albumID, userID, timestamp, ext)
+	return fmt.Sprintf("albums/%d/%d-%d%s", albumID, userID, timestamp, ext)
+}
 
-	// Generate presigned URL (15 minutes)
-	url, err := u.s3Service.GeneratePresignedURL(key, contentType, 15*time.Minute)
+// groupUploadMaxBytes is the largest file the group may upload: its own
+// limit, or the server's when it has none.
+func (u *PhotoUsecase) groupUploadMaxBytes(ctx context.Context, groupID uint) (int64, error) {
+	group, err := u.groupRepo.FindByID(ctx, groupID)
 	if err != nil {
-		return "", "", err
+		return 0, err
+	}
+	if group.UploadMaxBytes != nil && *group.UploadMaxBytes < u.uploadMaxBytes {
+		return *group.UploadMaxBytes, nil
 	}
+	return u.uploadMaxBytes, nil
+}
 
-	return url, key, nil
+// CreatePhoto registers an uploaded file as a photo. The key must have been
+// issued to the user for the album, and the content type, size and
+// dimensions are read from the object rather than taken from the client.
+func (u *PhotoUsecase) CreatePhoto(ctx context.Context, albumID uint, s3Key string, uploadedBy uint, groupID uint) (*model.Photo, error) {
+	if _, err := u.albumRepo.FindByID(ctx, albumID, groupID); err != nil {
+		return nil, err
+	}
+
+	upload, err := u.uploadRepo.FindByKey(ctx, s3Key)
+	if err != nil || upload.GroupID != groupID || upload.AlbumID != albumID || upload.UserID != uploadedBy || !time.Now().Before(upload.ExpiresAt) {
+		return nil, ErrUploadNotIssued
+	}
+
+	photo, err := u.inspectUpload(ctx, upload)
+	if err != nil {
+		return nil, err
+	}
+	photo.UploadedBy = uploadedBy
+	photo.Status = model.PhotoPending
+	photo.ProcessAfter = time.Now()
+
+	err = u.uow.Do(ctx, func(repos repository.Repositories) error {
+		consumed, err := repos.PhotoUploads.Consume(ctx, upload.ID, time.Now())
+		if err != nil {
+			return err
+		}
+		if !consumed {
+			return ErrUploadNotIssued
+		}
+		return repos.Photos.Create(ctx, photo)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	u.events.Publish(ctx, event.PhotoChanged{GroupID: groupID, AlbumID: albumID, PhotoID: photo.ID, Action: event.ActionCreated})
+	return photo, nil
 }
 
-func (u *PhotoUsecase) CreatePhoto(albumID uint, s3Key, contentType string, sizeBytes int64, width, height int, uploadedBy uint, groupID uint) (*model.Photo, error) {
-	if _, err := u.albumRepo.FindByID(albumID, groupID); err != nil {
+// inspectUpload checks the uploaded object against its upload and reads the
+// photo's content type, size and dimensions from it.
+func (u *PhotoUsecase) inspectUpload(ctx context.Context, upload *model.PhotoUpload) (*model.Photo, error) {
+	info, err := u.objectStorage.HeadObject(ctx, upload.S3Key)
+	if errors.Is(err, ErrObjectNotFound) {
+		return nil, ErrUploadMissing
+	}
+	if err != nil {
 		return nil, err
 	}
+	if info.ContentType != upload.ContentType || info.Size != upload.SizeBytes {
+		return nil, ErrUploadMismatch
+	}
+	want, ok := model.PhotoFormats[info.ContentType]
+	if !ok {
+		return nil, ErrUnsupportedImage
+	}
 
-	photo := &model.Photo{
-		GroupID:     groupID,
-		AlbumID:     albumID,
-		S3Key:       s3Key,
-		ContentType: contentType,
-		SizeBytes:   sizeBytes,
+	header, err := u.objectStorage.GetObjectPrefix(ctx, upload.S3Key, photoHeaderBytes)
+	if errors.Is(err, ErrObjectNotFound) {
+		return nil, ErrUploadMissing
+	}
+	if err != nil {
+		return nil, err
+	}
+	format, width, height, err := u.images.ReadSize(header)
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+	if format != want {
+		return nil, ErrUploadMismatch
+	}
+
+	return &model.Photo{
+		GroupID:     upload.GroupID,
+		AlbumID:     upload.AlbumID,
+		S3Key:       upload.S3Key,
+		ContentType: info.ContentType,
+		SizeBytes:   info.Size,
 		Width:       width,
 		Height:      height,
-		UploadedBy:  uploadedBy,
+	}, nil
+}
+
+// SweepExpiredUploads queues the objects of issued keys that were never
+// registered as photos for deletion, and returns how many there were.
+// Several workers may run it at once.
+func (u *PhotoUsecase) SweepExpiredUploads(ctx context.Context, now time.Time) (int, error) {
+	expired, err := u.uploadRepo.ExpireDue(ctx, now, uploadSweepBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	if expired > 0 {
+		log.Printf("upload sweep: expired=%d", expired)
 	}
+	return expired, nil
+}
+
+func (u *PhotoUsecase) GetPhoto(ctx context.Context, id uint, groupID uint) (*model.Photo, error) {
+	return u.photoRepo.FindByID(ctx, id, groupID)
+}
+
+func (u *PhotoUsecase) GetVariants(ctx context.Context, photo *model.Photo) ([]*model.PhotoVariant, error) {
+	return u.photoRepo.FindVariants(ctx, []uint{photo.ID})
+}
 
-	if err := u.photoRepo.Create(photo); err != nil {
+// SignURLs returns presigned GET URLs for the photo and its variants. A URL
+// is reused until shortly before it expires.
+func (u *PhotoUsecase) SignURLs(photo *model.Photo, variants []*model.PhotoVariant) (*PhotoURLs, error) {
+	now := time.Now()
+	original, err := u.signURL(photo.S3Key, now)
+	if err != nil {
 		return nil, err
 	}
+	urls := &PhotoURLs{
+		Original:  original.url,
+		Variants:  make(map[string]string, len(variants)),
+		ExpiresAt: original.expiresAt,
+	}
+	for _, variant := range variants {
+		signed, err := u.signURL(variant.S3Key, now)
+		if err != nil {
+			return nil, err
+		}
+		urls.Variants[variant.Name] = signed.url
+		if signed.expiresAt.Before(urls.ExpiresAt) {
+			urls.ExpiresAt = signed.expiresAt
+		}
+	}
+	return urls, nil
+}
 
-	return photo, nil
+func (u *PhotoUsecase) signURL(key string, now time.Time) (signedURL, error) {
+	return u.urls.get(key, now, func() (string, error) {
+		return u.objectStorage.PresignGet(key, photoURLTTL)
+	})
 }
 
-func (u *PhotoUsecase) GetPhoto(id uint, groupID uint) (*model.Photo, error) {
-	return u.photoRepo.FindByID(id, groupID)
+// OpenContent streams the photo's original, or the named variant, for
+// clients that cannot reach the storage. byteRange and ifNoneMatch are
+// passed on to it; see ObjectStorage.OpenObject.
+func (u *PhotoUsecase) OpenContent(ctx context.Context, photo *model.Photo, variantName, byteRange, ifNoneMatch string) (*Object, error) {
+	key := photo.S3Key
+	if variantName != "" {
+		variants, err := u.GetVariants(ctx, photo)
+		if err != nil {
+			return nil, err
+		}
+		key = ""
+		for _, variant := range variants {
+			if variant.Name == variantName {
+				key = variant.S3Key
+			}
+		}
+		if key == "" {
+			return nil, ErrPhotoVariantNotFound
+		}
+	}
+	return u.objectStorage.OpenObject(ctx, key, byteRange, ifNoneMatch)
 }
 
-func (u *PhotoUsecase) DeletePhoto(id uint, groupID uint) error {
-	photo, err := u.photoRepo.FindByID(id, groupID)
+func (u *PhotoUsecase) DeletePhoto(ctx context.Context, id uint, actor *model.GroupMember) error {
+	groupID := actor.GroupID
+	photo, err := u.photoRepo.FindByID(ctx, id, groupID)
 	if err != nil {
 		return err
 	}
+	if err := authorize(actor, actionDeletePhoto, photo.UploadedBy); err != nil {
+		return err
+	}
 
-	// Delete from S3
-	if err := u.s3Service.DeleteObject(photo.S3Key); err != nil {
+	// The S3 object stays until the trash is purged.
+	if err := u.photoRepo.Delete(ctx, id); err != nil {
 		return err
 	}
 
-	// Delete from database
-	return u.photoRepo.Delete(id)
+	u.events.Publish(ctx, event.PhotoChanged{GroupID: groupID, AlbumID: photo.AlbumID, PhotoID: id, Action: event.ActionDeleted})
+	return nil
 }
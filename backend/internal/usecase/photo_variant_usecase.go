@@ -0,0 +1,174 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+	"time"
+
+	"memoria/internal/domain/model"
+	"memoria/internal/domain/repository"
+)
+
+const (
+	// photoVariantBatchSize bounds photos processed per run; each one is
+	// downloaded and decoded in memory.
+	photoVariantBatchSize = 10
+	// photoVariantLease keeps other workers off a photo while it is being
+	// processed.
+	photoVariantLease = 10 * time.Minute
+	// A photo that cannot be read or stored is retried after 1, 4, 16 and
+	// 64 minutes, then marked failed.
+	photoVariantMaxAttempts = 5
+	photoVariantBaseDelay   = time.Minute
+	// photoOriginalMaxBytes caps the original downloaded for processing.
+	photoOriginalMaxBytes = 64 << 20
+	photoVariantQuality   = 82
+)
+
+// photoVariantSizes are the variants made of every photo, largest first so
+// that each one is scaled from the previous. size bounds the longer side.
+var photoVariantSizes = []struct {
+	name string
+	size int
+}{
+	{"large", 2048},
+	{"medium", 1024},
+	{"thumb", 320},
+}
+
+// PhotoVariantUsecase makes the resized copies clients show in place of
+// the original, and records each photo's real dimensions.
+type PhotoVariantUsecase struct {
+	photoRepo     repository.PhotoRepository
+	objectStorage ObjectStorage
+	images        ImageCodec
+}
+
+func NewPhotoVariantUsecase(photoRepo repository.PhotoRepository, objectStorage ObjectStorage, images ImageCodec) *PhotoVariantUsecase {
+	return &PhotoVariantUsecase{
+		photoRepo:     photoRepo,
+		objectStorage: objectStorage,
+		images:        images,
+	}
+}
+
+// ProcessPending makes the variants of pending photos whose next attempt
+// is due and returns how many photos it handled, successfully or not.
+// Photos that can be measured but not decoded, such as WebP and HEIC, are
+// kept as original_only without variants. Several workers may run it at
+// once.
+func (u *PhotoVariantUsecase) ProcessPending(ctx context.Context, now time.Time) (int, error) {
+	photos, err := u.photoRepo.ClaimPending(ctx, now, now.Add(photoVariantLease), photoVariantBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	ready, originalOnly, failed := 0, 0, 0
+	for _, photo := range photos {
+		if err := ctx.Err(); err != nil {
+			return ready + originalOnly + failed, err
+		}
+		variants, err := u.makeVariants(ctx, photo)
+		if err == nil {
+			photo.Status = model.PhotoReady
+			if len(variants) == 0 {
+				photo.Status = model.PhotoOriginalOnly
+			}
+			photo.ProcessingError = ""
+			if err := u.photoRepo.SaveVariants(ctx, photo, variants); err != nil {
+				return ready + originalOnly + failed, err
+			}
+			if len(variants) == 0 {
+				originalOnly++
+			} else {
+				ready++
+			}
+			continue
+		}
+
+		photo.ProcessingAttempts++
+		photo.ProcessingError = err.Error()
+		photo.ProcessAfter = now.Add(photoVariantDelay(photo.ProcessingAttempts))
+		if errors.Is(err, ErrUnreadableImage) || photo.ProcessingAttempts >= photoVariantMaxAttempts {
+			photo.Status = model.PhotoFailed
+			failed++
+		}
+		log.Printf("photo variants: photo %d (attempt %d): %v", photo.ID, photo.ProcessingAttempts, err)
+		if err := u.photoRepo.UpdateProcessing(ctx, photo); err != nil {
+			return ready + originalOnly + failed, err
+		}
+	}
+	if ready > 0 || originalOnly > 0 || failed > 0 {
+		log.Printf("photo variants: ready=%d original_only=%d failed=%d", ready, originalOnly, failed)
+	}
+	return len(photos), nil
+}
+
+// Requeue makes failed photos, or with all every photo, pending again so
+// their variants are made afresh. It returns how many were queued.
+func (u *PhotoVariantUsecase) Requeue(ctx context.Context, all bool) (int64, error) {
+	statuses := []string{model.PhotoFailed}
+	if all {
+		statuses = append(statuses, model.PhotoReady, model.PhotoOriginalOnly)
+	}
+	return u.photoRepo.Requeue(ctx, statuses, time.Now())
+}
+
+// makeVariants downloads the original, sets the photo's real dimensions
+// and uploads its variants. It returns no variants for an image that can
+// only be measured.
+func (u *PhotoVariantUsecase) makeVariants(ctx context.Context, photo *model.Photo) ([]*model.PhotoVariant, error) {
+	data, err := u.objectStorage.GetObject(ctx, photo.S3Key, photoOriginalMaxBytes)
+	if err != nil {
+		return nil, fmt.Errorf("download original: %w", err)
+	}
+	img, err := u.images.Decode(data)
+	if errors.Is(err, ErrUnreadableImage) {
+		if _, width, height, sizeErr := u.images.ReadSize(data); sizeErr == nil {
+			photo.Width, photo.Height = width, height
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	photo.Width, photo.Height = img.Size()
+
+	variants := make([]*model.PhotoVariant, 0, len(photoVariantSizes))
+	for _, spec := range photoVariantSizes {
+		img = img.Fit(spec.size)
+		encoded, err := img.EncodeJPEG(photoVariantQuality)
+		if err != nil {
+			return nil, fmt.Errorf("encode %s: %w", spec.name, err)
+		}
+		variant := &model.PhotoVariant{
+			Name:      spec.name,
+			S3Key:     photoVariantKey(photo.S3Key, spec.name),
+			SizeBytes: int64(len(encoded)),
+		}
+		variant.Width, variant.Height = img.Size()
+		if err := u.objectStorage.PutObject(ctx, variant.S3Key, "image/jpeg", encoded); err != nil {
+			return nil, fmt.Errorf("upload %s: %w", spec.name, err)
+		}
+		variants = append(variants, variant)
+	}
+	return variants, nil
+}
+
+// photoVariantKey derives a variant's key from the original's, e.g.
+// albums/1/2-123.png becomes albums/1/2-123_thumb.jpg.
+func photoVariantKey(key, name string) string {
+	return strings.TrimSuffix(key, path.Ext(key)) + "_" + name + ".jpg"
+}
+
+func photoVariantDelay(attempts int) time.Duration {
+	delay := photoVariantBaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 4
+	}
+	return delay
+}
@@ -0,0 +1,324 @@
+package usecase_test
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+	"time"
+
+	"memoria/internal/adapter/imaging"
+	"memoria/internal/adapter/storage"
+	"memoria/internal/domain/model"
+	"memoria/internal/domain/repository"
+	"memoria/internal/usecase"
+)
+
+// memPhotoRepo keeps photos and their variants in memory.
+type memPhotoRepo struct {
+	repository.PhotoRepository
+	photos   map[uint]*model.Photo
+	variants map[uint][]*model.PhotoVariant
+}
+
+func newMemPhotoRepo() *memPhotoRepo {
+	return &memPhotoRepo{
+		photos:   make(map[uint]*model.Photo),
+		variants: make(map[uint][]*model.PhotoVariant),
+	}
+}
+
+func (r *memPhotoRepo) add(id uint, key string) *model.Photo {
+	photo := &model.Photo{S3Key: key, Status: model.PhotoPending}
+	photo.ID = id
+	r.photos[id] = photo
+	return photo
+}
+
+func (r *memPhotoRepo) ClaimPending(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*model.Photo, error) {
+	var claimed []*model.Photo
+	for _, photo := range r.photos {
+		if photo.Status != model.PhotoPending || photo.ProcessAfter.After(now) || len(claimed) == limit {
+			continue
+		}
+		photo.ProcessAfter = leaseUntil
+		copied := *photo
+		claimed = append(claimed, &copied)
+	}
+	return claimed, nil
+}
+
+func (r *memPhotoRepo) SaveVariants(ctx context.Context, photo *model.Photo, variants []*model.PhotoVariant) error {
+	copied := *photo
+	r.photos[photo.ID] = &copied
+	r.variants[photo.ID] = variants
+	return nil
+}
+
+func (r *memPhotoRepo) UpdateProcessing(ctx context.Context, photo *model.Photo) error {
+	copied := *photo
+	r.photos[photo.ID] = &copied
+	return nil
+}
+
+func (r *memPhotoRepo) Requeue(ctx context.Context, statuses []string, now time.Time) (int64, error) {
+	var n int64
+	for _, photo := range r.photos {
+		for _, status := range statuses {
+			if photo.Status == status {
+				photo.Status = model.PhotoPending
+				photo.ProcessingAttempts = 0
+				photo.ProcessAfter = now
+				n++
+			}
+		}
+	}
+	return n, nil
+}
+
+func newTestLocalStorage(t *testing.T) *storage.LocalStorage {
+	t.Helper()
+	s, err := storage.NewLocalStorage(t.TempDir(), "http://api.example.com", "test-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+type wantVariant struct {
+	name          string
+	key           string
+	width, height int
+}
+
+// checkVariants compares the recorded variants with want and checks that
+// each one is stored as a JPEG of the recorded size and dimensions.
+func checkVariants(t *testing.T, store *storage.LocalStorage, got []*model.PhotoVariant, want []wantVariant) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d variants, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		v := got[i]
+		if v.Name != w.name || v.S3Key != w.key || v.Width != w.width || v.Height != w.height {
+			t.Errorf("variant %d = %s %s %dx%d, want %s %s %dx%d", i, v.Name, v.S3Key, v.Width, v.Height, w.name, w.key, w.width, w.height)
+		}
+		info, err := store.HeadObject(context.Background(), v.S3Key)
+		if err != nil {
+			t.Fatalf("HeadObject(%s): %v", v.S3Key, err)
+		}
+		if info.Size != v.SizeBytes || info.ContentType != "image/jpeg" {
+			t.Errorf("%s is stored as %d bytes of %s, want %d bytes of image/jpeg", v.S3Key, info.Size, info.ContentType, v.SizeBytes)
+		}
+		data, err := store.GetObject(context.Background(), v.S3Key, 1<<20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s is not a JPEG: %v", v.S3Key, err)
+		}
+		if cfg.Width != w.width || cfg.Height != w.height {
+			t.Errorf("%s is %dx%d, want %dx%d", v.S3Key, cfg.Width, cfg.Height, w.width, w.height)
+		}
+	}
+}
+
+func TestPhotoVariantsProcessPending(t *testing.T) {
+	ctx := context.Background()
+	store := newTestLocalStorage(t)
+	photos := newMemPhotoRepo()
+	u := usecase.NewPhotoVariantUsecase(photos, store, imaging.Codec{})
+
+	if err := store.PutObject(ctx, "albums/1/2-123.png", "image/png", encodePNG(t, 3000, 1500)); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.PutObject(ctx, "albums/1/3-456.png", "image/png", encodePNG(t, 200, 300)); err != nil {
+		t.Fatal(err)
+	}
+	photos.add(1, "albums/1/2-123.png")
+	photos.add(2, "albums/1/3-456.png")
+
+	n, err := u.ProcessPending(ctx, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Fatalf("ProcessPending handled %d photos, want 2", n)
+	}
+
+	large := photos.photos[1]
+	if large.Status != model.PhotoReady || large.Width != 3000 || large.Height != 1500 {
+		t.Errorf("photo 1 = %s %dx%d, want ready 3000x1500", large.Status, large.Width, large.Height)
+	}
+	checkVariants(t, store, photos.variants[1], []wantVariant{
+		{"large", "albums/1/2-123_large.jpg", 2048, 1024},
+		{"medium", "albums/1/2-123_medium.jpg", 1024, 512},
+		{"thumb", "albums/1/2-123_thumb.jpg", 320, 160},
+	})
+
+	// Variants are never larger than the original.
+	small := photos.photos[2]
+	if small.Status != model.PhotoReady || small.Width != 200 || small.Height != 300 {
+		t.Errorf("photo 2 = %s %dx%d, want ready 200x300", small.Status, small.Width, small.Height)
+	}
+	checkVariants(t, store, photos.variants[2], []wantVariant{
+		{"large", "albums/1/3-456_large.jpg", 200, 300},
+		{"medium", "albums/1/3-456_medium.jpg", 200, 300},
+		{"thumb", "albums/1/3-456_thumb.jpg", 200, 300},
+	})
+}
+
+func TestPhotoVariantsRerunIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	store := newTestLocalStorage(t)
+	photos := newMemPhotoRepo()
+	u := usecase.NewPhotoVariantUsecase(photos, store, imaging.Codec{})
+
+	if err := store.PutObject(ctx, "albums/1/2-123.png", "image/png", encodePNG(t, 1600, 1200)); err != nil {
+		t.Fatal(err)
+	}
+	photos.add(1, "albums/1/2-123.png")
+
+	if _, err := u.ProcessPending(ctx, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	first := photos.variants[1]
+
+	if n, err := u.Requeue(ctx, true); err != nil || n != 1 {
+		t.Fatalf("Requeue = %d, %v, want 1", n, err)
+	}
+	if n, err := u.ProcessPending(ctx, time.Now()); err != nil || n != 1 {
+		t.Fatalf("second ProcessPending = %d, %v, want 1", n, err)
+	}
+	// Nothing is left to do once the photo is ready.
+	if n, err := u.ProcessPending(ctx, time.Now()); err != nil || n != 0 {
+		t.Fatalf("third ProcessPending = %d, %v, want 0", n, err)
+	}
+
+	want := []wantVariant{
+		{"large", "albums/1/2-123_large.jpg", 1600, 1200},
+		{"medium", "albums/1/2-123_medium.jpg", 1024, 768},
+		{"thumb", "albums/1/2-123_thumb.jpg", 320, 240},
+	}
+	checkVariants(t, store, photos.variants[1], want)
+	for i, v := range photos.variants[1] {
+		if v.SizeBytes != first[i].SizeBytes {
+			t.Errorf("%s is %d bytes after the re-run, was %d", v.S3Key, v.SizeBytes, first[i].SizeBytes)
+		}
+	}
+
+	// The re-run overwrote the variants instead of adding objects.
+	objects, err := store.ListObjects(ctx, "albums/1/", "", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := make([]string, 0, len(objects))
+	for _, o := range objects {
+		keys = append(keys, o.Key)
+	}
+	wantKeys := []string{"albums/1/2-123.png", "albums/1/2-123_large.jpg", "albums/1/2-123_medium.jpg", "albums/1/2-123_thumb.jpg"}
+	if len(keys) != len(wantKeys) {
+		t.Fatalf("objects = %v, want %v", keys, wantKeys)
+	}
+	for i := range wantKeys {
+		if keys[i] != wantKeys[i] {
+			t.Errorf("objects = %v, want %v", keys, wantKeys)
+			break
+		}
+	}
+}
+
+// webpHeader is the start of an extended WebP file, enough to read its
+// size but not its pixels.
+func webpHeader(width, height int) []byte {
+	data := []byte("RIFF\x16\x00\x00\x00WEBPVP8X\x0a\x00\x00\x00\x00\x00\x00\x00")
+	for _, n := range []int{width - 1, height - 1} {
+		data = append(data, byte(n), byte(n>>8), byte(n>>16))
+	}
+	return data
+}
+
+func TestPhotoVariantsOriginalOnly(t *testing.T) {
+	ctx := context.Background()
+	store := newTestLocalStorage(t)
+	photos := newMemPhotoRepo()
+	u := usecase.NewPhotoVariantUsecase(photos, store, imaging.Codec{})
+
+	if err := store.PutObject(ctx, "albums/1/2-123.webp", "image/webp", webpHeader(1200, 800)); err != nil {
+		t.Fatal(err)
+	}
+	photos.add(1, "albums/1/2-123.webp")
+
+	if n, err := u.ProcessPending(ctx, time.Now()); err != nil || n != 1 {
+		t.Fatalf("ProcessPending = %d, %v, want 1", n, err)
+	}
+	// A WebP can be measured but not decoded, so only the original is shown.
+	p := photos.photos[1]
+	if p.Status != model.PhotoOriginalOnly || p.Width != 1200 || p.Height != 800 || p.ProcessingAttempts != 0 {
+		t.Errorf("photo = %s %dx%d after %d attempts, want original_only 1200x800 after 0", p.Status, p.Width, p.Height, p.ProcessingAttempts)
+	}
+	if len(photos.variants[1]) != 0 {
+		t.Errorf("got %d variants, want none", len(photos.variants[1]))
+	}
+
+	// Only a full re-run picks it up again.
+	if n, err := u.Requeue(ctx, false); err != nil || n != 0 {
+		t.Errorf("Requeue(failed) = %d, %v, want 0", n, err)
+	}
+	if n, err := u.Requeue(ctx, true); err != nil || n != 1 {
+		t.Errorf("Requeue(all) = %d, %v, want 1", n, err)
+	}
+}
+
+func TestPhotoVariantsFailures(t *testing.T) {
+	ctx := context.Background()
+	store := newTestLocalStorage(t)
+	photos := newMemPhotoRepo()
+	u := usecase.NewPhotoVariantUsecase(photos, store, imaging.Codec{})
+
+	if err := store.PutObject(ctx, "albums/1/2-123.heic", "image/heic", []byte("not an image")); err != nil {
+		t.Fatal(err)
+	}
+	photos.add(1, "albums/1/2-123.heic")
+	photos.add(2, "albums/1/3-456.png") // never uploaded
+
+	now := time.Now()
+	if _, err := u.ProcessPending(ctx, now); err != nil {
+		t.Fatal(err)
+	}
+
+	// An unreadable image fails at once.
+	if p := photos.photos[1]; p.Status != model.PhotoFailed || p.ProcessingAttempts != 1 {
+		t.Errorf("unreadable photo = %s after %d attempts, want failed after 1", p.Status, p.ProcessingAttempts)
+	}
+	// A missing original is retried a minute later.
+	p := photos.photos[2]
+	if p.Status != model.PhotoPending || p.ProcessingAttempts != 1 || p.ProcessingError == "" {
+		t.Errorf("missing original = %s after %d attempts (%q), want pending after 1", p.Status, p.ProcessingAttempts, p.ProcessingError)
+	}
+	if !p.ProcessAfter.Equal(now.Add(time.Minute)) {
+		t.Errorf("next attempt = %v, want %v", p.ProcessAfter, now.Add(time.Minute))
+	}
+	if len(photos.variants) != 0 {
+		t.Errorf("variants were saved for failed photos: %v", photos.variants)
+	}
+}
@@ -0,0 +1,82 @@
+package usecase
+
+import (
+	"errors"
+
+	"memoria/internal/domain/model"
+)
+
+// ErrForbidden is returned when the member's role does not allow the action.
+var ErrForbidden = errors.New("forbidden")
+
+// action is something a group member does that not every member may do.
+type action string
+
+const (
+	actionManageInvites       action = "invites.manage"
+	actionManageExchangeRates action = "exchange_rates.manage"
+	actionManageWebhooks      action = "webhooks.manage"
+	actionChangeMemberRole    action = "members.change_role"
+	actionRemoveMember        action = "members.remove"
+	actionEditGroup           action = "group.edit"
+	actionTransferGroup       action = "group.transfer"
+	actionDeleteGroup         action = "group.delete"
+	actionRestoreGroup        action = "group.restore"
+	actionEditAlbum           action = "album.edit"
+	actionDeleteAlbum         action = "album.delete"
+	actionDeletePhoto         action = "photo.delete"
+	actionEditPost            action = "post.edit"
+	actionDeletePost          action = "post.delete"
+	actionDeleteComment       action = "comment.delete"
+	actionEditTrip            action = "trip.edit"
+	actionDeleteTrip          action = "trip.delete"
+)
+
+// permission allows an action to members whose role is in roles and, when
+// author is set, to whoever created the resource.
+type permission struct {
+	roles  []string
+	author bool
+}
+
+// policy is the one place group permissions are decided. Anything not
+// listed here, such as creating content or editing a trip's plan, is open
+// to every member of the group.
+var policy = map[action]permission{
+	actionManageInvites:       {roles: []string{model.RoleManager}},
+	actionManageExchangeRates: {roles: []string{model.RoleManager}},
+	actionManageWebhooks:      {roles: []string{model.RoleManager}},
+	actionChangeMemberRole:    {roles: []string{model.RoleManager}},
+	actionRemoveMember:        {roles: []string{model.RoleManager}},
+	actionEditGroup:           {roles: []string{model.RoleManager}},
+	actionTransferGroup:       {author: true},
+	actionDeleteGroup:         {author: true},
+	actionRestoreGroup:        {author: true},
+	actionEditAlbum:           {roles: []string{model.RoleManager}, author: true},
+	actionDeleteAlbum:         {roles: []string{model.RoleManager}, author: true},
+	actionDeletePhoto:         {roles: []string{model.RoleManager}, author: true},
+	actionEditPost:            {roles: []string{model.RoleManager}, author: true},
+	actionDeletePost:          {roles: []string{model.RoleManager}, author: true},
+	actionDeleteComment:       {roles: []string{model.RoleManager}, author: true},
+	actionEditTrip:            {roles: []string{model.RoleManager}, author: true},
+	actionDeleteTrip:          {roles: []string{model.RoleManager}, author: true},
+}
+
+// authorize returns ErrForbidden unless actor may perform the action on a
+// resource created by authorID. Pass 0 for actions without a resource.
+// Unknown actions are denied.
+func authorize(actor *model.GroupMember, a action, authorID uint) error {
+	perm, ok := policy[a]
+	if !ok || actor == nil {
+		return ErrForbidden
+	}
+	if perm.author && authorID != 0 && authorID == actor.UserID {
+		return nil
+	}
+	for _, role := range perm.roles {
+		if actor.Role == role {
+			return nil
+		}
+	}
+	return ErrForbidden
+}
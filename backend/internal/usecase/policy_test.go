@@ -0,0 +1,188 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"memoria/internal/domain/model"
+	"memoria/internal/domain/repository"
+)
+
+const (
+	testGroupID  uint = 1
+	testAuthorID uint = 10
+)
+
+// Actors for the policy table. The owner created the resource (or the
+// group) but holds no role beyond member; the member acts on someone else's
+// resource.
+var (
+	policyOwner   = &model.GroupMember{GroupID: testGroupID, UserID: testAuthorID, Role: model.RoleMember}
+	policyManager = &model.GroupMember{GroupID: testGroupID, UserID: 20, Role: model.RoleManager}
+	policyMember  = &model.GroupMember{GroupID: testGroupID, UserID: 30, Role: model.RoleMember}
+)
+
+func TestAuthorize(t *testing.T) {
+	type allowed struct{ owner, manager, member bool }
+	managers := allowed{manager: true}
+	authors := allowed{owner: true}
+	managersAndAuthors := allowed{owner: true, manager: true}
+
+	tests := []struct {
+		action action
+		// withAuthor passes testAuthorID as the resource's author. Actions
+		// on the group itself pass the group creator the same way.
+		withAuthor bool
+		want       allowed
+	}{
+		{actionManageInvites, false, managers},
+		{actionManageExchangeRates, false, managers},
+		{actionManageWebhooks, false, managers},
+		{actionChangeMemberRole, false, managers},
+		{actionRemoveMember, false, managers},
+		{actionEditGroup, false, managers},
+		{actionTransferGroup, true, authors},
+		{actionDeleteGroup, true, authors},
+		{actionRestoreGroup, true, authors},
+		{actionEditAlbum, true, managersAndAuthors},
+		{actionDeleteAlbum, true, managersAndAuthors},
+		{actionDeletePhoto, true, managersAndAuthors},
+		{actionEditPost, true, managersAndAuthors},
+		{actionDeletePost, true, managersAndAuthors},
+		{actionDeleteComment, true, managersAndAuthors},
+		{actionEditTrip, true, managersAndAuthors},
+		{actionDeleteTrip, true, managersAndAuthors},
+	}
+	if len(tests) != len(policy) {
+		t.Fatalf("table covers %d actions, policy has %d", len(tests), len(policy))
+	}
+
+	for _, tt := range tests {
+		var authorID uint
+		if tt.withAuthor {
+			authorID = testAuthorID
+		}
+		actors := []struct {
+			name  string
+			actor *model.GroupMember
+			want  bool
+		}{
+			{"owner", policyOwner, tt.want.owner},
+			{"manager", policyManager, tt.want.manager},
+			{"member", policyMember, tt.want.member},
+		}
+		for _, a := range actors {
+			t.Run(string(tt.action)+"/"+a.name, func(t *testing.T) {
+				err := authorize(a.actor, tt.action, authorID)
+				if a.want && err != nil {
+					t.Errorf("authorize = %v, want allowed", err)
+				}
+				if !a.want && !errors.Is(err, ErrForbidden) {
+					t.Errorf("authorize = %v, want ErrForbidden", err)
+				}
+			})
+		}
+	}
+}
+
+func TestAuthorizeDeniesUnknownActionsAndNoActor(t *testing.T) {
+	if err := authorize(policyManager, action("group.unknown"), 0); !errors.Is(err, ErrForbidden) {
+		t.Errorf("unknown action: authorize = %v, want ErrForbidden", err)
+	}
+	if err := authorize(nil, actionEditPost, testAuthorID); !errors.Is(err, ErrForbidden) {
+		t.Errorf("no actor: authorize = %v, want ErrForbidden", err)
+	}
+	// Author rights need a known author; 0 never matches.
+	author := &model.GroupMember{GroupID: testGroupID, Role: model.RoleMember}
+	if err := authorize(author, actionEditPost, 0); !errors.Is(err, ErrForbidden) {
+		t.Errorf("no author: authorize = %v, want ErrForbidden", err)
+	}
+}
+
+// memGroupMemberRepo keeps one group's members in memory. UpdateRole keeps
+// the last manager as the database implementation does.
+type memGroupMemberRepo struct {
+	repository.GroupMemberRepository
+	members map[uint]*model.GroupMember
+}
+
+func newMemGroupMemberRepo(members ...*model.GroupMember) *memGroupMemberRepo {
+	repo := &memGroupMemberRepo{members: make(map[uint]*model.GroupMember)}
+	for _, m := range members {
+		copied := *m
+		repo.members[m.UserID] = &copied
+	}
+	return repo
+}
+
+func (r *memGroupMemberRepo) FindByGroupAndUser(ctx context.Context, groupID, userID uint) (*model.GroupMember, error) {
+	m, ok := r.members[userID]
+	if !ok || m.GroupID != groupID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *m
+	return &copied, nil
+}
+
+func (r *memGroupMemberRepo) UpdateRole(ctx context.Context, groupID, userID uint, role string) (bool, error) {
+	m, ok := r.members[userID]
+	if !ok || m.GroupID != groupID {
+		return false, gorm.ErrRecordNotFound
+	}
+	managers := 0
+	for _, other := range r.members {
+		if other.GroupID == groupID && other.Role == model.RoleManager {
+			managers++
+		}
+	}
+	if role != model.RoleManager && m.Role == model.RoleManager && managers == 1 {
+		return false, nil
+	}
+	m.Role = role
+	return true, nil
+}
+
+func TestChangeMemberRole(t *testing.T) {
+	manager := &model.GroupMember{GroupID: testGroupID, UserID: 1, Role: model.RoleManager}
+	secondManager := &model.GroupMember{GroupID: testGroupID, UserID: 2, Role: model.RoleManager}
+	member := &model.GroupMember{GroupID: testGroupID, UserID: 3, Role: model.RoleMember}
+
+	tests := []struct {
+		name     string
+		members  []*model.GroupMember
+		actor    *model.GroupMember
+		userID   uint
+		role     string
+		wantErr  error
+		wantRole string
+	}{
+		{"manager promotes a member", []*model.GroupMember{manager, member}, manager, member.UserID, model.RoleManager, nil, model.RoleManager},
+		{"manager demotes another manager", []*model.GroupMember{manager, secondManager}, manager, secondManager.UserID, model.RoleMember, nil, model.RoleMember},
+		{"manager steps down while another remains", []*model.GroupMember{manager, secondManager}, manager, manager.UserID, model.RoleMember, nil, model.RoleMember},
+		{"last manager cannot step down", []*model.GroupMember{manager, member}, manager, manager.UserID, model.RoleMember, ErrLastManager, model.RoleManager},
+		{"member cannot promote themselves", []*model.GroupMember{manager, member}, member, member.UserID, model.RoleManager, ErrForbidden, model.RoleMember},
+		{"member cannot demote a manager", []*model.GroupMember{manager, member}, member, manager.UserID, model.RoleMember, ErrForbidden, model.RoleManager},
+		{"unknown role", []*model.GroupMember{manager, member}, manager, member.UserID, "admin", ErrInvalidRole, model.RoleMember},
+		{"same role is a no-op", []*model.GroupMember{manager, member}, manager, member.UserID, model.RoleMember, nil, model.RoleMember},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemGroupMemberRepo(tt.members...)
+			u := NewGroupUsecase(nil, repo, nil, 0, 0)
+
+			got, err := u.ChangeMemberRole(context.Background(), tt.userID, tt.role, tt.actor)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ChangeMemberRole = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got.Role != tt.wantRole {
+				t.Errorf("returned role = %q, want %q", got.Role, tt.wantRole)
+			}
+			if stored := repo.members[tt.userID].Role; stored != tt.wantRole {
+				t.Errorf("stored role = %q, want %q", stored, tt.wantRole)
+			}
+		})
+	}
+}
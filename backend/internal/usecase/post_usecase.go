@@ -1,8 +1,10 @@
 package usecase
 
 import (
+	"context"
 	"time"
 
+	"memoria/internal/domain/event"
 	"memoria/internal/domain/model"
 	"memoria/internal/domain/repository"
 )
@@ -12,18 +14,22 @@ type PostUsecase struct {
 	tagRepo   repository.TagRepository
 	albumRepo repository.AlbumRepository
 	photoRepo repository.PhotoRepository
+	uow       repository.UnitOfWork
+	events    *event.Bus
 }
 
-func NewPostUsecase(postRepo repository.PostRepository, tagRepo repository.TagRepository, albumRepo repository.AlbumRepository, photoRepo repository.PhotoRepository) *PostUsecase {
+func NewPostUsecase(postRepo repository.PostRepository, tagRepo repository.TagRepository, albumRepo repository.AlbumRepository, photoRepo repository.PhotoRepository, uow repository.UnitOfWork, events *event.Bus) *PostUsecase {
 	return &PostUsecase{
 		postRepo:  postRepo,
 		tagRepo:   tagRepo,
 		albumRepo: albumRepo,
 		photoRepo: photoRepo,
+		uow:       uow,
+		events:    events,
 	}
 }
 
-func (u *PostUsecase) CreatePost(postType, title, body string, authorID uint, tagNames []string, groupID uint) (*model.Post, error) {
+func (u *PostUsecase) CreatePost(ctx context.Context, postType, title, body string, authorID uint, tagNames []string, groupID uint) (*model.Post, error) {
 	post := &model.Post{
 		GroupID:     groupID,
 		Type:        postType,
@@ -33,43 +39,47 @@ func (u *PostUsecase) CreatePost(postType, title, body string, authorID uint, ta
 		PublishedAt: time.Now(),
 	}
 
-	if err := u.postRepo.Create(post); err != nil {
-		return nil, err
-	}
+	err := u.uow.Do(ctx, func(repos repository.Repositories) error {
+		if err := repos.Posts.Create(ctx, post); err != nil {
+			return err
+		}
 
-	// Add tags
-	for _, tagName := range tagNames {
-		tag, err := u.tagRepo.FindByName(tagName)
-		if err != nil {
-			// Create new tag if not exists
-			tag = &model.Tag{Name: tagName}
-			if err := u.tagRepo.Create(tag); err != nil {
-				return nil, err
+		// Add tags
+		for _, tagName := range tagNames {
+			tag, err := repos.Tags.FindByName(ctx, tagName)
+			if err != nil {
+				// Create new tag if not exists
+				tag = &model.Tag{Name: tagName}
+				if err := repos.Tags.Create(ctx, tag); err != nil {
+					return err
+				}
 			}
-		}
 
-		if err := u.postRepo.AddTag(post.ID, tag.ID); err != nil {
-			return nil, err
+			if err := repos.Posts.AddTag(ctx, post.ID, tag.ID); err != nil {
+				return err
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	u.events.Publish(ctx, event.PostCreated{Post: post})
 	return post, nil
 }
 
-func (u *PostUsecase) GetPost(id uint, groupID uint) (*model.Post, error) {
-	return u.postRepo.FindByID(id, groupID)
+func (u *PostUsecase) GetPost(ctx context.Context, id uint, groupID uint) (*model.Post, error) {
+	return u.postRepo.FindByID(ctx, id, groupID)
 }
 
-func (u *PostUsecase) GetAllPosts(groupID uint) ([]*model.Post, error) {
-	return u.postRepo.FindAll(groupID)
+func (u *PostUsecase) GetAllPosts(ctx context.Context, groupID uint, opts repository.ListOptions) (*repository.Page[*model.Post], error) {
+	return u.postRepo.FindAll(ctx, groupID, opts)
 }
 
-func (u *PostUsecase) GetPostsByTag(tagID uint, groupID uint) ([]*model.Post, error) {
-	return u.postRepo.FindByTagID(tagID, groupID)
-}
-
-func (u *PostUsecase) UpdatePost(id uint, postType, title, body string, tagNames []string, groupID uint) (*model.Post, error) {
-	post, err := u.postRepo.FindByID(id, groupID)
+func (u *PostUsecase) UpdatePost(ctx context.Context, id uint, postType, title, body string, tagNames []string, actor *model.GroupMember) (*model.Post, error) {
+	groupID := actor.GroupID
+	post, err := u.findAuthorized(ctx, id, actor, actionEditPost)
 	if err != nil {
 		return nil, err
 	}
@@ -78,49 +88,73 @@ func (u *PostUsecase) UpdatePost(id uint, postType, title, body string, tagNames
 	post.Title = title
 	post.Body = body
 
-	if err := u.postRepo.Update(post); err != nil {
-		return nil, err
-	}
+	err = u.uow.Do(ctx, func(repos repository.Repositories) error {
+		if err := repos.Posts.Update(ctx, post); err != nil {
+			return err
+		}
 
-	// Update tags (simple approach: clear and re-add)
-	// In production, you might want a more sophisticated approach
-	for _, tagName := range tagNames {
-		tag, err := u.tagRepo.FindByName(tagName)
-		if err != nil {
-			tag = &model.Tag{Name: tagName}
-			if err := u.tagRepo.Create(tag); err != nil {
-				continue
+		// Add tags; tags the post already has are kept
+		for _, tagName := range tagNames {
+			tag, err := repos.Tags.FindByName(ctx, tagName)
+			if err != nil {
+				tag = &model.Tag{Name: tagName}
+				if err := repos.Tags.Create(ctx, tag); err != nil {
+					return err
+				}
+			}
+
+			if err := repos.Posts.AddTag(ctx, post.ID, tag.ID); err != nil {
+				return err
 			}
 		}
-		u.postRepo.AddTag(post.ID, tag.ID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	u.events.Publish(ctx, event.PostChanged{GroupID: groupID, PostID: post.ID, Action: event.ActionUpdated})
+
 	return post, nil
 }
 
-func (u *PostUsecase) DeletePost(id uint, groupID uint) error {
-	if _, err := u.postRepo.FindByID(id, groupID); err != nil {
+func (u *PostUsecase) DeletePost(ctx context.Context, id uint, actor *model.GroupMember) error {
+	groupID := actor.GroupID
+	if _, err := u.findAuthorized(ctx, id, actor, actionDeletePost); err != nil {
+		return err
+	}
+	if err := u.postRepo.Delete(ctx, id); err != nil {
 		return err
 	}
-	return u.postRepo.Delete(id)
+	u.events.Publish(ctx, event.PostChanged{GroupID: groupID, PostID: id, Action: event.ActionDeleted})
+	return nil
 }
 
-func (u *PostUsecase) AddLike(postID, userID uint, groupID uint) error {
-	if _, err := u.postRepo.FindByID(postID, groupID); err != nil {
+func (u *PostUsecase) AddLike(ctx context.Context, postID, userID uint, groupID uint) error {
+	if _, err := u.postRepo.FindByID(ctx, postID, groupID); err != nil {
 		return err
 	}
-	return u.postRepo.AddLike(postID, userID)
+	if err := u.postRepo.AddLike(ctx, postID, userID); err != nil {
+		return err
+	}
+	u.events.Publish(ctx, event.LikeChanged{GroupID: groupID, PostID: postID, UserID: userID, Liked: true})
+	return nil
 }
 
-func (u *PostUsecase) RemoveLike(postID, userID uint, groupID uint) error {
-	if _, err := u.postRepo.FindByID(postID, groupID); err != nil {
+func (u *PostUsecase) RemoveLike(ctx context.Context, postID, userID uint, groupID uint) error {
+	if _, err := u.postRepo.FindByID(ctx, postID, groupID); err != nil {
+		return err
+	}
+	if err := u.postRepo.RemoveLike(ctx, postID, userID); err != nil {
 		return err
 	}
-	return u.postRepo.RemoveLike(postID, userID)
+	u.events.Publish(ctx, event.LikeChanged{GroupID: groupID, PostID: postID, UserID: userID, Liked: false})
+	return nil
 }
 
-func (u *PostUsecase) CreateComment(postID, userID uint, body string, groupID uint) (*model.PostComment, error) {
-	if _, err := u.postRepo.FindByID(postID, groupID); err != nil {
+func (u *PostUsecase) CreateComment(ctx context.Context, postID, userID uint, body string, groupID uint) (*model.PostComment, error) {
+	post, err := u.postRepo.FindByID(ctx, postID, groupID)
+	if err != nil {
 		return nil, err
 	}
 	comment := &model.PostComment{
@@ -129,64 +163,113 @@ func (u *PostUsecase) CreateComment(postID, userID uint, body string, groupID ui
 		Body:   body,
 	}
 
-	if err := u.postRepo.CreateComment(comment); err != nil {
+	if err := u.postRepo.CreateComment(ctx, comment); err != nil {
 		return nil, err
 	}
 
+	u.events.Publish(ctx, event.CommentCreated{Post: post, Comment: comment})
 	return comment, nil
 }
 
-func (u *PostUsecase) DeleteComment(id uint) error {
-	return u.postRepo.DeleteComment(id)
+func (u *PostUsecase) DeleteComment(ctx context.Context, id uint, actor *model.GroupMember) error {
+	groupID := actor.GroupID
+	comment, err := u.postRepo.FindCommentByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if _, err := u.postRepo.FindByID(ctx, comment.PostID, groupID); err != nil {
+		return err
+	}
+	if err := authorize(actor, actionDeleteComment, comment.UserID); err != nil {
+		return err
+	}
+	if err := u.postRepo.DeleteComment(ctx, id); err != nil {
+		return err
+	}
+	u.events.Publish(ctx, event.CommentDeleted{GroupID: groupID, PostID: comment.PostID, CommentID: id})
+	return nil
 }
 
-func (u *PostUsecase) GetComments(postID uint, groupID uint) ([]*model.PostComment, error) {
-	if _, err := u.postRepo.FindByID(postID, groupID); err != nil {
+func (u *PostUsecase) GetComments(ctx context.Context, postID uint, groupID uint, opts repository.ListOptions) (*repository.Page[*model.PostComment], error) {
+	if _, err := u.postRepo.FindByID(ctx, postID, groupID); err != nil {
 		return nil, err
 	}
-	return u.postRepo.FindCommentsByPostID(postID)
+	return u.postRepo.FindCommentsByPostID(ctx, postID, opts)
 }
 
-func (u *PostUsecase) AddAlbum(postID, albumID uint, groupID uint) error {
-	if _, err := u.postRepo.FindByID(postID, groupID); err != nil {
+func (u *PostUsecase) AddAlbum(ctx context.Context, postID, albumID uint, actor *model.GroupMember) error {
+	groupID := actor.GroupID
+	if _, err := u.findAuthorized(ctx, postID, actor, actionEditPost); err != nil {
+		return err
+	}
+	if _, err := u.albumRepo.FindByID(ctx, albumID, groupID); err != nil {
 		return err
 	}
-	if _, err := u.albumRepo.FindByID(albumID, groupID); err != nil {
+	if err := u.postRepo.AddAlbum(ctx, postID, albumID); err != nil {
 		return err
 	}
-	return u.postRepo.AddAlbum(postID, albumID)
+	u.events.Publish(ctx, event.PostChanged{GroupID: groupID, PostID: postID, Action: event.ActionUpdated})
+	return nil
 }
 
-func (u *PostUsecase) RemoveAlbum(postID, albumID uint, groupID uint) error {
-	if _, err := u.postRepo.FindByID(postID, groupID); err != nil {
+func (u *PostUsecase) RemoveAlbum(ctx context.Context, postID, albumID uint, actor *model.GroupMember) error {
+	groupID := actor.GroupID
+	if _, err := u.findAuthorized(ctx, postID, actor, actionEditPost); err != nil {
+		return err
+	}
+	if _, err := u.albumRepo.FindByID(ctx, albumID, groupID); err != nil {
 		return err
 	}
-	if _, err := u.albumRepo.FindByID(albumID, groupID); err != nil {
+	if err := u.postRepo.RemoveAlbum(ctx, postID, albumID); err != nil {
 		return err
 	}
-	return u.postRepo.RemoveAlbum(postID, albumID)
+	u.events.Publish(ctx, event.PostChanged{GroupID: groupID, PostID: postID, Action: event.ActionUpdated})
+	return nil
 }
 
-func (u *PostUsecase) AddPhoto(postID, photoID uint, groupID uint) error {
-	if _, err := u.postRepo.FindByID(postID, groupID); err != nil {
+func (u *PostUsecase) AddPhoto(ctx context.Context, postID, photoID uint, actor *model.GroupMember) error {
+	groupID := actor.GroupID
+	if _, err := u.findAuthorized(ctx, postID, actor, actionEditPost); err != nil {
 		return err
 	}
-	if _, err := u.photoRepo.FindByID(photoID, groupID); err != nil {
+	if _, err := u.photoRepo.FindByID(ctx, photoID, groupID); err != nil {
 		return err
 	}
-	return u.postRepo.AddPhoto(postID, photoID)
+	if err := u.postRepo.AddPhoto(ctx, postID, photoID); err != nil {
+		return err
+	}
+	u.events.Publish(ctx, event.PostChanged{GroupID: groupID, PostID: postID, Action: event.ActionUpdated})
+	return nil
 }
 
-func (u *PostUsecase) RemovePhoto(postID, photoID uint, groupID uint) error {
-	if _, err := u.postRepo.FindByID(postID, groupID); err != nil {
+func (u *PostUsecase) RemovePhoto(ctx context.Context, postID, photoID uint, actor *model.GroupMember) error {
+	groupID := actor.GroupID
+	if _, err := u.findAuthorized(ctx, postID, actor, actionEditPost); err != nil {
+		return err
+	}
+	if _, err := u.photoRepo.FindByID(ctx, photoID, groupID); err != nil {
 		return err
 	}
-	if _, err := u.photoRepo.FindByID(photoID, groupID); err != nil {
+	if err := u.postRepo.RemovePhoto(ctx, postID, photoID); err != nil {
 		return err
 	}
-	return u.postRepo.RemovePhoto(postID, photoID)
+	u.events.Publish(ctx, event.PostChanged{GroupID: groupID, PostID: postID, Action: event.ActionUpdated})
+	return nil
+}
+
+func (u *PostUsecase) GetAllTags(ctx context.Context) ([]*model.Tag, error) {
+	return u.tagRepo.FindAll(ctx)
 }
 
-func (u *PostUsecase) GetAllTags() ([]*model.Tag, error) {
-	return u.tagRepo.FindAll()
+// findAuthorized loads a post in the actor's group and checks the actor may
+// perform a on it.
+func (u *PostUsecase) findAuthorized(ctx context.Context, id uint, actor *model.GroupMember, a action) (*model.Post, error) {
+	post, err := u.postRepo.FindByID(ctx, id, actor.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorize(actor, a, post.AuthorID); err != nil {
+		return nil, err
+	}
+	return post, nil
 }
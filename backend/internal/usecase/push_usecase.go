@@ -0,0 +1,218 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"memoria/internal/domain/model"
+	"memoria/internal/domain/repository"
+)
+
+const (
+	// pushTTL is how long push services keep a message for offline browsers.
+	pushTTL = 24 * time.Hour
+	// pushMaxAttempts bounds sends of a message, including the first.
+	pushMaxAttempts = 4
+	// pushRetryBaseDelay doubles after every failed attempt: 30s, 1m, 2m.
+	pushRetryBaseDelay = 30 * time.Second
+	// pushMaxRetryDelay caps the wait, including a push service's Retry-After.
+	pushMaxRetryDelay = time.Hour
+	// pushLease keeps other workers off a delivery while it is being sent.
+	pushLease = 2 * time.Minute
+	// pushRetryBatchSize bounds deliveries retried per run.
+	pushRetryBatchSize = 100
+	// pushConcurrency bounds concurrent requests to push services.
+	pushConcurrency = 8
+)
+
+// ErrPushSubscriptionGone is returned by a PushSender when the push service
+// no longer knows the subscription (404 or 410) and it should be deleted.
+var ErrPushSubscriptionGone = errors.New("push subscription is gone")
+
+// PushTemporaryError is returned by a PushSender for a failure worth
+// retrying: a network error, 429 or 5xx. RetryAfter is set when the push
+// service asked for a delay.
+type PushTemporaryError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *PushTemporaryError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PushTemporaryError) Unwrap() error {
+	return e.Err
+}
+
+// PushSender delivers an encrypted payload to one subscription.
+type PushSender interface {
+	Send(ctx context.Context, endpoint, p256dh, auth string, payload []byte, ttl time.Duration) error
+}
+
+// PushEndpointValidator accepts the endpoints of push services the server
+// is willing to post to.
+type PushEndpointValidator interface {
+	ValidateEndpoint(endpoint string) error
+}
+
+type PushUsecase struct {
+	pushRepo     repository.WebPushSubscriptionRepository
+	deliveryRepo repository.PushDeliveryRepository
+	sender       PushSender
+	slots        chan struct{}
+}
+
+// NewPushUsecase builds the push delivery. sender may be nil when VAPID keys
+// are not configured, in which case Deliver does nothing.
+func NewPushUsecase(pushRepo repository.WebPushSubscriptionRepository, deliveryRepo repository.PushDeliveryRepository, sender PushSender) *PushUsecase {
+	return &PushUsecase{
+		pushRepo:     pushRepo,
+		deliveryRepo: deliveryRepo,
+		sender:       sender,
+		slots:        make(chan struct{}, pushConcurrency),
+	}
+}
+
+type pushPayload struct {
+	ID       uint   `json:"id"`
+	Category string `json:"category"`
+	Title    string `json:"title"`
+	Body     string `json:"body"`
+}
+
+// Deliver queues newly created notifications for their users' browsers and
+// sends them in the background. Call it after the notifications are
+// committed. Messages that fail are left to RetryDue, so they survive a
+// restart.
+func (u *PushUsecase) Deliver(ctx context.Context, notifications []*model.Notification) {
+	if u.sender == nil || len(notifications) == 0 {
+		return
+	}
+
+	// The first attempt is leased to this process; a crash mid-send leaves
+	// the delivery for the retry worker.
+	leaseUntil := time.Now().Add(pushLease)
+	var deliveries []*model.PushDelivery
+	subscriptions := make(map[uint]*model.WebPushSubscription)
+	for _, notification := range notifications {
+		userSubscriptions, err := u.pushRepo.FindByUserID(ctx, notification.UserID)
+		if err != nil {
+			log.Printf("push: failed to load subscriptions for user %d: %v", notification.UserID, err)
+			continue
+		}
+		payload, err := json.Marshal(pushPayload{
+			ID:       notification.ID,
+			Category: notification.Category,
+			Title:    notification.Title,
+			Body:     notification.Body,
+		})
+		if err != nil {
+			continue
+		}
+		for _, subscription := range userSubscriptions {
+			subscriptions[subscription.ID] = subscription
+			deliveries = append(deliveries, &model.PushDelivery{
+				SubscriptionID: subscription.ID,
+				Payload:        string(payload),
+				NextAttemptAt:  leaseUntil,
+			})
+		}
+	}
+	if len(deliveries) == 0 {
+		return
+	}
+	if err := u.deliveryRepo.Create(ctx, deliveries); err != nil {
+		log.Printf("push: failed to queue %d deliveries: %v", len(deliveries), err)
+		return
+	}
+
+	go u.attemptAll(ctx, deliveries, subscriptions)
+}
+
+// RetryDue resends queued deliveries whose retry time has passed and returns
+// how many were attempted. Several workers may run it at once.
+func (u *PushUsecase) RetryDue(ctx context.Context, now time.Time) (int, error) {
+	if u.sender == nil {
+		return 0, nil
+	}
+	deliveries, err := u.deliveryRepo.ClaimDue(ctx, now, now.Add(pushLease), pushRetryBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	if len(deliveries) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]uint, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		ids = append(ids, delivery.SubscriptionID)
+	}
+	found, err := u.pushRepo.FindByIDs(ctx, ids)
+	if err != nil {
+		return 0, err
+	}
+	subscriptions := make(map[uint]*model.WebPushSubscription, len(found))
+	for _, subscription := range found {
+		subscriptions[subscription.ID] = subscription
+	}
+
+	u.attemptAll(ctx, deliveries, subscriptions)
+	return len(deliveries), nil
+}
+
+func (u *PushUsecase) attemptAll(ctx context.Context, deliveries []*model.PushDelivery, subscriptions map[uint]*model.WebPushSubscription) {
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		subscription, ok := subscriptions[delivery.SubscriptionID]
+		if !ok {
+			// Deleted since; its deliveries go with it.
+			continue
+		}
+		wg.Add(1)
+		u.slots <- struct{}{}
+		go func(delivery *model.PushDelivery) {
+			defer wg.Done()
+			defer func() { <-u.slots }()
+			u.attempt(ctx, delivery, subscription)
+		}(delivery)
+	}
+	wg.Wait()
+}
+
+// attempt sends the delivery once. Transient failures are scheduled for a
+// retry with exponential backoff, honouring Retry-After, and subscriptions
+// the push service reports as gone are deleted with their deliveries.
+func (u *PushUsecase) attempt(ctx context.Context, delivery *model.PushDelivery, subscription *model.WebPushSubscription) {
+	err := u.sender.Send(ctx, subscription.Endpoint, subscription.P256dh, subscription.Auth, []byte(delivery.Payload), pushTTL)
+	delivery.Attempts++
+
+	var temporary *PushTemporaryError
+	switch {
+	case err == nil:
+		err = u.deliveryRepo.Delete(ctx, delivery.ID)
+	case errors.Is(err, ErrPushSubscriptionGone):
+		err = u.pushRepo.Delete(ctx, subscription.ID)
+	case errors.As(err, &temporary) && delivery.Attempts < pushMaxAttempts:
+		wait := pushRetryBaseDelay << (delivery.Attempts - 1)
+		if temporary.RetryAfter > wait {
+			wait = temporary.RetryAfter
+		}
+		if wait > pushMaxRetryDelay {
+			wait = pushMaxRetryDelay
+		}
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = time.Now().Add(wait)
+		err = u.deliveryRepo.Update(ctx, delivery)
+	default:
+		log.Printf("push: delivery to subscription %d failed: %v", subscription.ID, err)
+		err = u.deliveryRepo.Delete(ctx, delivery.ID)
+	}
+	if err != nil {
+		log.Printf("push: failed to update delivery %d: %v", delivery.ID, err)
+	}
+}
@@ -0,0 +1,368 @@
+package usecase_test
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"memoria/internal/adapter/webpush"
+	"memoria/internal/domain/model"
+	"memoria/internal/usecase"
+)
+
+// pushStore keeps subscriptions and their queued deliveries in memory.
+// Deleting a subscription deletes its deliveries, like the foreign key.
+type pushStore struct {
+	mu            sync.Mutex
+	nextID        uint
+	subscriptions map[uint]*model.WebPushSubscription
+	deliveries    map[uint]*model.PushDelivery
+}
+
+func newPushStore() *pushStore {
+	return &pushStore{
+		subscriptions: make(map[uint]*model.WebPushSubscription),
+		deliveries:    make(map[uint]*model.PushDelivery),
+	}
+}
+
+func (s *pushStore) addSubscription(userID uint, endpoint string) *model.WebPushSubscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	p256dh, auth := newPushKeys()
+	subscription := &model.WebPushSubscription{UserID: userID, Endpoint: endpoint, P256dh: p256dh, Auth: auth}
+	subscription.ID = s.nextID
+	s.subscriptions[subscription.ID] = subscription
+	return subscription
+}
+
+func (s *pushStore) pending() []*model.PushDelivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	deliveries := make([]*model.PushDelivery, 0, len(s.deliveries))
+	for _, delivery := range s.deliveries {
+		copied := *delivery
+		deliveries = append(deliveries, &copied)
+	}
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].ID < deliveries[j].ID })
+	return deliveries
+}
+
+func (s *pushStore) hasSubscription(id uint) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.subscriptions[id]
+	return ok
+}
+
+func newPushKeys() (p256dh, auth string) {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	secret := make([]byte, 16)
+	rand.Read(secret)
+	return base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()), base64.RawURLEncoding.EncodeToString(secret)
+}
+
+type memPushSubscriptionRepo struct{ *pushStore }
+
+func (r memPushSubscriptionRepo) Upsert(ctx context.Context, subscription *model.WebPushSubscription) error {
+	return errors.New("not implemented")
+}
+
+func (r memPushSubscriptionRepo) FindByUserID(ctx context.Context, userID uint) ([]*model.WebPushSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var subscriptions []*model.WebPushSubscription
+	for _, subscription := range r.subscriptions {
+		if subscription.UserID == userID {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	return subscriptions, nil
+}
+
+func (r memPushSubscriptionRepo) FindByIDs(ctx context.Context, ids []uint) ([]*model.WebPushSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var subscriptions []*model.WebPushSubscription
+	for _, id := range ids {
+		if subscription, ok := r.subscriptions[id]; ok {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	return subscriptions, nil
+}
+
+func (r memPushSubscriptionRepo) FindByID(ctx context.Context, id uint, userID uint) (*model.WebPushSubscription, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (r memPushSubscriptionRepo) FindByEndpoint(ctx context.Context, endpoint string, userID uint) (*model.WebPushSubscription, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (r memPushSubscriptionRepo) Delete(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.subscriptions, id)
+	for deliveryID, delivery := range r.deliveries {
+		if delivery.SubscriptionID == id {
+			delete(r.deliveries, deliveryID)
+		}
+	}
+	return nil
+}
+
+type memPushDeliveryRepo struct{ *pushStore }
+
+func (r memPushDeliveryRepo) Create(ctx context.Context, deliveries []*model.PushDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, delivery := range deliveries {
+		r.nextID++
+		delivery.ID = r.nextID
+		copied := *delivery
+		r.deliveries[delivery.ID] = &copied
+	}
+	return nil
+}
+
+func (r memPushDeliveryRepo) ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*model.PushDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var claimed []*model.PushDelivery
+	for _, delivery := range r.deliveries {
+		if len(claimed) < limit && !delivery.NextAttemptAt.After(now) {
+			delivery.NextAttemptAt = leaseUntil
+			copied := *delivery
+			claimed = append(claimed, &copied)
+		}
+	}
+	return claimed, nil
+}
+
+func (r memPushDeliveryRepo) Update(ctx context.Context, delivery *model.PushDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.deliveries[delivery.ID]; !ok {
+		return errors.New("record not found")
+	}
+	copied := *delivery
+	r.deliveries[delivery.ID] = &copied
+	return nil
+}
+
+func (r memPushDeliveryRepo) Delete(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.deliveries, id)
+	return nil
+}
+
+// fakePushService answers each push with the status set for its path.
+type fakePushService struct {
+	*httptest.Server
+	mu         sync.Mutex
+	statuses   map[string]int
+	retryAfter string
+	received   map[string]int
+}
+
+func newFakePushService(t *testing.T) *fakePushService {
+	t.Helper()
+	service := &fakePushService{statuses: make(map[string]int), received: make(map[string]int)}
+	service.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		service.mu.Lock()
+		defer service.mu.Unlock()
+		service.received[r.URL.Path]++
+		status, ok := service.statuses[r.URL.Path]
+		if !ok {
+			status = http.StatusCreated
+		}
+		if service.retryAfter != "" {
+			w.Header().Set("Retry-After", service.retryAfter)
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(service.Close)
+	return service
+}
+
+func (s *fakePushService) respond(path string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statuses[path] = status
+}
+
+func (s *fakePushService) count(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.received[path]
+}
+
+func newTestPushUsecase(t *testing.T) (*usecase.PushUsecase, *pushStore) {
+	t.Helper()
+	publicKey, privateKey, err := webpush.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender, err := webpush.NewSender(publicKey, privateKey, "mailto:ops@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := newPushStore()
+	return usecase.NewPushUsecase(memPushSubscriptionRepo{store}, memPushDeliveryRepo{store}, sender), store
+}
+
+// queue adds a delivery for the subscription that is due now.
+func queue(t *testing.T, store *pushStore, subscription *model.WebPushSubscription) {
+	t.Helper()
+	err := memPushDeliveryRepo{store}.Create(context.Background(), []*model.PushDelivery{{
+		SubscriptionID: subscription.ID,
+		Payload:        `{"id":1}`,
+		NextAttemptAt:  time.Now().Add(-time.Second),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPushRetryDueDeletesGoneSubscriptions(t *testing.T) {
+	for _, status := range []int{http.StatusNotFound, http.StatusGone} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			service := newFakePushService(t)
+			service.respond("/gone", status)
+			pushUsecase, store := newTestPushUsecase(t)
+			gone := store.addSubscription(1, service.URL+"/gone")
+			kept := store.addSubscription(1, service.URL+"/kept")
+			queue(t, store, gone)
+			queue(t, store, kept)
+
+			n, err := pushUsecase.RetryDue(context.Background(), time.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != 2 {
+				t.Errorf("attempted %d deliveries, want 2", n)
+			}
+			if store.hasSubscription(gone.ID) {
+				t.Errorf("subscription answered with %d was not deleted", status)
+			}
+			if !store.hasSubscription(kept.ID) {
+				t.Error("working subscription was deleted")
+			}
+			if pending := store.pending(); len(pending) != 0 {
+				t.Errorf("%d deliveries left, want none", len(pending))
+			}
+		})
+	}
+}
+
+func TestPushRetryDueBacksOffTransientFailures(t *testing.T) {
+	service := newFakePushService(t)
+	service.respond("/busy", http.StatusServiceUnavailable)
+	pushUsecase, store := newTestPushUsecase(t)
+	subscription := store.addSubscription(1, service.URL+"/busy")
+	queue(t, store, subscription)
+
+	// Retries wait 30s, 1m and 2m, and the fourth attempt is the last.
+	now := time.Now()
+	wantDelays := []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute}
+	for attempt, wantDelay := range wantDelays {
+		if _, err := pushUsecase.RetryDue(context.Background(), now); err != nil {
+			t.Fatal(err)
+		}
+		pending := store.pending()
+		if len(pending) != 1 {
+			t.Fatalf("after attempt %d: %d deliveries, want 1", attempt+1, len(pending))
+		}
+		delivery := pending[0]
+		if delivery.Attempts != attempt+1 {
+			t.Errorf("Attempts = %d, want %d", delivery.Attempts, attempt+1)
+		}
+		if delivery.LastError == "" {
+			t.Error("LastError is empty")
+		}
+		if delay := time.Until(delivery.NextAttemptAt); delay < wantDelay-5*time.Second || delay > wantDelay {
+			t.Errorf("after attempt %d: next attempt in %v, want %v", attempt+1, delay, wantDelay)
+		}
+
+		// Nothing is due until the delay passes.
+		if n, _ := pushUsecase.RetryDue(context.Background(), now); n != 0 {
+			t.Errorf("retried %d deliveries before they were due", n)
+		}
+		now = delivery.NextAttemptAt
+	}
+
+	// The last attempt gives up and drops the message, but keeps the
+	// subscription.
+	if _, err := pushUsecase.RetryDue(context.Background(), now); err != nil {
+		t.Fatal(err)
+	}
+	if pending := store.pending(); len(pending) != 0 {
+		t.Errorf("%d deliveries left after 4 attempts, want none", len(pending))
+	}
+	if got := service.count("/busy"); got != 4 {
+		t.Errorf("push service got %d requests, want 4", got)
+	}
+	if !store.hasSubscription(subscription.ID) {
+		t.Error("subscription was deleted")
+	}
+}
+
+func TestPushRetryDueHonoursRetryAfter(t *testing.T) {
+	service := newFakePushService(t)
+	service.respond("/limited", http.StatusTooManyRequests)
+	service.retryAfter = "600"
+	pushUsecase, store := newTestPushUsecase(t)
+	queue(t, store, store.addSubscription(1, service.URL+"/limited"))
+
+	if _, err := pushUsecase.RetryDue(context.Background(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	pending := store.pending()
+	if len(pending) != 1 {
+		t.Fatalf("%d deliveries, want 1", len(pending))
+	}
+	if delay := time.Until(pending[0].NextAttemptAt); delay < 9*time.Minute || delay > 10*time.Minute {
+		t.Errorf("next attempt in %v, want the 10m Retry-After", delay)
+	}
+}
+
+func TestPushDeliverQueuesAndSends(t *testing.T) {
+	service := newFakePushService(t)
+	service.respond("/busy", http.StatusInternalServerError)
+	pushUsecase, store := newTestPushUsecase(t)
+	store.addSubscription(1, service.URL+"/ok")
+	store.addSubscription(1, service.URL+"/busy")
+	store.addSubscription(2, service.URL+"/other-user")
+
+	pushUsecase.Deliver(context.Background(), []*model.Notification{{UserID: 1, Title: "hello"}})
+
+	// Deliver sends in the background; the failed message stays queued.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		pending := store.pending()
+		if len(pending) == 1 && pending[0].Attempts == 1 {
+			if service.count("/ok") != 1 || service.count("/other-user") != 0 {
+				t.Errorf("requests: ok=%d other-user=%d, want 1 and 0", service.count("/ok"), service.count("/other-user"))
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("deliveries never settled: %+v", pending)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,146 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"memoria/internal/domain/model"
+	"memoria/internal/domain/repository"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 50
+	maxSearchLength    = 100
+	maxSearchTerms     = 5
+	// A snippet is searchSnippetLength characters of text starting
+	// searchSnippetLead characters before the first match.
+	searchSnippetLength = 120
+	searchSnippetLead   = 30
+)
+
+var ErrInvalidSearchQuery = errors.New("invalid search query: must be 1 to 100 characters and at most 5 words")
+
+type SearchUsecase struct {
+	searchRepo repository.SearchRepository
+}
+
+func NewSearchUsecase(searchRepo repository.SearchRepository) *SearchUsecase {
+	return &SearchUsecase{
+		searchRepo: searchRepo,
+	}
+}
+
+type SearchHit struct {
+	*model.SearchResult
+	// Snippet is the matching part of the text, HTML-escaped, with every
+	// match wrapped in <mark>.
+	Snippet string
+}
+
+// Search finds the group's content containing every whitespace-separated
+// word of query, best match first.
+func (u *SearchUsecase) Search(ctx context.Context, groupID uint, query string, limit int) ([]*SearchHit, error) {
+	query = strings.TrimSpace(query)
+	terms := strings.Fields(query)
+	if len(terms) == 0 || len(terms) > maxSearchTerms || utf8.RuneCountInString(query) > maxSearchLength {
+		return nil, ErrInvalidSearchQuery
+	}
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	results, err := u.searchRepo.Search(ctx, groupID, terms, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]*SearchHit, len(results))
+	for i, result := range results {
+		// Albums, posts and trips may match on the title alone.
+		text, matches := result.Text, findMatches(result.Text, terms)
+		if len(matches) == 0 {
+			if titleMatches := findMatches(result.Title, terms); len(titleMatches) > 0 {
+				text, matches = result.Title, titleMatches
+			}
+		}
+		hits[i] = &SearchHit{SearchResult: result, Snippet: searchSnippet(text, matches)}
+	}
+	return hits, nil
+}
+
+// findMatches returns the rune ranges of text containing a term, ignoring
+// case, sorted and merged where they overlap.
+func findMatches(text string, terms []string) [][2]int {
+	folded := foldRunes(text)
+	var matches [][2]int
+	for _, term := range terms {
+		t := foldRunes(term)
+		for i := 0; i+len(t) <= len(folded); i++ {
+			if string(folded[i:i+len(t)]) == string(t) {
+				matches = append(matches, [2]int{i, i + len(t)})
+			}
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i][0] < matches[j][0] })
+
+	merged := matches[:0]
+	for _, match := range matches {
+		if n := len(merged); n > 0 && match[0] <= merged[n-1][1] {
+			merged[n-1][1] = max(merged[n-1][1], match[1])
+			continue
+		}
+		merged = append(merged, match)
+	}
+	return merged
+}
+
+// foldRunes lowercases rune by rune, so indexes line up with the original.
+func foldRunes(s string) []rune {
+	runes := []rune(s)
+	for i, r := range runes {
+		runes[i] = unicode.ToLower(r)
+	}
+	return runes
+}
+
+// searchSnippet cuts text around the first match and marks the matches.
+// Text without a match is cut from its start.
+func searchSnippet(text string, matches [][2]int) string {
+	runes := []rune(text)
+	start := 0
+	if len(matches) > 0 {
+		start = max(matches[0][0]-searchSnippetLead, 0)
+	}
+	end := min(start+searchSnippetLength, len(runes))
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	pos := start
+	for _, match := range matches {
+		if match[0] >= end {
+			break
+		}
+		matchEnd := min(match[1], end)
+		b.WriteString(html.EscapeString(string(runes[pos:match[0]])))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(string(runes[match[0]:matchEnd])))
+		b.WriteString("</mark>")
+		pos = matchEnd
+	}
+	b.WriteString(html.EscapeString(string(runes[pos:end])))
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
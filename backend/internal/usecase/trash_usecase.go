@@ -0,0 +1,109 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"memoria/internal/domain/event"
+	"memoria/internal/domain/model"
+	"memoria/internal/domain/repository"
+)
+
+// trashPurgeBatchSize caps how many items one purge run removes.
+const trashPurgeBatchSize = 100
+
+var (
+	ErrInvalidTrashType   = errors.New("invalid type: must be album, photo, post, comment or trip")
+	ErrTrashParentDeleted = errors.New("restore the album or post it belongs to first")
+)
+
+// restoreActions maps each kind of item to the action that deletes it:
+// whoever may delete something may also bring it back.
+var restoreActions = map[string]action{
+	model.TrashAlbum:   actionDeleteAlbum,
+	model.TrashPhoto:   actionDeletePhoto,
+	model.TrashPost:    actionDeletePost,
+	model.TrashComment: actionDeleteComment,
+	model.TrashTrip:    actionDeleteTrip,
+}
+
+type TrashUsecase struct {
+	trashRepo repository.TrashRepository
+	events    *event.Bus
+	retention time.Duration
+}
+
+// NewTrashUsecase keeps deleted content for retention before PurgeExpired
+// removes it.
+func NewTrashUsecase(trashRepo repository.TrashRepository, events *event.Bus, retention time.Duration) *TrashUsecase {
+	return &TrashUsecase{
+		trashRepo: trashRepo,
+		events:    events,
+		retention: retention,
+	}
+}
+
+func (u *TrashUsecase) GetTrash(ctx context.Context, groupID uint) ([]*model.TrashItem, error) {
+	return u.trashRepo.FindByGroupID(ctx, groupID)
+}
+
+// PurgeAt is when the item will be deleted for good.
+func (u *TrashUsecase) PurgeAt(item *model.TrashItem) time.Time {
+	return item.DeletedAt.Add(u.retention)
+}
+
+// Restore takes an item out of the trash. Albums and posts come back with
+// the photos and comments that were deleted along with them.
+func (u *TrashUsecase) Restore(ctx context.Context, itemType string, id uint, actor *model.GroupMember) error {
+	a, ok := restoreActions[itemType]
+	if !ok {
+		return ErrInvalidTrashType
+	}
+	item, err := u.trashRepo.FindItem(ctx, itemType, id, actor.GroupID)
+	if err != nil {
+		return err
+	}
+	if err := authorize(actor, a, item.AuthorID); err != nil {
+		return err
+	}
+	if item.ParentDeleted {
+		return ErrTrashParentDeleted
+	}
+
+	if err := u.trashRepo.Restore(ctx, item); err != nil {
+		return err
+	}
+
+	switch item.Type {
+	case model.TrashPhoto:
+		u.events.Publish(ctx, event.PhotoChanged{GroupID: item.GroupID, AlbumID: item.ParentID, PhotoID: item.ID, Action: event.ActionCreated})
+	case model.TrashPost:
+		u.events.Publish(ctx, event.PostChanged{GroupID: item.GroupID, PostID: item.ID, Action: event.ActionCreated})
+	case model.TrashTrip:
+		u.events.Publish(ctx, event.TripChanged{GroupID: item.GroupID, TripID: item.ID, Section: "trip", Action: event.ActionCreated})
+	}
+	return nil
+}
+
+// PurgeExpired deletes items that have been in the trash longer than the
+// retention period. Photo objects are queued for the object-cleanup job.
+func (u *TrashUsecase) PurgeExpired(ctx context.Context, now time.Time) (int, error) {
+	items, err := u.trashRepo.FindExpired(ctx, now.Add(-u.retention), trashPurgeBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, item := range items {
+		if err := u.trashRepo.Purge(ctx, item); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	if purged > 0 {
+		log.Printf("trash purged: items=%d", purged)
+	}
+	return purged, nil
+}
@@ -0,0 +1,165 @@
+package usecase
+
+import (
+	"context"
+
+	"memoria/internal/domain/model"
+)
+
+// TripCostTotal is a cost total in the trip's base currency together with
+// the amounts it was converted from, per original currency.
+type TripCostTotal struct {
+	Total     int64
+	Originals []CurrencyAmount
+}
+
+type TripBudgetLine struct {
+	Item          *model.TripBudgetItem
+	ConvertedCost int64
+	Converted     bool // false when no exchange rate was found
+}
+
+type TripExpenseCategoryTotal struct {
+	Category string
+	Total    int64
+}
+
+// TripActualExpenses sums the expenses recorded as actually spent (IsActual),
+// separately from the planned budget.
+type TripActualExpenses struct {
+	Total      int64
+	Categories []TripExpenseCategoryTotal
+	Originals  []CurrencyAmount
+}
+
+// TripBudget holds every budget and expense total converted into the trip's
+// base currency. Amounts without a usable exchange rate are left out of the
+// totals and their currency pairs are listed in MissingRates.
+type TripBudget struct {
+	BaseCurrency string
+	Transport    TripCostTotal
+	Lodging      TripCostTotal
+	Items        []TripBudgetLine
+	ItemsTotal   int64
+	Total        int64
+	Actual       *TripActualExpenses
+	MissingRates []string
+}
+
+func (u *TripUsecase) GetBudget(ctx context.Context, tripID uint, groupID uint) (*TripBudget, error) {
+	trip, err := u.tripRepo.FindByID(ctx, tripID, groupID)
+	if err != nil {
+		return nil, err
+	}
+	converter, err := u.currencyConverter(ctx, trip.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	transports, err := u.detailRepo.FindTransports(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+	lodgings, err := u.detailRepo.FindLodgings(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := u.detailRepo.FindBudgetItems(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+	expenses, err := u.expenseRepo.FindByTripID(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+
+	base := trip.BaseCurrency
+	tripDate := trip.StartAt.Format("2006-01-02")
+	budget := &TripBudget{
+		BaseCurrency: base,
+		Items:        make([]TripBudgetLine, 0, len(items)),
+	}
+
+	var transportOriginals currencyTotals
+	for _, transport := range transports {
+		cost := transportCost(transport)
+		transportOriginals.add(currencyOrDefault(transport.Currency), cost)
+		if converted, ok := converter.convert(cost, transport.Currency, base, transport.Date); ok {
+			budget.Transport.Total += converted
+		}
+	}
+	budget.Transport.Originals = transportOriginals.list()
+
+	var lodgingOriginals currencyTotals
+	for _, lodging := range lodgings {
+		lodgingOriginals.add(currencyOrDefault(lodging.Currency), lodging.CostYen)
+		if converted, ok := converter.convert(lodging.CostYen, lodging.Currency, base, lodging.Date); ok {
+			budget.Lodging.Total += converted
+		}
+	}
+	budget.Lodging.Originals = lodgingOriginals.list()
+
+	for _, item := range items {
+		converted, ok := converter.convert(item.CostYen, item.Currency, base, tripDate)
+		budget.Items = append(budget.Items, TripBudgetLine{Item: item, ConvertedCost: converted, Converted: ok})
+		budget.ItemsTotal += converted
+	}
+	budget.Total = budget.Transport.Total + budget.Lodging.Total + budget.ItemsTotal
+
+	budget.Actual = actualExpenses(expenses, converter, base, tripDate)
+	budget.MissingRates = converter.missingPairs()
+	return budget, nil
+}
+
+func actualExpenses(expenses []*model.TripExpense, converter *currencyConverter, base, date string) *TripActualExpenses {
+	actual := &TripActualExpenses{Categories: []TripExpenseCategoryTotal{}}
+	var originals currencyTotals
+	categoryIndex := map[string]int{}
+	for _, expense := range expenses {
+		if !expense.IsActual {
+			continue
+		}
+		originals.add(currencyOrDefault(expense.Currency), expense.Amount)
+		amount, ok := converter.convert(expense.Amount, expense.Currency, base, date)
+		if !ok {
+			continue
+		}
+		actual.Total += amount
+		idx, ok := categoryIndex[expense.Category]
+		if !ok {
+			idx = len(actual.Categories)
+			categoryIndex[expense.Category] = idx
+			actual.Categories = append(actual.Categories, TripExpenseCategoryTotal{Category: expense.Category})
+		}
+		actual.Categories[idx].Total += amount
+	}
+	actual.Originals = originals.list()
+	return actual
+}
+
+func (u *TripUsecase) currencyConverter(ctx context.Context, groupID uint) (*currencyConverter, error) {
+	rates, err := u.exchangeRateRepo.FindByGroupID(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	return newCurrencyConverter(rates), nil
+}
+
+// transportCost mirrors how the transport form is filled in: cars cost fuel
+// and tolls, rentals cost fuel and the rental fee, everything else a fare.
+func transportCost(transport *model.TripTransport) int64 {
+	switch transport.Mode {
+	case "car":
+		return transport.GasolineCostYen + transport.HighwayCostYen
+	case "rental":
+		return transport.GasolineCostYen + transport.RentalFeeYen
+	default:
+		return transport.FareYen
+	}
+}
+
+func currencyOrDefault(currency string) string {
+	if currency == "" {
+		return DefaultCurrency
+	}
+	return currency
+}
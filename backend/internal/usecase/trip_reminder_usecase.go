@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"memoria/internal/domain/event"
+	"memoria/internal/domain/model"
+	"memoria/internal/domain/repository"
+)
+
+// tripReminderBatchSize bounds how many due trips one run handles; the rest
+// are picked up by the next tick.
+const tripReminderBatchSize = 100
+
+type TripReminderUsecase struct {
+	reminderRepo            repository.TripReminderRepository
+	groupMemberRepo         repository.GroupMemberRepository
+	notificationSettingRepo repository.NotificationSettingRepository
+	pushUsecase             *PushUsecase
+	events                  *event.Bus
+	maxDelay                time.Duration
+}
+
+// NewTripReminderUsecase builds the reminder sender. Reminders whose NotifyAt
+// is older than maxDelay are never sent, so a worker that was down for a
+// long time does not flood members with stale reminders.
+func NewTripReminderUsecase(
+	reminderRepo repository.TripReminderRepository,
+	groupMemberRepo repository.GroupMemberRepository,
+	notificationSettingRepo repository.NotificationSettingRepository,
+	pushUsecase *PushUsecase,
+	events *event.Bus,
+	maxDelay time.Duration,
+) *TripReminderUsecase {
+	return &TripReminderUsecase{
+		reminderRepo:            reminderRepo,
+		groupMemberRepo:         groupMemberRepo,
+		notificationSettingRepo: notificationSettingRepo,
+		pushUsecase:             pushUsecase,
+		events:                  events,
+		maxDelay:                maxDelay,
+	}
+}
+
+// SendDueReminders creates trip notifications for every trip whose NotifyAt
+// has passed and returns how many reminders were sent. It is safe to call
+// concurrently from several processes.
+func (u *TripReminderUsecase) SendDueReminders(ctx context.Context, now time.Time) (int, error) {
+	trips, err := u.reminderRepo.FindDue(ctx, now, now.Add(-u.maxDelay), tripReminderBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, trip := range trips {
+		recipients, err := u.recipients(ctx, trip.GroupID)
+		if err != nil {
+			return sent, err
+		}
+
+		notifications := make([]*model.Notification, len(recipients))
+		for i, userID := range recipients {
+			notifications[i] = &model.Notification{
+				UserID:   userID,
+				Category: NotificationCategoryTrip,
+				Title:    trip.Title,
+				Body:     fmt.Sprintf("「%s」の出発が近づいています。", trip.Title),
+			}
+		}
+
+		reminder := &model.TripReminder{
+			TripID:     trip.ID,
+			NotifyAt:   *trip.NotifyAt,
+			SentAt:     now,
+			Recipients: len(recipients),
+		}
+		recorded, err := u.reminderRepo.Record(ctx, reminder, notifications)
+		if err != nil {
+			return sent, err
+		}
+		if recorded {
+			sent++
+			u.pushUsecase.Deliver(ctx, notifications)
+			u.events.Publish(ctx, event.TripReminderSent{Trip: trip})
+			log.Printf("trip reminder sent: trip=%d recipients=%d", trip.ID, len(recipients))
+		}
+	}
+	return sent, nil
+}
+
+// recipients returns the group's members minus those who disabled trip
+// notifications.
+func (u *TripReminderUsecase) recipients(ctx context.Context, groupID uint) ([]uint, error) {
+	members, err := u.groupMemberRepo.FindByGroupID(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	userIDs := make([]uint, len(members))
+	for i, member := range members {
+		userIDs[i] = member.UserID
+	}
+	return enabledRecipients(ctx, u.notificationSettingRepo, NotificationCategoryTrip, userIDs)
+}
@@ -0,0 +1,355 @@
+package usecase
+
+import (
+	"context"
+	"sort"
+
+	"memoria/internal/domain/event"
+	"memoria/internal/domain/model"
+)
+
+const (
+	SplitTypeEqual  = "equal"
+	SplitTypeShares = "shares"
+	SplitTypeFixed  = "fixed"
+)
+
+// TripInputError reports expense or settlement input the trip cannot accept,
+// as opposed to a failure to load or store it.
+type TripInputError struct {
+	Message string
+}
+
+func (e *TripInputError) Error() string {
+	return e.Message
+}
+
+type TripMemberBalance struct {
+	UserID   uint
+	Paid     int64 // total paid for others' shares and their own
+	Owed     int64 // total share of expenses
+	Sent     int64 // settled transfers sent
+	Received int64 // settled transfers received
+	Balance  int64 // positive: should receive, negative: should pay
+}
+
+type TripTransfer struct {
+	FromUserID uint
+	ToUserID   uint
+	Amount     int64
+}
+
+// TripSettlementSummary amounts are in BaseCurrency. Expenses in a currency
+// without an exchange rate are left out and listed in MissingRates.
+type TripSettlementSummary struct {
+	BaseCurrency string
+	Balances     []TripMemberBalance
+	Transfers    []TripTransfer
+	Settled      []*model.TripSettlement
+	MissingRates []string
+}
+
+// validateExpenseSplit checks that the payer and every participant belong to
+// the trip's group and that the split adds up to the expense amount.
+func (u *TripUsecase) validateExpenseSplit(ctx context.Context, groupID uint, expense *model.TripExpense, participants []*model.TripExpenseParticipant) error {
+	switch expense.SplitType {
+	case SplitTypeEqual, SplitTypeShares, SplitTypeFixed:
+	default:
+		return &TripInputError{Message: "invalid split_type: must be 'equal', 'shares' or 'fixed'"}
+	}
+	if expense.PaidBy == nil {
+		if len(participants) > 0 {
+			return &TripInputError{Message: "paid_by is required when participants are given"}
+		}
+		return nil
+	}
+	if len(participants) == 0 {
+		return &TripInputError{Message: "participants are required when paid_by is given"}
+	}
+
+	members, err := u.groupMemberRepo.FindByGroupID(ctx, groupID)
+	if err != nil {
+		return err
+	}
+	memberSet := make(map[uint]struct{}, len(members))
+	for _, member := range members {
+		memberSet[member.UserID] = struct{}{}
+	}
+	if _, ok := memberSet[*expense.PaidBy]; !ok {
+		return &TripInputError{Message: "payer is not a member of the group"}
+	}
+
+	seen := map[uint]struct{}{}
+	for _, participant := range participants {
+		if _, ok := memberSet[participant.UserID]; !ok {
+			return &TripInputError{Message: "participant is not a member of the group"}
+		}
+		if _, ok := seen[participant.UserID]; ok {
+			return &TripInputError{Message: "duplicate participant"}
+		}
+		seen[participant.UserID] = struct{}{}
+	}
+
+	_, err = splitExpenseAmount(expense.Amount, expense.SplitType, participants)
+	return err
+}
+
+// splitExpenseAmount returns how much of amount each participant owes.
+// Remainders from integer division go one unit at a time to participants in
+// user ID order so the shares always sum to amount.
+func splitExpenseAmount(amount int64, splitType string, participants []*model.TripExpenseParticipant) (map[uint]int64, error) {
+	if len(participants) == 0 {
+		return nil, &TripInputError{Message: "no participants"}
+	}
+	ordered := make([]*model.TripExpenseParticipant, len(participants))
+	copy(ordered, participants)
+	sort.Slice(ordered, func(i, j int) bool {
+		return ordered[i].UserID < ordered[j].UserID
+	})
+
+	shares := make(map[uint]int64, len(ordered))
+	switch splitType {
+	case SplitTypeEqual:
+		count := int64(len(ordered))
+		for i, participant := range ordered {
+			shares[participant.UserID] = amount / count
+			if int64(i) < amount%count {
+				shares[participant.UserID]++
+			}
+		}
+	case SplitTypeShares:
+		var totalShares int64
+		for _, participant := range ordered {
+			if participant.Shares <= 0 {
+				return nil, &TripInputError{Message: "shares must be positive"}
+			}
+			totalShares += int64(participant.Shares)
+		}
+		var assigned int64
+		for _, participant := range ordered {
+			share := amount * int64(participant.Shares) / totalShares
+			shares[participant.UserID] = share
+			assigned += share
+		}
+		for i := 0; assigned < amount; i = (i + 1) % len(ordered) {
+			shares[ordered[i].UserID]++
+			assigned++
+		}
+	case SplitTypeFixed:
+		var total int64
+		for _, participant := range ordered {
+			if participant.Amount < 0 {
+				return nil, &TripInputError{Message: "participant amount must not be negative"}
+			}
+			shares[participant.UserID] = participant.Amount
+			total += participant.Amount
+		}
+		if total != amount {
+			return nil, &TripInputError{Message: "participant amounts must add up to the expense amount"}
+		}
+	default:
+		return nil, &TripInputError{Message: "invalid split_type"}
+	}
+	return shares, nil
+}
+
+// GetSettlement builds each member's balance from the trip's actual, paid
+// expenses and the transfers already settled, then suggests the transfers
+// that clear the remaining debts.
+func (u *TripUsecase) GetSettlement(ctx context.Context, tripID uint, groupID uint) (*TripSettlementSummary, error) {
+	trip, err := u.tripRepo.FindByID(ctx, tripID, groupID)
+	if err != nil {
+		return nil, err
+	}
+	expenses, participants, err := u.GetExpenses(ctx, tripID, groupID)
+	if err != nil {
+		return nil, err
+	}
+	converter, err := u.currencyConverter(ctx, trip.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	settled, err := u.settlementRepo.FindByTripID(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := u.groupMemberRepo.FindByGroupID(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	tripDate := trip.StartAt.Format("2006-01-02")
+	balances := map[uint]*TripMemberBalance{}
+	order := []uint{}
+	balanceOf := func(userID uint) *TripMemberBalance {
+		balance, ok := balances[userID]
+		if !ok {
+			balance = &TripMemberBalance{UserID: userID}
+			balances[userID] = balance
+			order = append(order, userID)
+		}
+		return balance
+	}
+	for _, member := range members {
+		balanceOf(member.UserID)
+	}
+
+	for _, expense := range expenses {
+		if !expense.IsActual || expense.PaidBy == nil {
+			continue
+		}
+		shares, err := splitExpenseAmount(expense.Amount, expense.SplitType, participants[expense.ID])
+		if err != nil {
+			// Skip expenses whose split can no longer be computed rather than failing the whole settlement.
+			continue
+		}
+		// Convert each share rather than the total so fixed splits stay exact
+		// in the original currency; the payer is credited with their sum.
+		converted := make(map[uint]int64, len(shares))
+		var paid int64
+		ok := true
+		for userID, share := range shares {
+			amount, convertedOK := converter.convert(share, expense.Currency, trip.BaseCurrency, tripDate)
+			if !convertedOK {
+				ok = false
+				break
+			}
+			converted[userID] = amount
+			paid += amount
+		}
+		if !ok {
+			continue
+		}
+		balanceOf(*expense.PaidBy).Paid += paid
+		for userID, share := range converted {
+			balanceOf(userID).Owed += share
+		}
+	}
+	for _, settlement := range settled {
+		balanceOf(settlement.FromUserID).Sent += settlement.Amount
+		balanceOf(settlement.ToUserID).Received += settlement.Amount
+	}
+
+	summary := &TripSettlementSummary{
+		BaseCurrency: trip.BaseCurrency,
+		Balances:     make([]TripMemberBalance, 0, len(order)),
+		Settled:      settled,
+		MissingRates: converter.missingPairs(),
+	}
+	net := map[uint]int64{}
+	for _, userID := range order {
+		balance := balances[userID]
+		balance.Balance = balance.Paid - balance.Owed + balance.Sent - balance.Received
+		net[userID] = balance.Balance
+		summary.Balances = append(summary.Balances, *balance)
+	}
+	summary.Transfers = minimizeTransfers(net)
+	return summary, nil
+}
+
+// minimizeTransfers repeatedly matches the largest debtor with the largest
+// creditor. This settles n members in at most n-1 transfers.
+func minimizeTransfers(net map[uint]int64) []TripTransfer {
+	type entry struct {
+		userID uint
+		amount int64
+	}
+	creditors := []entry{}
+	debtors := []entry{}
+	for userID, amount := range net {
+		if amount > 0 {
+			creditors = append(creditors, entry{userID, amount})
+		} else if amount < 0 {
+			debtors = append(debtors, entry{userID, -amount})
+		}
+	}
+	byAmount := func(entries []entry) func(i, j int) bool {
+		return func(i, j int) bool {
+			if entries[i].amount != entries[j].amount {
+				return entries[i].amount > entries[j].amount
+			}
+			return entries[i].userID < entries[j].userID
+		}
+	}
+
+	transfers := []TripTransfer{}
+	for len(creditors) > 0 && len(debtors) > 0 {
+		sort.Slice(creditors, byAmount(creditors))
+		sort.Slice(debtors, byAmount(debtors))
+
+		amount := creditors[0].amount
+		if debtors[0].amount < amount {
+			amount = debtors[0].amount
+		}
+		transfers = append(transfers, TripTransfer{
+			FromUserID: debtors[0].userID,
+			ToUserID:   creditors[0].userID,
+			Amount:     amount,
+		})
+		creditors[0].amount -= amount
+		debtors[0].amount -= amount
+		if creditors[0].amount == 0 {
+			creditors = creditors[1:]
+		}
+		if debtors[0].amount == 0 {
+			debtors = debtors[1:]
+		}
+	}
+	return transfers
+}
+
+// SettleTransfer records that fromUserID paid toUserID. Only one of the two
+// parties may record it.
+func (u *TripUsecase) SettleTransfer(ctx context.Context, tripID, fromUserID, toUserID uint, amount int64, settledBy uint, groupID uint) (*model.TripSettlement, error) {
+	if _, err := u.tripRepo.FindByID(ctx, tripID, groupID); err != nil {
+		return nil, err
+	}
+	if fromUserID == toUserID {
+		return nil, &TripInputError{Message: "cannot settle a transfer to oneself"}
+	}
+	if amount <= 0 {
+		return nil, &TripInputError{Message: "amount must be positive"}
+	}
+	if settledBy != fromUserID && settledBy != toUserID {
+		return nil, ErrForbidden
+	}
+	if _, err := u.groupMemberRepo.FindByGroupAndUser(ctx, groupID, fromUserID); err != nil {
+		return nil, &TripInputError{Message: "payer is not a member of the group"}
+	}
+	if _, err := u.groupMemberRepo.FindByGroupAndUser(ctx, groupID, toUserID); err != nil {
+		return nil, &TripInputError{Message: "recipient is not a member of the group"}
+	}
+
+	settlement := &model.TripSettlement{
+		TripID:     tripID,
+		FromUserID: fromUserID,
+		ToUserID:   toUserID,
+		Amount:     amount,
+		SettledBy:  settledBy,
+	}
+	if err := u.settlementRepo.Create(ctx, settlement); err != nil {
+		return nil, err
+	}
+	u.publishChange(ctx, groupID, tripID, "settlement", event.ActionCreated)
+	return settlement, nil
+}
+
+// DeleteSettlement undoes a settled transfer. Only its payer, its recipient
+// or whoever recorded it may undo it.
+func (u *TripUsecase) DeleteSettlement(ctx context.Context, tripID, id, userID uint, groupID uint) error {
+	if _, err := u.tripRepo.FindByID(ctx, tripID, groupID); err != nil {
+		return err
+	}
+	settlement, err := u.settlementRepo.FindByID(ctx, id, tripID)
+	if err != nil {
+		return err
+	}
+	if userID != settlement.FromUserID && userID != settlement.ToUserID && userID != settlement.SettledBy {
+		return ErrForbidden
+	}
+	if err := u.settlementRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	u.publishChange(ctx, groupID, tripID, "settlement", event.ActionDeleted)
+	return nil
+}
@@ -1,22 +1,30 @@
 package usecase
 
 import (
+	"context"
 	"math"
 	"time"
 
+	"memoria/internal/domain/event"
 	"memoria/internal/domain/model"
 	"memoria/internal/domain/repository"
 )
 
 type TripUsecase struct {
-	tripRepo          repository.TripRepository
-	itineraryRepo     repository.TripItineraryRepository
-	wishlistRepo      repository.TripWishlistRepository
-	expenseRepo       repository.TripExpenseRepository
-	relationRepo      repository.TripRelationRepository
-	detailRepo        repository.TripDetailRepository
-	albumRepo         repository.AlbumRepository
-	postRepo          repository.PostRepository
+	tripRepo         repository.TripRepository
+	itineraryRepo    repository.TripItineraryRepository
+	wishlistRepo     repository.TripWishlistRepository
+	expenseRepo      repository.TripExpenseRepository
+	settlementRepo   repository.TripSettlementRepository
+	exchangeRateRepo repository.ExchangeRateRepository
+	relationRepo     repository.TripRelationRepository
+	detailRepo       repository.TripDetailRepository
+	albumRepo        repository.AlbumRepository
+	postRepo         repository.PostRepository
+	groupRepo        repository.GroupRepository
+	groupMemberRepo  repository.GroupMemberRepository
+	uow              repository.UnitOfWork
+	events           *event.Bus
 }
 
 func NewTripUsecase(
@@ -24,161 +32,190 @@ func NewTripUsecase(
 	itineraryRepo repository.TripItineraryRepository,
 	wishlistRepo repository.TripWishlistRepository,
 	expenseRepo repository.TripExpenseRepository,
+	settlementRepo repository.TripSettlementRepository,
+	exchangeRateRepo repository.ExchangeRateRepository,
 	relationRepo repository.TripRelationRepository,
 	detailRepo repository.TripDetailRepository,
 	albumRepo repository.AlbumRepository,
 	postRepo repository.PostRepository,
+	groupRepo repository.GroupRepository,
+	groupMemberRepo repository.GroupMemberRepository,
+	uow repository.UnitOfWork,
+	events *event.Bus,
 ) *TripUsecase {
 	return &TripUsecase{
-		tripRepo:      tripRepo,
-		itineraryRepo: itineraryRepo,
-		wishlistRepo:  wishlistRepo,
-		expenseRepo:   expenseRepo,
-		relationRepo:  relationRepo,
-		detailRepo:    detailRepo,
-		albumRepo:     albumRepo,
-		postRepo:      postRepo,
+		tripRepo:         tripRepo,
+		itineraryRepo:    itineraryRepo,
+		wishlistRepo:     wishlistRepo,
+		expenseRepo:      expenseRepo,
+		settlementRepo:   settlementRepo,
+		exchangeRateRepo: exchangeRateRepo,
+		relationRepo:     relationRepo,
+		detailRepo:       detailRepo,
+		albumRepo:        albumRepo,
+		postRepo:         postRepo,
+		groupRepo:        groupRepo,
+		groupMemberRepo:  groupMemberRepo,
+		uow:              uow,
+		events:           events,
 	}
 }
 
+// publishChange tells live clients that a section of the trip changed.
+func (u *TripUsecase) publishChange(ctx context.Context, groupID, tripID uint, section string, action event.Action) {
+	u.events.Publish(ctx, event.TripChanged{GroupID: groupID, TripID: tripID, Section: section, Action: action})
+}
+
 // Trip operations
-func (u *TripUsecase) CreateTrip(title string, startAt, endAt time.Time, note string, createdBy uint, notifyAt *time.Time, albumIDs, postIDs []uint, groupID uint) (*model.Trip, error) {
+func (u *TripUsecase) CreateTrip(ctx context.Context, title string, startAt, endAt time.Time, note string, createdBy uint, notifyAt *time.Time, baseCurrency string, albumIDs, postIDs []uint, groupID uint) (*model.Trip, error) {
+	group, err := u.groupRepo.FindByID(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	baseCurrency, err = normalizeCurrency(baseCurrency, group.DefaultCurrency)
+	if err != nil {
+		return nil, err
+	}
 	for _, albumID := range albumIDs {
-		if _, err := u.albumRepo.FindByID(albumID, groupID); err != nil {
+		if _, err := u.albumRepo.FindByID(ctx, albumID, groupID); err != nil {
 			return nil, err
 		}
 	}
 	for _, postID := range postIDs {
-		if _, err := u.postRepo.FindByID(postID, groupID); err != nil {
+		if _, err := u.postRepo.FindByID(ctx, postID, groupID); err != nil {
 			return nil, err
 		}
 	}
 
 	trip := &model.Trip{
-		GroupID:   groupID,
-		Title:     title,
-		StartAt:   startAt,
-		EndAt:     endAt,
-		Note:      note,
-		CreatedBy: createdBy,
-		NotifyAt:  notifyAt,
-	}
-
-	if err := u.tripRepo.Create(trip); err != nil {
-		return nil, err
-	}
-
-	if err := u.relationRepo.AddAlbums(trip.ID, albumIDs); err != nil {
-		return nil, err
-	}
-	if err := u.relationRepo.AddPosts(trip.ID, postIDs); err != nil {
+		GroupID:      groupID,
+		Title:        title,
+		StartAt:      startAt,
+		EndAt:        endAt,
+		Note:         note,
+		CreatedBy:    createdBy,
+		NotifyAt:     notifyAt,
+		BaseCurrency: baseCurrency,
+	}
+
+	err = u.uow.Do(ctx, func(repos repository.Repositories) error {
+		if err := repos.Trips.Create(ctx, trip); err != nil {
+			return err
+		}
+		if err := repos.TripRelations.AddAlbums(ctx, trip.ID, albumIDs); err != nil {
+			return err
+		}
+		return repos.TripRelations.AddPosts(ctx, trip.ID, postIDs)
+	})
+	if err != nil {
 		return nil, err
 	}
 
+	u.publishChange(ctx, groupID, trip.ID, "trip", event.ActionCreated)
 	return trip, nil
 }
 
-func (u *TripUsecase) GetTrip(id uint, groupID uint) (*model.Trip, error) {
-	return u.tripRepo.FindByID(id, groupID)
+func (u *TripUsecase) GetTrip(ctx context.Context, id uint, groupID uint) (*model.Trip, error) {
+	return u.tripRepo.FindByID(ctx, id, groupID)
 }
 
-func (u *TripUsecase) GetTripRelations(tripID uint, groupID uint) ([]*model.Album, []*model.Post, error) {
-	if _, err := u.tripRepo.FindByID(tripID, groupID); err != nil {
+func (u *TripUsecase) GetTripRelations(ctx context.Context, tripID uint, groupID uint) ([]*model.Album, []*model.Post, error) {
+	if _, err := u.tripRepo.FindByID(ctx, tripID, groupID); err != nil {
 		return nil, nil, err
 	}
-	albums, err := u.relationRepo.FindAlbumsByTripID(tripID)
+	albums, err := u.relationRepo.FindAlbumsByTripID(ctx, tripID)
 	if err != nil {
 		return nil, nil, err
 	}
-	posts, err := u.relationRepo.FindPostsByTripID(tripID)
+	posts, err := u.relationRepo.FindPostsByTripID(ctx, tripID)
 	if err != nil {
 		return nil, nil, err
 	}
 	return albums, posts, nil
 }
 
-func (u *TripUsecase) GetSchedule(tripID uint, groupID uint) ([]*model.TripScheduleItem, error) {
-	if _, err := u.tripRepo.FindByID(tripID, groupID); err != nil {
+func (u *TripUsecase) GetSchedule(ctx context.Context, tripID uint, groupID uint) ([]*model.TripScheduleItem, error) {
+	if _, err := u.tripRepo.FindByID(ctx, tripID, groupID); err != nil {
 		return nil, err
 	}
-	return u.detailRepo.FindScheduleItems(tripID)
+	return u.detailRepo.FindScheduleItems(ctx, tripID)
 }
 
-func (u *TripUsecase) UpdateSchedule(tripID uint, items []*model.TripScheduleItem, groupID uint) error {
-	if _, err := u.tripRepo.FindByID(tripID, groupID); err != nil {
+func (u *TripUsecase) UpdateSchedule(ctx context.Context, tripID uint, items []*model.TripScheduleItem, groupID uint) error {
+	if _, err := u.tripRepo.FindByID(ctx, tripID, groupID); err != nil {
+		return err
+	}
+	if err := u.detailRepo.ReplaceScheduleItems(ctx, tripID, items); err != nil {
 		return err
 	}
-	return u.detailRepo.ReplaceScheduleItems(tripID, items)
+	u.publishChange(ctx, groupID, tripID, "schedule", event.ActionUpdated)
+	return nil
 }
 
-func (u *TripUsecase) GetTransports(tripID uint, groupID uint) ([]*model.TripTransport, error) {
-	if _, err := u.tripRepo.FindByID(tripID, groupID); err != nil {
+func (u *TripUsecase) GetTransports(ctx context.Context, tripID uint, groupID uint) ([]*model.TripTransport, error) {
+	if _, err := u.tripRepo.FindByID(ctx, tripID, groupID); err != nil {
 		return nil, err
 	}
-	return u.detailRepo.FindTransports(tripID)
+	return u.detailRepo.FindTransports(ctx, tripID)
 }
 
-func (u *TripUsecase) UpdateTransports(tripID uint, transports []*model.TripTransport, groupID uint) error {
-	if _, err := u.tripRepo.FindByID(tripID, groupID); err != nil {
+func (u *TripUsecase) UpdateTransports(ctx context.Context, tripID uint, transports []*model.TripTransport, groupID uint) error {
+	trip, err := u.tripRepo.FindByID(ctx, tripID, groupID)
+	if err != nil {
 		return err
 	}
 	for _, transport := range transports {
+		if transport.Currency, err = normalizeCurrency(transport.Currency, trip.BaseCurrency); err != nil {
+			return err
+		}
 		u.applyTransportCosts(transport)
 	}
-	return u.detailRepo.ReplaceTransports(tripID, transports)
+	if err := u.detailRepo.ReplaceTransports(ctx, tripID, transports); err != nil {
+		return err
+	}
+	u.publishChange(ctx, groupID, tripID, "transports", event.ActionUpdated)
+	return nil
 }
 
-func (u *TripUsecase) GetLodgings(tripID uint, groupID uint) ([]*model.TripLodging, error) {
-	if _, err := u.tripRepo.FindByID(tripID, groupID); err != nil {
+func (u *TripUsecase) GetLodgings(ctx context.Context, tripID uint, groupID uint) ([]*model.TripLodging, error) {
+	if _, err := u.tripRepo.FindByID(ctx, tripID, groupID); err != nil {
 		return nil, err
 	}
-	return u.detailRepo.FindLodgings(tripID)
+	return u.detailRepo.FindLodgings(ctx, tripID)
 }
 
-func (u *TripUsecase) UpdateLodgings(tripID uint, lodgings []*model.TripLodging, groupID uint) error {
-	if _, err := u.tripRepo.FindByID(tripID, groupID); err != nil {
+func (u *TripUsecase) UpdateLodgings(ctx context.Context, tripID uint, lodgings []*model.TripLodging, groupID uint) error {
+	trip, err := u.tripRepo.FindByID(ctx, tripID, groupID)
+	if err != nil {
 		return err
 	}
-	return u.detailRepo.ReplaceLodgings(tripID, lodgings)
-}
-
-func (u *TripUsecase) GetBudget(tripID uint, groupID uint) ([]*model.TripBudgetItem, int64, int64, int64, error) {
-	if _, err := u.tripRepo.FindByID(tripID, groupID); err != nil {
-		return nil, 0, 0, 0, err
-	}
-	transportTotal, err := u.sumTransportCosts(tripID)
-	if err != nil {
-		return nil, 0, 0, 0, err
+	for _, lodging := range lodgings {
+		if lodging.Currency, err = normalizeCurrency(lodging.Currency, trip.BaseCurrency); err != nil {
+			return err
+		}
 	}
-	lodgingTotal, err := u.sumLodgingCosts(tripID)
-	if err != nil {
-		return nil, 0, 0, 0, err
+	if err := u.detailRepo.ReplaceLodgings(ctx, tripID, lodgings); err != nil {
+		return err
 	}
-	items, err := u.detailRepo.FindBudgetItems(tripID)
+	u.publishChange(ctx, groupID, tripID, "lodgings", event.ActionUpdated)
+	return nil
+}
+
+func (u *TripUsecase) UpdateBudget(ctx context.Context, tripID uint, items []*model.TripBudgetItem, groupID uint) error {
+	trip, err := u.tripRepo.FindByID(ctx, tripID, groupID)
 	if err != nil {
-		return nil, 0, 0, 0, err
+		return err
 	}
-	var manualTotal int64
 	for _, item := range items {
-		manualTotal += item.CostYen
+		if item.Currency, err = normalizeCurrency(item.Currency, trip.BaseCurrency); err != nil {
+			return err
+		}
 	}
-	total := transportTotal + lodgingTotal + manualTotal
-	return items, transportTotal, lodgingTotal, total, nil
-}
-
-func (u *TripUsecase) UpdateBudget(tripID uint, items []*model.TripBudgetItem, groupID uint) error {
-	if _, err := u.tripRepo.FindByID(tripID, groupID); err != nil {
+	if err := u.detailRepo.ReplaceBudgetItems(ctx, tripID, items); err != nil {
 		return err
 	}
-	return u.detailRepo.ReplaceBudgetItems(tripID, items)
-}
-
-func (u *TripUsecase) sumTransportCosts(tripID uint) (int64, error) {
-	return u.detailRepo.SumTransportCosts(tripID)
-}
-
-func (u *TripUsecase) sumLodgingCosts(tripID uint) (int64, error) {
-	return u.detailRepo.SumLodgingCosts(tripID)
+	u.publishChange(ctx, groupID, tripID, "budget", event.ActionUpdated)
+	return nil
 }
 
 func (u *TripUsecase) applyTransportCosts(transport *model.TripTransport) {
@@ -190,38 +227,72 @@ func (u *TripUsecase) applyTransportCosts(transport *model.TripTransport) {
 	}
 }
 
-func (u *TripUsecase) GetAllTrips(groupID uint) ([]*model.Trip, error) {
-	return u.tripRepo.FindAll(groupID)
+func (u *TripUsecase) GetAllTrips(ctx context.Context, groupID uint, opts repository.ListOptions) (*repository.Page[*model.Trip], error) {
+	return u.tripRepo.FindAll(ctx, groupID, opts)
 }
 
-func (u *TripUsecase) UpdateTrip(id uint, title string, startAt, endAt time.Time, note string, notifyAt *time.Time, groupID uint) (*model.Trip, error) {
-	trip, err := u.tripRepo.FindByID(id, groupID)
+func (u *TripUsecase) UpdateTrip(ctx context.Context, id uint, title string, startAt, endAt time.Time, note string, notifyAt *time.Time, baseCurrency string, actor *model.GroupMember) (*model.Trip, error) {
+	groupID := actor.GroupID
+	trip, err := u.tripRepo.FindByID(ctx, id, groupID)
 	if err != nil {
 		return nil, err
 	}
+	if err := authorize(actor, actionEditTrip, trip.CreatedBy); err != nil {
+		return nil, err
+	}
+	baseCurrency, err = normalizeCurrency(baseCurrency, trip.BaseCurrency)
+	if err != nil {
+		return nil, &TripInputError{Message: err.Error()}
+	}
+	// Settled transfers are recorded in the base currency, so it is fixed
+	// once there are any.
+	if baseCurrency != trip.BaseCurrency {
+		settled, err := u.settlementRepo.FindByTripID(ctx, trip.ID)
+		if err != nil {
+			return nil, err
+		}
+		if len(settled) > 0 {
+			return nil, &TripInputError{Message: "base_currency cannot change after transfers are settled"}
+		}
+	}
 
 	trip.Title = title
 	trip.StartAt = startAt
 	trip.EndAt = endAt
 	trip.Note = note
 	trip.NotifyAt = notifyAt
+	trip.BaseCurrency = baseCurrency
 
-	if err := u.tripRepo.Update(trip); err != nil {
+	if err := u.tripRepo.Update(ctx, trip); err != nil {
 		return nil, err
 	}
 
+	u.publishChange(ctx, groupID, trip.ID, "trip", event.ActionUpdated)
 	return trip, nil
 }
 
-func (u *TripUsecase) DeleteTrip(id uint, groupID uint) error {
-	if _, err := u.tripRepo.FindByID(id, groupID); err != nil {
+func (u *TripUsecase) DeleteTrip(ctx context.Context, id uint, actor *model.GroupMember) error {
+	groupID := actor.GroupID
+	trip, err := u.tripRepo.FindByID(ctx, id, groupID)
+	if err != nil {
+		return err
+	}
+	if err := authorize(actor, actionDeleteTrip, trip.CreatedBy); err != nil {
+		return err
+	}
+	if err := u.tripRepo.Delete(ctx, id); err != nil {
 		return err
 	}
-	return u.tripRepo.Delete(id)
+	u.publishChange(ctx, groupID, id, "trip", event.ActionDeleted)
+	return nil
 }
 
 // Itinerary operations
-func (u *TripUsecase) CreateItinerary(tripID uint, title string, startAt, endAt time.Time, location, note string) (*model.TripItinerary, error) {
+func (u *TripUsecase) CreateItinerary(ctx context.Context, tripID uint, title string, startAt, endAt time.Time, location, note string, groupID uint) (*model.TripItinerary, error) {
+	if _, err := u.tripRepo.FindByID(ctx, tripID, groupID); err != nil {
+		return nil, err
+	}
+
 	itinerary := &model.TripItinerary{
 		TripID:   tripID,
 		Title:    title,
@@ -231,40 +302,64 @@ func (u *TripUsecase) CreateItinerary(tripID uint, title string, startAt, endAt
 		Note:     note,
 	}
 
-	if err := u.itineraryRepo.Create(itinerary); err != nil {
+	if err := u.itineraryRepo.Create(ctx, itinerary); err != nil {
 		return nil, err
 	}
 
+	u.publishChange(ctx, groupID, tripID, "itineraries", event.ActionCreated)
 	return itinerary, nil
 }
 
-func (u *TripUsecase) GetItineraries(tripID uint) ([]*model.TripItinerary, error) {
-	return u.itineraryRepo.FindByTripID(tripID)
+func (u *TripUsecase) GetItineraries(ctx context.Context, tripID uint, groupID uint) ([]*model.TripItinerary, error) {
+	if _, err := u.tripRepo.FindByID(ctx, tripID, groupID); err != nil {
+		return nil, err
+	}
+	return u.itineraryRepo.FindByTripID(ctx, tripID)
 }
 
-func (u *TripUsecase) UpdateItinerary(id uint, title string, startAt, endAt time.Time, location, note string) (*model.TripItinerary, error) {
-	itinerary := &model.TripItinerary{
-		BaseModel: model.BaseModel{ID: id},
-		Title:     title,
-		StartAt:   startAt,
-		EndAt:     endAt,
-		Location:  location,
-		Note:      note,
+func (u *TripUsecase) UpdateItinerary(ctx context.Context, tripID, id uint, title string, startAt, endAt time.Time, location, note string, groupID uint) (*model.TripItinerary, error) {
+	if _, err := u.tripRepo.FindByID(ctx, tripID, groupID); err != nil {
+		return nil, err
+	}
+	itinerary, err := u.itineraryRepo.FindByID(ctx, id, tripID)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := u.itineraryRepo.Update(itinerary); err != nil {
+	itinerary.Title = title
+	itinerary.StartAt = startAt
+	itinerary.EndAt = endAt
+	itinerary.Location = location
+	itinerary.Note = note
+
+	if err := u.itineraryRepo.Update(ctx, itinerary); err != nil {
 		return nil, err
 	}
 
+	u.publishChange(ctx, groupID, tripID, "itineraries", event.ActionUpdated)
 	return itinerary, nil
 }
 
-func (u *TripUsecase) DeleteItinerary(id uint) error {
-	return u.itineraryRepo.Delete(id)
+func (u *TripUsecase) DeleteItinerary(ctx context.Context, tripID, id uint, groupID uint) error {
+	if _, err := u.tripRepo.FindByID(ctx, tripID, groupID); err != nil {
+		return err
+	}
+	if _, err := u.itineraryRepo.FindByID(ctx, id, tripID); err != nil {
+		return err
+	}
+	if err := u.itineraryRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	u.publishChange(ctx, groupID, tripID, "itineraries", event.ActionDeleted)
+	return nil
 }
 
 // Wishlist operations
-func (u *TripUsecase) CreateWishlist(tripID uint, title, location, note string, priority int) (*model.TripWishlist, error) {
+func (u *TripUsecase) CreateWishlist(ctx context.Context, tripID uint, title, location, note string, priority int, groupID uint) (*model.TripWishlist, error) {
+	if _, err := u.tripRepo.FindByID(ctx, tripID, groupID); err != nil {
+		return nil, err
+	}
+
 	wishlist := &model.TripWishlist{
 		TripID:   tripID,
 		Title:    title,
@@ -273,78 +368,173 @@ func (u *TripUsecase) CreateWishlist(tripID uint, title, location, note string,
 		Priority: priority,
 	}
 
-	if err := u.wishlistRepo.Create(wishlist); err != nil {
+	if err := u.wishlistRepo.Create(ctx, wishlist); err != nil {
 		return nil, err
 	}
 
+	u.publishChange(ctx, groupID, tripID, "wishlists", event.ActionCreated)
 	return wishlist, nil
 }
 
-func (u *TripUsecase) GetWishlists(tripID uint) ([]*model.TripWishlist, error) {
-	return u.wishlistRepo.FindByTripID(tripID)
+func (u *TripUsecase) GetWishlists(ctx context.Context, tripID uint, groupID uint) ([]*model.TripWishlist, error) {
+	if _, err := u.tripRepo.FindByID(ctx, tripID, groupID); err != nil {
+		return nil, err
+	}
+	return u.wishlistRepo.FindByTripID(ctx, tripID)
 }
 
-func (u *TripUsecase) UpdateWishlist(id uint, title, location, note string, priority int) (*model.TripWishlist, error) {
-	wishlist := &model.TripWishlist{
-		BaseModel: model.BaseModel{ID: id},
-		Title:     title,
-		Location:  location,
-		Note:      note,
-		Priority:  priority,
+func (u *TripUsecase) UpdateWishlist(ctx context.Context, tripID, id uint, title, location, note string, priority int, groupID uint) (*model.TripWishlist, error) {
+	if _, err := u.tripRepo.FindByID(ctx, tripID, groupID); err != nil {
+		return nil, err
+	}
+	wishlist, err := u.wishlistRepo.FindByID(ctx, id, tripID)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := u.wishlistRepo.Update(wishlist); err != nil {
+	wishlist.Title = title
+	wishlist.Location = location
+	wishlist.Note = note
+	wishlist.Priority = priority
+
+	if err := u.wishlistRepo.Update(ctx, wishlist); err != nil {
 		return nil, err
 	}
 
+	u.publishChange(ctx, groupID, tripID, "wishlists", event.ActionUpdated)
 	return wishlist, nil
 }
 
-func (u *TripUsecase) DeleteWishlist(id uint) error {
-	return u.wishlistRepo.Delete(id)
+func (u *TripUsecase) DeleteWishlist(ctx context.Context, tripID, id uint, groupID uint) error {
+	if _, err := u.tripRepo.FindByID(ctx, tripID, groupID); err != nil {
+		return err
+	}
+	if _, err := u.wishlistRepo.FindByID(ctx, id, tripID); err != nil {
+		return err
+	}
+	if err := u.wishlistRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	u.publishChange(ctx, groupID, tripID, "wishlists", event.ActionDeleted)
+	return nil
 }
 
 // Expense operations
-func (u *TripUsecase) CreateExpense(tripID uint, title, category string, amount int64, currency string, isActual bool, note string) (*model.TripExpense, error) {
-	expense := &model.TripExpense{
-		TripID:   tripID,
-		Title:    title,
-		Category: category,
-		Amount:   amount,
-		Currency: currency,
-		IsActual: isActual,
-		Note:     note,
+func (u *TripUsecase) CreateExpense(ctx context.Context, tripID uint, title, category string, amount int64, currency string, isActual bool, note string, paidBy *uint, splitType string, participants []*model.TripExpenseParticipant, groupID uint) (*model.TripExpense, []*model.TripExpenseParticipant, error) {
+	trip, err := u.tripRepo.FindByID(ctx, tripID, groupID)
+	if err != nil {
+		return nil, nil, err
 	}
-
-	if err := u.expenseRepo.Create(expense); err != nil {
-		return nil, err
+	if splitType == "" {
+		splitType = SplitTypeEqual
+	}
+	currency, err = normalizeCurrency(currency, trip.BaseCurrency)
+	if err != nil {
+		return nil, nil, &TripInputError{Message: err.Error()}
 	}
 
-	return expense, nil
-}
-
-func (u *TripUsecase) GetExpenses(tripID uint) ([]*model.TripExpense, error) {
-	return u.expenseRepo.FindByTripID(tripID)
-}
-
-func (u *TripUsecase) UpdateExpense(id uint, title, category string, amount int64, currency string, isActual bool, note string) (*model.TripExpense, error) {
 	expense := &model.TripExpense{
-		BaseModel: model.BaseModel{ID: id},
+		TripID:    tripID,
 		Title:     title,
 		Category:  category,
 		Amount:    amount,
 		Currency:  currency,
 		IsActual:  isActual,
 		Note:      note,
+		PaidBy:    paidBy,
+		SplitType: splitType,
+	}
+	if err := u.validateExpenseSplit(ctx, trip.GroupID, expense, participants); err != nil {
+		return nil, nil, err
 	}
 
-	if err := u.expenseRepo.Update(expense); err != nil {
-		return nil, err
+	err = u.uow.Do(ctx, func(repos repository.Repositories) error {
+		if err := repos.TripExpenses.Create(ctx, expense); err != nil {
+			return err
+		}
+		return repos.TripExpenses.ReplaceParticipants(ctx, expense.ID, participants)
+	})
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return expense, nil
+	u.publishChange(ctx, groupID, tripID, "expenses", event.ActionCreated)
+	return expense, participants, nil
 }
 
-func (u *TripUsecase) DeleteExpense(id uint) error {
-	return u.expenseRepo.Delete(id)
+// GetExpenses returns the trip's expenses together with their participants keyed by expense ID.
+func (u *TripUsecase) GetExpenses(ctx context.Context, tripID uint, groupID uint) ([]*model.TripExpense, map[uint][]*model.TripExpenseParticipant, error) {
+	if _, err := u.tripRepo.FindByID(ctx, tripID, groupID); err != nil {
+		return nil, nil, err
+	}
+	expenses, err := u.expenseRepo.FindByTripID(ctx, tripID)
+	if err != nil {
+		return nil, nil, err
+	}
+	participants, err := u.expenseRepo.FindParticipantsByTripID(ctx, tripID)
+	if err != nil {
+		return nil, nil, err
+	}
+	byExpense := make(map[uint][]*model.TripExpenseParticipant, len(expenses))
+	for _, participant := range participants {
+		byExpense[participant.ExpenseID] = append(byExpense[participant.ExpenseID], participant)
+	}
+	return expenses, byExpense, nil
+}
+
+func (u *TripUsecase) UpdateExpense(ctx context.Context, tripID, id uint, title, category string, amount int64, currency string, isActual bool, note string, paidBy *uint, splitType string, participants []*model.TripExpenseParticipant, groupID uint) (*model.TripExpense, []*model.TripExpenseParticipant, error) {
+	trip, err := u.tripRepo.FindByID(ctx, tripID, groupID)
+	if err != nil {
+		return nil, nil, err
+	}
+	expense, err := u.expenseRepo.FindByID(ctx, id, tripID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if splitType == "" {
+		splitType = SplitTypeEqual
+	}
+	currency, err = normalizeCurrency(currency, trip.BaseCurrency)
+	if err != nil {
+		return nil, nil, &TripInputError{Message: err.Error()}
+	}
+
+	expense.Title = title
+	expense.Category = category
+	expense.Amount = amount
+	expense.Currency = currency
+	expense.IsActual = isActual
+	expense.Note = note
+	expense.PaidBy = paidBy
+	expense.SplitType = splitType
+	if err := u.validateExpenseSplit(ctx, trip.GroupID, expense, participants); err != nil {
+		return nil, nil, err
+	}
+
+	err = u.uow.Do(ctx, func(repos repository.Repositories) error {
+		if err := repos.TripExpenses.Update(ctx, expense); err != nil {
+			return err
+		}
+		return repos.TripExpenses.ReplaceParticipants(ctx, expense.ID, participants)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	u.publishChange(ctx, groupID, tripID, "expenses", event.ActionUpdated)
+	return expense, participants, nil
+}
+
+func (u *TripUsecase) DeleteExpense(ctx context.Context, tripID, id uint, groupID uint) error {
+	if _, err := u.tripRepo.FindByID(ctx, tripID, groupID); err != nil {
+		return err
+	}
+	if _, err := u.expenseRepo.FindByID(ctx, id, tripID); err != nil {
+		return err
+	}
+	if err := u.expenseRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	u.publishChange(ctx, groupID, tripID, "expenses", event.ActionDeleted)
+	return nil
 }
@@ -0,0 +1,318 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"memoria/internal/domain/model"
+	"memoria/internal/domain/repository"
+)
+
+const (
+	// uploadSessionTTL is how long a multipart upload may take. Sessions
+	// not completed by then are aborted by the upload-session-sweep job.
+	uploadSessionTTL = 24 * time.Hour
+	// uploadPartURLExpiry is how long a presigned part URL works.
+	uploadPartURLExpiry = time.Hour
+	// uploadPartSize is the size of every part but the last. Larger files
+	// get larger parts to stay within MaxParts.
+	uploadPartSize = 8 << 20
+	// uploadPartURLBatchSize bounds part URLs signed per request.
+	uploadPartURLBatchSize = 100
+	// uploadSessionSweepBatchSize bounds expired sessions handled per run.
+	uploadSessionSweepBatchSize = 100
+)
+
+// Upload session error codes, alongside the upload error codes.
+const (
+	UploadInvalidPart = "invalid_part"
+	UploadIncomplete  = "upload_incomplete"
+	UploadRestart     = "upload_restart"
+)
+
+var (
+	ErrUploadSessionNotFound  = errors.New("upload session not found or expired")
+	ErrUploadSessionCompleted = errors.New("upload session has already been completed")
+
+	ErrUploadIncomplete  = &UploadError{Code: UploadIncomplete, Message: "not every part has been uploaded"}
+	ErrUploadSessionLost = &UploadError{Code: UploadRestart, Message: "the uploaded parts are gone; start a new upload session"}
+)
+
+// UploadPartURL is a presigned URL to PUT one part to.
+type UploadPartURL struct {
+	PartNumber int
+	URL        string
+	SizeBytes  int64
+}
+
+// UploadSessionUsecase uploads large photos in parts that can be retried
+// one at a time, for mobile connections where a single PUT often fails. The session checks the file and issues its key like
+// PhotoUsecase.GenerateUploadURL, and completing it registers the photo
+// through PhotoUsecase.CreatePhoto.
+type UploadSessionUsecase struct {
+	sessionRepo   repository.UploadSessionRepository
+	uow           repository.UnitOfWork
+	objectStorage ObjectStorage
+	photoUsecase  *PhotoUsecase
+}
+
+func NewUploadSessionUsecase(sessionRepo repository.UploadSessionRepository, uow repository.UnitOfWork, objectStorage ObjectStorage, photoUsecase *PhotoUsecase) *UploadSessionUsecase {
+	return &UploadSessionUsecase{
+		sessionRepo:   sessionRepo,
+		uow:           uow,
+		objectStorage: objectStorage,
+		photoUsecase:  photoUsecase,
+	}
+}
+
+// CreateSession starts a multipart upload of a file to the album. The same
+// content types and size limit apply as to a single upload.
+func (u *UploadSessionUsecase) CreateSession(ctx context.Context, albumID uint, filename, contentType string, sizeBytes int64, userID uint, groupID uint) (*model.UploadSession, error) {
+	contentType, err := u.photoUsecase.checkUpload(ctx, albumID, contentType, sizeBytes, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	key := photoUploadKey(albumID, userID, filename)
+	uploadID, err := u.objectStorage.CreateMultipartUpload(ctx, key, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(uploadSessionTTL)
+	session := &model.UploadSession{
+		GroupID:     groupID,
+		AlbumID:     albumID,
+		UserID:      userID,
+		S3Key:       key,
+		UploadID:    uploadID,
+		ContentType: contentType,
+		SizeBytes:   sizeBytes,
+		PartSize:    max(uploadPartSize, (sizeBytes+MaxParts-1)/MaxParts),
+		ExpiresAt:   expiresAt,
+	}
+	err = u.uow.Do(ctx, func(repos repository.Repositories) error {
+		// The key stays registrable for as long as the session runs.
+		if err := repos.PhotoUploads.Create(ctx, &model.PhotoUpload{
+			GroupID:     groupID,
+			AlbumID:     albumID,
+			UserID:      userID,
+			S3Key:       key,
+			ContentType: contentType,
+			SizeBytes:   sizeBytes,
+			ExpiresAt:   expiresAt,
+		}); err != nil {
+			return err
+		}
+		return repos.UploadSessions.Create(ctx, session)
+	})
+	if err != nil {
+		if abortErr := u.objectStorage.AbortMultipartUpload(ctx, key, uploadID); abortErr != nil {
+			log.Printf("upload session: failed to abort %s: %v", key, abortErr)
+		}
+		return nil, err
+	}
+	return session, nil
+}
+
+// GetSession returns the user's session and the parts reported so far, so
+// that an interrupted upload can resume with the missing parts.
+func (u *UploadSessionUsecase) GetSession(ctx context.Context, id uint, userID uint, groupID uint) (*model.UploadSession, []*model.UploadSessionPart, error) {
+	session, err := u.findSession(ctx, id, userID, groupID)
+	if err != nil {
+		return nil, nil, err
+	}
+	parts, err := u.sessionRepo.FindParts(ctx, session.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return session, parts, nil
+}
+
+// SignPartURLs presigns a PUT for each of the numbered parts. A URL only
+// accepts the part's exact size, and works until the returned time.
+func (u *UploadSessionUsecase) SignPartURLs(ctx context.Context, id uint, partNumbers []int, userID uint, groupID uint) ([]UploadPartURL, time.Time, error) {
+	session, err := u.findSession(ctx, id, userID, groupID)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if session.CompletedAt != nil {
+		return nil, time.Time{}, ErrUploadSessionCompleted
+	}
+	if len(partNumbers) == 0 || len(partNumbers) > uploadPartURLBatchSize {
+		return nil, time.Time{}, &UploadError{
+			Code:    UploadInvalidPart,
+			Message: fmt.Sprintf("request between 1 and %d part URLs at a time", uploadPartURLBatchSize),
+		}
+	}
+
+	expiresIn := min(uploadPartURLExpiry, time.Until(session.ExpiresAt))
+	urls := make([]UploadPartURL, 0, len(partNumbers))
+	for _, partNumber := range partNumbers {
+		sizeBytes, err := uploadPartBytes(session, partNumber)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		url, err := u.objectStorage.PresignUploadPart(session.S3Key, session.UploadID, partNumber, sizeBytes, expiresIn)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		urls = append(urls, UploadPartURL{PartNumber: partNumber, URL: url, SizeBytes: sizeBytes})
+	}
+	return urls, time.Now().Add(expiresIn), nil
+}
+
+// ReportPart records the ETag the storage returned for an uploaded part.
+// Reporting a part again replaces it.
+func (u *UploadSessionUsecase) ReportPart(ctx context.Context, id uint, partNumber int, etag string, userID uint, groupID uint) error {
+	session, err := u.findSession(ctx, id, userID, groupID)
+	if err != nil {
+		return err
+	}
+	if session.CompletedAt != nil {
+		return ErrUploadSessionCompleted
+	}
+	if _, err := uploadPartBytes(session, partNumber); err != nil {
+		return err
+	}
+	etag = strings.Trim(strings.TrimSpace(etag), `"`)
+	if etag == "" {
+		return &UploadError{Code: UploadInvalidPart, Message: "etag is required"}
+	}
+
+	return u.sessionRepo.SavePart(ctx, &model.UploadSessionPart{
+		SessionID:  session.ID,
+		PartNumber: partNumber,
+		ETag:       `"` + etag + `"`,
+		CreatedAt:  time.Now(),
+	})
+}
+
+// Complete joins the reported parts into the file and registers it as a
+// photo exactly like a single upload. When registering fails, the joined
+// file is kept and Complete can be retried until the session expires.
+func (u *UploadSessionUsecase) Complete(ctx context.Context, id uint, userID uint, groupID uint) (*model.Photo, error) {
+	session, err := u.findSession(ctx, id, userID, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.CompletedAt == nil {
+		parts, err := u.sessionRepo.FindParts(ctx, session.ID)
+		if err != nil {
+			return nil, err
+		}
+		if len(parts) != UploadPartCount(session) {
+			return nil, ErrUploadIncomplete
+		}
+		completed := make([]CompletedPart, len(parts))
+		for i, part := range parts {
+			completed[i] = CompletedPart{PartNumber: part.PartNumber, ETag: part.ETag}
+		}
+
+		err = u.objectStorage.CompleteMultipartUpload(ctx, session.S3Key, session.UploadID, completed)
+		switch {
+		case errors.Is(err, ErrInvalidPart):
+			return nil, &UploadError{Code: UploadInvalidPart, Message: "a part is missing or does not match its etag; upload it again"}
+		case errors.Is(err, ErrUploadNotFound):
+			// An earlier attempt may have joined the parts and failed to
+			// record it; then the whole file is there. Otherwise the upload
+			// was aborted and its parts are gone.
+			info, err := u.objectStorage.HeadObject(ctx, session.S3Key)
+			if errors.Is(err, ErrObjectNotFound) || (err == nil && info.Size != session.SizeBytes) {
+				return nil, ErrUploadSessionLost
+			}
+			if err != nil {
+				return nil, err
+			}
+		case err != nil:
+			return nil, err
+		}
+		if err := u.sessionRepo.MarkCompleted(ctx, session.ID, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	photo, err := u.photoUsecase.CreatePhoto(ctx, session.AlbumID, session.S3Key, session.UserID, session.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	// The photo exists either way; an undeleted session expires harmlessly.
+	if err := u.sessionRepo.Delete(ctx, session.ID); err != nil {
+		log.Printf("upload session: failed to delete session %d: %v", session.ID, err)
+	}
+	return photo, nil
+}
+
+// Abort discards the session and its uploaded parts. A file already joined
+// is deleted once its key expires.
+func (u *UploadSessionUsecase) Abort(ctx context.Context, id uint, userID uint, groupID uint) error {
+	session, err := u.findSession(ctx, id, userID, groupID)
+	if err != nil {
+		return err
+	}
+	return u.abort(ctx, session)
+}
+
+// SweepExpired aborts sessions that were abandoned before they were
+// completed and returns how many there were. Several workers may run it at
+// once.
+func (u *UploadSessionUsecase) SweepExpired(ctx context.Context, now time.Time) (int, error) {
+	sessions, err := u.sessionRepo.FindExpired(ctx, now, uploadSessionSweepBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	for i, session := range sessions {
+		if err := u.abort(ctx, session); err != nil {
+			return i, err
+		}
+	}
+	if len(sessions) > 0 {
+		log.Printf("upload session sweep: aborted=%d", len(sessions))
+	}
+	return len(sessions), nil
+}
+
+func (u *UploadSessionUsecase) abort(ctx context.Context, session *model.UploadSession) error {
+	if session.CompletedAt == nil {
+		if err := u.objectStorage.AbortMultipartUpload(ctx, session.S3Key, session.UploadID); err != nil {
+			return err
+		}
+	}
+	return u.sessionRepo.Delete(ctx, session.ID)
+}
+
+// findSession returns the session if it belongs to the user and has not
+// expired.
+func (u *UploadSessionUsecase) findSession(ctx context.Context, id uint, userID uint, groupID uint) (*model.UploadSession, error) {
+	session, err := u.sessionRepo.FindByID(ctx, id, groupID)
+	if err != nil || session.UserID != userID || !time.Now().Before(session.ExpiresAt) {
+		return nil, ErrUploadSessionNotFound
+	}
+	return session, nil
+}
+
+// UploadPartCount is how many parts make up the session's file.
+func UploadPartCount(session *model.UploadSession) int {
+	return int((session.SizeBytes + session.PartSize - 1) / session.PartSize)
+}
+
+// uploadPartBytes is the size of the numbered part: PartSize, or what is
+// left for the last part.
+func uploadPartBytes(session *model.UploadSession, partNumber int) (int64, error) {
+	count := UploadPartCount(session)
+	if partNumber < 1 || partNumber > count {
+		return 0, &UploadError{
+			Code:    UploadInvalidPart,
+			Message: fmt.Sprintf("part number must be between 1 and %d", count),
+		}
+	}
+	if partNumber < count {
+		return session.PartSize, nil
+	}
+	return session.SizeBytes - int64(count-1)*session.PartSize, nil
+}
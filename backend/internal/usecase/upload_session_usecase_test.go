@@ -0,0 +1,467 @@
+package usecase_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"memoria/internal/adapter/imaging"
+	"memoria/internal/adapter/storage"
+	"memoria/internal/domain/event"
+	"memoria/internal/domain/model"
+	"memoria/internal/domain/repository"
+	"memoria/internal/usecase"
+)
+
+const (
+	testUploadGroupID uint = 1
+	testUploadAlbumID uint = 2
+	testUploadUserID  uint = 3
+	// testUploadPartSize is the part size of files that fit in MaxParts
+	// parts of it.
+	testUploadPartSize = 8 << 20
+)
+
+type memAlbumRepo struct{ repository.AlbumRepository }
+
+func (memAlbumRepo) FindByID(ctx context.Context, id uint, groupID uint) (*model.Album, error) {
+	if id != testUploadAlbumID || groupID != testUploadGroupID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	album := &model.Album{GroupID: groupID}
+	album.ID = id
+	return album, nil
+}
+
+type memGroupRepo struct{ repository.GroupRepository }
+
+func (memGroupRepo) FindByID(ctx context.Context, id uint) (*model.Group, error) {
+	group := &model.Group{}
+	group.ID = id
+	return group, nil
+}
+
+type memPhotoUploadRepo struct {
+	repository.PhotoUploadRepository
+	uploads map[string]*model.PhotoUpload
+	nextID  uint
+}
+
+func (r *memPhotoUploadRepo) Create(ctx context.Context, upload *model.PhotoUpload) error {
+	r.nextID++
+	upload.ID = r.nextID
+	r.uploads[upload.S3Key] = upload
+	return nil
+}
+
+func (r *memPhotoUploadRepo) FindByKey(ctx context.Context, s3Key string) (*model.PhotoUpload, error) {
+	upload, ok := r.uploads[s3Key]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return upload, nil
+}
+
+func (r *memPhotoUploadRepo) Consume(ctx context.Context, id uint, now time.Time) (bool, error) {
+	for key, upload := range r.uploads {
+		if upload.ID == id && now.Before(upload.ExpiresAt) {
+			delete(r.uploads, key)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memPhotoRepo) Create(ctx context.Context, photo *model.Photo) error {
+	photo.ID = uint(len(r.photos) + 1)
+	r.photos[photo.ID] = photo
+	return nil
+}
+
+// memUploadSessionRepo keeps sessions and their parts in memory.
+// markCompletedErr fails the next MarkCompleted.
+type memUploadSessionRepo struct {
+	repository.UploadSessionRepository
+	sessions         map[uint]*model.UploadSession
+	parts            map[uint]map[int]*model.UploadSessionPart
+	nextID           uint
+	markCompletedErr error
+}
+
+func (r *memUploadSessionRepo) Create(ctx context.Context, session *model.UploadSession) error {
+	r.nextID++
+	session.ID = r.nextID
+	copied := *session
+	r.sessions[session.ID] = &copied
+	r.parts[session.ID] = make(map[int]*model.UploadSessionPart)
+	return nil
+}
+
+func (r *memUploadSessionRepo) FindByID(ctx context.Context, id uint, groupID uint) (*model.UploadSession, error) {
+	session, ok := r.sessions[id]
+	if !ok || session.GroupID != groupID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *session
+	return &copied, nil
+}
+
+func (r *memUploadSessionRepo) SavePart(ctx context.Context, part *model.UploadSessionPart) error {
+	r.parts[part.SessionID][part.PartNumber] = part
+	return nil
+}
+
+func (r *memUploadSessionRepo) FindParts(ctx context.Context, sessionID uint) ([]*model.UploadSessionPart, error) {
+	parts := make([]*model.UploadSessionPart, 0, len(r.parts[sessionID]))
+	for _, part := range r.parts[sessionID] {
+		parts = append(parts, part)
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].PartNumber < parts[j].PartNumber })
+	return parts, nil
+}
+
+func (r *memUploadSessionRepo) MarkCompleted(ctx context.Context, id uint, now time.Time) error {
+	if err := r.markCompletedErr; err != nil {
+		r.markCompletedErr = nil
+		return err
+	}
+	r.sessions[id].CompletedAt = &now
+	return nil
+}
+
+func (r *memUploadSessionRepo) Delete(ctx context.Context, id uint) error {
+	delete(r.sessions, id)
+	delete(r.parts, id)
+	return nil
+}
+
+func (r *memUploadSessionRepo) FindExpired(ctx context.Context, now time.Time, limit int) ([]*model.UploadSession, error) {
+	var expired []*model.UploadSession
+	for _, session := range r.sessions {
+		if !session.ExpiresAt.After(now) {
+			copied := *session
+			expired = append(expired, &copied)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool { return expired[i].ID < expired[j].ID })
+	if len(expired) > limit {
+		expired = expired[:limit]
+	}
+	return expired, nil
+}
+
+// memUnitOfWork runs fn on the in-memory repositories without rolling
+// anything back.
+type memUnitOfWork struct{ repos repository.Repositories }
+
+func (u memUnitOfWork) Do(ctx context.Context, fn func(repos repository.Repositories) error) error {
+	return fn(u.repos)
+}
+
+type uploadSessionTest struct {
+	usecase  *usecase.UploadSessionUsecase
+	store    *storage.LocalStorage
+	sessions *memUploadSessionRepo
+	uploads  *memPhotoUploadRepo
+	photos   *memPhotoRepo
+}
+
+func newUploadSessionTest(t *testing.T) *uploadSessionTest {
+	t.Helper()
+	tt := &uploadSessionTest{
+		store: newTestLocalStorage(t),
+		sessions: &memUploadSessionRepo{
+			sessions: make(map[uint]*model.UploadSession),
+			parts:    make(map[uint]map[int]*model.UploadSessionPart),
+		},
+		uploads: &memPhotoUploadRepo{uploads: make(map[string]*model.PhotoUpload)},
+		photos:  newMemPhotoRepo(),
+	}
+	uow := memUnitOfWork{repos: repository.Repositories{
+		Photos:         tt.photos,
+		PhotoUploads:   tt.uploads,
+		UploadSessions: tt.sessions,
+	}}
+	// video/quicktime is allowed but can never be registered as a photo.
+	photoUsecase := usecase.NewPhotoUsecase(tt.photos, memAlbumRepo{}, memGroupRepo{}, tt.uploads, uow, tt.store, imaging.Codec{}, event.NewBus(), 1<<40, []string{"image/png", "video/quicktime"})
+	tt.usecase = usecase.NewUploadSessionUsecase(tt.sessions, uow, tt.store, photoUsecase)
+	return tt
+}
+
+func (tt *uploadSessionTest) create(t *testing.T, sizeBytes int64) *model.UploadSession {
+	t.Helper()
+	session, err := tt.usecase.CreateSession(context.Background(), testUploadAlbumID, "photo.png", "image/png", sizeBytes, testUploadUserID, testUploadGroupID)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	return session
+}
+
+// uploadPart PUTs the numbered part of data to the storage and reports it.
+func (tt *uploadSessionTest) uploadPart(t *testing.T, session *model.UploadSession, data []byte, partNumber int) {
+	t.Helper()
+	ctx := context.Background()
+	start := int64(partNumber-1) * session.PartSize
+	end := min(start+session.PartSize, int64(len(data)))
+	etag, err := tt.store.WritePart(ctx, session.S3Key, session.UploadID, partNumber, end-start, bytes.NewReader(data[start:end]))
+	if err != nil {
+		t.Fatalf("WritePart(%d): %v", partNumber, err)
+	}
+	if err := tt.usecase.ReportPart(ctx, session.ID, partNumber, etag, testUploadUserID, testUploadGroupID); err != nil {
+		t.Fatalf("ReportPart(%d): %v", partNumber, err)
+	}
+}
+
+func (tt *uploadSessionTest) complete(session *model.UploadSession) (*model.Photo, error) {
+	return tt.usecase.Complete(context.Background(), session.ID, testUploadUserID, testUploadGroupID)
+}
+
+// uploadFile is a PNG of size bytes: a real header followed by padding,
+// which is all photo registration reads.
+func uploadFile(t *testing.T, size int) []byte {
+	t.Helper()
+	data := make([]byte, size)
+	copy(data, encodePNG(t, 40, 30))
+	return data
+}
+
+// A file that could only fail at Complete is rejected before any part is
+// uploaded.
+func TestUploadSessionRejectsTypesThatCannotBePhotos(t *testing.T) {
+	tt := newUploadSessionTest(t)
+	_, err := tt.usecase.CreateSession(context.Background(), testUploadAlbumID, "clip.mov", "video/quicktime", testUploadPartSize, testUploadUserID, testUploadGroupID)
+	var uploadErr *usecase.UploadError
+	if !errors.As(err, &uploadErr) || uploadErr.Code != usecase.UploadContentTypeNotAllowed {
+		t.Fatalf("CreateSession = %v, want %s", err, usecase.UploadContentTypeNotAllowed)
+	}
+	if len(tt.sessions.sessions) != 0 {
+		t.Errorf("%d sessions were created", len(tt.sessions.sessions))
+	}
+}
+
+func TestUploadSessionPartSizeAtMaxParts(t *testing.T) {
+	tests := []struct {
+		name         string
+		sizeBytes    int64
+		wantPartSize int64
+		wantParts    int
+		wantLastPart int64
+	}{
+		{"one byte", 1, testUploadPartSize, 1, 1},
+		{"one part", testUploadPartSize, testUploadPartSize, 1, testUploadPartSize},
+		{"just over one part", testUploadPartSize + 1, testUploadPartSize, 2, 1},
+		{"exactly MaxParts parts", testUploadPartSize * usecase.MaxParts, testUploadPartSize, usecase.MaxParts, testUploadPartSize},
+		// One byte more grows every part by a byte instead of adding a part.
+		{"one byte over MaxParts parts", testUploadPartSize*usecase.MaxParts + 1, testUploadPartSize + 1, usecase.MaxParts, testUploadPartSize + 1 - (usecase.MaxParts - 1)},
+		{"far over MaxParts parts", 3 * testUploadPartSize * usecase.MaxParts, 3 * testUploadPartSize, usecase.MaxParts, 3 * testUploadPartSize},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tt := newUploadSessionTest(t)
+			session := tt.create(t, tc.sizeBytes)
+			if session.PartSize != tc.wantPartSize {
+				t.Errorf("PartSize = %d, want %d", session.PartSize, tc.wantPartSize)
+			}
+			if got := usecase.UploadPartCount(session); got != tc.wantParts {
+				t.Fatalf("UploadPartCount = %d, want %d", got, tc.wantParts)
+			}
+
+			ctx := context.Background()
+			urls, _, err := tt.usecase.SignPartURLs(ctx, session.ID, []int{tc.wantParts}, testUploadUserID, testUploadGroupID)
+			if err != nil {
+				t.Fatalf("SignPartURLs(last): %v", err)
+			}
+			if urls[0].SizeBytes != tc.wantLastPart {
+				t.Errorf("last part is %d bytes, want %d", urls[0].SizeBytes, tc.wantLastPart)
+			}
+
+			_, _, err = tt.usecase.SignPartURLs(ctx, session.ID, []int{tc.wantParts + 1}, testUploadUserID, testUploadGroupID)
+			var uploadErr *usecase.UploadError
+			if !errors.As(err, &uploadErr) || uploadErr.Code != usecase.UploadInvalidPart {
+				t.Errorf("SignPartURLs(past the last) = %v, want %s", err, usecase.UploadInvalidPart)
+			}
+		})
+	}
+}
+
+func TestUploadSessionResume(t *testing.T) {
+	ctx := context.Background()
+	tt := newUploadSessionTest(t)
+	data := uploadFile(t, 2*testUploadPartSize+1000)
+	session := tt.create(t, int64(len(data)))
+
+	tt.uploadPart(t, session, data, 1)
+	tt.uploadPart(t, session, data, 3)
+	if _, err := tt.complete(session); !errors.Is(err, usecase.ErrUploadIncomplete) {
+		t.Fatalf("Complete with a part missing = %v, want ErrUploadIncomplete", err)
+	}
+
+	// The client comes back, asks what is there and uploads the rest.
+	got, parts, err := tt.usecase.GetSession(ctx, session.ID, testUploadUserID, testUploadGroupID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.UploadID != session.UploadID || len(parts) != 2 || parts[0].PartNumber != 1 || parts[1].PartNumber != 3 {
+		t.Fatalf("GetSession = %s with %d parts, want parts 1 and 3 of %s", got.UploadID, len(parts), session.UploadID)
+	}
+	tt.uploadPart(t, session, data, 2)
+	// Uploading and reporting a part again replaces it.
+	tt.uploadPart(t, session, data, 3)
+
+	photo, err := tt.complete(session)
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if photo.S3Key != session.S3Key || photo.SizeBytes != int64(len(data)) || photo.Width != 40 || photo.Height != 30 {
+		t.Errorf("photo = %s %d bytes %dx%d, want %s %d bytes 40x30", photo.S3Key, photo.SizeBytes, photo.Width, photo.Height, session.S3Key, len(data))
+	}
+	stored, err := tt.store.GetObject(ctx, session.S3Key, int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(stored, data) {
+		t.Error("the stored file is not the uploaded one")
+	}
+	if _, ok := tt.sessions.sessions[session.ID]; ok {
+		t.Error("the session was not deleted")
+	}
+}
+
+func TestUploadSessionCompleteRetry(t *testing.T) {
+	tt := newUploadSessionTest(t)
+	data := uploadFile(t, testUploadPartSize+1000)
+	session := tt.create(t, int64(len(data)))
+	tt.uploadPart(t, session, data, 1)
+	tt.uploadPart(t, session, data, 2)
+
+	// The parts are joined, but recording it fails.
+	recordErr := errors.New("connection reset")
+	tt.sessions.markCompletedErr = recordErr
+	if _, err := tt.complete(session); !errors.Is(err, recordErr) {
+		t.Fatalf("first Complete = %v, want %v", err, recordErr)
+	}
+	if tt.sessions.sessions[session.ID].CompletedAt != nil {
+		t.Fatal("the session was marked completed")
+	}
+
+	// The storage no longer knows the upload; the joined file is checked
+	// instead.
+	photo, err := tt.complete(session)
+	if err != nil {
+		t.Fatalf("retried Complete: %v", err)
+	}
+	if photo.SizeBytes != int64(len(data)) {
+		t.Errorf("photo is %d bytes, want %d", photo.SizeBytes, len(data))
+	}
+	if len(tt.photos.photos) != 1 {
+		t.Errorf("%d photos were registered, want 1", len(tt.photos.photos))
+	}
+	if _, err := tt.complete(session); !errors.Is(err, usecase.ErrUploadSessionNotFound) {
+		t.Errorf("Complete after success = %v, want ErrUploadSessionNotFound", err)
+	}
+}
+
+func TestUploadSessionCompleteAfterPartsAreLost(t *testing.T) {
+	tests := []struct {
+		name string
+		// object is what is stored at the session's key, if anything.
+		object []byte
+	}{
+		{"no file", nil},
+		{"file of another size", []byte("partial")},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			tt := newUploadSessionTest(t)
+			data := uploadFile(t, testUploadPartSize+1000)
+			session := tt.create(t, int64(len(data)))
+			tt.uploadPart(t, session, data, 1)
+			tt.uploadPart(t, session, data, 2)
+
+			// The storage aborted the upload, e.g. by a lifecycle rule.
+			if err := tt.store.AbortMultipartUpload(ctx, session.S3Key, session.UploadID); err != nil {
+				t.Fatal(err)
+			}
+			if tc.object != nil {
+				if err := tt.store.PutObject(ctx, session.S3Key, "image/png", tc.object); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err := tt.complete(session)
+			var uploadErr *usecase.UploadError
+			if !errors.As(err, &uploadErr) || uploadErr.Code != usecase.UploadRestart {
+				t.Fatalf("Complete = %v, want %s", err, usecase.UploadRestart)
+			}
+			if tt.sessions.sessions[session.ID].CompletedAt != nil {
+				t.Error("the session was marked completed")
+			}
+			if len(tt.photos.photos) != 0 {
+				t.Error("a photo was registered")
+			}
+		})
+	}
+}
+
+func TestUploadSessionSweepExpired(t *testing.T) {
+	ctx := context.Background()
+	tt := newUploadSessionTest(t)
+	data := uploadFile(t, testUploadPartSize+1000)
+
+	abandoned := tt.create(t, int64(len(data)))
+	tt.uploadPart(t, abandoned, data, 1)
+
+	// Joined and marked completed, but the photo was never registered.
+	joined := tt.create(t, int64(len(data)))
+	tt.uploadPart(t, joined, data, 1)
+	tt.uploadPart(t, joined, data, 2)
+	tt.sessions.markCompletedErr = errors.New("registering failed")
+	if _, err := tt.complete(joined); err == nil {
+		t.Fatal("Complete succeeded")
+	}
+	now := time.Now()
+	tt.sessions.sessions[joined.ID].CompletedAt = &now
+
+	running := tt.create(t, int64(len(data)))
+	tt.uploadPart(t, running, data, 1)
+	tt.sessions.sessions[running.ID].ExpiresAt = now.Add(48 * time.Hour)
+
+	n, err := tt.usecase.SweepExpired(ctx, now.Add(25*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("SweepExpired = %d, want 2", n)
+	}
+	for _, session := range []*model.UploadSession{abandoned, joined} {
+		if _, ok := tt.sessions.sessions[session.ID]; ok {
+			t.Errorf("session %d was not deleted", session.ID)
+		}
+	}
+	if _, ok := tt.sessions.sessions[running.ID]; !ok {
+		t.Error("the running session was deleted")
+	}
+
+	// The abandoned upload's parts are discarded; the running one keeps
+	// its parts.
+	if _, err := tt.store.WritePart(ctx, abandoned.S3Key, abandoned.UploadID, 2, 1, bytes.NewReader([]byte{0})); !errors.Is(err, usecase.ErrUploadNotFound) {
+		t.Errorf("WritePart to the abandoned upload = %v, want ErrUploadNotFound", err)
+	}
+	if _, err := tt.store.WritePart(ctx, running.S3Key, running.UploadID, 2, 1, bytes.NewReader([]byte{0})); err != nil {
+		t.Errorf("WritePart to the running upload: %v", err)
+	}
+	// The joined file waits for upload-sweep to expire its key.
+	if _, err := tt.store.HeadObject(ctx, joined.S3Key); err != nil {
+		t.Errorf("the joined file is gone: %v", err)
+	}
+
+	if n, err := tt.usecase.SweepExpired(ctx, now.Add(25*time.Hour)); err != nil || n != 0 {
+		t.Errorf("second SweepExpired = %d, %v, want 0", n, err)
+	}
+}
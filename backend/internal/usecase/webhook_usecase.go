@@ -0,0 +1,481 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"memoria/internal/domain/event"
+	"memoria/internal/domain/model"
+	"memoria/internal/domain/repository"
+)
+
+// Platforms a GroupWebhook can post to.
+const (
+	WebhookPlatformDiscord = "discord"
+	WebhookPlatformSlack   = "slack"
+)
+
+// Webhook events a GroupWebhook can subscribe to.
+const (
+	WebhookEventNewPost      = "new_post"
+	WebhookEventNewComment   = "new_comment"
+	WebhookEventTripReminder = "trip_reminder"
+	WebhookEventNewMember    = "new_member"
+	// webhookEventTest is only sent from the "send test" button.
+	webhookEventTest = "test"
+)
+
+var webhookEvents = []string{
+	WebhookEventNewPost,
+	WebhookEventNewComment,
+	WebhookEventTripReminder,
+	WebhookEventNewMember,
+}
+
+const (
+	// webhookMaxAttempts includes the first attempt.
+	webhookMaxAttempts = 5
+	// webhookRetryBaseDelay is multiplied by 4 after every failed attempt:
+	// 30s, 2m, 8m, 32m.
+	webhookRetryBaseDelay = 30 * time.Second
+	// webhookLease keeps other workers off a delivery while it is being sent.
+	webhookLease = 2 * time.Minute
+	// webhookRetryBatchSize bounds deliveries retried per run.
+	webhookRetryBatchSize = 50
+	// webhookDeliveryLogSize is how many deliveries the log shows.
+	webhookDeliveryLogSize = 50
+)
+
+var (
+	ErrInvalidWebhookPlatform = errors.New("platform must be discord or slack")
+	ErrInvalidWebhookEvents   = errors.New("events must be one or more of new_post, new_comment, trip_reminder, new_member")
+	ErrInvalidWebhookURL      = errors.New("invalid webhook url")
+)
+
+// WebhookMessage is the platform independent content of a webhook post.
+type WebhookMessage struct {
+	Title     string
+	Body      string
+	URL       string // optional link to the page in memoria
+	GroupName string
+	Timestamp time.Time
+}
+
+// WebhookClient renders messages for a platform and posts them.
+type WebhookClient interface {
+	Format(platform string, msg WebhookMessage) ([]byte, error)
+	// Post sends payload and returns the response status. A status of 0
+	// means the request never got a response.
+	Post(ctx context.Context, url string, payload []byte) (int, error)
+}
+
+// WebhookURLValidator accepts only the platform's own incoming webhook URLs,
+// returning ErrInvalidWebhookURL otherwise, so a group manager cannot make
+// the server post to arbitrary addresses.
+type WebhookURLValidator interface {
+	ValidateURL(platform, url string) error
+}
+
+type WebhookUsecase struct {
+	webhookRepo  repository.WebhookRepository
+	deliveryRepo repository.WebhookDeliveryRepository
+	groupRepo    repository.GroupRepository
+	userRepo     repository.UserRepository
+	client       WebhookClient
+	urls         WebhookURLValidator
+	appBaseURL   string
+}
+
+// NewWebhookUsecase builds webhook management and delivery. appBaseURL is the
+// main frontend used for links in messages; links are omitted when empty.
+func NewWebhookUsecase(
+	webhookRepo repository.WebhookRepository,
+	deliveryRepo repository.WebhookDeliveryRepository,
+	groupRepo repository.GroupRepository,
+	userRepo repository.UserRepository,
+	client WebhookClient,
+	urls WebhookURLValidator,
+	appBaseURL string,
+) *WebhookUsecase {
+	return &WebhookUsecase{
+		webhookRepo:  webhookRepo,
+		deliveryRepo: deliveryRepo,
+		groupRepo:    groupRepo,
+		userRepo:     userRepo,
+		client:       client,
+		urls:         urls,
+		appBaseURL:   strings.TrimRight(appBaseURL, "/"),
+	}
+}
+
+func (u *WebhookUsecase) GetWebhooks(ctx context.Context, actor *model.GroupMember) ([]*model.GroupWebhook, error) {
+	if err := authorize(actor, actionManageWebhooks, 0); err != nil {
+		return nil, err
+	}
+	return u.webhookRepo.FindByGroupID(ctx, actor.GroupID)
+}
+
+func (u *WebhookUsecase) CreateWebhook(ctx context.Context, platform, name, url string, events []string, actor *model.GroupMember) (*model.GroupWebhook, error) {
+	if err := authorize(actor, actionManageWebhooks, 0); err != nil {
+		return nil, err
+	}
+	if platform != WebhookPlatformDiscord && platform != WebhookPlatformSlack {
+		return nil, ErrInvalidWebhookPlatform
+	}
+	if err := u.urls.ValidateURL(platform, url); err != nil {
+		return nil, err
+	}
+	eventList, err := normalizeWebhookEvents(events)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = platform
+	}
+
+	hook := &model.GroupWebhook{
+		GroupID:   actor.GroupID,
+		Platform:  platform,
+		Name:      name,
+		URL:       url,
+		Events:    eventList,
+		Enabled:   true,
+		CreatedBy: actor.UserID,
+	}
+	if err := u.webhookRepo.Create(ctx, hook); err != nil {
+		return nil, err
+	}
+	return hook, nil
+}
+
+// UpdateWebhook changes the given fields; nil leaves a field unchanged.
+func (u *WebhookUsecase) UpdateWebhook(ctx context.Context, id uint, name, url *string, events []string, enabled *bool, actor *model.GroupMember) (*model.GroupWebhook, error) {
+	if err := authorize(actor, actionManageWebhooks, 0); err != nil {
+		return nil, err
+	}
+	hook, err := u.webhookRepo.FindByID(ctx, id, actor.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	if name != nil && *name != "" {
+		hook.Name = *name
+	}
+	if url != nil {
+		if err := u.urls.ValidateURL(hook.Platform, *url); err != nil {
+			return nil, err
+		}
+		hook.URL = *url
+	}
+	if events != nil {
+		eventList, err := normalizeWebhookEvents(events)
+		if err != nil {
+			return nil, err
+		}
+		hook.Events = eventList
+	}
+	if enabled != nil {
+		hook.Enabled = *enabled
+	}
+	if err := u.webhookRepo.Update(ctx, hook); err != nil {
+		return nil, err
+	}
+	return hook, nil
+}
+
+func (u *WebhookUsecase) DeleteWebhook(ctx context.Context, id uint, actor *model.GroupMember) error {
+	if err := authorize(actor, actionManageWebhooks, 0); err != nil {
+		return err
+	}
+	if _, err := u.webhookRepo.FindByID(ctx, id, actor.GroupID); err != nil {
+		return err
+	}
+	return u.webhookRepo.Delete(ctx, id)
+}
+
+// GetDeliveries returns the webhook's most recent deliveries, newest first.
+func (u *WebhookUsecase) GetDeliveries(ctx context.Context, id uint, actor *model.GroupMember) ([]*model.WebhookDelivery, error) {
+	if err := authorize(actor, actionManageWebhooks, 0); err != nil {
+		return nil, err
+	}
+	if _, err := u.webhookRepo.FindByID(ctx, id, actor.GroupID); err != nil {
+		return nil, err
+	}
+	return u.deliveryRepo.FindByWebhookID(ctx, id, webhookDeliveryLogSize)
+}
+
+// SendTest posts a test message right away and returns its delivery. A
+// failed test is retried like any other delivery.
+func (u *WebhookUsecase) SendTest(ctx context.Context, id uint, actor *model.GroupMember) (*model.WebhookDelivery, error) {
+	if err := authorize(actor, actionManageWebhooks, 0); err != nil {
+		return nil, err
+	}
+	groupID := actor.GroupID
+	hook, err := u.webhookRepo.FindByID(ctx, id, groupID)
+	if err != nil {
+		return nil, err
+	}
+	msg := WebhookMessage{
+		Title:     "memoria からのテスト通知",
+		Body:      "この Webhook は正しく設定されています。",
+		URL:       u.groupURL(groupID, "manage"),
+		GroupName: u.groupName(ctx, groupID),
+		Timestamp: time.Now(),
+	}
+	delivery, err := u.enqueue(ctx, hook, webhookEventTest, msg)
+	if err != nil {
+		return nil, err
+	}
+	u.attempt(ctx, delivery, hook)
+	return delivery, nil
+}
+
+// PostCreated, CommentCreated, TripReminderSent and MemberJoined post the
+// event to the group's webhooks. They are event bus handlers and already run
+// off the request goroutine.
+
+func (u *WebhookUsecase) PostCreated(ctx context.Context, e event.PostCreated) {
+	post := e.Post
+	u.dispatch(ctx, post.GroupID, WebhookEventNewPost, func() WebhookMessage {
+		title := post.Title
+		if title == "" {
+			title = "新しいメモ"
+		}
+		return WebhookMessage{
+			Title:     title,
+			Body:      fmt.Sprintf("%s さんが投稿しました\n\n%s", u.userName(ctx, post.AuthorID), post.Body),
+			URL:       u.groupURL(post.GroupID, "posts"),
+			GroupName: u.groupName(ctx, post.GroupID),
+			Timestamp: post.PublishedAt,
+		}
+	})
+}
+
+func (u *WebhookUsecase) CommentCreated(ctx context.Context, e event.CommentCreated) {
+	post, comment := e.Post, e.Comment
+	u.dispatch(ctx, post.GroupID, WebhookEventNewComment, func() WebhookMessage {
+		return WebhookMessage{
+			Title:     fmt.Sprintf("「%s」に新しいコメント", postTitle(post)),
+			Body:      fmt.Sprintf("%s: %s", u.userName(ctx, comment.UserID), comment.Body),
+			URL:       u.groupURL(post.GroupID, "posts"),
+			GroupName: u.groupName(ctx, post.GroupID),
+			Timestamp: comment.CreatedAt,
+		}
+	})
+}
+
+func (u *WebhookUsecase) TripReminderSent(ctx context.Context, e event.TripReminderSent) {
+	trip := e.Trip
+	u.dispatch(ctx, trip.GroupID, WebhookEventTripReminder, func() WebhookMessage {
+		return WebhookMessage{
+			Title:     fmt.Sprintf("「%s」の出発が近づいています", trip.Title),
+			Body:      fmt.Sprintf("%s 〜 %s", trip.StartAt.Format("2006/01/02"), trip.EndAt.Format("2006/01/02")),
+			URL:       u.groupURL(trip.GroupID, fmt.Sprintf("trips/%d", trip.ID)),
+			GroupName: u.groupName(ctx, trip.GroupID),
+			Timestamp: time.Now(),
+		}
+	})
+}
+
+func (u *WebhookUsecase) MemberJoined(ctx context.Context, e event.MemberJoined) {
+	u.dispatch(ctx, e.GroupID, WebhookEventNewMember, func() WebhookMessage {
+		return WebhookMessage{
+			Title:     fmt.Sprintf("%s さんがグループに参加しました", displayName(e.User)),
+			URL:       u.groupURL(e.GroupID, "manage"),
+			GroupName: u.groupName(ctx, e.GroupID),
+			Timestamp: time.Now(),
+		}
+	})
+}
+
+// RetryDue resends pending deliveries whose retry time has passed and
+// returns how many were attempted. Several workers may run it at once.
+func (u *WebhookUsecase) RetryDue(ctx context.Context, now time.Time) (int, error) {
+	deliveries, err := u.deliveryRepo.ClaimDue(ctx, now, now.Add(webhookLease), webhookRetryBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	if len(deliveries) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]uint, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		ids = append(ids, delivery.WebhookID)
+	}
+	hooks, err := u.webhookRepo.FindByIDs(ctx, ids)
+	if err != nil {
+		return 0, err
+	}
+	byID := make(map[uint]*model.GroupWebhook, len(hooks))
+	for _, hook := range hooks {
+		byID[hook.ID] = hook
+	}
+
+	for _, delivery := range deliveries {
+		hook, ok := byID[delivery.WebhookID]
+		if !ok || !hook.Enabled {
+			delivery.Status = "failed"
+			delivery.Error = "webhook was disabled or deleted"
+			delivery.NextAttemptAt = nil
+			if err := u.deliveryRepo.Update(ctx, delivery); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		u.attempt(ctx, delivery, hook)
+	}
+	return len(deliveries), nil
+}
+
+func (u *WebhookUsecase) dispatch(ctx context.Context, groupID uint, event string, build func() WebhookMessage) {
+	hooks, err := u.webhookRepo.FindEnabledByEvent(ctx, groupID, event)
+	if err != nil {
+		log.Printf("webhook: failed to load webhooks for group %d: %v", groupID, err)
+		return
+	}
+	if len(hooks) == 0 {
+		return
+	}
+	msg := build()
+	for _, hook := range hooks {
+		delivery, err := u.enqueue(ctx, hook, event, msg)
+		if err != nil {
+			log.Printf("webhook: failed to record delivery for webhook %d: %v", hook.ID, err)
+			continue
+		}
+		u.attempt(ctx, delivery, hook)
+	}
+}
+
+// enqueue records the delivery before it is sent, leased to this process, so
+// a crash mid-send leaves it for the retry worker.
+func (u *WebhookUsecase) enqueue(ctx context.Context, hook *model.GroupWebhook, event string, msg WebhookMessage) (*model.WebhookDelivery, error) {
+	payload, err := u.client.Format(hook.Platform, msg)
+	if err != nil {
+		return nil, err
+	}
+	leaseUntil := time.Now().Add(webhookLease)
+	delivery := &model.WebhookDelivery{
+		WebhookID:     hook.ID,
+		Event:         event,
+		Payload:       string(payload),
+		Status:        "pending",
+		NextAttemptAt: &leaseUntil,
+	}
+	if err := u.deliveryRepo.Create(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+func (u *WebhookUsecase) attempt(ctx context.Context, delivery *model.WebhookDelivery, hook *model.GroupWebhook) {
+	status, err := u.client.Post(ctx, hook.URL, []byte(delivery.Payload))
+	now := time.Now()
+	delivery.Attempts++
+	delivery.ResponseStatus = status
+
+	switch {
+	case err == nil:
+		delivery.Status = "succeeded"
+		delivery.Error = ""
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+	case isRetryableWebhookStatus(status) && delivery.Attempts < webhookMaxAttempts:
+		delay := webhookRetryBaseDelay
+		for i := 1; i < delivery.Attempts; i++ {
+			delay *= 4
+		}
+		next := now.Add(delay)
+		delivery.Status = "pending"
+		delivery.Error = err.Error()
+		delivery.NextAttemptAt = &next
+	default:
+		delivery.Status = "failed"
+		delivery.Error = err.Error()
+		delivery.NextAttemptAt = nil
+	}
+
+	if err := u.deliveryRepo.Update(ctx, delivery); err != nil {
+		log.Printf("webhook: failed to update delivery %d: %v", delivery.ID, err)
+	}
+}
+
+// isRetryableWebhookStatus treats network errors (status 0), rate limits and
+// server errors as transient.
+func isRetryableWebhookStatus(status int) bool {
+	return status == 0 || status == 429 || status >= 500
+}
+
+func (u *WebhookUsecase) groupName(ctx context.Context, groupID uint) string {
+	group, err := u.groupRepo.FindByID(ctx, groupID)
+	if err != nil {
+		return ""
+	}
+	return group.Name
+}
+
+func (u *WebhookUsecase) userName(ctx context.Context, userID uint) string {
+	user, err := u.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return "メンバー"
+	}
+	return displayName(user)
+}
+
+func (u *WebhookUsecase) groupURL(groupID uint, path string) string {
+	if u.appBaseURL == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/%d/%s", u.appBaseURL, groupID, path)
+}
+
+// postTitle is the title shown for a post; memos have none.
+func postTitle(post *model.Post) string {
+	if post.Title == "" {
+		return "メモ"
+	}
+	return post.Title
+}
+
+func displayName(user *model.User) string {
+	if user.DisplayName != "" {
+		return user.DisplayName
+	}
+	return strings.SplitN(user.Email, "@", 2)[0]
+}
+
+// normalizeWebhookEvents validates events and returns them comma separated
+// in a fixed order.
+func normalizeWebhookEvents(events []string) (string, error) {
+	selected := make(map[string]bool, len(events))
+	for _, event := range events {
+		event = strings.TrimSpace(event)
+		if !isWebhookEvent(event) {
+			return "", ErrInvalidWebhookEvents
+		}
+		selected[event] = true
+	}
+	if len(selected) == 0 {
+		return "", ErrInvalidWebhookEvents
+	}
+	ordered := make([]string, 0, len(selected))
+	for _, event := range webhookEvents {
+		if selected[event] {
+			ordered = append(ordered, event)
+		}
+	}
+	return strings.Join(ordered, ","), nil
+}
+
+func isWebhookEvent(event string) bool {
+	for _, known := range webhookEvents {
+		if event == known {
+			return true
+		}
+	}
+	return false
+}
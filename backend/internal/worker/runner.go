@@ -0,0 +1,61 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Job is a task the runner calls every Interval. Run must be safe to run in
+// several processes at once; the runner itself does not coordinate replicas.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+type Runner struct {
+	jobs []Job
+}
+
+func NewRunner(jobs ...Job) *Runner {
+	return &Runner{jobs: jobs}
+}
+
+// Start runs every job once immediately and then on its interval until ctx
+// is cancelled. It returns right away; the returned channel is closed once
+// every job has stopped.
+func (r *Runner) Start(ctx context.Context) <-chan struct{} {
+	var wg sync.WaitGroup
+	for _, job := range r.jobs {
+		wg.Add(1)
+		go func(job Job) {
+			defer wg.Done()
+			r.loop(ctx, job)
+		}(job)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	return done
+}
+
+func (r *Runner) loop(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := job.Run(ctx); err != nil {
+			log.Printf("worker: %s failed: %v", job.Name, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,26 @@
+DROP TABLE IF EXISTS trip_budget_items;
+DROP TABLE IF EXISTS trip_lodgings;
+DROP TABLE IF EXISTS trip_transports;
+DROP TABLE IF EXISTS trip_schedule_items;
+DROP TABLE IF EXISTS trip_posts;
+DROP TABLE IF EXISTS trip_albums;
+DROP TABLE IF EXISTS trip_expenses;
+DROP TABLE IF EXISTS trip_wishlists;
+DROP TABLE IF EXISTS trip_itineraries;
+DROP TABLE IF EXISTS trips;
+DROP TABLE IF EXISTS web_push_subscriptions;
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS notification_settings;
+DROP TABLE IF EXISTS post_comments;
+DROP TABLE IF EXISTS post_likes;
+DROP TABLE IF EXISTS post_photos;
+DROP TABLE IF EXISTS post_tags;
+DROP TABLE IF EXISTS tags;
+DROP TABLE IF EXISTS album_posts;
+DROP TABLE IF EXISTS posts;
+DROP TABLE IF EXISTS photos;
+DROP TABLE IF EXISTS albums;
+DROP TABLE IF EXISTS invites;
+DROP TABLE IF EXISTS group_members;
+DROP TABLE IF EXISTS groups;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,311 @@
+-- Initial schema, equivalent to the former GORM AutoMigrate model list.
+-- IF NOT EXISTS keeps this migration safe to apply on databases that were
+-- already created by AutoMigrate.
+
+-- Users & Groups
+CREATE TABLE IF NOT EXISTS users (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL,
+    firebase_uid text NOT NULL,
+    email text NOT NULL,
+    display_name text,
+    role text NOT NULL,
+    last_access_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_firebase_uid ON users (firebase_uid);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+CREATE INDEX IF NOT EXISTS idx_users_last_access_at ON users (last_access_at);
+
+CREATE TABLE IF NOT EXISTS groups (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL,
+    name text NOT NULL,
+    created_by bigint NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS group_members (
+    group_id bigint NOT NULL,
+    user_id bigint NOT NULL,
+    role text NOT NULL,
+    joined_at timestamptz NOT NULL,
+    PRIMARY KEY (group_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS invites (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL,
+    group_id bigint NOT NULL,
+    email text NOT NULL,
+    token text NOT NULL,
+    status text NOT NULL,
+    role text NOT NULL DEFAULT 'member',
+    expires_at timestamptz NOT NULL,
+    invited_by bigint NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_invites_group_id ON invites (group_id);
+CREATE INDEX IF NOT EXISTS idx_invites_email ON invites (email);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invites_token ON invites (token);
+
+-- Albums/Photos/Posts
+CREATE TABLE IF NOT EXISTS albums (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL,
+    group_id bigint NOT NULL,
+    title text NOT NULL,
+    description text,
+    cover_photo_id bigint,
+    created_by bigint NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_albums_group_id ON albums (group_id);
+
+CREATE TABLE IF NOT EXISTS photos (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL,
+    group_id bigint NOT NULL,
+    album_id bigint NOT NULL,
+    s3_key text NOT NULL,
+    content_type text,
+    size_bytes bigint,
+    width bigint,
+    height bigint,
+    uploaded_by bigint NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_photos_group_id ON photos (group_id);
+CREATE INDEX IF NOT EXISTS idx_photos_album_id ON photos (album_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_photos_s3_key ON photos (s3_key);
+
+CREATE TABLE IF NOT EXISTS posts (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL,
+    group_id bigint NOT NULL,
+    type text NOT NULL,
+    title text,
+    body text NOT NULL,
+    author_id bigint NOT NULL,
+    published_at timestamptz NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_posts_group_id ON posts (group_id);
+
+CREATE TABLE IF NOT EXISTS album_posts (
+    album_id bigint NOT NULL,
+    post_id bigint NOT NULL,
+    created_at timestamptz NOT NULL,
+    PRIMARY KEY (album_id, post_id)
+);
+
+CREATE TABLE IF NOT EXISTS tags (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL,
+    name text NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_name ON tags (name);
+
+CREATE TABLE IF NOT EXISTS post_tags (
+    post_id bigint NOT NULL,
+    tag_id bigint NOT NULL,
+    created_at timestamptz NOT NULL,
+    PRIMARY KEY (post_id, tag_id)
+);
+
+CREATE TABLE IF NOT EXISTS post_photos (
+    post_id bigint NOT NULL,
+    photo_id bigint NOT NULL,
+    created_at timestamptz NOT NULL,
+    PRIMARY KEY (post_id, photo_id)
+);
+
+CREATE TABLE IF NOT EXISTS post_likes (
+    post_id bigint NOT NULL,
+    user_id bigint NOT NULL,
+    created_at timestamptz NOT NULL,
+    PRIMARY KEY (post_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS post_comments (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL,
+    post_id bigint NOT NULL,
+    user_id bigint NOT NULL,
+    body text NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_post_comments_post_id ON post_comments (post_id);
+
+-- Notifications
+CREATE TABLE IF NOT EXISTS notification_settings (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL,
+    user_id bigint NOT NULL,
+    category text NOT NULL,
+    enabled boolean NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_notification_settings_user_id ON notification_settings (user_id);
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL,
+    user_id bigint NOT NULL,
+    category text NOT NULL,
+    title text NOT NULL,
+    body text NOT NULL,
+    read_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_notifications_user_id ON notifications (user_id);
+
+CREATE TABLE IF NOT EXISTS web_push_subscriptions (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL,
+    user_id bigint NOT NULL,
+    endpoint text NOT NULL,
+    auth text NOT NULL,
+    p256dh text NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_web_push_subscriptions_user_id ON web_push_subscriptions (user_id);
+
+-- Trips
+CREATE TABLE IF NOT EXISTS trips (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL,
+    group_id bigint NOT NULL,
+    title text NOT NULL,
+    start_at timestamptz NOT NULL,
+    end_at timestamptz NOT NULL,
+    note text,
+    created_by bigint NOT NULL,
+    notify_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_trips_group_id ON trips (group_id);
+
+CREATE TABLE IF NOT EXISTS trip_itineraries (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL,
+    trip_id bigint NOT NULL,
+    title text NOT NULL,
+    start_at timestamptz NOT NULL,
+    end_at timestamptz NOT NULL,
+    location text,
+    note text
+);
+CREATE INDEX IF NOT EXISTS idx_trip_itineraries_trip_id ON trip_itineraries (trip_id);
+
+CREATE TABLE IF NOT EXISTS trip_wishlists (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL,
+    trip_id bigint NOT NULL,
+    title text NOT NULL,
+    location text,
+    note text,
+    priority bigint
+);
+CREATE INDEX IF NOT EXISTS idx_trip_wishlists_trip_id ON trip_wishlists (trip_id);
+
+CREATE TABLE IF NOT EXISTS trip_expenses (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL,
+    trip_id bigint NOT NULL,
+    title text NOT NULL,
+    category text NOT NULL,
+    amount bigint NOT NULL,
+    currency text NOT NULL,
+    is_actual boolean NOT NULL,
+    note text
+);
+CREATE INDEX IF NOT EXISTS idx_trip_expenses_trip_id ON trip_expenses (trip_id);
+
+CREATE TABLE IF NOT EXISTS trip_albums (
+    trip_id bigint NOT NULL,
+    album_id bigint NOT NULL,
+    created_at timestamptz NOT NULL,
+    PRIMARY KEY (trip_id, album_id)
+);
+
+CREATE TABLE IF NOT EXISTS trip_posts (
+    trip_id bigint NOT NULL,
+    post_id bigint NOT NULL,
+    created_at timestamptz NOT NULL,
+    PRIMARY KEY (trip_id, post_id)
+);
+
+CREATE TABLE IF NOT EXISTS trip_schedule_items (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL,
+    trip_id bigint NOT NULL,
+    date text NOT NULL,
+    time text NOT NULL,
+    content text NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_trip_schedule_items_trip_id ON trip_schedule_items (trip_id);
+CREATE INDEX IF NOT EXISTS idx_trip_schedule_items_date ON trip_schedule_items (date);
+
+CREATE TABLE IF NOT EXISTS trip_transports (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL,
+    trip_id bigint NOT NULL,
+    mode text NOT NULL,
+    date text NOT NULL,
+    from_location text,
+    to_location text,
+    note text,
+    departure_time text,
+    arrival_time text,
+    route_name text,
+    train_name text,
+    ferry_name text,
+    flight_number text,
+    airline text,
+    terminal text,
+    company_name text,
+    pickup_location text,
+    dropoff_location text,
+    rental_url text,
+    distance_km decimal,
+    fuel_efficiency_km_per_l decimal,
+    gasoline_price_yen_per_l decimal,
+    gasoline_cost_yen bigint,
+    highway_cost_yen bigint,
+    rental_fee_yen bigint,
+    fare_yen bigint
+);
+CREATE INDEX IF NOT EXISTS idx_trip_transports_trip_id ON trip_transports (trip_id);
+
+CREATE TABLE IF NOT EXISTS trip_lodgings (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL,
+    trip_id bigint NOT NULL,
+    date text NOT NULL,
+    name text NOT NULL,
+    reservation_url text,
+    address text,
+    check_in text,
+    check_out text,
+    reservation_number text,
+    cost_yen bigint
+);
+CREATE INDEX IF NOT EXISTS idx_trip_lodgings_trip_id ON trip_lodgings (trip_id);
+
+CREATE TABLE IF NOT EXISTS trip_budget_items (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL,
+    trip_id bigint NOT NULL,
+    name text NOT NULL,
+    cost_yen bigint NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_trip_budget_items_trip_id ON trip_budget_items (trip_id);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS trip_settlements;
+DROP TABLE IF EXISTS trip_expense_participants;
+DROP INDEX IF EXISTS idx_trip_expenses_paid_by;
+ALTER TABLE trip_expenses DROP COLUMN IF EXISTS split_type;
+ALTER TABLE trip_expenses DROP COLUMN IF EXISTS paid_by;
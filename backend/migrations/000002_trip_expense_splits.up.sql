@@ -0,0 +1,23 @@
+ALTER TABLE trip_expenses ADD COLUMN IF NOT EXISTS paid_by bigint;
+ALTER TABLE trip_expenses ADD COLUMN IF NOT EXISTS split_type text NOT NULL DEFAULT 'equal';
+CREATE INDEX IF NOT EXISTS idx_trip_expenses_paid_by ON trip_expenses (paid_by);
+
+CREATE TABLE IF NOT EXISTS trip_expense_participants (
+    expense_id bigint NOT NULL,
+    user_id bigint NOT NULL,
+    shares bigint NOT NULL DEFAULT 1,
+    amount bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY (expense_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS trip_settlements (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL,
+    trip_id bigint NOT NULL,
+    from_user_id bigint NOT NULL,
+    to_user_id bigint NOT NULL,
+    amount bigint NOT NULL,
+    settled_by bigint NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_trip_settlements_trip_id ON trip_settlements (trip_id);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS exchange_rates;
+ALTER TABLE trip_budget_items DROP COLUMN IF EXISTS currency;
+ALTER TABLE trip_lodgings DROP COLUMN IF EXISTS currency;
+ALTER TABLE trip_transports DROP COLUMN IF EXISTS currency;
+ALTER TABLE trips DROP COLUMN IF EXISTS base_currency;
@@ -0,0 +1,17 @@
+ALTER TABLE trips ADD COLUMN IF NOT EXISTS base_currency text NOT NULL DEFAULT 'JPY';
+ALTER TABLE trip_transports ADD COLUMN IF NOT EXISTS currency text NOT NULL DEFAULT 'JPY';
+ALTER TABLE trip_lodgings ADD COLUMN IF NOT EXISTS currency text NOT NULL DEFAULT 'JPY';
+ALTER TABLE trip_budget_items ADD COLUMN IF NOT EXISTS currency text NOT NULL DEFAULT 'JPY';
+
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL,
+    group_id bigint NOT NULL,
+    from_currency text NOT NULL,
+    to_currency text NOT NULL,
+    effective_date text NOT NULL,
+    rate numeric(20, 10) NOT NULL,
+    created_by bigint NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_exchange_rates_pair ON exchange_rates (group_id, from_currency, to_currency, effective_date);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS calendar_feed_tokens (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL,
+    user_id bigint NOT NULL,
+    token_hash text NOT NULL,
+    revoked_at timestamptz,
+    last_used_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_calendar_feed_tokens_user_id ON calendar_feed_tokens (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_calendar_feed_tokens_token_hash ON calendar_feed_tokens (token_hash);
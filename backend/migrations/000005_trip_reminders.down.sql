@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS trip_reminders;
+DROP INDEX IF EXISTS idx_trips_notify_at;
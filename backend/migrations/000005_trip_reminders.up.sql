@@ -0,0 +1,12 @@
+CREATE INDEX IF NOT EXISTS idx_trips_notify_at ON trips (notify_at);
+
+CREATE TABLE IF NOT EXISTS trip_reminders (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL,
+    trip_id bigint NOT NULL,
+    notify_at timestamptz NOT NULL,
+    sent_at timestamptz NOT NULL,
+    recipients bigint NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_trip_reminders_trip_notify ON trip_reminders (trip_id, notify_at);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_web_push_subscriptions_endpoint;
+DROP INDEX IF EXISTS idx_notification_settings_user_category;
@@ -0,0 +1,11 @@
+-- Keep the newest row when a user has duplicate settings for a category.
+DELETE FROM notification_settings a
+    USING notification_settings b
+    WHERE a.user_id = b.user_id AND a.category = b.category AND a.id < b.id;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notification_settings_user_category ON notification_settings (user_id, category);
+
+-- An endpoint identifies one browser; keep its newest registration.
+DELETE FROM web_push_subscriptions a
+    USING web_push_subscriptions b
+    WHERE a.endpoint = b.endpoint AND a.id < b.id;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_web_push_subscriptions_endpoint ON web_push_subscriptions (endpoint);
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS group_webhooks (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL,
+    group_id bigint NOT NULL,
+    platform text NOT NULL,
+    name text NOT NULL,
+    url text NOT NULL,
+    events text NOT NULL,
+    enabled boolean NOT NULL DEFAULT true,
+    created_by bigint NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_group_webhooks_group_id ON group_webhooks (group_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL,
+    webhook_id bigint NOT NULL,
+    event text NOT NULL,
+    payload text NOT NULL,
+    status text NOT NULL DEFAULT 'pending',
+    attempts bigint NOT NULL DEFAULT 0,
+    response_status bigint,
+    error text,
+    next_attempt_at timestamptz,
+    delivered_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_next_attempt_at ON webhook_deliveries (next_attempt_at);
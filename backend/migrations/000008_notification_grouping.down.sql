@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_notifications_user_group_key;
+ALTER TABLE notifications DROP COLUMN IF EXISTS count;
+ALTER TABLE notifications DROP COLUMN IF EXISTS group_key;
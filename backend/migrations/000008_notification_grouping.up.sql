@@ -0,0 +1,3 @@
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS group_key text;
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS count bigint NOT NULL DEFAULT 1;
+CREATE INDEX IF NOT EXISTS idx_notifications_user_group_key ON notifications (user_id, group_key);
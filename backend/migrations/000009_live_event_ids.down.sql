@@ -0,0 +1 @@
+DROP SEQUENCE IF EXISTS live_event_id_seq;
@@ -0,0 +1,3 @@
+-- Event IDs for the live event stream. They only need to be unique across
+-- server instances, so a bare sequence is enough.
+CREATE SEQUENCE IF NOT EXISTS live_event_id_seq;
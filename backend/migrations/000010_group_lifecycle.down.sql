@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_groups_purge_after;
+DROP INDEX IF EXISTS idx_groups_deleted_at;
+ALTER TABLE groups DROP COLUMN IF EXISTS purge_after;
+ALTER TABLE groups DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE groups DROP COLUMN IF EXISTS default_currency;
+ALTER TABLE groups DROP COLUMN IF EXISTS description;
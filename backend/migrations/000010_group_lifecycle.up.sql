@@ -0,0 +1,6 @@
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS description text;
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS default_currency text NOT NULL DEFAULT 'JPY';
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS purge_after timestamptz;
+CREATE INDEX IF NOT EXISTS idx_groups_deleted_at ON groups (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_groups_purge_after ON groups (purge_after);
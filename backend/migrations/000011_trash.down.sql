@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_trips_deleted_at;
+DROP INDEX IF EXISTS idx_post_comments_deleted_at;
+DROP INDEX IF EXISTS idx_posts_deleted_at;
+DROP INDEX IF EXISTS idx_photos_deleted_at;
+DROP INDEX IF EXISTS idx_albums_deleted_at;
+ALTER TABLE trips DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE post_comments DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE posts DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE photos DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE albums DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,11 @@
+-- Deleted content stays in the trash until the trash-purge job removes it.
+ALTER TABLE albums ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+ALTER TABLE photos ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+ALTER TABLE post_comments ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+ALTER TABLE trips ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_albums_deleted_at ON albums (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_photos_deleted_at ON photos (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_posts_deleted_at ON posts (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_post_comments_deleted_at ON post_comments (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_trips_deleted_at ON trips (deleted_at);
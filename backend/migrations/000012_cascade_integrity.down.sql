@@ -0,0 +1,35 @@
+ALTER TABLE trip_budget_items DROP CONSTRAINT IF EXISTS fk_trip_budget_items_trip_id;
+ALTER TABLE trip_lodgings DROP CONSTRAINT IF EXISTS fk_trip_lodgings_trip_id;
+ALTER TABLE trip_transports DROP CONSTRAINT IF EXISTS fk_trip_transports_trip_id;
+ALTER TABLE trip_schedule_items DROP CONSTRAINT IF EXISTS fk_trip_schedule_items_trip_id;
+ALTER TABLE trip_posts DROP CONSTRAINT IF EXISTS fk_trip_posts_post_id;
+ALTER TABLE trip_posts DROP CONSTRAINT IF EXISTS fk_trip_posts_trip_id;
+ALTER TABLE trip_albums DROP CONSTRAINT IF EXISTS fk_trip_albums_album_id;
+ALTER TABLE trip_albums DROP CONSTRAINT IF EXISTS fk_trip_albums_trip_id;
+ALTER TABLE trip_settlements DROP CONSTRAINT IF EXISTS fk_trip_settlements_trip_id;
+ALTER TABLE trip_expense_participants DROP CONSTRAINT IF EXISTS fk_trip_expense_participants_expense_id;
+ALTER TABLE trip_expenses DROP CONSTRAINT IF EXISTS fk_trip_expenses_trip_id;
+ALTER TABLE trip_wishlists DROP CONSTRAINT IF EXISTS fk_trip_wishlists_trip_id;
+ALTER TABLE trip_itineraries DROP CONSTRAINT IF EXISTS fk_trip_itineraries_trip_id;
+ALTER TABLE trip_reminders DROP CONSTRAINT IF EXISTS fk_trip_reminders_trip_id;
+ALTER TABLE trips DROP CONSTRAINT IF EXISTS fk_trips_group_id;
+ALTER TABLE exchange_rates DROP CONSTRAINT IF EXISTS fk_exchange_rates_group_id;
+ALTER TABLE webhook_deliveries DROP CONSTRAINT IF EXISTS fk_webhook_deliveries_webhook_id;
+ALTER TABLE group_webhooks DROP CONSTRAINT IF EXISTS fk_group_webhooks_group_id;
+ALTER TABLE post_comments DROP CONSTRAINT IF EXISTS fk_post_comments_post_id;
+ALTER TABLE post_likes DROP CONSTRAINT IF EXISTS fk_post_likes_post_id;
+ALTER TABLE post_photos DROP CONSTRAINT IF EXISTS fk_post_photos_photo_id;
+ALTER TABLE post_photos DROP CONSTRAINT IF EXISTS fk_post_photos_post_id;
+ALTER TABLE post_tags DROP CONSTRAINT IF EXISTS fk_post_tags_tag_id;
+ALTER TABLE post_tags DROP CONSTRAINT IF EXISTS fk_post_tags_post_id;
+ALTER TABLE album_posts DROP CONSTRAINT IF EXISTS fk_album_posts_post_id;
+ALTER TABLE album_posts DROP CONSTRAINT IF EXISTS fk_album_posts_album_id;
+ALTER TABLE posts DROP CONSTRAINT IF EXISTS fk_posts_group_id;
+ALTER TABLE albums DROP CONSTRAINT IF EXISTS fk_albums_cover_photo_id;
+ALTER TABLE photos DROP CONSTRAINT IF EXISTS fk_photos_album_id;
+ALTER TABLE photos DROP CONSTRAINT IF EXISTS fk_photos_group_id;
+ALTER TABLE albums DROP CONSTRAINT IF EXISTS fk_albums_group_id;
+ALTER TABLE invites DROP CONSTRAINT IF EXISTS fk_invites_group_id;
+ALTER TABLE group_members DROP CONSTRAINT IF EXISTS fk_group_members_group_id;
+
+DROP TABLE IF EXISTS object_deletions;
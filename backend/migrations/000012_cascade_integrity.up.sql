@@ -0,0 +1,51 @@
+-- Queue of S3 objects whose rows were deleted; drained by the
+-- object-cleanup worker job.
+CREATE TABLE IF NOT EXISTS object_deletions (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL,
+    s3_key text NOT NULL,
+    attempts bigint NOT NULL DEFAULT 0,
+    last_error text,
+    next_attempt_at timestamptz NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_object_deletions_s3_key ON object_deletions (s3_key);
+CREATE INDEX IF NOT EXISTS idx_object_deletions_next_attempt_at ON object_deletions (next_attempt_at);
+
+-- Foreign keys are added NOT VALID: they hold for every write from now on
+-- without failing on orphans already in the table. `go run ./cmd/orphans
+-- -repair` removes those and validates the constraints.
+
+ALTER TABLE group_members ADD CONSTRAINT fk_group_members_group_id FOREIGN KEY (group_id) REFERENCES groups (id) NOT VALID;
+ALTER TABLE invites ADD CONSTRAINT fk_invites_group_id FOREIGN KEY (group_id) REFERENCES groups (id) NOT VALID;
+ALTER TABLE albums ADD CONSTRAINT fk_albums_group_id FOREIGN KEY (group_id) REFERENCES groups (id) NOT VALID;
+ALTER TABLE photos ADD CONSTRAINT fk_photos_group_id FOREIGN KEY (group_id) REFERENCES groups (id) NOT VALID;
+ALTER TABLE photos ADD CONSTRAINT fk_photos_album_id FOREIGN KEY (album_id) REFERENCES albums (id) NOT VALID;
+ALTER TABLE albums ADD CONSTRAINT fk_albums_cover_photo_id FOREIGN KEY (cover_photo_id) REFERENCES photos (id) ON DELETE SET NULL NOT VALID;
+ALTER TABLE posts ADD CONSTRAINT fk_posts_group_id FOREIGN KEY (group_id) REFERENCES groups (id) NOT VALID;
+ALTER TABLE album_posts ADD CONSTRAINT fk_album_posts_album_id FOREIGN KEY (album_id) REFERENCES albums (id) NOT VALID;
+ALTER TABLE album_posts ADD CONSTRAINT fk_album_posts_post_id FOREIGN KEY (post_id) REFERENCES posts (id) NOT VALID;
+ALTER TABLE post_tags ADD CONSTRAINT fk_post_tags_post_id FOREIGN KEY (post_id) REFERENCES posts (id) NOT VALID;
+ALTER TABLE post_tags ADD CONSTRAINT fk_post_tags_tag_id FOREIGN KEY (tag_id) REFERENCES tags (id) NOT VALID;
+ALTER TABLE post_photos ADD CONSTRAINT fk_post_photos_post_id FOREIGN KEY (post_id) REFERENCES posts (id) NOT VALID;
+ALTER TABLE post_photos ADD CONSTRAINT fk_post_photos_photo_id FOREIGN KEY (photo_id) REFERENCES photos (id) NOT VALID;
+ALTER TABLE post_likes ADD CONSTRAINT fk_post_likes_post_id FOREIGN KEY (post_id) REFERENCES posts (id) NOT VALID;
+ALTER TABLE post_comments ADD CONSTRAINT fk_post_comments_post_id FOREIGN KEY (post_id) REFERENCES posts (id) NOT VALID;
+ALTER TABLE group_webhooks ADD CONSTRAINT fk_group_webhooks_group_id FOREIGN KEY (group_id) REFERENCES groups (id) NOT VALID;
+ALTER TABLE webhook_deliveries ADD CONSTRAINT fk_webhook_deliveries_webhook_id FOREIGN KEY (webhook_id) REFERENCES group_webhooks (id) NOT VALID;
+ALTER TABLE exchange_rates ADD CONSTRAINT fk_exchange_rates_group_id FOREIGN KEY (group_id) REFERENCES groups (id) NOT VALID;
+ALTER TABLE trips ADD CONSTRAINT fk_trips_group_id FOREIGN KEY (group_id) REFERENCES groups (id) NOT VALID;
+ALTER TABLE trip_reminders ADD CONSTRAINT fk_trip_reminders_trip_id FOREIGN KEY (trip_id) REFERENCES trips (id) NOT VALID;
+ALTER TABLE trip_itineraries ADD CONSTRAINT fk_trip_itineraries_trip_id FOREIGN KEY (trip_id) REFERENCES trips (id) NOT VALID;
+ALTER TABLE trip_wishlists ADD CONSTRAINT fk_trip_wishlists_trip_id FOREIGN KEY (trip_id) REFERENCES trips (id) NOT VALID;
+ALTER TABLE trip_expenses ADD CONSTRAINT fk_trip_expenses_trip_id FOREIGN KEY (trip_id) REFERENCES trips (id) NOT VALID;
+ALTER TABLE trip_expense_participants ADD CONSTRAINT fk_trip_expense_participants_expense_id FOREIGN KEY (expense_id) REFERENCES trip_expenses (id) NOT VALID;
+ALTER TABLE trip_settlements ADD CONSTRAINT fk_trip_settlements_trip_id FOREIGN KEY (trip_id) REFERENCES trips (id) NOT VALID;
+ALTER TABLE trip_albums ADD CONSTRAINT fk_trip_albums_trip_id FOREIGN KEY (trip_id) REFERENCES trips (id) NOT VALID;
+ALTER TABLE trip_albums ADD CONSTRAINT fk_trip_albums_album_id FOREIGN KEY (album_id) REFERENCES albums (id) NOT VALID;
+ALTER TABLE trip_posts ADD CONSTRAINT fk_trip_posts_trip_id FOREIGN KEY (trip_id) REFERENCES trips (id) NOT VALID;
+ALTER TABLE trip_posts ADD CONSTRAINT fk_trip_posts_post_id FOREIGN KEY (post_id) REFERENCES posts (id) NOT VALID;
+ALTER TABLE trip_schedule_items ADD CONSTRAINT fk_trip_schedule_items_trip_id FOREIGN KEY (trip_id) REFERENCES trips (id) NOT VALID;
+ALTER TABLE trip_transports ADD CONSTRAINT fk_trip_transports_trip_id FOREIGN KEY (trip_id) REFERENCES trips (id) NOT VALID;
+ALTER TABLE trip_lodgings ADD CONSTRAINT fk_trip_lodgings_trip_id FOREIGN KEY (trip_id) REFERENCES trips (id) NOT VALID;
+ALTER TABLE trip_budget_items ADD CONSTRAINT fk_trip_budget_items_trip_id FOREIGN KEY (trip_id) REFERENCES trips (id) NOT VALID;
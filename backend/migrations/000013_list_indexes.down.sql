@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_trips_group_start;
+DROP INDEX IF EXISTS idx_post_comments_post_created;
+DROP INDEX IF EXISTS idx_photos_album_created;
+DROP INDEX IF EXISTS idx_albums_group_created;
+DROP INDEX IF EXISTS idx_posts_group_published;
@@ -0,0 +1,6 @@
+-- Lists page by (date column, id); these keep each page an index range scan.
+CREATE INDEX IF NOT EXISTS idx_posts_group_published ON posts (group_id, published_at, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_albums_group_created ON albums (group_id, created_at, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_photos_album_created ON photos (album_id, created_at, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_post_comments_post_created ON post_comments (post_id, created_at, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_trips_group_start ON trips (group_id, start_at, id) WHERE deleted_at IS NULL;
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_trip_schedule_items_content_trgm;
+DROP INDEX IF EXISTS idx_trips_note_trgm;
+DROP INDEX IF EXISTS idx_trips_title_trgm;
+DROP INDEX IF EXISTS idx_albums_description_trgm;
+DROP INDEX IF EXISTS idx_albums_title_trgm;
+DROP INDEX IF EXISTS idx_post_comments_body_trgm;
+DROP INDEX IF EXISTS idx_posts_body_trgm;
+DROP INDEX IF EXISTS idx_posts_title_trgm;
+-- The pg_trgm extension is left installed; other objects may use it.
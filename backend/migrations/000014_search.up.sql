@@ -0,0 +1,13 @@
+-- Search matches substrings with ILIKE, which trigram indexes can serve for
+-- any language, including Japanese text without spaces between words.
+-- pg_trgm is a trusted extension, so the database owner can create it.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_posts_title_trgm ON posts USING gin (title gin_trgm_ops) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_posts_body_trgm ON posts USING gin (body gin_trgm_ops) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_post_comments_body_trgm ON post_comments USING gin (body gin_trgm_ops) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_albums_title_trgm ON albums USING gin (title gin_trgm_ops) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_albums_description_trgm ON albums USING gin (description gin_trgm_ops) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_trips_title_trgm ON trips USING gin (title gin_trgm_ops) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_trips_note_trgm ON trips USING gin (note gin_trgm_ops) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_trip_schedule_items_content_trgm ON trip_schedule_items USING gin (content gin_trgm_ops);
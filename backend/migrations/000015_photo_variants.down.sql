@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS photo_variants;
+DROP INDEX IF EXISTS idx_photos_pending;
+ALTER TABLE photos DROP COLUMN IF EXISTS process_after;
+ALTER TABLE photos DROP COLUMN IF EXISTS processing_error;
+ALTER TABLE photos DROP COLUMN IF EXISTS processing_attempts;
+ALTER TABLE photos DROP COLUMN IF EXISTS status;
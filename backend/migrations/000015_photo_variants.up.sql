@@ -0,0 +1,22 @@
+-- The photo-variants worker job resizes new photos; existing photos start
+-- out pending too, so the job (or cmd/photo-backfill) processes them.
+ALTER TABLE photos ADD COLUMN IF NOT EXISTS status text NOT NULL DEFAULT 'pending';
+ALTER TABLE photos ADD COLUMN IF NOT EXISTS processing_attempts bigint NOT NULL DEFAULT 0;
+ALTER TABLE photos ADD COLUMN IF NOT EXISTS processing_error text;
+ALTER TABLE photos ADD COLUMN IF NOT EXISTS process_after timestamptz NOT NULL DEFAULT now();
+CREATE INDEX IF NOT EXISTS idx_photos_pending ON photos (process_after) WHERE status = 'pending' AND deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS photo_variants (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL,
+    photo_id bigint NOT NULL,
+    name text NOT NULL,
+    s3_key text NOT NULL,
+    width bigint NOT NULL,
+    height bigint NOT NULL,
+    size_bytes bigint NOT NULL,
+    CONSTRAINT fk_photo_variants_photo_id FOREIGN KEY (photo_id) REFERENCES photos (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_photo_variants_photo_name ON photo_variants (photo_id, name);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_photo_variants_s3_key ON photo_variants (s3_key);
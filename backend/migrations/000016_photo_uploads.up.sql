@@ -0,0 +1,16 @@
+-- Keys handed out by POST /albums/:id/photos/presign. Registering the photo
+-- deletes the row; the upload-sweep worker job queues the objects of
+-- expired rows in object_deletions. Rows of purged albums simply expire.
+CREATE TABLE IF NOT EXISTS photo_uploads (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL,
+    group_id bigint NOT NULL,
+    album_id bigint NOT NULL,
+    user_id bigint NOT NULL,
+    s3_key text NOT NULL,
+    content_type text NOT NULL,
+    expires_at timestamptz NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_photo_uploads_s3_key ON photo_uploads (s3_key);
+CREATE INDEX IF NOT EXISTS idx_photo_uploads_expires_at ON photo_uploads (expires_at);
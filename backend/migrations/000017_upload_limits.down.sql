@@ -0,0 +1,2 @@
+ALTER TABLE photo_uploads DROP COLUMN IF EXISTS size_bytes;
+ALTER TABLE groups DROP COLUMN IF EXISTS upload_max_bytes;
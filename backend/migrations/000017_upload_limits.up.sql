@@ -0,0 +1,5 @@
+-- Per-group upload limit (NULL uses UPLOAD_MAX_BYTES) and the size signed
+-- into each upload URL. Uploads issued before this have no size and cannot
+-- be registered; they expire within an hour.
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS upload_max_bytes bigint;
+ALTER TABLE photo_uploads ADD COLUMN IF NOT EXISTS size_bytes bigint NOT NULL DEFAULT 0;
@@ -0,0 +1,30 @@
+-- Multipart uploads started by POST /albums/:id/photos/upload-sessions.
+-- Completing a session registers the photo and deletes the row; the
+-- upload-session-sweep worker job aborts and deletes expired rows. Parts go
+-- with their session.
+CREATE TABLE IF NOT EXISTS upload_sessions (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL,
+    group_id bigint NOT NULL,
+    album_id bigint NOT NULL,
+    user_id bigint NOT NULL,
+    s3_key text NOT NULL,
+    upload_id text NOT NULL,
+    content_type text NOT NULL,
+    size_bytes bigint NOT NULL,
+    part_size bigint NOT NULL,
+    completed_at timestamptz,
+    expires_at timestamptz NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_upload_sessions_s3_key ON upload_sessions (s3_key);
+CREATE INDEX IF NOT EXISTS idx_upload_sessions_expires_at ON upload_sessions (expires_at);
+
+CREATE TABLE IF NOT EXISTS upload_session_parts (
+    session_id bigint NOT NULL,
+    part_number bigint NOT NULL,
+    e_tag text NOT NULL,
+    created_at timestamptz NOT NULL,
+    PRIMARY KEY (session_id, part_number),
+    CONSTRAINT fk_upload_session_parts_session_id FOREIGN KEY (session_id) REFERENCES upload_sessions (id) ON DELETE CASCADE
+);
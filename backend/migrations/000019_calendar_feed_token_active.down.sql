@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_calendar_feed_tokens_active_user_id;
@@ -0,0 +1,8 @@
+-- At most one active feed token per user. Tokens issued concurrently before
+-- this could leave several; all but the newest are revoked.
+UPDATE calendar_feed_tokens t SET revoked_at = now()
+WHERE revoked_at IS NULL AND EXISTS (
+    SELECT 1 FROM calendar_feed_tokens n
+    WHERE n.user_id = t.user_id AND n.revoked_at IS NULL AND n.id > t.id
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_calendar_feed_tokens_active_user_id ON calendar_feed_tokens (user_id) WHERE revoked_at IS NULL;
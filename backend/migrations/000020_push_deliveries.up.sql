@@ -0,0 +1,16 @@
+-- Web push messages not sent yet. Rows are deleted once sent or given up
+-- on; the push-retry worker job resends the rest. Deliveries go with their
+-- subscription.
+CREATE TABLE IF NOT EXISTS push_deliveries (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL,
+    subscription_id bigint NOT NULL,
+    payload text NOT NULL,
+    attempts bigint NOT NULL DEFAULT 0,
+    last_error text,
+    next_attempt_at timestamptz NOT NULL,
+    CONSTRAINT fk_push_deliveries_subscription_id FOREIGN KEY (subscription_id) REFERENCES web_push_subscriptions (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_push_deliveries_subscription_id ON push_deliveries (subscription_id);
+CREATE INDEX IF NOT EXISTS idx_push_deliveries_next_attempt_at ON push_deliveries (next_attempt_at);
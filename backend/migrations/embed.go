@@ -0,0 +1,8 @@
+package migrations
+
+import "embed"
+
+// FS holds the versioned SQL migrations bundled into the server and migrate binaries.
+//
+//go:embed *.sql
+var FS embed.FS
@@ -64,8 +64,8 @@ export default function DashboardPage() {
           api.get('/albums'),
           groupId ? api.get(`/groups/${groupId}/members`) : Promise.resolve({ data: [] }),
         ])
-        setPosts(postsRes.data || [])
-        setAlbums(albumsRes.data || [])
+        setPosts(postsRes.data?.posts || [])
+        setAlbums(albumsRes.data?.albums || [])
         const meMember = (membersRes.data || []).find((m: { user_id: number; role: string }) => m.user_id === userRes.data.id)
         setIsManager(meMember?.role === 'manager')
       } catch (error) {
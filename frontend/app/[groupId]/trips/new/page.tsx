@@ -48,9 +48,12 @@ export default function NewTripPage() {
         }
         const meRes = await api.get('/me')
         setUser(meRes.data)
-        const [albumRes, postRes] = await Promise.all([api.get('/albums'), api.get('/posts')])
-        setAlbums(albumRes.data || [])
-        setPosts(postRes.data || [])
+        const [albumRes, postRes] = await Promise.all([
+          api.get('/albums', { params: { limit: 100 } }),
+          api.get('/posts', { params: { limit: 100 } }),
+        ])
+        setAlbums(albumRes.data?.albums || [])
+        setPosts(postRes.data?.posts || [])
       } catch (err) {
         console.error('Failed to fetch trip relation options:', err)
         router.push(buildLoginUrl(getCurrentPathWithQuery()))
@@ -47,8 +47,8 @@ export default function TripsPage() {
         }
         const userRes = await api.get('/me')
         setUser(userRes.data)
-        const res = await api.get('/trips')
-        setTrips(res.data || [])
+        const res = await api.get('/trips', { params: { limit: 100 } })
+        setTrips(res.data?.trips || [])
       } catch (err) {
         console.error('Failed to fetch trips:', err)
         setError(getErrorMessage(err, '旅行一覧の取得に失敗しました'))